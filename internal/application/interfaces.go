@@ -10,7 +10,9 @@ import (
 // TweetServiceInterface defines the interface for tweet services
 type TweetServiceInterface interface {
 	CreateTweet(ctx context.Context, req services.CreateTweetRequest) (*domain.Tweet, error)
+	GetTweet(ctx context.Context, id string) (*domain.Tweet, error)
 	GetUserTweets(ctx context.Context, userID string) ([]*domain.Tweet, error)
+	GetTweetsByUserIDs(ctx context.Context, userIDs []string) (map[string][]*domain.Tweet, error)
 }
 
 // FollowServiceInterface defines the interface for follow services
@@ -18,4 +20,60 @@ type FollowServiceInterface interface {
 	FollowUser(ctx context.Context, req services.FollowUserRequest) error
 	UnfollowUser(ctx context.Context, req services.FollowUserRequest) error
 	GetTimeline(ctx context.Context, userID string) ([]*domain.Tweet, error)
+	GetTimelinePage(ctx context.Context, userID string, page domain.Page) ([]*domain.Tweet, error)
+	GetFollowees(ctx context.Context, userIDs []string) (map[string][]string, error)
+	GetFollowers(ctx context.Context, userIDs []string) (map[string][]string, error)
+}
+
+// ScheduleServiceInterface defines the interface for scheduled tweet services
+type ScheduleServiceInterface interface {
+	ScheduleTweet(ctx context.Context, req services.ScheduleTweetRequest) (*domain.ScheduledTweet, error)
+	GetScheduledTweets(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error)
+	RescheduleTweet(ctx context.Context, req services.RescheduleTweetRequest) (*domain.ScheduledTweet, error)
+	CancelScheduledTweet(ctx context.Context, userID, id string) error
+}
+
+// PollServiceInterface defines the interface for poll services
+type PollServiceInterface interface {
+	Vote(ctx context.Context, req services.VotePollRequest) (*domain.Tweet, error)
+	WithResults(ctx context.Context, viewerID string, tweets []*domain.Tweet) ([]*domain.Tweet, error)
+}
+
+// MediaServiceInterface defines the interface for media services
+type MediaServiceInterface interface {
+	Upload(ctx context.Context, req services.UploadMediaRequest) (*domain.Media, error)
+	GetMedia(ctx context.Context, id string) (*domain.Media, error)
+	OpenMedia(ctx context.Context, id string, thumbnail bool) (*services.MediaContent, error)
+}
+
+// LinkPreviewServiceInterface defines the interface for link preview services
+type LinkPreviewServiceInterface interface {
+	WithCards(ctx context.Context, tweets []*domain.Tweet) ([]*domain.Tweet, error)
+}
+
+// WebhookServiceInterface defines the interface for webhook services
+type WebhookServiceInterface interface {
+	CreateWebhook(ctx context.Context, req services.CreateWebhookRequest) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context, userID string) ([]*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id string) error
+	GetDeliveries(ctx context.Context, userID, webhookID string) ([]*domain.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, userID, webhookID, deliveryID string) (*domain.WebhookDelivery, error)
+}
+
+// UserServiceInterface defines the interface for user services
+type UserServiceInterface interface {
+	GetUser(ctx context.Context, id string) (*domain.User, error)
+	GetUsers(ctx context.Context, ids []string) (map[string]*domain.User, error)
+}
+
+// FederationServiceInterface defines the interface for remote followers
+type FederationServiceInterface interface {
+	AddRemoteFollower(ctx context.Context, userID, actorID string) error
+	RemoveRemoteFollower(ctx context.Context, userID, actorID string) error
+	GetRemoteFollowers(ctx context.Context, userID string) ([]string, error)
+}
+
+// LiveTimelineServiceInterface defines the interface for live timeline updates
+type LiveTimelineServiceInterface interface {
+	StreamTimeline(ctx context.Context, userID string, send func(*domain.Tweet) error) error
 }
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"uala-challenge/internal/domain"
+)
+
+// DefaultEventBuffer is how many events an asynchronous subscriber may fall
+// behind before publishing waits for it
+const DefaultEventBuffer = 256
+
+// EventHandler handles a domain event. Events are delivered at least once,
+// so handlers must tolerate seeing the same event ID again.
+type EventHandler func(ctx context.Context, event domain.RecordedEvent) error
+
+// EventBus delivers domain events to in-process subscribers. Synchronous
+// subscribers run on the publishing goroutine, in subscription order, before
+// Publish returns. Asynchronous subscribers each have their own queue and
+// goroutine, started by Run, so slow handlers do not hold up publishing.
+// Subscribers are told apart by name, which must be unique.
+type EventBus struct {
+	mutex       sync.RWMutex
+	subscribers []*eventSubscriber
+	buffer      int
+}
+
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+	// queue is nil for synchronous subscribers
+	queue chan queuedEvent
+}
+
+// queuedEvent is an event waiting for an asynchronous subscriber
+type queuedEvent struct {
+	ctx     context.Context
+	event   domain.RecordedEvent
+	handled *HandledBy
+	ack     *eventAck
+}
+
+// HandledBy records which subscribers have handled an event. Publishing the
+// event again with the same HandledBy only reaches the subscribers that have
+// not, so that one failing subscriber does not make the others see it twice.
+// The zero value is ready to use; a nil *HandledBy records nothing.
+type HandledBy struct {
+	mutex sync.Mutex
+	names map[string]bool
+}
+
+func (h *HandledBy) has(name string) bool {
+	if h == nil {
+		return false
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.names[name]
+}
+
+func (h *HandledBy) add(name string) {
+	if h == nil {
+		return
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.names == nil {
+		h.names = make(map[string]bool)
+	}
+	h.names[name] = true
+}
+
+// eventAck calls done once every asynchronous subscriber has handled an
+// event, with the first error any of them returned
+type eventAck struct {
+	mutex     sync.Mutex
+	remaining int
+	err       error
+	done      func(error)
+}
+
+func (a *eventAck) finish(err error) {
+	a.mutex.Lock()
+	if a.err == nil {
+		a.err = err
+	}
+	a.remaining--
+	last, firstErr := a.remaining == 0, a.err
+	a.mutex.Unlock()
+
+	if last && a.done != nil {
+		a.done(firstErr)
+	}
+}
+
+// NewEventBus creates an event bus whose asynchronous subscribers buffer up
+// to buffer events
+func NewEventBus(buffer int) *EventBus {
+	return &EventBus{buffer: buffer}
+}
+
+// Subscribe registers a handler that runs before Publish returns
+func (b *EventBus) Subscribe(name string, handler EventHandler) {
+	b.subscribe(&eventSubscriber{name: name, handler: handler})
+}
+
+// SubscribeAsync registers a handler that runs in the background once Run
+// has started
+func (b *EventBus) SubscribeAsync(name string, handler EventHandler) {
+	b.subscribe(&eventSubscriber{name: name, handler: handler, queue: make(chan queuedEvent, b.buffer)})
+}
+
+func (b *EventBus) subscribe(sub *eventSubscriber) {
+	b.mutex.Lock()
+	b.subscribers = append(b.subscribers, sub)
+	b.mutex.Unlock()
+}
+
+// Publish delivers an event to the synchronous subscribers and then queues
+// it for the asynchronous ones, skipping those handled already records and
+// adding those that handle it now. A failing subscriber does not keep the
+// event from the others. done, if not nil, is called once every subscriber
+// has handled the event, with the first error any of them returned.
+// Asynchronous handlers run with a context that is not cancelled along with
+// ctx.
+func (b *EventBus) Publish(ctx context.Context, event domain.RecordedEvent, handled *HandledBy, done func(error)) {
+	b.mutex.RLock()
+	subscribers := b.subscribers
+	b.mutex.RUnlock()
+
+	var async []*eventSubscriber
+	var firstErr error
+	for _, sub := range subscribers {
+		if handled.has(sub.name) {
+			continue
+		}
+		if sub.queue != nil {
+			async = append(async, sub)
+			continue
+		}
+		err := sub.handler(ctx, event)
+		if err != nil {
+			slog.WarnContext(ctx, "event subscriber failed", "subscriber", sub.name, "event_id", event.ID, "event_type", event.Event.EventType(), "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		handled.add(sub.name)
+	}
+
+	if len(async) == 0 {
+		if done != nil {
+			done(firstErr)
+		}
+		return
+	}
+
+	ack := &eventAck{remaining: len(async), err: firstErr, done: done}
+	queued := queuedEvent{ctx: context.WithoutCancel(ctx), event: event, handled: handled, ack: ack}
+	for _, sub := range async {
+		select {
+		case sub.queue <- queued:
+		case <-ctx.Done():
+			ack.finish(ctx.Err())
+		}
+	}
+}
+
+// Run runs the asynchronous subscribers until the context is cancelled.
+// Events still queued then are dropped; the outbox delivers them again.
+func (b *EventBus) Run(ctx context.Context) {
+	b.mutex.RLock()
+	subscribers := b.subscribers
+	b.mutex.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, sub := range subscribers {
+		if sub.queue == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(sub *eventSubscriber) {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case queued := <-sub.queue:
+					err := sub.handler(queued.ctx, queued.event)
+					if err != nil {
+						slog.WarnContext(queued.ctx, "event subscriber failed", "subscriber", sub.name, "event_id", queued.event.ID, "event_type", queued.event.Event.EventType(), "error", err)
+					} else {
+						queued.handled.add(sub.name)
+					}
+					queued.ack.finish(err)
+				}
+			}
+		}(sub)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+// mockOutbox is a unit of work over the mock repositories that keeps the
+// recorded events in an outbox
+type mockOutbox struct {
+	direct directUnitOfWork
+	mutex  sync.Mutex
+	events []*domain.OutboxEvent
+}
+
+type mockTransaction struct {
+	directUnitOfWork
+	events []domain.Event
+}
+
+func (t *mockTransaction) Record(events ...domain.Event) {
+	t.events = append(t.events, events...)
+}
+
+func (m *mockOutbox) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	tx := &mockTransaction{directUnitOfWork: m.direct}
+	err := fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, event := range tx.events {
+		stored, err := domain.NewOutboxEvent(domain.UUIDv7Generator{}.NewID(), event, time.Now())
+		if err != nil {
+			return err
+		}
+		m.events = append(m.events, stored)
+	}
+	return nil
+}
+
+func (m *mockOutbox) GetPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var pending []*domain.OutboxEvent
+	for _, event := range m.events {
+		if !event.Dispatched() && len(pending) < limit {
+			stored := *event
+			pending = append(pending, &stored)
+		}
+	}
+	return pending, nil
+}
+
+func (m *mockOutbox) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, event := range m.events {
+		if event.ID == id {
+			event.DispatchedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *mockOutbox) DeleteDispatchedBefore(ctx context.Context, t time.Time) error {
+	return nil
+}
+
+// types returns the types of the recorded events and clears the outbox
+func (m *mockOutbox) types() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var types []string
+	for _, event := range m.events {
+		types = append(types, event.Type)
+	}
+	m.events = nil
+	return types
+}
+
+func (m *mockOutbox) pending() int {
+	pending, _ := m.GetPending(context.Background(), 100)
+	return len(pending)
+}
+
+// recordingHandler records the IDs of the events it handles and fails while
+// err is set
+type recordingHandler struct {
+	mutex sync.Mutex
+	ids   []string
+	err   error
+}
+
+func (h *recordingHandler) handle(ctx context.Context, event domain.RecordedEvent) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ids = append(h.ids, event.ID)
+	return h.err
+}
+
+func (h *recordingHandler) fail(err error) {
+	h.mutex.Lock()
+	h.err = err
+	h.mutex.Unlock()
+}
+
+func (h *recordingHandler) handled() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]string(nil), h.ids...)
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestServices_RecordEvents(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &mockUserRepository{users: make(map[string]*domain.User)}
+	tweetRepo := &mockTweetRepository{}
+	scheduledRepo := &mockScheduledTweetRepository{scheduled: make(map[string]*domain.ScheduledTweet)}
+	followRepo := &mockFollowRepository{follows: make(map[string][]string)}
+	outbox := &mockOutbox{direct: directUnitOfWork{users: userRepo, tweets: tweetRepo, follows: followRepo}}
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	tweetService := NewTweetService(tweetRepo, userRepo, WithUnitOfWork(outbox))
+	followService := NewFollowService(followRepo, tweetRepo, WithFollowUnitOfWork(outbox))
+	scheduleService := NewScheduleService(scheduledRepo, tweetRepo, userRepo, clock, WithScheduleUnitOfWork(outbox))
+
+	tests := []struct {
+		name     string
+		action   func() error
+		expected []string
+	}{
+		{"first tweet creates the user", func() error {
+			_, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "alice", Content: "Hello"})
+			return err
+		}, []string{domain.EventUserCreated, domain.EventTweetCreated}},
+		{"later tweets", func() error {
+			_, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "alice", Content: "Again"})
+			return err
+		}, []string{domain.EventTweetCreated}},
+		{"invalid tweet", func() error {
+			_, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "bob", Content: ""})
+			if err != domain.ErrTweetEmpty {
+				t.Errorf("Expected %v, got %v", domain.ErrTweetEmpty, err)
+			}
+			return nil
+		}, nil},
+		{"follow", func() error {
+			return followService.FollowUser(ctx, FollowUserRequest{FollowerID: "alice", FolloweeID: "bob"})
+		}, []string{domain.EventUserFollowed}},
+		{"follow again", func() error {
+			return followService.FollowUser(ctx, FollowUserRequest{FollowerID: "alice", FolloweeID: "bob"})
+		}, nil},
+		{"follow yourself", func() error {
+			followService.FollowUser(ctx, FollowUserRequest{FollowerID: "alice", FolloweeID: "alice"})
+			return nil
+		}, nil},
+		{"unfollow", func() error {
+			return followService.UnfollowUser(ctx, FollowUserRequest{FollowerID: "alice", FolloweeID: "bob"})
+		}, []string{domain.EventUserUnfollowed}},
+		{"unfollow again", func() error {
+			return followService.UnfollowUser(ctx, FollowUserRequest{FollowerID: "alice", FolloweeID: "bob"})
+		}, nil},
+		{"schedule a tweet", func() error {
+			_, err := scheduleService.ScheduleTweet(ctx, ScheduleTweetRequest{UserID: "carol", Content: "Later", PublishAt: clock.Now().Add(time.Hour)})
+			return err
+		}, []string{domain.EventUserCreated}},
+		{"publish scheduled tweets", func() error {
+			clock.Advance(time.Hour)
+			_, err := scheduleService.PublishDue(ctx)
+			return err
+		}, []string{domain.EventTweetCreated}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.action(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if types := outbox.types(); !equalStrings(types, tt.expected) {
+				t.Errorf("Expected events %v, got %v", tt.expected, types)
+			}
+		})
+	}
+
+	if user, _ := userRepo.GetByID(ctx, "bob"); user != nil {
+		t.Error("Expected an invalid tweet not to create its user")
+	}
+}
+
+func TestEventBus_Publish(t *testing.T) {
+	bus := NewEventBus(DefaultEventBuffer)
+	first, second, async := &recordingHandler{}, &recordingHandler{}, &recordingHandler{}
+	bus.Subscribe("first", first.handle)
+	bus.SubscribeAsync("async", async.handle)
+	bus.Subscribe("second", second.handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		bus.Run(ctx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+
+	// Synchronous subscribers have run when Publish returns; the
+	// asynchronous one acknowledges through done
+	done := make(chan error, 1)
+	event := recorded("event1", domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+	bus.Publish(context.Background(), event, nil, func(err error) { done <- err })
+	if !equalStrings(first.handled(), []string{"event1"}) || !equalStrings(second.handled(), []string{"event1"}) {
+		t.Errorf("Expected both synchronous subscribers to have handled the event, got %v and %v", first.handled(), second.handled())
+	}
+	if err := awaitDone(t, done); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// A failing synchronous subscriber does not keep the event from the
+	// others, and publishing it again only reaches the one that failed
+	errFailed := errors.New("failed")
+	first.fail(errFailed)
+	var handled HandledBy
+	event = recorded("event2", event.Event)
+	bus.Publish(context.Background(), event, &handled, func(err error) { done <- err })
+	if err := awaitDone(t, done); !errors.Is(err, errFailed) {
+		t.Errorf("Expected %v, got %v", errFailed, err)
+	}
+	if len(second.handled()) != 2 || len(async.handled()) != 2 {
+		t.Error("Expected subscribers after the failure to see the event")
+	}
+
+	first.fail(nil)
+	bus.Publish(context.Background(), event, &handled, func(err error) { done <- err })
+	if err := awaitDone(t, done); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(first.handled()) != 3 || len(second.handled()) != 2 || len(async.handled()) != 2 {
+		t.Errorf("Expected only the failed subscriber to see the event again, got %v, %v and %v", first.handled(), second.handled(), async.handled())
+	}
+}
+
+// awaitDone waits for a Publish done callback
+func awaitDone(t *testing.T, done chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected every subscriber to handle the event")
+		return nil
+	}
+}
+
+func TestOutboxRelay_RedeliversUntilHandled(t *testing.T) {
+	ctx := context.Background()
+	outbox := &mockOutbox{direct: directUnitOfWork{users: &mockUserRepository{users: make(map[string]*domain.User)}, tweets: &mockTweetRepository{}}}
+	bus := NewEventBus(DefaultEventBuffer)
+	failing, other, async := &recordingHandler{}, &recordingHandler{}, &recordingHandler{}
+	bus.Subscribe("failing", failing.handle)
+	bus.Subscribe("other", other.handle)
+	bus.SubscribeAsync("async", async.handle)
+	relay := NewOutboxRelay(outbox, bus, domain.SystemClock{})
+	tweetService := NewTweetService(nil, outbox.direct.users, WithUnitOfWork(relay.UnitOfWork(outbox)))
+
+	// A failing synchronous subscriber does not fail the request or keep
+	// the events from the other subscribers, and the events stay pending
+	errFailed := errors.New("failed")
+	failing.fail(errFailed)
+	if _, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "alice", Content: "Hello"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(failing.handled()) != 2 || len(other.handled()) != 2 || outbox.pending() != 2 {
+		t.Fatalf("Expected 2 pending events handled by both synchronous subscribers, got %d handled by %v and %v", outbox.pending(), failing.handled(), other.handled())
+	}
+
+	// Published events wait for the asynchronous subscriber, which only
+	// runs once the bus does
+	if published, _ := relay.Dispatch(ctx); published != 0 {
+		t.Errorf("Expected events in flight not to be published again, got %d", published)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		bus.Run(runCtx)
+		close(stopped)
+	}()
+	defer func() {
+		cancel()
+		<-stopped
+	}()
+	waitFor(t, func() bool { return len(async.handled()) == 2 })
+	waitFor(t, func() bool { return inFlight(relay) == 0 })
+	if outbox.pending() != 2 {
+		t.Error("Expected the events to stay pending until every subscriber handled them")
+	}
+
+	// Only the failed subscriber sees the events again, with the same IDs
+	failing.fail(nil)
+	if published, err := relay.Dispatch(ctx); err != nil || published != 2 {
+		t.Fatalf("Expected 2 events published again, got %d, %v", published, err)
+	}
+	waitFor(t, func() bool { return outbox.pending() == 0 })
+	if handled := failing.handled(); len(handled) != 4 || !equalStrings(handled[:2], handled[2:]) {
+		t.Errorf("Expected both events to reach the failed subscriber again with the same IDs, got %v", handled)
+	}
+	if len(other.handled()) != 2 || len(async.handled()) != 2 {
+		t.Errorf("Expected the other subscribers not to see the events again, got %v and %v", other.handled(), async.handled())
+	}
+
+	// Likewise for an asynchronous failure
+	async.fail(errFailed)
+	if _, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "alice", Content: "Again"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	waitFor(t, func() bool { return len(async.handled()) == 3 })
+	waitFor(t, func() bool { return inFlight(relay) == 0 })
+	async.fail(nil)
+	if published, err := relay.Dispatch(ctx); err != nil || published != 1 {
+		t.Fatalf("Expected 1 event published again, got %d, %v", published, err)
+	}
+	waitFor(t, func() bool { return outbox.pending() == 0 })
+	if len(async.handled()) != 4 || len(failing.handled()) != 5 || len(other.handled()) != 3 {
+		t.Errorf("Expected only the asynchronous subscriber to see the event again, got %v, %v and %v", async.handled(), failing.handled(), other.handled())
+	}
+	if relayed(relay) != 0 {
+		t.Error("Expected dispatched events to be forgotten")
+	}
+}
+
+func TestOutboxRelay_Check(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	outbox := &mockOutbox{}
+	relay := NewOutboxRelay(outbox, NewEventBus(DefaultEventBuffer), clock)
+
+	if err := relay.Check(ctx); err != nil {
+		t.Errorf("Expected an empty outbox to pass, got %v", err)
+	}
+
+	stored, err := domain.NewOutboxEvent("event1", domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"}, clock.Now())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	outbox.events = append(outbox.events, stored)
+	clock.Advance(minOutboxStallAfter)
+	if err := relay.Check(ctx); err != nil {
+		t.Errorf("Expected a recent pending event to pass, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if err := relay.Check(ctx); err == nil || !strings.Contains(err.Error(), "1m1s") {
+		t.Errorf("Expected a stalled event bus to fail with the event's age, got %v", err)
+	}
+
+	if err := outbox.MarkDispatched(ctx, stored.ID, clock.Now()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := relay.Check(ctx); err != nil {
+		t.Errorf("Expected the check to pass once the event is dispatched, got %v", err)
+	}
+}
+
+// inFlight returns how many events wait for subscribers
+func inFlight(relay *OutboxRelay) int {
+	relay.relayedMutex.Lock()
+	defer relay.relayedMutex.Unlock()
+
+	count := 0
+	for _, relayed := range relay.relayed {
+		if relayed.inFlight {
+			count++
+		}
+	}
+	return count
+}
+
+// relayed returns how many published events the relay remembers
+func relayed(relay *OutboxRelay) int {
+	relay.relayedMutex.Lock()
+	defer relay.relayedMutex.Unlock()
+	return len(relay.relayed)
+}
+
+// waitFor polls condition until it holds or a deadline passes
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+
+	"uala-challenge/internal/domain"
+)
+
+// FederationService keeps track of accounts on other servers that follow
+// local users. Remote followers are stored in the follow repository under
+// their actor IDs, which are absolute URLs and so never collide with local
+// user IDs.
+type FederationService struct {
+	followRepo domain.FollowRepository
+	userRepo   domain.UserRepository
+}
+
+// NewFederationService creates a new federation service
+func NewFederationService(followRepo domain.FollowRepository, userRepo domain.UserRepository) *FederationService {
+	return &FederationService{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// AddRemoteFollower records that the remote actor follows a local user
+func (s *FederationService) AddRemoteFollower(ctx context.Context, userID, actorID string) error {
+	if !IsRemoteActorID(actorID) {
+		return domain.ErrInvalidActorID
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	_, err = s.followRepo.Follow(ctx, actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "remote follower added", "user_id", userID, "actor_id", actorID)
+	return nil
+}
+
+// RemoveRemoteFollower records that the remote actor stopped following a
+// local user
+func (s *FederationService) RemoveRemoteFollower(ctx context.Context, userID, actorID string) error {
+	if !IsRemoteActorID(actorID) {
+		return domain.ErrInvalidActorID
+	}
+
+	_, err := s.followRepo.Unfollow(ctx, actorID, userID)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "remote follower removed", "user_id", userID, "actor_id", actorID)
+	return nil
+}
+
+// GetRemoteFollowers retrieves the actor IDs of the remote accounts that
+// follow a local user
+func (s *FederationService) GetRemoteFollowers(ctx context.Context, userID string) ([]string, error) {
+	followers, err := s.followRepo.GetFollowersByFolloweeIDs(ctx, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	remote := []string{}
+	for _, follower := range followers[userID] {
+		if IsRemoteActorID(follower) {
+			remote = append(remote, follower)
+		}
+	}
+	return remote, nil
+}
+
+// IsRemoteActorID reports whether id names an account on another server
+func IsRemoteActorID(id string) bool {
+	u, err := url.Parse(id)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestFederationService_RemoteFollowers(t *testing.T) {
+	ctx := context.Background()
+	followRepo := &mockFollowRepository{follows: map[string][]string{
+		"bob": {"alice"},
+	}}
+	userRepo := &mockUserRepository{users: map[string]*domain.User{
+		"alice": {ID: "alice", Name: "User-alice"},
+	}}
+	service := NewFederationService(followRepo, userRepo)
+
+	const actorID = "https://mastodon.example/users/carol"
+	if err := service.AddRemoteFollower(ctx, "alice", actorID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	followers, err := service.GetRemoteFollowers(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// Local followers are left out
+	if len(followers) != 1 || followers[0] != actorID {
+		t.Errorf("Expected only %s, got %v", actorID, followers)
+	}
+
+	if err := service.RemoveRemoteFollower(ctx, "alice", actorID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	followers, _ = service.GetRemoteFollowers(ctx, "alice")
+	if len(followers) != 0 {
+		t.Errorf("Expected no remote followers, got %v", followers)
+	}
+}
+
+func TestFederationService_AddRemoteFollower_Errors(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &mockUserRepository{users: map[string]*domain.User{
+		"alice": {ID: "alice", Name: "User-alice"},
+	}}
+	service := NewFederationService(&mockFollowRepository{follows: make(map[string][]string)}, userRepo)
+
+	tests := []struct {
+		name    string
+		userID  string
+		actorID string
+		err     error
+	}{
+		{"local follower", "alice", "bob", domain.ErrInvalidActorID},
+		{"relative URL", "alice", "/users/bob", domain.ErrInvalidActorID},
+		{"unsupported scheme", "alice", "ftp://example.com/bob", domain.ErrInvalidActorID},
+		{"unknown user", "nobody", "https://mastodon.example/users/carol", domain.ErrUserNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.AddRemoteFollower(ctx, tt.userID, tt.actorID); err != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
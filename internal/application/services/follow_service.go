@@ -2,7 +2,7 @@ package services
 
 import (
 	"context"
-	"sort"
+	"log/slog"
 
 	"uala-challenge/internal/domain"
 )
@@ -11,14 +11,52 @@ import (
 type FollowService struct {
 	followRepo domain.FollowRepository
 	tweetRepo  domain.TweetRepository
+	uow        domain.UnitOfWork
+	sort       TimelineSorter
+}
+
+// TimelineSorter orders the tweets of a timeline newest first
+type TimelineSorter func(ctx context.Context, tweets []*domain.Tweet)
+
+// SortTimeline sorts by creation time (newest first), breaking ties by ID so
+// that tweets created at the same time always come in the same order
+func SortTimeline(_ context.Context, tweets []*domain.Tweet) {
+	domain.SortNewestFirst(tweets)
+}
+
+// FollowServiceOption configures optional follow service dependencies
+type FollowServiceOption func(*FollowService)
+
+// WithFollowUnitOfWork stores follows and unfollows through uow, recording a
+// UserFollowed or UserUnfollowed event with each
+func WithFollowUnitOfWork(uow domain.UnitOfWork) FollowServiceOption {
+	return func(s *FollowService) {
+		s.uow = uow
+	}
+}
+
+// WithTimelineSorter sorts timelines with sort instead of SortTimeline, which
+// lets the infrastructure layer wrap the sort, e.g. in a span of its own
+func WithTimelineSorter(sort TimelineSorter) FollowServiceOption {
+	return func(s *FollowService) {
+		s.sort = sort
+	}
 }
 
 // NewFollowService creates a new follow service
-func NewFollowService(followRepo domain.FollowRepository, tweetRepo domain.TweetRepository) *FollowService {
-	return &FollowService{
+func NewFollowService(followRepo domain.FollowRepository, tweetRepo domain.TweetRepository, opts ...FollowServiceOption) *FollowService {
+	s := &FollowService{
 		followRepo: followRepo,
 		tweetRepo:  tweetRepo,
+		sort:       SortTimeline,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.uow == nil {
+		s.uow = directUnitOfWork{follows: followRepo}
 	}
+	return s
 }
 
 // FollowUserRequest represents the request to follow a user
@@ -27,7 +65,8 @@ type FollowUserRequest struct {
 	FolloweeID string `json:"followee_id"`
 }
 
-// FollowUser creates a follow relationship
+// FollowUser creates a follow relationship. Following a user again changes
+// nothing and records no event.
 func (s *FollowService) FollowUser(ctx context.Context, req FollowUserRequest) error {
 	// Validate follow relationship
 	err := domain.ValidateFollow(req.FollowerID, req.FolloweeID)
@@ -36,12 +75,46 @@ func (s *FollowService) FollowUser(ctx context.Context, req FollowUserRequest) e
 	}
 
 	// Create follow relationship
-	return s.followRepo.Follow(ctx, req.FollowerID, req.FolloweeID)
+	var followed bool
+	err = s.uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		followed, err = tx.Follows().Follow(ctx, req.FollowerID, req.FolloweeID)
+		if err != nil || !followed {
+			return err
+		}
+		tx.Record(domain.UserFollowed{FollowerID: req.FollowerID, FolloweeID: req.FolloweeID})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if followed {
+		slog.InfoContext(ctx, "user followed", "follower_id", req.FollowerID, "followee_id", req.FolloweeID)
+	}
+	return nil
 }
 
-// UnfollowUser removes a follow relationship
+// UnfollowUser removes a follow relationship. Unfollowing a user who is not
+// followed changes nothing and records no event.
 func (s *FollowService) UnfollowUser(ctx context.Context, req FollowUserRequest) error {
-	return s.followRepo.Unfollow(ctx, req.FollowerID, req.FolloweeID)
+	var unfollowed bool
+	err := s.uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		var err error
+		unfollowed, err = tx.Follows().Unfollow(ctx, req.FollowerID, req.FolloweeID)
+		if err != nil || !unfollowed {
+			return err
+		}
+		tx.Record(domain.UserUnfollowed{FollowerID: req.FollowerID, FolloweeID: req.FolloweeID})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if unfollowed {
+		slog.InfoContext(ctx, "user unfollowed", "follower_id", req.FollowerID, "followee_id", req.FolloweeID)
+	}
+	return nil
 }
 
 // GetTimeline retrieves tweets from followed users
@@ -63,9 +136,50 @@ func (s *FollowService) GetTimeline(ctx context.Context, userID string) ([]*doma
 	}
 
 	// Sort by creation time (newest first)
-	sort.Slice(tweets, func(i, j int) bool {
-		return tweets[i].CreatedAt.After(tweets[j].CreatedAt)
-	})
+	s.sort(ctx, tweets)
 
 	return tweets, nil
 }
+
+// GetTimelinePage retrieves a page of the tweets from followed users, newest
+// first. The repository limits the query, so a page costs the same however
+// long the timeline is.
+func (s *FollowService) GetTimelinePage(ctx context.Context, userID string, page domain.Page) ([]*domain.Tweet, error) {
+	followees, err := s.followRepo.GetFollowees(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(followees) == 0 {
+		return []*domain.Tweet{}, nil
+	}
+
+	return s.tweetRepo.GetPageByUserIDs(ctx, followees, page)
+}
+
+// GetFollowees retrieves who each of userIDs follows with one repository
+// call, keyed by user
+func (s *FollowService) GetFollowees(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return s.followRepo.GetFolloweesByFollowerIDs(ctx, userIDs)
+}
+
+// GetFollowers retrieves which local users follow each of userIDs with one
+// repository call, keyed by user. Remote followers share the repository but
+// are not users here, so they are left out; FederationService lists them.
+func (s *FollowService) GetFollowers(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	followers, err := s.followRepo.GetFollowersByFolloweeIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	local := make(map[string][]string, len(followers))
+	for userID, ids := range followers {
+		local[userID] = []string{}
+		for _, id := range ids {
+			if !IsRemoteActorID(id) {
+				local[userID] = append(local[userID], id)
+			}
+		}
+	}
+	return local, nil
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
 )
 
 // Mock repositories for testing
@@ -12,26 +14,53 @@ type mockFollowRepository struct {
 	follows map[string][]string // followerID -> []followeeID
 }
 
-func (m *mockFollowRepository) Follow(ctx context.Context, followerID, followeeID string) error {
+func (m *mockFollowRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	for _, followee := range m.follows[followerID] {
+		if followee == followeeID {
+			return false, nil
+		}
+	}
 	m.follows[followerID] = append(m.follows[followerID], followeeID)
-	return nil
+	return true, nil
 }
 
-func (m *mockFollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+func (m *mockFollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
 	followees := m.follows[followerID]
 	for i, followee := range followees {
 		if followee == followeeID {
 			m.follows[followerID] = append(followees[:i], followees[i+1:]...)
-			break
+			return true, nil
 		}
 	}
-	return nil
+	return false, nil
 }
 
 func (m *mockFollowRepository) GetFollowees(ctx context.Context, followerID string) ([]string, error) {
 	return m.follows[followerID], nil
 }
 
+func (m *mockFollowRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error) {
+	followees := make(map[string][]string)
+	for _, followerID := range followerIDs {
+		followees[followerID] = m.follows[followerID]
+	}
+	return followees, nil
+}
+
+func (m *mockFollowRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error) {
+	followers := make(map[string][]string)
+	for _, followeeID := range followeeIDs {
+		for followerID, followees := range m.follows {
+			for _, followee := range followees {
+				if followee == followeeID {
+					followers[followeeID] = append(followers[followeeID], followerID)
+				}
+			}
+		}
+	}
+	return followers, nil
+}
+
 type mockTweetRepositoryForFollow struct {
 	tweets []*domain.Tweet
 }
@@ -41,6 +70,15 @@ func (m *mockTweetRepositoryForFollow) Create(ctx context.Context, tweet *domain
 	return nil
 }
 
+func (m *mockTweetRepositoryForFollow) GetByID(ctx context.Context, id string) (*domain.Tweet, error) {
+	for _, tweet := range m.tweets {
+		if tweet.ID == id {
+			return tweet, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockTweetRepositoryForFollow) GetByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
 	var userTweets []*domain.Tweet
 	for _, tweet := range m.tweets {
@@ -66,6 +104,10 @@ func (m *mockTweetRepositoryForFollow) GetByUserIDs(ctx context.Context, userIDs
 	return tweets, nil
 }
 
+func (m *mockTweetRepositoryForFollow) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	return (&mockTweetRepository{tweets: m.tweets}).GetPageByUserIDs(ctx, userIDs, page)
+}
+
 func TestFollowService_FollowUser(t *testing.T) {
 	ctx := context.Background()
 
@@ -151,6 +193,26 @@ func TestFollowService_UnfollowUser(t *testing.T) {
 	}
 }
 
+func TestFollowService_GetFollowersLeavesOutRemoteActors(t *testing.T) {
+	ctx := context.Background()
+
+	followRepo := &mockFollowRepository{
+		follows: map[string][]string{
+			"user2":                            {"user1"},
+			"https://remote.example/users/bob": {"user1"},
+		},
+	}
+	service := NewFollowService(followRepo, &mockTweetRepositoryForFollow{})
+
+	followers, err := service.GetFollowers(ctx, []string{"user1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(followers["user1"]) != 1 || followers["user1"][0] != "user2" {
+		t.Errorf("Expected only the local follower user2, got %v", followers["user1"])
+	}
+}
+
 func TestFollowService_GetTimeline(t *testing.T) {
 	ctx := context.Background()
 
@@ -214,3 +276,99 @@ func TestFollowService_GetTimeline_EmptyFollows(t *testing.T) {
 		t.Errorf("Expected 0 tweets for user with no follows, got %d", len(tweets))
 	}
 }
+
+func TestFollowService_GetTimeline_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	followRepo := &mockFollowRepository{
+		follows: map[string][]string{
+			"user1": {"user2", "user3"},
+		},
+	}
+	tweetRepo := &mockTweetRepository{}
+	tweetService := NewTweetService(tweetRepo, &mockUserRepository{users: make(map[string]*domain.User)},
+		WithClock(clock),
+		WithIDGenerator(domaintest.NewSequentialIDs("tweet")),
+	)
+
+	// Three tweets in the same tick, then one a second later
+	for _, req := range []CreateTweetRequest{
+		{UserID: "user2", Content: "First"},
+		{UserID: "user3", Content: "Second"},
+		{UserID: "user2", Content: "Third"},
+	} {
+		if _, err := tweetService.CreateTweet(ctx, req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	clock.Advance(time.Second)
+	if _, err := tweetService.CreateTweet(ctx, CreateTweetRequest{UserID: "user3", Content: "Fourth"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	service := NewFollowService(followRepo, tweetRepo)
+	want := []string{"tweet-000004", "tweet-000003", "tweet-000002", "tweet-000001"}
+	for run := 0; run < 3; run++ {
+		tweets, err := service.GetTimeline(ctx, "user1")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(tweets) != len(want) {
+			t.Fatalf("Expected %d tweets, got %d", len(want), len(tweets))
+		}
+		for i, tweet := range tweets {
+			if tweet.ID != want[i] {
+				t.Errorf("Expected %s at position %d, got %s", want[i], i, tweet.ID)
+			}
+		}
+	}
+}
+
+func TestFollowService_GetTimelinePage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	followRepo := &mockFollowRepository{
+		follows: map[string][]string{
+			"user1": {"user2", "user3"},
+		},
+	}
+	tweetRepo := &mockTweetRepositoryForFollow{tweets: []*domain.Tweet{
+		{ID: "1", UserID: "user2", CreatedAt: now},
+		{ID: "2", UserID: "user3", CreatedAt: now.Add(time.Minute)},
+		{ID: "3", UserID: "user4", CreatedAt: now.Add(2 * time.Minute)},
+		{ID: "4", UserID: "user2", CreatedAt: now.Add(time.Minute)},
+	}}
+
+	service := NewFollowService(followRepo, tweetRepo)
+
+	tests := []struct {
+		name     string
+		page     domain.Page
+		expected []string
+	}{
+		{"first page", domain.Page{Limit: 2}, []string{"4", "2"}},
+		{"next page", domain.Page{Limit: 2, After: "2"}, []string{"1"}},
+		{"past the end", domain.Page{Limit: 2, After: "1"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tweets, err := service.GetTimelinePage(ctx, "user1", tt.page)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			var ids []string
+			for _, tweet := range tweets {
+				ids = append(ids, tweet.ID)
+			}
+			if !equalStrings(ids, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	if _, err := service.GetTimelinePage(ctx, "user1", domain.Page{Limit: 2, After: "missing"}); err != domain.ErrPageCursorNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrPageCursorNotFound, err)
+	}
+}
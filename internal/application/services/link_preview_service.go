@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// Link preview defaults
+const (
+	// DefaultUnfurlTimeout bounds a single preview fetch
+	DefaultUnfurlTimeout = 5 * time.Second
+	// DefaultUnfurlWorkers is the number of concurrent preview fetches
+	DefaultUnfurlWorkers = 4
+	// unfurlQueueSize bounds pending fetches; further URLs are dropped until
+	// they are seen again
+	unfurlQueueSize = 256
+	// unfurlRetryAfter is how long a URL that failed to unfurl is left alone
+	unfurlRetryAfter = time.Hour
+	// maxUnfurlFailures bounds the failed URLs remembered, so that tweets
+	// full of unique unreachable URLs cannot grow memory without limit
+	maxUnfurlFailures = 10000
+	// previewRefreshAfter is how long a cached preview is shown before it
+	// is fetched again, so that cards follow changes to the page
+	previewRefreshAfter = 24 * time.Hour
+)
+
+// PreviewFetcher fetches the preview card metadata of a URL
+type PreviewFetcher interface {
+	Fetch(ctx context.Context, url string) (*domain.LinkPreview, error)
+}
+
+// LinkPreviewService unfurls URLs in tweets into preview cards asynchronously
+// and caches the result by URL, refreshing it once it is
+// previewRefreshAfter old
+type LinkPreviewService struct {
+	previewRepo domain.LinkPreviewRepository
+	fetcher     PreviewFetcher
+	clock       domain.Clock
+	timeout     time.Duration
+
+	queue    chan string
+	mutex    sync.Mutex
+	pending  map[string]bool
+	failures map[string]time.Time
+}
+
+// NewLinkPreviewService creates a new link preview service
+func NewLinkPreviewService(previewRepo domain.LinkPreviewRepository, fetcher PreviewFetcher, clock domain.Clock) *LinkPreviewService {
+	return &LinkPreviewService{
+		previewRepo: previewRepo,
+		fetcher:     fetcher,
+		clock:       clock,
+		timeout:     DefaultUnfurlTimeout,
+		queue:       make(chan string, unfurlQueueSize),
+		pending:     make(map[string]bool),
+		failures:    make(map[string]time.Time),
+	}
+}
+
+// Enqueue schedules the tweet's first URL for unfurling without blocking.
+// It satisfies the Unfurler hook of TweetService.
+func (s *LinkPreviewService) Enqueue(tweet *domain.Tweet) {
+	url := tweet.FirstURL()
+	if url == "" {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.pending[url] {
+		return
+	}
+	if failedAt, failed := s.failures[url]; failed {
+		if s.clock.Now().Sub(failedAt) < unfurlRetryAfter {
+			return
+		}
+		delete(s.failures, url)
+	}
+
+	select {
+	case s.queue <- url:
+		s.pending[url] = true
+	default:
+		// Queue full: the URL will be enqueued again the next time it is read
+	}
+}
+
+// Run processes queued URLs with the given number of workers until the
+// context is cancelled
+func (s *LinkPreviewService) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case url := <-s.queue:
+					if err := s.Unfurl(ctx, url); err != nil {
+						slog.WarnContext(ctx, "failed to fetch link preview", "url", url, "error", err)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Unfurl fetches and caches the preview of a URL unless a fresh one is
+// already cached
+func (s *LinkPreviewService) Unfurl(ctx context.Context, url string) error {
+	defer func() {
+		s.mutex.Lock()
+		delete(s.pending, url)
+		s.mutex.Unlock()
+	}()
+
+	cached, err := s.previewRepo.Get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if cached != nil && !s.stale(cached) {
+		return nil
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	preview, err := s.fetcher.Fetch(fetchCtx, url)
+	if err != nil {
+		s.recordFailure(url)
+		return err
+	}
+
+	preview.URL = url
+	preview.FetchedAt = s.clock.Now()
+	return s.previewRepo.Save(ctx, preview)
+}
+
+// stale reports whether a cached preview is due to be fetched again
+func (s *LinkPreviewService) stale(preview *domain.LinkPreview) bool {
+	return s.clock.Now().Sub(preview.FetchedAt) >= previewRefreshAfter
+}
+
+// recordFailure leaves url alone for unfurlRetryAfter. Once
+// maxUnfurlFailures URLs are remembered, expired ones are forgotten to make
+// room; if none have expired, url is not remembered and may be fetched again
+// the next time it is read.
+func (s *LinkPreviewService) recordFailure(url string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	if len(s.failures) >= maxUnfurlFailures {
+		for failedURL, failedAt := range s.failures {
+			if now.Sub(failedAt) >= unfurlRetryAfter {
+				delete(s.failures, failedURL)
+			}
+		}
+	}
+	if len(s.failures) < maxUnfurlFailures {
+		s.failures[url] = now
+	}
+}
+
+// WithCards returns copies of the tweets with cached preview cards attached.
+// URLs that have not been unfurled yet, or whose preview is stale, are queued
+// for unfurling; a stale card is still shown until it is replaced.
+func (s *LinkPreviewService) WithCards(ctx context.Context, tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	carded := make([]*domain.Tweet, len(tweets))
+	for i, tweet := range tweets {
+		carded[i] = tweet
+
+		url := tweet.FirstURL()
+		if url == "" {
+			continue
+		}
+
+		preview, err := s.previewRepo.Get(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		if preview == nil || s.stale(preview) {
+			s.Enqueue(tweet)
+		}
+		if preview == nil {
+			continue
+		}
+
+		withCard := *tweet
+		withCard.Card = preview
+		carded[i] = &withCard
+	}
+
+	return carded, nil
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+type mockLinkPreviewRepository struct {
+	mutex    sync.Mutex
+	previews map[string]*domain.LinkPreview
+}
+
+func (m *mockLinkPreviewRepository) Get(ctx context.Context, url string) (*domain.LinkPreview, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.previews[url], nil
+}
+
+func (m *mockLinkPreviewRepository) Save(ctx context.Context, preview *domain.LinkPreview) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.previews[preview.URL] = preview
+	return nil
+}
+
+type mockPreviewFetcher struct {
+	mutex   sync.Mutex
+	calls   map[string]int
+	fetched chan string
+	err     error
+}
+
+func (m *mockPreviewFetcher) Fetch(ctx context.Context, url string) (*domain.LinkPreview, error) {
+	m.mutex.Lock()
+	m.calls[url]++
+	m.mutex.Unlock()
+
+	if m.fetched != nil {
+		defer func() { m.fetched <- url }()
+	}
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &domain.LinkPreview{Title: "Title of " + url}, nil
+}
+
+func TestLinkPreviewService_Unfurl(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := &mockLinkPreviewRepository{previews: map[string]*domain.LinkPreview{}}
+	fetcher := &mockPreviewFetcher{calls: map[string]int{}}
+	service := NewLinkPreviewService(repo, fetcher, clock)
+
+	url := "https://example.com/article"
+	if err := service.Unfurl(ctx, url); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	preview := repo.previews[url]
+	if preview == nil || preview.URL != url || !preview.FetchedAt.Equal(clock.Now()) {
+		t.Fatalf("Expected cached preview for %s, got %+v", url, preview)
+	}
+
+	// Cached URLs are not fetched again
+	if err := service.Unfurl(ctx, url); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if fetcher.calls[url] != 1 {
+		t.Errorf("Expected 1 fetch, got %d", fetcher.calls[url])
+	}
+}
+
+func TestLinkPreviewService_AsyncUnfurlAndCards(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := &mockLinkPreviewRepository{previews: map[string]*domain.LinkPreview{}}
+	fetcher := &mockPreviewFetcher{calls: map[string]int{}, fetched: make(chan string, 1)}
+	service := NewLinkPreviewService(repo, fetcher, clock)
+	go service.Run(ctx, 1)
+
+	withLink := &domain.Tweet{ID: "1", UserID: "user1", Content: "Read this: https://example.com/post."}
+	withoutLink := &domain.Tweet{ID: "2", UserID: "user1", Content: "No links here"}
+
+	service.Enqueue(withLink)
+	select {
+	case url := <-fetcher.fetched:
+		if url != "https://example.com/post" {
+			t.Errorf("Expected trailing punctuation to be trimmed, got %s", url)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for the unfurl worker")
+	}
+
+	// Wait for the worker to save the preview
+	deadline := time.Now().Add(time.Second)
+	for {
+		if preview, _ := repo.Get(ctx, "https://example.com/post"); preview != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the preview to be cached")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	tweets, err := service.WithCards(ctx, []*domain.Tweet{withLink, withoutLink})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweets[0].Card == nil || tweets[0].Card.Title != "Title of https://example.com/post" {
+		t.Errorf("Expected card to be attached, got %+v", tweets[0].Card)
+	}
+	if withLink.Card != nil {
+		t.Error("Expected the original tweet to be left unchanged")
+	}
+	if tweets[1] != withoutLink {
+		t.Error("Expected tweets without links to be returned unchanged")
+	}
+}
+
+func TestLinkPreviewService_FailedFetchBackoff(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := &mockLinkPreviewRepository{previews: map[string]*domain.LinkPreview{}}
+	fetcher := &mockPreviewFetcher{calls: map[string]int{}, err: errors.New("connection refused")}
+	service := NewLinkPreviewService(repo, fetcher, clock)
+
+	tweet := &domain.Tweet{ID: "1", UserID: "user1", Content: "https://down.example.com"}
+	if err := service.Unfurl(ctx, tweet.FirstURL()); err == nil {
+		t.Fatal("Expected fetch error")
+	}
+
+	// Failed URLs are not queued again until the retry period passes
+	service.Enqueue(tweet)
+	if len(service.queue) != 0 {
+		t.Errorf("Expected failed URL not to be queued, got %d queued", len(service.queue))
+	}
+
+	clock.Advance(unfurlRetryAfter)
+	service.Enqueue(tweet)
+	service.Enqueue(tweet)
+	if len(service.queue) != 1 {
+		t.Errorf("Expected URL to be queued once after the retry period, got %d queued", len(service.queue))
+	}
+}
+
+func TestLinkPreviewService_RefreshesStalePreviews(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := &mockLinkPreviewRepository{previews: map[string]*domain.LinkPreview{}}
+	fetcher := &mockPreviewFetcher{calls: map[string]int{}}
+	service := NewLinkPreviewService(repo, fetcher, clock)
+
+	tweet := &domain.Tweet{ID: "1", UserID: "user1", Content: "https://example.com/article"}
+	url := tweet.FirstURL()
+	if err := service.Unfurl(ctx, url); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A stale card is still shown, and queued to be fetched again
+	clock.Advance(previewRefreshAfter)
+	tweets, err := service.WithCards(ctx, []*domain.Tweet{tweet})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweets[0].Card == nil {
+		t.Error("Expected the stale card to be attached")
+	}
+	if len(service.queue) != 1 {
+		t.Fatalf("Expected the stale URL to be queued, got %d queued", len(service.queue))
+	}
+
+	if err := service.Unfurl(ctx, <-service.queue); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if fetcher.calls[url] != 2 || !repo.previews[url].FetchedAt.Equal(clock.Now()) {
+		t.Errorf("Expected the preview to be fetched again, got %d fetches, fetched at %v", fetcher.calls[url], repo.previews[url].FetchedAt)
+	}
+}
+
+func TestLinkPreviewService_FailuresAreBounded(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := &mockLinkPreviewRepository{previews: map[string]*domain.LinkPreview{}}
+	fetcher := &mockPreviewFetcher{calls: map[string]int{}, err: errors.New("connection refused")}
+	service := NewLinkPreviewService(repo, fetcher, clock)
+
+	for i := 0; i < maxUnfurlFailures+10; i++ {
+		service.Unfurl(ctx, fmt.Sprintf("https://down%d.example.com", i))
+	}
+	if len(service.failures) != maxUnfurlFailures {
+		t.Errorf("Expected at most %d failures remembered, got %d", maxUnfurlFailures, len(service.failures))
+	}
+
+	// Once they expire, old failures make room for new ones
+	clock.Advance(unfurlRetryAfter)
+	service.Unfurl(ctx, "https://new.example.com")
+	if len(service.failures) != 1 {
+		t.Errorf("Expected expired failures to be pruned, got %d", len(service.failures))
+	}
+
+	// An expired failure is forgotten when its URL is seen again
+	clock.Advance(unfurlRetryAfter)
+	service.Enqueue(&domain.Tweet{ID: "1", UserID: "user1", Content: "https://new.example.com"})
+	if len(service.failures) != 0 {
+		t.Errorf("Expected the expired failure to be forgotten, got %d", len(service.failures))
+	}
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // register WebP decoder
+
+	"uala-challenge/internal/domain"
+)
+
+// Image processing limits
+const (
+	// ThumbnailMaxSize is the maximum width or height of a generated thumbnail
+	ThumbnailMaxSize = 320
+	// maxImagePixels guards against decompression bombs
+	maxImagePixels = 40_000_000
+)
+
+// processedImage is an uploaded image after validation, metadata stripping
+// and thumbnail generation
+type processedImage struct {
+	contentType   string
+	data          []byte
+	width         int
+	height        int
+	thumbnail     []byte
+	thumbnailType string
+}
+
+// processImage validates an upload by magic bytes and size, strips its
+// metadata and generates a thumbnail
+func processImage(data []byte) (*processedImage, error) {
+	if len(data) > domain.MaxMediaSize {
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	contentType := detectImageType(data)
+	if contentType == "" {
+		return nil, domain.ErrUnsupportedMediaType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.ErrInvalidMedia
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, domain.ErrMediaTooLarge
+	}
+
+	stripped, err := stripMetadata(contentType, data)
+	if err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, domain.ErrInvalidMedia
+	}
+
+	thumbnail, thumbnailType, err := makeThumbnail(img, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return &processedImage{
+		contentType:   contentType,
+		data:          stripped,
+		width:         config.Width,
+		height:        config.Height,
+		thumbnail:     thumbnail,
+		thumbnailType: thumbnailType,
+	}, nil
+}
+
+// detectImageType identifies supported images by their magic bytes,
+// returning an empty string for anything else
+func detectImageType(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "image/webp"
+	}
+	return ""
+}
+
+// stripMetadata removes EXIF and other textual metadata without re-encoding
+// the image. GIF has no EXIF support and is returned unchanged.
+func stripMetadata(contentType string, data []byte) ([]byte, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		return stripPNG(data)
+	case "image/webp":
+		return stripWebP(data)
+	}
+	return data, nil
+}
+
+// stripJPEG drops APP1 (EXIF/XMP), APP13 (IPTC) and comment segments
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[0:2]...) // SOI
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, domain.ErrInvalidMedia
+		}
+		// Skip fill bytes
+		for i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+
+		marker := data[i+1]
+		switch {
+		case marker == 0xD9 || marker == 0xDA:
+			// End of image or start of scan: the rest is entropy-coded data
+			return append(out, data[i:]...), nil
+		case marker >= 0xD0 && marker <= 0xD7 || marker == 0x01:
+			// Standalone markers carry no length
+			out = append(out, data[i:i+2]...)
+			i += 2
+			continue
+		}
+
+		if i+4 > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:i+4]))
+		if end > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+
+		if marker != 0xE1 && marker != 0xED && marker != 0xFE {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	return out, nil
+}
+
+// pngMetadataChunks are the PNG chunk types removed by stripPNG
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG drops EXIF, textual and timestamp chunks
+func stripPNG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[0:8]...) // signature
+
+	i := 8
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+		length := int(binary.BigEndian.Uint32(data[i : i+4]))
+		end := i + 12 + length // length + type + data + CRC
+		if length < 0 || end > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	return out, nil
+}
+
+// VP8X feature flags advertising metadata chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP drops EXIF and XMP chunks and clears their VP8X feature flags
+func stripWebP(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[0:12]...) // RIFF header
+
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+		fourCC := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4 : i+8]))
+		end := i + 8 + size + size%2 // chunks are padded to even sizes
+		if size < 0 || end > len(data) {
+			return nil, domain.ErrInvalidMedia
+		}
+
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
+
+// makeThumbnail scales the image to fit within ThumbnailMaxSize. JPEG sources
+// produce JPEG thumbnails; everything else produces PNG to preserve alpha.
+func makeThumbnail(img image.Image, contentType string) ([]byte, string, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > ThumbnailMaxSize || height > ThumbnailMaxSize {
+		if width >= height {
+			height = max(1, height*ThumbnailMaxSize/width)
+			width = ThumbnailMaxSize
+		} else {
+			width = max(1, width*ThumbnailMaxSize/height)
+			height = ThumbnailMaxSize
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+
+	if err := png.Encode(&buf, thumb); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"uala-challenge/internal/domain"
+)
+
+// MediaService handles image uploads and retrieval
+type MediaService struct {
+	mediaRepo domain.MediaRepository
+	blobStore domain.BlobStore
+	clock     domain.Clock
+	ids       domain.IDGenerator
+}
+
+// MediaServiceOption configures optional media service settings
+type MediaServiceOption func(*MediaService)
+
+// WithMediaIDGenerator overrides the generator of uploaded media's IDs
+func WithMediaIDGenerator(ids domain.IDGenerator) MediaServiceOption {
+	return func(s *MediaService) {
+		s.ids = ids
+	}
+}
+
+// NewMediaService creates a new media service
+func NewMediaService(mediaRepo domain.MediaRepository, blobStore domain.BlobStore, clock domain.Clock, opts ...MediaServiceOption) *MediaService {
+	s := &MediaService{
+		mediaRepo: mediaRepo,
+		blobStore: blobStore,
+		clock:     clock,
+		ids:       domain.UUIDv7Generator{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// UploadMediaRequest represents the request to upload an image
+type UploadMediaRequest struct {
+	UserID string `json:"user_id"`
+	Data   []byte `json:"-"`
+}
+
+// Upload validates and processes an image, then stores it and its thumbnail
+// under content-addressed keys
+func (s *MediaService) Upload(ctx context.Context, req UploadMediaRequest) (*domain.Media, error) {
+	processed, err := processImage(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	key := contentKey(processed.data)
+	err = s.blobStore.Put(ctx, key, processed.data)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnailKey := contentKey(processed.thumbnail)
+	err = s.blobStore.Put(ctx, thumbnailKey, processed.thumbnail)
+	if err != nil {
+		return nil, err
+	}
+
+	media := &domain.Media{
+		ID:                   s.ids.NewID(),
+		UserID:               req.UserID,
+		ContentType:          processed.contentType,
+		Size:                 len(processed.data),
+		Width:                processed.width,
+		Height:               processed.height,
+		Key:                  key,
+		ThumbnailKey:         thumbnailKey,
+		ThumbnailContentType: processed.thumbnailType,
+		CreatedAt:            s.clock.Now(),
+	}
+
+	err = s.mediaRepo.Create(ctx, media)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "media uploaded", "media_id", media.ID, "user_id", media.UserID, "content_type", media.ContentType, "size", media.Size)
+	return media, nil
+}
+
+// GetMedia retrieves the metadata of an uploaded image
+func (s *MediaService) GetMedia(ctx context.Context, id string) (*domain.Media, error) {
+	media, err := s.mediaRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if media == nil {
+		return nil, domain.ErrMediaNotFound
+	}
+
+	return media, nil
+}
+
+// OpenMedia opens the stored image, or its thumbnail, returning the blob key
+// (usable as a strong validator) and content type alongside the content
+func (s *MediaService) OpenMedia(ctx context.Context, id string, thumbnail bool) (*MediaContent, error) {
+	media, err := s.GetMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &MediaContent{Key: media.Key, ContentType: media.ContentType}
+	if thumbnail {
+		content.Key = media.ThumbnailKey
+		content.ContentType = media.ThumbnailContentType
+	}
+
+	content.Body, err = s.blobStore.Get(ctx, content.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// MediaContent is an opened media blob. Callers must close Body.
+type MediaContent struct {
+	Key         string
+	ContentType string
+	Body        io.ReadCloser
+}
+
+// contentKey derives the content-addressed blob key for data
+func contentKey(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
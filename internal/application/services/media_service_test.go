@@ -0,0 +1,301 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+type mockMediaRepository struct {
+	media map[string]*domain.Media
+}
+
+func (m *mockMediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	m.media[media.ID] = media
+	return nil
+}
+
+func (m *mockMediaRepository) GetByID(ctx context.Context, id string) (*domain.Media, error) {
+	return m.media[id], nil
+}
+
+type mockBlobStore struct {
+	blobs map[string][]byte
+}
+
+func (m *mockBlobStore) Put(ctx context.Context, key string, data []byte) error {
+	m.blobs[key] = data
+	return nil
+}
+
+func (m *mockBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, exists := m.blobs[key]
+	if !exists {
+		return nil, domain.ErrBlobNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// testImage returns a solid-colour image of the given size
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode PNG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// encodeJPEGWithEXIF encodes a JPEG and inserts an APP1 EXIF segment after SOI
+func encodeJPEGWithEXIF(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	data := buf.Bytes()
+
+	payload := []byte("Exif\x00\x00GPS-SECRET-LOCATION")
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+// pngChunk builds a PNG chunk with a zero CRC; tests only use it for chunks that get stripped
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(data)))
+	copy(chunk[4:8], chunkType)
+	chunk = append(chunk, data...)
+	return append(chunk, 0, 0, 0, 0)
+}
+
+func TestDetectImageType(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "image/jpeg"},
+		{"png", []byte("\x89PNG\r\n\x1a\n...."), "image/png"},
+		{"gif87a", []byte("GIF87a...."), "image/gif"},
+		{"gif89a", []byte("GIF89a...."), "image/gif"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"svg", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), ""},
+		{"riff but not webp", []byte("RIFF\x00\x00\x00\x00WAVEfmt "), ""},
+		{"empty", []byte{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectImageType(tt.data); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestStripMetadata(t *testing.T) {
+	t.Run("jpeg APP1 segment is removed", func(t *testing.T) {
+		data := encodeJPEGWithEXIF(t, testImage(8, 8))
+
+		stripped, err := stripMetadata("image/jpeg", data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if bytes.Contains(stripped, []byte("GPS-SECRET-LOCATION")) {
+			t.Error("Expected EXIF payload to be stripped")
+		}
+		if _, err := jpeg.Decode(bytes.NewReader(stripped)); err != nil {
+			t.Errorf("Expected stripped JPEG to decode, got %v", err)
+		}
+	})
+
+	t.Run("png eXIf and text chunks are removed", func(t *testing.T) {
+		data := encodePNG(t, testImage(8, 8))
+		// Insert metadata chunks right after IHDR (8 byte signature + 25 byte IHDR)
+		withMeta := append([]byte{}, data[:33]...)
+		withMeta = append(withMeta, pngChunk("eXIf", []byte("GPS-SECRET-LOCATION"))...)
+		withMeta = append(withMeta, pngChunk("tEXt", []byte("Author\x00Someone"))...)
+		withMeta = append(withMeta, data[33:]...)
+
+		stripped, err := stripMetadata("image/png", withMeta)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !bytes.Equal(stripped, data) {
+			t.Error("Expected metadata chunks to be stripped")
+		}
+	})
+
+	t.Run("webp EXIF and XMP chunks are removed", func(t *testing.T) {
+		chunk := func(fourCC string, data []byte) []byte {
+			c := append([]byte(fourCC), 0, 0, 0, 0)
+			binary.LittleEndian.PutUint32(c[4:], uint32(len(data)))
+			c = append(c, data...)
+			if len(data)%2 == 1 {
+				c = append(c, 0)
+			}
+			return c
+		}
+		var body []byte
+		body = append(body, chunk("VP8X", []byte{webpFlagEXIF | webpFlagXMP, 0, 0, 0, 0, 0, 0, 0, 0, 0})...)
+		body = append(body, chunk("VP8L", []byte{1, 2, 3})...)
+		body = append(body, chunk("EXIF", []byte("GPS-SECRET-LOCATION"))...)
+		body = append(body, chunk("XMP ", []byte("<x/>"))...)
+		data := append([]byte("RIFF\x00\x00\x00\x00WEBP"), body...)
+		binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+		stripped, err := stripMetadata("image/webp", data)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if bytes.Contains(stripped, []byte("GPS-SECRET-LOCATION")) || bytes.Contains(stripped, []byte("XMP ")) {
+			t.Error("Expected metadata chunks to be stripped")
+		}
+		if stripped[20]&(webpFlagEXIF|webpFlagXMP) != 0 {
+			t.Error("Expected VP8X metadata flags to be cleared")
+		}
+		if size := binary.LittleEndian.Uint32(stripped[4:8]); int(size) != len(stripped)-8 {
+			t.Errorf("Expected RIFF size %d, got %d", len(stripped)-8, size)
+		}
+	})
+
+	t.Run("truncated jpeg is rejected", func(t *testing.T) {
+		if _, err := stripMetadata("image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF}); err != domain.ErrInvalidMedia {
+			t.Errorf("Expected %v, got %v", domain.ErrInvalidMedia, err)
+		}
+	})
+}
+
+func TestMediaService_Upload(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		name      string
+		data      []byte
+		errorType error
+	}{
+		{"unsupported type", []byte("GIF? no, plain text"), domain.ErrUnsupportedMediaType},
+		{"corrupt png", []byte("\x89PNG\r\n\x1a\ngarbage"), domain.ErrInvalidMedia},
+		{"too large", append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, domain.MaxMediaSize)...), domain.ErrMediaTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewMediaService(&mockMediaRepository{media: map[string]*domain.Media{}}, &mockBlobStore{blobs: map[string][]byte{}}, clock)
+			if _, err := service.Upload(ctx, UploadMediaRequest{UserID: "user123", Data: tt.data}); err != tt.errorType {
+				t.Errorf("Expected %v, got %v", tt.errorType, err)
+			}
+		})
+	}
+
+	t.Run("jpeg is stripped and thumbnailed", func(t *testing.T) {
+		mediaRepo := &mockMediaRepository{media: map[string]*domain.Media{}}
+		blobStore := &mockBlobStore{blobs: map[string][]byte{}}
+		service := NewMediaService(mediaRepo, blobStore, clock)
+
+		media, err := service.Upload(ctx, UploadMediaRequest{UserID: "user123", Data: encodeJPEGWithEXIF(t, testImage(640, 480))})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if media.ContentType != "image/jpeg" || media.Width != 640 || media.Height != 480 {
+			t.Errorf("Unexpected media metadata: %+v", media)
+		}
+		if media.Key != contentKey(blobStore.blobs[media.Key]) {
+			t.Error("Expected blob to be stored under its content hash")
+		}
+		if bytes.Contains(blobStore.blobs[media.Key], []byte("GPS-SECRET-LOCATION")) {
+			t.Error("Expected stored blob to be stripped of EXIF")
+		}
+
+		content, err := service.OpenMedia(ctx, media.ID, true)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer content.Body.Close()
+
+		thumb, err := jpeg.Decode(content.Body)
+		if err != nil {
+			t.Fatalf("Expected JPEG thumbnail, got %v", err)
+		}
+		if b := thumb.Bounds(); b.Dx() != ThumbnailMaxSize || b.Dy() != 240 {
+			t.Errorf("Expected %dx240 thumbnail, got %dx%d", ThumbnailMaxSize, b.Dx(), b.Dy())
+		}
+	})
+
+	t.Run("unknown media", func(t *testing.T) {
+		service := NewMediaService(&mockMediaRepository{media: map[string]*domain.Media{}}, &mockBlobStore{blobs: map[string][]byte{}}, clock)
+		if _, err := service.OpenMedia(ctx, "missing", false); err != domain.ErrMediaNotFound {
+			t.Errorf("Expected %v, got %v", domain.ErrMediaNotFound, err)
+		}
+	})
+}
+
+func TestTweetService_CreateTweetWithMedia(t *testing.T) {
+	ctx := context.Background()
+	mediaRepo := &mockMediaRepository{media: map[string]*domain.Media{
+		"m1":    {ID: "m1", UserID: "user123"},
+		"m2":    {ID: "m2", UserID: "user123"},
+		"m3":    {ID: "m3", UserID: "user123"},
+		"m4":    {ID: "m4", UserID: "user123"},
+		"m5":    {ID: "m5", UserID: "user123"},
+		"other": {ID: "other", UserID: "someone-else"},
+	}}
+
+	tests := []struct {
+		name      string
+		mediaIDs  []string
+		errorType error
+	}{
+		{"single image", []string{"m1"}, nil},
+		{"four images with a duplicate", []string{"m1", "m2", "m3", "m4", "m1"}, nil},
+		{"five images", []string{"m1", "m2", "m3", "m4", "m5"}, domain.ErrTooManyMedia},
+		{"unknown media", []string{"missing"}, domain.ErrUnknownMediaID},
+		{"media owned by another user", []string{"other"}, domain.ErrUnknownMediaID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userRepo := &mockUserRepository{users: make(map[string]*domain.User)}
+			tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{}}
+			service := NewTweetService(tweetRepo, userRepo, WithMediaRepository(mediaRepo))
+
+			tweet, err := service.CreateTweet(ctx, CreateTweetRequest{UserID: "user123", Content: "Look!", MediaIDs: tt.mediaIDs})
+			if !errors.Is(err, tt.errorType) {
+				t.Fatalf("Expected %v, got %v", tt.errorType, err)
+			}
+			if err == nil && len(tweet.MediaIDs) > domain.MaxMediaPerTweet {
+				t.Errorf("Expected at most %d media, got %d", domain.MaxMediaPerTweet, len(tweet.MediaIDs))
+			}
+			if err != nil && len(tweetRepo.tweets) != 0 {
+				t.Error("Expected no tweet to be saved")
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// Outbox relay defaults
+const (
+	DefaultOutboxPollInterval = time.Second
+	outboxEventsPerPoll       = 100
+	outboxRetention           = 24 * time.Hour
+	outboxPruneInterval       = time.Hour
+	// minOutboxStallAfter is the shortest age of the oldest pending event at
+	// which the relay is reported stalled
+	minOutboxStallAfter = time.Minute
+)
+
+// OutboxRelay publishes the events committed to the outbox on an event bus
+// and marks them dispatched once every subscriber has handled them. Events
+// whose subscribers failed, or that were committed just before a crash, stay
+// pending and are published again, so subscribers never miss one. Until the
+// process restarts, an event is only published again to the subscribers that
+// failed it.
+type OutboxRelay struct {
+	outbox       domain.OutboxRepository
+	bus          *EventBus
+	clock        domain.Clock
+	pollInterval time.Duration
+
+	// dispatchMutex serializes Dispatch so that events are published in
+	// order; relayed holds the events published but not yet dispatched
+	dispatchMutex sync.Mutex
+	relayedMutex  sync.Mutex
+	relayed       map[string]*relayedEvent
+}
+
+// relayedEvent is a pending event that has been published at least once
+type relayedEvent struct {
+	handled HandledBy
+	// inFlight is set while subscribers are handling the event
+	inFlight bool
+}
+
+// OutboxRelayOption configures optional outbox relay settings
+type OutboxRelayOption func(*OutboxRelay)
+
+// WithOutboxPollInterval overrides how often Run looks for pending events
+func WithOutboxPollInterval(interval time.Duration) OutboxRelayOption {
+	return func(r *OutboxRelay) {
+		r.pollInterval = interval
+	}
+}
+
+// NewOutboxRelay creates a new outbox relay
+func NewOutboxRelay(outbox domain.OutboxRepository, bus *EventBus, clock domain.Clock, opts ...OutboxRelayOption) *OutboxRelay {
+	r := &OutboxRelay{
+		outbox:       outbox,
+		bus:          bus,
+		clock:        clock,
+		pollInterval: DefaultOutboxPollInterval,
+		relayed:      make(map[string]*relayedEvent),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// UnitOfWork wraps uow so that the events of every committed transaction are
+// dispatched before Do returns, and synchronous subscribers have seen them
+// by the time the change is acknowledged. Failing to dispatch does not fail
+// the transaction: the events stay in the outbox for Run.
+func (r *OutboxRelay) UnitOfWork(uow domain.UnitOfWork) domain.UnitOfWork {
+	return &dispatchingUnitOfWork{UnitOfWork: uow, relay: r}
+}
+
+type dispatchingUnitOfWork struct {
+	domain.UnitOfWork
+	relay *OutboxRelay
+}
+
+func (u *dispatchingUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	err := u.UnitOfWork.Do(ctx, fn)
+	if err != nil {
+		return err
+	}
+
+	_, err = u.relay.Dispatch(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to dispatch events, will retry", "error", err)
+	}
+	return nil
+}
+
+// Dispatch publishes pending events, oldest first, and returns how many were
+// published. Events a subscriber fails stay pending without holding up the
+// ones after them.
+func (r *OutboxRelay) Dispatch(ctx context.Context) (int, error) {
+	r.dispatchMutex.Lock()
+	defer r.dispatchMutex.Unlock()
+
+	pending, err := r.outbox.GetPending(ctx, outboxEventsPerPoll)
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	for _, stored := range pending {
+		handled, ok := r.begin(stored.ID)
+		if !ok {
+			continue
+		}
+
+		event, err := stored.Decode()
+		if err != nil {
+			// Retrying cannot help, so the event is dropped rather than
+			// reported on every poll
+			slog.ErrorContext(ctx, "dropping undecodable event", "event_id", stored.ID, "event_type", stored.Type, "error", err)
+			r.finish(ctx, stored.ID, nil)
+			continue
+		}
+
+		id := stored.ID
+		r.bus.Publish(ctx, event, handled, func(err error) {
+			r.finish(ctx, id, err)
+		})
+		published++
+	}
+	return published, nil
+}
+
+// begin marks an event in flight, unless it already is, and returns the
+// subscribers that have handled it so far
+func (r *OutboxRelay) begin(id string) (*HandledBy, bool) {
+	r.relayedMutex.Lock()
+	defer r.relayedMutex.Unlock()
+
+	relayed := r.relayed[id]
+	if relayed == nil {
+		relayed = &relayedEvent{}
+		r.relayed[id] = relayed
+	}
+	if relayed.inFlight {
+		return nil, false
+	}
+	relayed.inFlight = true
+	return &relayed.handled, true
+}
+
+// finish marks an event dispatched once every subscriber handled it and
+// forgets it; a failed event is left pending for the next poll
+func (r *OutboxRelay) finish(ctx context.Context, id string, err error) {
+	if err == nil {
+		err = r.outbox.MarkDispatched(context.WithoutCancel(ctx), id, r.clock.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to mark event dispatched", "event_id", id, "error", err)
+		}
+	}
+
+	r.relayedMutex.Lock()
+	defer r.relayedMutex.Unlock()
+	if err == nil {
+		delete(r.relayed, id)
+	} else if relayed := r.relayed[id]; relayed != nil {
+		relayed.inFlight = false
+	}
+}
+
+// Check fails when the oldest pending event has waited too long, which means
+// the relay or a subscriber has stalled and events no longer reach webhooks,
+// the live feed or federation
+func (r *OutboxRelay) Check(ctx context.Context) error {
+	pending, err := r.outbox.GetPending(ctx, 1)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	stallAfter := max(3*r.pollInterval, minOutboxStallAfter)
+	if age := r.clock.Now().Sub(pending[0].OccurredAt); age > stallAfter {
+		return fmt.Errorf("event bus stalled: oldest pending event is %s old", age.Round(time.Second))
+	}
+	return nil
+}
+
+// Run publishes pending events every poll interval until the context is
+// cancelled, starting with those left over from before a restart, and prunes
+// dispatched events after a day
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	var pruned time.Time
+
+	for {
+		_, err := r.Dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "failed to dispatch events", "error", err)
+		}
+
+		if now := r.clock.Now(); now.Sub(pruned) >= outboxPruneInterval {
+			pruned = now
+			err = r.outbox.DeleteDispatchedBefore(ctx, now.Add(-outboxRetention))
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to prune outbox", "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
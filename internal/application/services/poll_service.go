@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+)
+
+// PollService handles poll voting and result visibility
+type PollService struct {
+	tweetRepo domain.TweetRepository
+	pollRepo  domain.PollRepository
+	clock     domain.Clock
+}
+
+// NewPollService creates a new poll service
+func NewPollService(tweetRepo domain.TweetRepository, pollRepo domain.PollRepository, clock domain.Clock) *PollService {
+	return &PollService{
+		tweetRepo: tweetRepo,
+		pollRepo:  pollRepo,
+		clock:     clock,
+	}
+}
+
+// VotePollRequest represents the request to vote in a tweet's poll
+type VotePollRequest struct {
+	UserID  string `json:"user_id"`
+	TweetID string `json:"tweet_id"`
+	Option  int    `json:"option"`
+}
+
+// Vote records the user's vote and returns the tweet with the results now visible
+func (s *PollService) Vote(ctx context.Context, req VotePollRequest) (*domain.Tweet, error) {
+	tweet, err := s.tweetRepo.GetByID(ctx, req.TweetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tweet == nil || tweet.Poll == nil {
+		return nil, domain.ErrPollNotFound
+	}
+
+	err = tweet.Poll.ValidateVote(req.Option, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository enforces one vote per user
+	err = s.pollRepo.Vote(ctx, req.TweetID, req.UserID, req.Option)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.view(ctx, req.UserID, tweet)
+}
+
+// WithResults returns copies of the tweets with poll results resolved for the
+// viewer. Tweets without a poll are returned unchanged.
+func (s *PollService) WithResults(ctx context.Context, viewerID string, tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	viewed := make([]*domain.Tweet, len(tweets))
+	for i, tweet := range tweets {
+		if tweet.Poll == nil {
+			viewed[i] = tweet
+			continue
+		}
+
+		view, err := s.view(ctx, viewerID, tweet)
+		if err != nil {
+			return nil, err
+		}
+		viewed[i] = view
+	}
+
+	return viewed, nil
+}
+
+func (s *PollService) view(ctx context.Context, viewerID string, tweet *domain.Tweet) (*domain.Tweet, error) {
+	votedOption := -1
+	if viewerID != "" {
+		option, voted, err := s.pollRepo.GetVote(ctx, tweet.ID, viewerID)
+		if err != nil {
+			return nil, err
+		}
+		if voted {
+			votedOption = option
+		}
+	}
+
+	tallies, err := s.pollRepo.GetTallies(ctx, tweet.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	view := *tweet
+	view.Poll = tweet.Poll.View(tallies, votedOption, s.clock.Now())
+	return &view, nil
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+type mockPollRepository struct {
+	votes map[string]map[string]int // tweetID -> userID -> option
+}
+
+func (m *mockPollRepository) Vote(ctx context.Context, tweetID, userID string, option int) error {
+	if m.votes[tweetID] == nil {
+		m.votes[tweetID] = make(map[string]int)
+	}
+	if _, voted := m.votes[tweetID][userID]; voted {
+		return domain.ErrAlreadyVoted
+	}
+	m.votes[tweetID][userID] = option
+	return nil
+}
+
+func (m *mockPollRepository) GetVote(ctx context.Context, tweetID, userID string) (int, bool, error) {
+	option, voted := m.votes[tweetID][userID]
+	return option, voted, nil
+}
+
+func (m *mockPollRepository) GetTallies(ctx context.Context, tweetID string) ([]int, error) {
+	tallies := make([]int, domain.MaxPollOptions)
+	for _, option := range m.votes[tweetID] {
+		tallies[option]++
+	}
+	return tallies, nil
+}
+
+func TestPollService_Vote(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	poll, err := domain.NewPoll([]string{"Yes", "No"}, time.Hour, clock.Now())
+	if err != nil {
+		t.Fatalf("Failed to create poll: %v", err)
+	}
+	pollTweet := &domain.Tweet{ID: "poll", UserID: "author", Content: "Do you like Go?", Poll: poll}
+	plainTweet := &domain.Tweet{ID: "plain", UserID: "author", Content: "No poll here"}
+
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{pollTweet, plainTweet}}
+	pollRepo := &mockPollRepository{votes: make(map[string]map[string]int)}
+	service := NewPollService(tweetRepo, pollRepo, clock)
+
+	tweet, err := service.Vote(ctx, VotePollRequest{UserID: "user1", TweetID: "poll", Option: 0})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweet.Poll.TotalVotes == nil || *tweet.Poll.TotalVotes != 1 {
+		t.Error("Expected results to be visible after voting")
+	}
+
+	tests := []struct {
+		name      string
+		req       VotePollRequest
+		errorType error
+	}{
+		{"second vote", VotePollRequest{UserID: "user1", TweetID: "poll", Option: 1}, domain.ErrAlreadyVoted},
+		{"invalid option", VotePollRequest{UserID: "user2", TweetID: "poll", Option: 5}, domain.ErrInvalidPollOption},
+		{"tweet without poll", VotePollRequest{UserID: "user2", TweetID: "plain", Option: 0}, domain.ErrPollNotFound},
+		{"unknown tweet", VotePollRequest{UserID: "user2", TweetID: "missing", Option: 0}, domain.ErrPollNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Vote(ctx, tt.req)
+			if err != tt.errorType {
+				t.Errorf("Expected error %v, got %v", tt.errorType, err)
+			}
+		})
+	}
+
+	clock.Advance(time.Hour)
+	_, err = service.Vote(ctx, VotePollRequest{UserID: "user2", TweetID: "poll", Option: 0})
+	if err != domain.ErrPollClosed {
+		t.Errorf("Expected error %v, got %v", domain.ErrPollClosed, err)
+	}
+}
+
+func TestPollService_WithResults(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	poll, _ := domain.NewPoll([]string{"Yes", "No"}, time.Hour, clock.Now())
+	pollTweet := &domain.Tweet{ID: "poll", UserID: "author", Content: "Do you like Go?", Poll: poll}
+	plainTweet := &domain.Tweet{ID: "plain", UserID: "author", Content: "No poll here"}
+
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{pollTweet, plainTweet}}
+	pollRepo := &mockPollRepository{votes: map[string]map[string]int{"poll": {"voter": 1}}}
+	service := NewPollService(tweetRepo, pollRepo, clock)
+
+	tweets, err := service.WithResults(ctx, "lurker", tweetRepo.tweets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweets[0].Poll.TotalVotes != nil {
+		t.Error("Expected results hidden from a viewer who has not voted")
+	}
+	if tweets[1] != plainTweet {
+		t.Error("Expected tweets without a poll to be returned unchanged")
+	}
+
+	tweets, err = service.WithResults(ctx, "voter", tweetRepo.tweets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweets[0].Poll.TotalVotes == nil || *tweets[0].Poll.Options[1].Votes != 1 {
+		t.Error("Expected results visible to a viewer who has voted")
+	}
+
+	clock.Advance(time.Hour)
+	tweets, err = service.WithResults(ctx, "lurker", tweetRepo.tweets)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !tweets[0].Poll.Closed || tweets[0].Poll.TotalVotes == nil {
+		t.Error("Expected results visible to everyone once the poll closes")
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// ScheduleService handles scheduled tweet business logic
+type ScheduleService struct {
+	scheduledRepo domain.ScheduledTweetRepository
+	userRepo      domain.UserRepository
+	uow           domain.UnitOfWork
+	clock         domain.Clock
+	ids           domain.IDGenerator
+	maxLength     int
+}
+
+// ScheduleServiceOption configures optional schedule service settings
+type ScheduleServiceOption func(*ScheduleService)
+
+// WithScheduledTweetMaxLength overrides the default tweet length limit
+func WithScheduledTweetMaxLength(maxLength int) ScheduleServiceOption {
+	return func(s *ScheduleService) {
+		s.maxLength = maxLength
+	}
+}
+
+// WithScheduleUnitOfWork stores created users and published tweets through
+// uow, recording a UserCreated or TweetCreated event with each
+func WithScheduleUnitOfWork(uow domain.UnitOfWork) ScheduleServiceOption {
+	return func(s *ScheduleService) {
+		s.uow = uow
+	}
+}
+
+// WithScheduleIDGenerator overrides the generator of scheduled tweets' IDs,
+// which their tweets keep when published
+func WithScheduleIDGenerator(ids domain.IDGenerator) ScheduleServiceOption {
+	return func(s *ScheduleService) {
+		s.ids = ids
+	}
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(scheduledRepo domain.ScheduledTweetRepository, tweetRepo domain.TweetRepository, userRepo domain.UserRepository, clock domain.Clock, opts ...ScheduleServiceOption) *ScheduleService {
+	s := &ScheduleService{
+		scheduledRepo: scheduledRepo,
+		userRepo:      userRepo,
+		clock:         clock,
+		ids:           domain.UUIDv7Generator{},
+		maxLength:     domain.MaxTweetLength,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.uow == nil {
+		s.uow = directUnitOfWork{users: userRepo, tweets: tweetRepo}
+	}
+	return s
+}
+
+// ScheduleTweetRequest represents the request to schedule a tweet
+type ScheduleTweetRequest struct {
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// RescheduleTweetRequest represents the request to move a scheduled tweet
+type RescheduleTweetRequest struct {
+	UserID    string    `json:"user_id"`
+	ID        string    `json:"id"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+// ScheduleTweet validates a tweet and queues it for publication
+func (s *ScheduleService) ScheduleTweet(ctx context.Context, req ScheduleTweetRequest) (*domain.ScheduledTweet, error) {
+	// Create scheduled tweet with domain validation
+	scheduled, err := domain.NewScheduledTweetWithLimit(s.ids.NewID(), req.UserID, req.Content, req.PublishAt, s.clock.Now(), s.maxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if user exists, create if not
+	user, err := s.userRepo.GetByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		err = s.uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+			user := &domain.User{ID: req.UserID, Name: "User-" + req.UserID}
+			err := tx.Users().Create(ctx, user)
+			if err != nil {
+				return err
+			}
+			tx.Record(domain.UserCreated{User: user})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.scheduledRepo.Create(ctx, scheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "tweet scheduled", "scheduled_tweet_id", scheduled.ID, "user_id", scheduled.UserID, "publish_at", scheduled.PublishAt)
+	return scheduled, nil
+}
+
+// GetScheduledTweets retrieves the pending scheduled tweets of a user (soonest first)
+func (s *ScheduleService) GetScheduledTweets(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	return s.scheduledRepo.GetByUserID(ctx, userID)
+}
+
+// RescheduleTweet changes the publish time of a pending scheduled tweet
+func (s *ScheduleService) RescheduleTweet(ctx context.Context, req RescheduleTweetRequest) (*domain.ScheduledTweet, error) {
+	scheduled, err := s.getOwned(ctx, req.UserID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.PublishAt.After(s.clock.Now()) {
+		return nil, domain.ErrPublishTimeInPast
+	}
+
+	scheduled.PublishAt = req.PublishAt
+	err = s.scheduledRepo.Update(ctx, scheduled)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "scheduled tweet rescheduled", "scheduled_tweet_id", scheduled.ID, "publish_at", scheduled.PublishAt)
+	return scheduled, nil
+}
+
+// CancelScheduledTweet removes a pending scheduled tweet
+func (s *ScheduleService) CancelScheduledTweet(ctx context.Context, userID, id string) error {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+
+	err := s.scheduledRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "scheduled tweet cancelled", "scheduled_tweet_id", id)
+	return nil
+}
+
+// PublishDue publishes every scheduled tweet whose publish time has passed
+// and returns how many tweets were published. A tweet that fails is logged
+// and left for the next call, without holding up the others.
+func (s *ScheduleService) PublishDue(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+
+	due, err := s.scheduledRepo.GetDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	published, failed := 0, 0
+	for _, scheduled := range due {
+		created, err := s.publish(ctx, scheduled, now)
+		if created {
+			published++
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to publish scheduled tweet", "scheduled_tweet_id", scheduled.ID, "error", err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return published, fmt.Errorf("%d of %d due scheduled tweets failed to publish", failed, len(due))
+	}
+	return published, nil
+}
+
+// publish creates the tweet of a scheduled tweet and then deletes the
+// scheduled tweet. They are kept in different stores and cannot commit
+// together, so publish is idempotent instead: the tweet has the scheduled
+// tweet's ID, and a scheduled tweet whose tweet already exists, because its
+// delete failed last time, is only deleted. created reports whether this
+// call published the tweet.
+func (s *ScheduleService) publish(ctx context.Context, scheduled *domain.ScheduledTweet, now time.Time) (created bool, err error) {
+	tweet := scheduled.Tweet(now)
+	err = s.uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		existing, err := tx.Tweets().GetByID(ctx, tweet.ID)
+		if err != nil || existing != nil {
+			return err
+		}
+		if err := tx.Tweets().Create(ctx, tweet); err != nil {
+			return err
+		}
+		tx.Record(domain.TweetCreated{Tweet: tweet})
+		created = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err := s.scheduledRepo.Delete(ctx, scheduled.ID); err != nil {
+		return created, err
+	}
+	if created {
+		slog.DebugContext(ctx, "scheduled tweet published", "scheduled_tweet_id", scheduled.ID, "user_id", scheduled.UserID)
+	}
+	return created, nil
+}
+
+// getOwned loads a scheduled tweet, hiding tweets that belong to other users
+func (s *ScheduleService) getOwned(ctx context.Context, userID, id string) (*domain.ScheduledTweet, error) {
+	scheduled, err := s.scheduledRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if scheduled == nil || scheduled.UserID != userID {
+		return nil, domain.ErrScheduledTweetNotFound
+	}
+
+	return scheduled, nil
+}
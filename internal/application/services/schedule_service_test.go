@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+type mockScheduledTweetRepository struct {
+	scheduled map[string]*domain.ScheduledTweet
+	// deleteErr fails deletes of the scheduled tweets it has
+	deleteErr map[string]error
+}
+
+func (m *mockScheduledTweetRepository) Create(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	m.scheduled[tweet.ID] = tweet
+	return nil
+}
+
+func (m *mockScheduledTweetRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledTweet, error) {
+	return m.scheduled[id], nil
+}
+
+func (m *mockScheduledTweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	var tweets []*domain.ScheduledTweet
+	for _, tweet := range m.scheduled {
+		if tweet.UserID == userID {
+			tweets = append(tweets, tweet)
+		}
+	}
+	return tweets, nil
+}
+
+func (m *mockScheduledTweetRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ScheduledTweet, error) {
+	var tweets []*domain.ScheduledTweet
+	for _, tweet := range m.scheduled {
+		if !tweet.PublishAt.After(now) {
+			tweets = append(tweets, tweet)
+		}
+	}
+	return tweets, nil
+}
+
+func (m *mockScheduledTweetRepository) Update(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	m.scheduled[tweet.ID] = tweet
+	return nil
+}
+
+func (m *mockScheduledTweetRepository) Delete(ctx context.Context, id string) error {
+	if err := m.deleteErr[id]; err != nil {
+		return err
+	}
+	delete(m.scheduled, id)
+	return nil
+}
+
+func newTestScheduleService() (*ScheduleService, *mockScheduledTweetRepository, *mockTweetRepository, *domaintest.FakeClock) {
+	scheduledRepo := &mockScheduledTweetRepository{scheduled: make(map[string]*domain.ScheduledTweet)}
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{}}
+	userRepo := &mockUserRepository{users: make(map[string]*domain.User)}
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	return NewScheduleService(scheduledRepo, tweetRepo, userRepo, clock), scheduledRepo, tweetRepo, clock
+}
+
+func TestScheduleService_ScheduleTweet(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		req         ScheduleTweetRequest
+		expectError error
+	}{
+		{
+			name:        "valid scheduled tweet",
+			req:         ScheduleTweetRequest{UserID: "user123", Content: "Launch day!", PublishAt: now.Add(time.Hour)},
+			expectError: nil,
+		},
+		{
+			name:        "empty content",
+			req:         ScheduleTweetRequest{UserID: "user123", Content: "", PublishAt: now.Add(time.Hour)},
+			expectError: domain.ErrTweetEmpty,
+		},
+		{
+			name:        "publish time in the past",
+			req:         ScheduleTweetRequest{UserID: "user123", Content: "Too late", PublishAt: now.Add(-time.Hour)},
+			expectError: domain.ErrPublishTimeInPast,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, scheduledRepo, tweetRepo, _ := newTestScheduleService()
+
+			scheduled, err := service.ScheduleTweet(ctx, tt.req)
+			if err != tt.expectError {
+				t.Fatalf("Expected error %v, got %v", tt.expectError, err)
+			}
+
+			if tt.expectError == nil {
+				if scheduledRepo.scheduled[scheduled.ID] == nil {
+					t.Error("Expected scheduled tweet to be stored")
+				}
+				if len(tweetRepo.tweets) != 0 {
+					t.Errorf("Expected no published tweets yet, got %d", len(tweetRepo.tweets))
+				}
+			}
+		})
+	}
+}
+
+func TestScheduleService_PublishDue(t *testing.T) {
+	ctx := context.Background()
+	service, scheduledRepo, tweetRepo, clock := newTestScheduleService()
+
+	soon, err := service.ScheduleTweet(ctx, ScheduleTweetRequest{UserID: "user123", Content: "Soon", PublishAt: clock.Now().Add(time.Minute)})
+	if err != nil {
+		t.Fatalf("Failed to schedule tweet: %v", err)
+	}
+	_, err = service.ScheduleTweet(ctx, ScheduleTweetRequest{UserID: "user123", Content: "Later", PublishAt: clock.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to schedule tweet: %v", err)
+	}
+
+	// Nothing is due yet
+	published, err := service.PublishDue(ctx)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if published != 0 {
+		t.Errorf("Expected 0 published tweets, got %d", published)
+	}
+
+	clock.Advance(time.Minute)
+
+	published, err = service.PublishDue(ctx)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if published != 1 {
+		t.Fatalf("Expected 1 published tweet, got %d", published)
+	}
+
+	if len(tweetRepo.tweets) != 1 || tweetRepo.tweets[0].ID != soon.ID {
+		t.Errorf("Expected tweet %s to be published", soon.ID)
+	}
+	if !tweetRepo.tweets[0].CreatedAt.Equal(clock.Now()) {
+		t.Errorf("Expected CreatedAt %v, got %v", clock.Now(), tweetRepo.tweets[0].CreatedAt)
+	}
+	if len(scheduledRepo.scheduled) != 1 {
+		t.Errorf("Expected 1 pending scheduled tweet, got %d", len(scheduledRepo.scheduled))
+	}
+}
+
+func TestScheduleService_PublishDueContinuesPastFailures(t *testing.T) {
+	ctx := context.Background()
+	service, scheduledRepo, tweetRepo, clock := newTestScheduleService()
+
+	var ids []string
+	for _, content := range []string{"First", "Second", "Third"} {
+		scheduled, err := service.ScheduleTweet(ctx, ScheduleTweetRequest{UserID: "user123", Content: content, PublishAt: clock.Now().Add(time.Minute)})
+		if err != nil {
+			t.Fatalf("Failed to schedule tweet: %v", err)
+		}
+		ids = append(ids, scheduled.ID)
+	}
+	clock.Advance(time.Minute)
+
+	// The first tweet is published but stays scheduled; the others are
+	// published all the same
+	scheduledRepo.deleteErr = map[string]error{ids[0]: errors.New("disk full")}
+	published, err := service.PublishDue(ctx)
+	if err == nil {
+		t.Error("Expected the failure to be reported")
+	}
+	if published != 3 || len(tweetRepo.tweets) != 3 {
+		t.Fatalf("Expected 3 published tweets, got %d and %d stored", published, len(tweetRepo.tweets))
+	}
+	if len(scheduledRepo.scheduled) != 1 || scheduledRepo.scheduled[ids[0]] == nil {
+		t.Errorf("Expected only %s to stay scheduled, got %v", ids[0], scheduledRepo.scheduled)
+	}
+
+	// Retrying deletes it without publishing it again
+	scheduledRepo.deleteErr = nil
+	published, err = service.PublishDue(ctx)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if published != 0 || len(tweetRepo.tweets) != 3 {
+		t.Errorf("Expected no tweet to be published again, got %d and %d stored", published, len(tweetRepo.tweets))
+	}
+	if len(scheduledRepo.scheduled) != 0 {
+		t.Errorf("Expected no pending scheduled tweets, got %d", len(scheduledRepo.scheduled))
+	}
+}
+
+func TestScheduleService_RescheduleAndCancel(t *testing.T) {
+	ctx := context.Background()
+	service, scheduledRepo, _, clock := newTestScheduleService()
+
+	scheduled, err := service.ScheduleTweet(ctx, ScheduleTweetRequest{UserID: "user123", Content: "Hello", PublishAt: clock.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to schedule tweet: %v", err)
+	}
+
+	// Another user cannot see or modify the tweet
+	_, err = service.RescheduleTweet(ctx, RescheduleTweetRequest{UserID: "intruder", ID: scheduled.ID, PublishAt: clock.Now().Add(2 * time.Hour)})
+	if err != domain.ErrScheduledTweetNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrScheduledTweetNotFound, err)
+	}
+
+	_, err = service.RescheduleTweet(ctx, RescheduleTweetRequest{UserID: "user123", ID: scheduled.ID, PublishAt: clock.Now().Add(-time.Hour)})
+	if err != domain.ErrPublishTimeInPast {
+		t.Errorf("Expected %v, got %v", domain.ErrPublishTimeInPast, err)
+	}
+
+	newTime := clock.Now().Add(2 * time.Hour)
+	rescheduled, err := service.RescheduleTweet(ctx, RescheduleTweetRequest{UserID: "user123", ID: scheduled.ID, PublishAt: newTime})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !rescheduled.PublishAt.Equal(newTime) {
+		t.Errorf("Expected PublishAt %v, got %v", newTime, rescheduled.PublishAt)
+	}
+
+	err = service.CancelScheduledTweet(ctx, "intruder", scheduled.ID)
+	if err != domain.ErrScheduledTweetNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrScheduledTweetNotFound, err)
+	}
+
+	err = service.CancelScheduledTweet(ctx, "user123", scheduled.ID)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(scheduledRepo.scheduled) != 0 {
+		t.Errorf("Expected no pending scheduled tweets, got %d", len(scheduledRepo.scheduled))
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSchedulerInterval is how often the scheduler looks for due tweets
+const DefaultSchedulerInterval = time.Second
+
+// minSchedulerStallAfter is the shortest gap between completed ticks that
+// counts as a stall, so that one slow publish run is not mistaken for one
+const minSchedulerStallAfter = 30 * time.Second
+
+// Scheduler is a background worker that publishes scheduled tweets when they become due
+type Scheduler struct {
+	service  *ScheduleService
+	interval time.Duration
+	// lastTick is the Unix time in nanoseconds of the last completed tick
+	lastTick atomic.Int64
+}
+
+// NewScheduler creates a new scheduler polling the schedule service every interval
+func NewScheduler(service *ScheduleService, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Run publishes due tweets until the context is cancelled. Tweets that became
+// due while the process was down are published on the first tick.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) tick(ctx context.Context) {
+	published, err := s.service.PublishDue(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to publish due scheduled tweets", "error", err)
+	}
+	if published > 0 {
+		slog.InfoContext(ctx, "published scheduled tweets", "count", published)
+	}
+	s.lastTick.Store(s.service.clock.Now().UnixNano())
+}
+
+// Check fails when the scheduler has stopped completing ticks, which means
+// scheduled tweets are no longer being published. It passes before the
+// first tick so that a starting process is not reported as stalled.
+func (s *Scheduler) Check(ctx context.Context) error {
+	last := s.lastTick.Load()
+	if last == 0 {
+		return nil
+	}
+
+	stallAfter := max(3*s.interval, minSchedulerStallAfter)
+	if since := s.service.clock.Now().Sub(time.Unix(0, last)); since > stallAfter {
+		return fmt.Errorf("scheduler stalled: no tick completed for %s", since.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Check(t *testing.T) {
+	service, _, _, clock := newTestScheduleService()
+	scheduler := NewScheduler(service, time.Second)
+
+	if err := scheduler.Check(context.Background()); err != nil {
+		t.Errorf("Expected a scheduler that has not ticked yet to pass, got %v", err)
+	}
+
+	scheduler.tick(context.Background())
+	clock.Advance(minSchedulerStallAfter)
+	if err := scheduler.Check(context.Background()); err != nil {
+		t.Errorf("Expected no error within the stall window, got %v", err)
+	}
+
+	clock.Advance(time.Second)
+	if err := scheduler.Check(context.Background()); err == nil {
+		t.Error("Expected a stalled scheduler to fail, got nil")
+	}
+
+	scheduler.tick(context.Background())
+	if err := scheduler.Check(context.Background()); err != nil {
+		t.Errorf("Expected scheduler to recover after a tick, got %v", err)
+	}
+}
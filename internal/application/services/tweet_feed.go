@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"uala-challenge/internal/domain"
+)
+
+// DefaultFeedBuffer is how many tweets a live subscriber may fall behind
+// before it is dropped
+const DefaultFeedBuffer = 64
+
+// ErrSubscriberTooSlow is returned to a live subscriber that fell behind the
+// feed. Clients should reconnect and catch up from the timeline.
+var ErrSubscriberTooSlow = errors.New("subscriber fell behind the live feed")
+
+// TweetFeed fans out newly created tweets to live subscribers. Publishing
+// never blocks: subscribers whose buffer is full are dropped.
+type TweetFeed struct {
+	mutex       sync.Mutex
+	subscribers map[*feedSubscriber]struct{}
+	buffer      int
+}
+
+type feedSubscriber struct {
+	tweets  chan *domain.Tweet
+	dropped chan struct{}
+}
+
+// NewTweetFeed creates a feed whose subscribers buffer up to buffer tweets
+func NewTweetFeed(buffer int) *TweetFeed {
+	return &TweetFeed{
+		subscribers: make(map[*feedSubscriber]struct{}),
+		buffer:      buffer,
+	}
+}
+
+// Publish delivers a tweet to every subscriber
+func (f *TweetFeed) Publish(tweet *domain.Tweet) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for sub := range f.subscribers {
+		select {
+		case sub.tweets <- tweet:
+		default:
+			close(sub.dropped)
+			delete(f.subscribers, sub)
+		}
+	}
+}
+
+// subscribe registers a new subscriber
+func (f *TweetFeed) subscribe() *feedSubscriber {
+	sub := &feedSubscriber{
+		tweets:  make(chan *domain.Tweet, f.buffer),
+		dropped: make(chan struct{}),
+	}
+
+	f.mutex.Lock()
+	f.subscribers[sub] = struct{}{}
+	f.mutex.Unlock()
+	return sub
+}
+
+// unsubscribe removes a subscriber, if it was not already dropped
+func (f *TweetFeed) unsubscribe(sub *feedSubscriber) {
+	f.mutex.Lock()
+	delete(f.subscribers, sub)
+	f.mutex.Unlock()
+}
+
+// HandleEvent delivers created tweets, including published scheduled
+// tweets, to the feed. It never blocks, so it can be a synchronous event bus
+// subscriber.
+func (f *TweetFeed) HandleEvent(ctx context.Context, event domain.RecordedEvent) error {
+	if created, ok := event.Event.(domain.TweetCreated); ok {
+		f.Publish(created.Tweet)
+	}
+	return nil
+}
+
+// LiveTimelineService streams new tweets from followed users as they are
+// posted
+type LiveTimelineService struct {
+	followRepo domain.FollowRepository
+	feed       *TweetFeed
+}
+
+// NewLiveTimelineService creates a new live timeline service
+func NewLiveTimelineService(followRepo domain.FollowRepository, feed *TweetFeed) *LiveTimelineService {
+	return &LiveTimelineService{
+		followRepo: followRepo,
+		feed:       feed,
+	}
+}
+
+// StreamTimeline calls send for each new tweet by a user that userID
+// follows, until ctx is done, send fails or the subscriber falls behind.
+// Follows are checked per tweet, so following someone takes effect
+// mid-stream.
+func (s *LiveTimelineService) StreamTimeline(ctx context.Context, userID string, send func(*domain.Tweet) error) error {
+	sub := s.feed.subscribe()
+	defer s.feed.unsubscribe(sub)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sub.dropped:
+			return ErrSubscriberTooSlow
+		case tweet := <-sub.tweets:
+			followees, err := s.followRepo.GetFollowees(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(followees, tweet.UserID) {
+				continue
+			}
+
+			err = send(tweet)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestTweetFeed_PublishesCreatedTweets(t *testing.T) {
+	feed := NewTweetFeed(1)
+
+	sub := feed.subscribe()
+	defer feed.unsubscribe(sub)
+
+	tweet := &domain.Tweet{ID: "1", UserID: "alice", Content: "Hello"}
+	for _, event := range []domain.Event{domain.UserCreated{User: &domain.User{ID: "alice"}}, domain.TweetCreated{Tweet: tweet}} {
+		if err := feed.HandleEvent(context.Background(), recorded("event", event)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	select {
+	case got := <-sub.tweets:
+		if got.ID != "1" {
+			t.Errorf("Expected tweet 1, got %s", got.ID)
+		}
+	default:
+		t.Error("Expected tweet to be published")
+	}
+}
+
+func TestTweetFeed_DropsSlowSubscribers(t *testing.T) {
+	feed := NewTweetFeed(1)
+	slow := feed.subscribe()
+	fast := feed.subscribe()
+	defer feed.unsubscribe(fast)
+
+	feed.Publish(&domain.Tweet{ID: "1"})
+	<-fast.tweets
+	feed.Publish(&domain.Tweet{ID: "2"})
+
+	select {
+	case <-slow.dropped:
+	default:
+		t.Error("Expected slow subscriber to be dropped")
+	}
+	select {
+	case <-fast.dropped:
+		t.Error("Expected fast subscriber to keep its subscription")
+	default:
+	}
+}
+
+func TestLiveTimelineService_StreamTimeline(t *testing.T) {
+	feed := NewTweetFeed(DefaultFeedBuffer)
+	followRepo := &mockFollowRepository{follows: map[string][]string{"bob": {"alice"}}}
+	service := NewLiveTimelineService(followRepo, feed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := make(chan *domain.Tweet)
+	done := make(chan error, 1)
+	go func() {
+		done <- service.StreamTimeline(ctx, "bob", func(tweet *domain.Tweet) error {
+			received <- tweet
+			return nil
+		})
+	}()
+
+	waitForSubscribers(t, feed, 1)
+
+	feed.Publish(&domain.Tweet{ID: "1", UserID: "carol"})
+	feed.Publish(&domain.Tweet{ID: "2", UserID: "alice"})
+
+	select {
+	case tweet := <-received:
+		if tweet.ID != "2" {
+			t.Errorf("Expected only the followed user's tweet, got %s", tweet.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a tweet from a followed user")
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestLiveTimelineService_StreamTimeline_SlowSubscriber(t *testing.T) {
+	feed := NewTweetFeed(1)
+	followRepo := &mockFollowRepository{follows: map[string][]string{"bob": {"alice"}}}
+	service := NewLiveTimelineService(followRepo, feed)
+
+	sending := make(chan struct{}, 3)
+	release := make(chan struct{})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- service.StreamTimeline(context.Background(), "bob", func(*domain.Tweet) error {
+			sending <- struct{}{}
+			<-release
+			return nil
+		})
+	}()
+	waitForSubscribers(t, feed, 1)
+
+	// The first tweet blocks in send, the second fills the buffer and the
+	// third overflows it
+	feed.Publish(&domain.Tweet{ID: "1", UserID: "alice"})
+	<-sending
+	feed.Publish(&domain.Tweet{ID: "2", UserID: "alice"})
+	feed.Publish(&domain.Tweet{ID: "3", UserID: "alice"})
+	close(release)
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrSubscriberTooSlow) {
+			t.Errorf("Expected ErrSubscriberTooSlow, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end")
+	}
+}
+
+// waitForSubscribers waits until the feed has n subscribers
+func waitForSubscribers(t *testing.T, feed *TweetFeed, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		feed.mutex.Lock()
+		count := len(feed.subscribers)
+		feed.mutex.Unlock()
+		if count == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Expected %d subscribers", n)
+}
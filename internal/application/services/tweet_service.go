@@ -2,7 +2,9 @@ package services
 
 import (
 	"context"
-	"sort"
+	"fmt"
+	"log/slog"
+	"time"
 
 	"uala-challenge/internal/domain"
 )
@@ -11,51 +13,193 @@ import (
 type TweetService struct {
 	tweetRepo domain.TweetRepository
 	userRepo  domain.UserRepository
+	mediaRepo domain.MediaRepository
+	unfurler  Unfurler
+	uow       domain.UnitOfWork
+	clock     domain.Clock
+	ids       domain.IDGenerator
+	maxLength int
+}
+
+// Unfurler is notified of created tweets so that their links can be
+// previewed in the background
+type Unfurler interface {
+	Enqueue(tweet *domain.Tweet)
+}
+
+// TweetServiceOption configures optional tweet service dependencies
+type TweetServiceOption func(*TweetService)
+
+// WithMediaRepository enables attaching uploaded media to tweets
+func WithMediaRepository(mediaRepo domain.MediaRepository) TweetServiceOption {
+	return func(s *TweetService) {
+		s.mediaRepo = mediaRepo
+	}
+}
+
+// WithUnfurler enables link previews for created tweets
+func WithUnfurler(unfurler Unfurler) TweetServiceOption {
+	return func(s *TweetService) {
+		s.unfurler = unfurler
+	}
+}
+
+// WithUnitOfWork stores created users and tweets through uow, recording a
+// UserCreated or TweetCreated event with each
+func WithUnitOfWork(uow domain.UnitOfWork) TweetServiceOption {
+	return func(s *TweetService) {
+		s.uow = uow
+	}
+}
+
+// WithClock overrides the clock that stamps created tweets
+func WithClock(clock domain.Clock) TweetServiceOption {
+	return func(s *TweetService) {
+		s.clock = clock
+	}
+}
+
+// WithIDGenerator overrides the generator of created tweets' IDs, which are
+// time-ordered UUIDs by default
+func WithIDGenerator(ids domain.IDGenerator) TweetServiceOption {
+	return func(s *TweetService) {
+		s.ids = ids
+	}
+}
+
+// WithMaxTweetLength overrides the default tweet length limit
+func WithMaxTweetLength(maxLength int) TweetServiceOption {
+	return func(s *TweetService) {
+		s.maxLength = maxLength
+	}
 }
 
 // NewTweetService creates a new tweet service
-func NewTweetService(tweetRepo domain.TweetRepository, userRepo domain.UserRepository) *TweetService {
-	return &TweetService{
+func NewTweetService(tweetRepo domain.TweetRepository, userRepo domain.UserRepository, opts ...TweetServiceOption) *TweetService {
+	s := &TweetService{
 		tweetRepo: tweetRepo,
 		userRepo:  userRepo,
+		clock:     domain.SystemClock{},
+		ids:       domain.UUIDv7Generator{},
+		maxLength: domain.MaxTweetLength,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.uow == nil {
+		s.uow = directUnitOfWork{users: userRepo, tweets: tweetRepo}
 	}
+	return s
 }
 
 // CreateTweetRequest represents the request to create a tweet
 type CreateTweetRequest struct {
-	UserID  string `json:"user_id"`
-	Content string `json:"content"`
+	UserID   string             `json:"user_id"`
+	Content  string             `json:"content"`
+	Poll     *CreatePollRequest `json:"poll,omitempty"`
+	MediaIDs []string           `json:"media_ids,omitempty"`
+}
+
+// CreatePollRequest represents an optional poll attached to a new tweet
+type CreatePollRequest struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
 }
 
 // CreateTweet creates a new tweet
 func (s *TweetService) CreateTweet(ctx context.Context, req CreateTweetRequest) (*domain.Tweet, error) {
-	// Check if user exists, create if not
+	// Check if user exists; it is created along with the tweet if not
 	user, err := s.userRepo.GetByID(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	if user == nil {
-		// Create a default user for this ID
-		user = domain.NewUser("User-" + req.UserID)
-		err = s.userRepo.Create(ctx, user)
+	// Create tweet with domain validation
+	tweet, err := domain.NewTweetWithLimit(s.ids.NewID(), req.UserID, req.Content, s.clock.Now(), s.maxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Poll != nil {
+		duration := time.Duration(req.Poll.DurationMinutes) * time.Minute
+		tweet.Poll, err = domain.NewPoll(req.Poll.Options, duration, tweet.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	// Create tweet with domain validation
-	tweet, err := domain.NewTweet(req.UserID, req.Content)
+	if len(req.MediaIDs) > 0 {
+		err = s.attachMedia(ctx, tweet, req.MediaIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Save the tweet, and a default user for this ID if there is none
+	err = s.uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		if user == nil {
+			user := &domain.User{ID: req.UserID, Name: "User-" + req.UserID}
+			err := tx.Users().Create(ctx, user)
+			if err != nil {
+				return err
+			}
+			tx.Record(domain.UserCreated{User: user})
+		}
+
+		err := tx.Tweets().Create(ctx, tweet)
+		if err != nil {
+			return err
+		}
+		tx.Record(domain.TweetCreated{Tweet: tweet})
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Save tweet
-	err = s.tweetRepo.Create(ctx, tweet)
+	slog.InfoContext(ctx, "tweet created", "tweet_id", tweet.ID, "user_id", tweet.UserID)
+
+	// Unfurl links asynchronously so that creation never waits on remote sites
+	if s.unfurler != nil {
+		s.unfurler.Enqueue(tweet)
+	}
+
+	return tweet, nil
+}
+
+// attachMedia attaches media uploaded by the tweet's author
+func (s *TweetService) attachMedia(ctx context.Context, tweet *domain.Tweet, mediaIDs []string) error {
+	err := tweet.AttachMedia(mediaIDs)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
+	for _, id := range tweet.MediaIDs {
+		if s.mediaRepo == nil {
+			return domain.ErrUnknownMediaID
+		}
+
+		media, err := s.mediaRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if media == nil || media.UserID != tweet.UserID {
+			return fmt.Errorf("%w: %s", domain.ErrUnknownMediaID, id)
+		}
+	}
+
+	return nil
+}
+
+// GetTweet retrieves a tweet by ID
+func (s *TweetService) GetTweet(ctx context.Context, id string) (*domain.Tweet, error) {
+	tweet, err := s.tweetRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if tweet == nil {
+		return nil, domain.ErrTweetNotFound
+	}
 	return tweet, nil
 }
 
@@ -67,9 +211,27 @@ func (s *TweetService) GetUserTweets(ctx context.Context, userID string) ([]*dom
 	}
 
 	// Sort by creation time (newest first)
-	sort.Slice(tweets, func(i, j int) bool {
-		return tweets[i].CreatedAt.After(tweets[j].CreatedAt)
-	})
+	domain.SortNewestFirst(tweets)
 
 	return tweets, nil
 }
+
+// GetTweetsByUserIDs retrieves the tweets of several users with one
+// repository call, keyed by user and sorted newest first
+func (s *TweetService) GetTweetsByUserIDs(ctx context.Context, userIDs []string) (map[string][]*domain.Tweet, error) {
+	tweets, err := s.tweetRepo.GetByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	domain.SortNewestFirst(tweets)
+
+	byUser := make(map[string][]*domain.Tweet, len(userIDs))
+	for _, userID := range userIDs {
+		byUser[userID] = []*domain.Tweet{}
+	}
+	for _, tweet := range tweets {
+		byUser[tweet.UserID] = append(byUser[tweet.UserID], tweet)
+	}
+	return byUser, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"testing"
+	"time"
 
 	"uala-challenge/internal/domain"
 )
@@ -21,6 +22,16 @@ func (m *mockUserRepository) GetByID(ctx context.Context, id string) (*domain.Us
 	return m.users[id], nil
 }
 
+func (m *mockUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	var users []*domain.User
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
 type mockTweetRepository struct {
 	tweets []*domain.Tweet
 }
@@ -30,6 +41,15 @@ func (m *mockTweetRepository) Create(ctx context.Context, tweet *domain.Tweet) e
 	return nil
 }
 
+func (m *mockTweetRepository) GetByID(ctx context.Context, id string) (*domain.Tweet, error) {
+	for _, tweet := range m.tweets {
+		if tweet.ID == id {
+			return tweet, nil
+		}
+	}
+	return nil, nil
+}
+
 func (m *mockTweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
 	var userTweets []*domain.Tweet
 	for _, tweet := range m.tweets {
@@ -41,8 +61,34 @@ func (m *mockTweetRepository) GetByUserID(ctx context.Context, userID string) ([
 }
 
 func (m *mockTweetRepository) GetByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Tweet, error) {
-	// Not used in tweet service tests
-	return nil, nil
+	var userTweets []*domain.Tweet
+	for _, tweet := range m.tweets {
+		for _, userID := range userIDs {
+			if tweet.UserID == userID {
+				userTweets = append(userTweets, tweet)
+			}
+		}
+	}
+	return userTweets, nil
+}
+
+func (m *mockTweetRepository) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	tweets, _ := m.GetByUserIDs(ctx, userIDs)
+	domain.SortNewestFirst(tweets)
+
+	start := 0
+	if page.After != "" {
+		start = -1
+		for i, tweet := range tweets {
+			if tweet.ID == page.After {
+				start = i + 1
+			}
+		}
+		if start < 0 {
+			return nil, domain.ErrPageCursorNotFound
+		}
+	}
+	return tweets[start:min(start+page.Limit, len(tweets))], nil
 }
 
 func TestTweetService_CreateTweet(t *testing.T) {
@@ -77,6 +123,33 @@ func TestTweetService_CreateTweet(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "valid poll",
+			req: CreateTweetRequest{
+				UserID:  "user123",
+				Content: "Tabs or spaces?",
+				Poll:    &CreatePollRequest{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60},
+			},
+			expectError: false,
+		},
+		{
+			name: "poll with a single option",
+			req: CreateTweetRequest{
+				UserID:  "user123",
+				Content: "Tabs or spaces?",
+				Poll:    &CreatePollRequest{Options: []string{"Tabs"}, DurationMinutes: 60},
+			},
+			expectError: true,
+		},
+		{
+			name: "poll without a duration",
+			req: CreateTweetRequest{
+				UserID:  "user123",
+				Content: "Tabs or spaces?",
+				Poll:    &CreatePollRequest{Options: []string{"Tabs", "Spaces"}},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -102,11 +175,29 @@ func TestTweetService_CreateTweet(t *testing.T) {
 				if tweet.UserID != tt.req.UserID {
 					t.Errorf("Expected user ID %s, got %s", tt.req.UserID, tweet.UserID)
 				}
+				if (tt.req.Poll != nil) != (tweet.Poll != nil) {
+					t.Errorf("Expected poll to be attached only when requested")
+				}
 			}
 		})
 	}
 }
 
+func TestTweetService_CreateTweet_MaxLength(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &mockUserRepository{users: make(map[string]*domain.User)}
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{}}
+
+	service := NewTweetService(tweetRepo, userRepo, WithMaxTweetLength(500))
+
+	if _, err := service.CreateTweet(ctx, CreateTweetRequest{UserID: "user123", Content: string(make([]byte, 500))}); err != nil {
+		t.Errorf("Expected no error within the configured limit, got %v", err)
+	}
+	if _, err := service.CreateTweet(ctx, CreateTweetRequest{UserID: "user123", Content: string(make([]byte, 501))}); err != domain.ErrTweetTooLong {
+		t.Errorf("Expected error %v, got %v", domain.ErrTweetTooLong, err)
+	}
+}
+
 func TestTweetService_GetUserTweets(t *testing.T) {
 	ctx := context.Background()
 	userID := "user123"
@@ -129,3 +220,65 @@ func TestTweetService_GetUserTweets(t *testing.T) {
 		t.Errorf("Expected 2 tweets, got %d", len(tweets))
 	}
 }
+
+func TestTweetService_GetTweet(t *testing.T) {
+	ctx := context.Background()
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{{ID: "1", UserID: "user123", Content: "First tweet"}}}
+	service := NewTweetService(tweetRepo, &mockUserRepository{users: make(map[string]*domain.User)})
+
+	tweet, err := service.GetTweet(ctx, "1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweet.Content != "First tweet" {
+		t.Errorf("Expected the stored tweet, got %+v", tweet)
+	}
+
+	if _, err := service.GetTweet(ctx, "missing"); err != domain.ErrTweetNotFound {
+		t.Errorf("Expected error %v, got %v", domain.ErrTweetNotFound, err)
+	}
+}
+
+func TestTweetService_CreateTweet_RegistersAuthor(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &mockUserRepository{users: make(map[string]*domain.User)}
+	service := NewTweetService(&mockTweetRepository{}, userRepo)
+
+	for i := 0; i < 2; i++ {
+		if _, err := service.CreateTweet(ctx, CreateTweetRequest{UserID: "user123", Content: "Hello"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if len(userRepo.users) != 1 {
+		t.Errorf("Expected 1 user, got %d", len(userRepo.users))
+	}
+	if user := userRepo.users["user123"]; user == nil || user.Name != "User-user123" {
+		t.Errorf("Expected user123 to be registered under its ID, got %+v", user)
+	}
+}
+
+func TestTweetService_GetTweetsByUserIDs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	tweetRepo := &mockTweetRepository{tweets: []*domain.Tweet{
+		{ID: "1", UserID: "alice", CreatedAt: now.Add(-time.Minute)},
+		{ID: "2", UserID: "bob", CreatedAt: now},
+		{ID: "3", UserID: "alice", CreatedAt: now},
+	}}
+	service := NewTweetService(tweetRepo, &mockUserRepository{users: make(map[string]*domain.User)})
+
+	byUser, err := service.GetTweetsByUserIDs(ctx, []string{"alice", "carol"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(byUser["alice"]) != 2 || byUser["alice"][0].ID != "3" {
+		t.Errorf("Expected alice's tweets newest first, got %v", byUser["alice"])
+	}
+	if tweets, ok := byUser["carol"]; !ok || len(tweets) != 0 {
+		t.Errorf("Expected an empty list for carol, got %v", tweets)
+	}
+	if _, ok := byUser["bob"]; ok {
+		t.Error("Expected only requested users in the result")
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+)
+
+// directUnitOfWork writes straight to the repositories and discards recorded
+// events. Services use it when they are not given a unit of work.
+type directUnitOfWork struct {
+	users   domain.UserRepository
+	tweets  domain.TweetRepository
+	follows domain.FollowRepository
+}
+
+func (u directUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	return fn(ctx, u)
+}
+
+func (u directUnitOfWork) Users() domain.UserRepository     { return u.users }
+func (u directUnitOfWork) Tweets() domain.TweetRepository   { return u.tweets }
+func (u directUnitOfWork) Follows() domain.FollowRepository { return u.follows }
+func (u directUnitOfWork) Record(events ...domain.Event)    {}
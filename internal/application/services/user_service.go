@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+)
+
+// UserService handles user lookups. Users are registered implicitly the
+// first time they post.
+type UserService struct {
+	userRepo domain.UserRepository
+}
+
+// NewUserService creates a new user service
+func NewUserService(userRepo domain.UserRepository) *UserService {
+	return &UserService{userRepo: userRepo}
+}
+
+// GetUser retrieves a user by ID
+func (s *UserService) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// GetUsers retrieves several users with one repository call, keyed by ID.
+// Unknown IDs are absent from the result.
+func (s *UserService) GetUsers(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+	users, err := s.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*domain.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	return byID, nil
+}
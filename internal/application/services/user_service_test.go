@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestUserService_GetUser(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &mockUserRepository{users: map[string]*domain.User{
+		"user123": {ID: "user123", Name: "User-user123"},
+	}}
+	service := NewUserService(userRepo)
+
+	user, err := service.GetUser(ctx, "user123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Name != "User-user123" {
+		t.Errorf("Expected name User-user123, got %s", user.Name)
+	}
+
+	if _, err := service.GetUser(ctx, "missing"); err != domain.ErrUserNotFound {
+		t.Errorf("Expected error %v, got %v", domain.ErrUserNotFound, err)
+	}
+}
+
+func TestUserService_GetUsers(t *testing.T) {
+	userRepo := &mockUserRepository{users: map[string]*domain.User{
+		"alice": {ID: "alice", Name: "User-alice"},
+		"bob":   {ID: "bob", Name: "User-bob"},
+	}}
+	service := NewUserService(userRepo)
+
+	users, err := service.GetUsers(context.Background(), []string{"alice", "nobody"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(users) != 1 || users["alice"] == nil {
+		t.Errorf("Expected only alice, got %v", users)
+	}
+}
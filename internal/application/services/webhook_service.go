@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// Webhook delivery defaults
+const (
+	DefaultWebhookWorkers      = 4
+	DefaultWebhookMaxAttempts  = 8
+	DefaultWebhookRetryBackoff = 10 * time.Second
+	DefaultWebhookPollInterval = time.Second
+	maxWebhookRetryBackoff     = time.Hour
+	webhookDeliveryLease       = time.Minute
+	webhookDeliveryRetention   = 7 * 24 * time.Hour
+	webhookPruneInterval       = time.Hour
+	webhookDeliveryLogLimit    = 100
+	webhookDeliveriesPerPoll   = 100
+)
+
+// WebhookSender posts a delivery's payload to a webhook. It returns the
+// response status, or zero when no response was received, and an error
+// unless the webhook answered with a 2xx status.
+type WebhookSender interface {
+	Send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error)
+}
+
+// WebhookService manages webhook subscriptions and delivers events to them.
+// Webhooks receive the events of the types they subscribe to that concern
+// their owner: the owner's tweets and those of the accounts they follow, and
+// follows by or of the owner. Events are queued in the delivery repository
+// before HandleEvent returns and are sent by Run, so they survive restarts
+// when the repository is durable.
+type WebhookService struct {
+	webhookRepo  domain.WebhookRepository
+	deliveryRepo domain.WebhookDeliveryRepository
+	followRepo   domain.FollowRepository
+	sender       WebhookSender
+	clock        domain.Clock
+	ids          domain.IDGenerator
+	maxAttempts  int
+	retryBackoff time.Duration
+	pollInterval time.Duration
+	wake         chan struct{}
+}
+
+// WebhookServiceOption configures optional webhook service settings
+type WebhookServiceOption func(*WebhookService)
+
+// WithWebhookRetries overrides how many times a delivery is attempted before
+// it is dead-lettered and the wait before the first retry
+func WithWebhookRetries(maxAttempts int, backoff time.Duration) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.maxAttempts = maxAttempts
+		s.retryBackoff = backoff
+	}
+}
+
+// WithWebhookPollInterval overrides how often Run looks for due deliveries
+// when it is not woken up by a new event
+func WithWebhookPollInterval(interval time.Duration) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.pollInterval = interval
+	}
+}
+
+// WithWebhookIDGenerator overrides the generator of webhook and delivery IDs
+func WithWebhookIDGenerator(ids domain.IDGenerator) WebhookServiceOption {
+	return func(s *WebhookService) {
+		s.ids = ids
+	}
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo domain.WebhookRepository, deliveryRepo domain.WebhookDeliveryRepository, followRepo domain.FollowRepository, sender WebhookSender, clock domain.Clock, opts ...WebhookServiceOption) *WebhookService {
+	s := &WebhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		followRepo:   followRepo,
+		sender:       sender,
+		clock:        clock,
+		ids:          domain.UUIDv7Generator{},
+		maxAttempts:  DefaultWebhookMaxAttempts,
+		retryBackoff: DefaultWebhookRetryBackoff,
+		pollInterval: DefaultWebhookPollInterval,
+		wake:         make(chan struct{}, 1),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CreateWebhookRequest represents the request to create a webhook
+type CreateWebhookRequest struct {
+	UserID string   `json:"user_id"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// CreateWebhook validates and stores a webhook subscription
+func (s *WebhookService) CreateWebhook(ctx context.Context, req CreateWebhookRequest) (*domain.Webhook, error) {
+	webhook, err := domain.NewWebhook(s.ids.NewID(), req.UserID, req.URL, req.Secret, req.Events, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.webhookRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= domain.MaxWebhooksPerUser {
+		return nil, domain.ErrTooManyWebhooks
+	}
+
+	err = s.webhookRepo.Create(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "webhook created", "webhook_id", webhook.ID, "user_id", webhook.UserID, "events", webhook.Events)
+	return webhook, nil
+}
+
+// GetWebhooks retrieves the webhooks of a user (oldest first)
+func (s *WebhookService) GetWebhooks(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	return s.webhookRepo.GetByUserID(ctx, userID)
+}
+
+// DeleteWebhook removes a webhook along with its queued and logged
+// deliveries
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, id string) error {
+	if _, err := s.getOwned(ctx, userID, id); err != nil {
+		return err
+	}
+
+	err := s.webhookRepo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.deliveryRepo.DeleteByWebhookID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "webhook deleted", "webhook_id", id)
+	return nil
+}
+
+// GetDeliveries retrieves the most recent deliveries of a webhook (newest
+// first)
+func (s *WebhookService) GetDeliveries(ctx context.Context, userID, webhookID string) ([]*domain.WebhookDelivery, error) {
+	if _, err := s.getOwned(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	return s.deliveryRepo.GetByWebhookID(ctx, webhookID, webhookDeliveryLogLimit)
+}
+
+// RetryDelivery queues a dead-lettered delivery again with a fresh set of
+// attempts
+func (s *WebhookService) RetryDelivery(ctx context.Context, userID, webhookID, deliveryID string) (*domain.WebhookDelivery, error) {
+	if _, err := s.getOwned(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if delivery == nil || delivery.WebhookID != webhookID {
+		return nil, domain.ErrWebhookDeliveryNotFound
+	}
+
+	err = delivery.Redeliver(s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.deliveryRepo.Update(ctx, delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "webhook delivery retried", "webhook_id", webhookID, "delivery_id", deliveryID)
+	s.notify()
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery of a domain event to every webhook
+// subscribed to it whose owner it concerns. It is meant to be a synchronous event bus subscriber, so
+// that an event only counts as handled once its deliveries are stored.
+// Handling an event again queues nothing new for the webhooks that have it
+// already. The webhook event has the domain event's ID, which receivers can
+// use to ignore an event queued again after a restart.
+func (s *WebhookService) HandleEvent(ctx context.Context, event domain.RecordedEvent) error {
+	var data interface{}
+	switch e := event.Event.(type) {
+	case domain.TweetCreated:
+		data = e.Tweet
+	case domain.UserFollowed, domain.UserUnfollowed:
+		data = e
+	default:
+		return nil
+	}
+
+	eventType := event.Event.EventType()
+	subscribed, err := s.webhookRepo.GetByEvent(ctx, eventType)
+	if err != nil {
+		return err
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	audience, err := s.audience(ctx, event.Event)
+	if err != nil {
+		return err
+	}
+	var webhooks []*domain.Webhook
+	for _, webhook := range subscribed {
+		if audience[webhook.UserID] {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	webhookEvent := &domain.WebhookEvent{ID: event.ID, Type: eventType, CreatedAt: event.OccurredAt, Data: data}
+	payload, err := json.Marshal(webhookEvent)
+	if err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	for _, webhook := range webhooks {
+		delivery := domain.NewWebhookDelivery(s.ids.NewID(), webhook.ID, webhookEvent, payload, now)
+		err = s.deliveryRepo.Create(ctx, delivery)
+		if err != nil {
+			return err
+		}
+	}
+
+	slog.DebugContext(ctx, "webhook event queued", "event_id", event.ID, "event_type", eventType, "webhooks", len(webhooks))
+	s.notify()
+	return nil
+}
+
+// audience returns the users an event concerns: the author of a tweet and
+// their followers, or both sides of a follow
+func (s *WebhookService) audience(ctx context.Context, event domain.Event) (map[string]bool, error) {
+	var userIDs []string
+	switch e := event.(type) {
+	case domain.TweetCreated:
+		followers, err := s.followRepo.GetFollowersByFolloweeIDs(ctx, []string{e.Tweet.UserID})
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(followers[e.Tweet.UserID], e.Tweet.UserID)
+	case domain.UserFollowed:
+		userIDs = []string{e.FollowerID, e.FolloweeID}
+	case domain.UserUnfollowed:
+		userIDs = []string{e.FollowerID, e.FolloweeID}
+	}
+
+	audience := make(map[string]bool, len(userIDs))
+	for _, userID := range userIDs {
+		audience[userID] = true
+	}
+	return audience, nil
+}
+
+// notify wakes Run up without blocking
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends due deliveries with the given number of workers until the
+// context is cancelled. Deliveries queued while the process was down are
+// sent on the first poll. Failed deliveries are retried with exponential
+// backoff and dead-lettered after the last attempt.
+func (s *WebhookService) Run(ctx context.Context, workers int) {
+	jobs := make(chan *domain.WebhookDelivery)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for delivery := range jobs {
+				s.attempt(ctx, delivery)
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		wg.Wait()
+	}()
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	var pruned time.Time
+	for {
+		if now := s.clock.Now(); now.Sub(pruned) >= webhookPruneInterval {
+			s.prune(ctx, now)
+			pruned = now
+		}
+
+		for _, delivery := range s.claimDue(ctx) {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- delivery:
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// claimDue returns the deliveries due now after pushing their next attempt
+// back by a lease, so that they are not handed out again while in flight.
+// A delivery whose attempt is cut short by a shutdown is sent again once
+// its lease expires.
+func (s *WebhookService) claimDue(ctx context.Context) []*domain.WebhookDelivery {
+	now := s.clock.Now()
+	due, err := s.deliveryRepo.GetDue(ctx, now, webhookDeliveriesPerPoll)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to list due webhook deliveries", "error", err)
+		return nil
+	}
+
+	claimed := make([]*domain.WebhookDelivery, 0, len(due))
+	for _, delivery := range due {
+		delivery.NextAttemptAt = now.Add(webhookDeliveryLease)
+		err = s.deliveryRepo.Update(ctx, delivery)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to claim webhook delivery", "delivery_id", delivery.ID, "error", err)
+			continue
+		}
+		claimed = append(claimed, delivery)
+	}
+	return claimed
+}
+
+// attempt sends a claimed delivery and records the outcome
+func (s *WebhookService) attempt(ctx context.Context, delivery *domain.WebhookDelivery) {
+	webhook, err := s.webhookRepo.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load webhook", "webhook_id", delivery.WebhookID, "error", err)
+		return
+	}
+	if webhook == nil {
+		// Deleted while the delivery was in flight
+		return
+	}
+
+	status, err := s.sender.Send(ctx, webhook, delivery)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down; the attempt does not count
+		return
+	}
+
+	now := s.clock.Now()
+	if err == nil {
+		delivery.RecordSuccess(status, now)
+		slog.DebugContext(ctx, "webhook delivered", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "status", status)
+	} else {
+		backoff := s.retryBackoff << delivery.Attempts
+		if backoff > maxWebhookRetryBackoff || backoff <= 0 {
+			backoff = maxWebhookRetryBackoff
+		}
+		delivery.RecordFailure(status, err.Error(), now, now.Add(backoff), s.maxAttempts)
+		if delivery.Status == domain.DeliveryDead {
+			slog.WarnContext(ctx, "webhook delivery dead-lettered", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "error", err)
+		} else {
+			slog.DebugContext(ctx, "webhook delivery will be retried", "webhook_id", webhook.ID, "delivery_id", delivery.ID, "attempts", delivery.Attempts, "backoff", backoff, "error", err)
+		}
+	}
+
+	err = s.deliveryRepo.Update(ctx, delivery)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// prune removes finished deliveries that have aged out of the delivery log
+func (s *WebhookService) prune(ctx context.Context, now time.Time) {
+	err := s.deliveryRepo.DeleteFinishedBefore(ctx, now.Add(-webhookDeliveryRetention))
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to prune webhook deliveries", "error", err)
+	}
+}
+
+// getOwned loads a webhook, hiding webhooks that belong to other users
+func (s *WebhookService) getOwned(ctx context.Context, userID, id string) (*domain.Webhook, error) {
+	webhook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook == nil || webhook.UserID != userID {
+		return nil, domain.ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
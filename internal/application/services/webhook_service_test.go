@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+type mockWebhookRepository struct {
+	webhooks map[string]*domain.Webhook
+}
+
+func (m *mockWebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	m.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (m *mockWebhookRepository) GetByID(ctx context.Context, id string) (*domain.Webhook, error) {
+	return m.webhooks[id], nil
+}
+
+func (m *mockWebhookRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	var webhooks []*domain.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.UserID == userID {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookRepository) GetByEvent(ctx context.Context, eventType string) ([]*domain.Webhook, error) {
+	var webhooks []*domain.Webhook
+	for _, webhook := range m.webhooks {
+		if webhook.Subscribes(eventType) {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (m *mockWebhookRepository) Delete(ctx context.Context, id string) error {
+	delete(m.webhooks, id)
+	return nil
+}
+
+type mockWebhookDeliveryRepository struct {
+	mutex      sync.Mutex
+	deliveries map[string]*domain.WebhookDelivery
+}
+
+func (m *mockWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, existing := range m.deliveries {
+		if existing.WebhookID == delivery.WebhookID && existing.EventID == delivery.EventID {
+			return nil
+		}
+	}
+	stored := *delivery
+	m.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (m *mockWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delivery, ok := m.deliveries[id]
+	if !ok {
+		return nil, nil
+	}
+	found := *delivery
+	return &found, nil
+}
+
+func (m *mockWebhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID {
+			found := *delivery
+			deliveries = append(deliveries, &found)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *mockWebhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	var deliveries []*domain.WebhookDelivery
+	for _, delivery := range m.deliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			found := *delivery
+			deliveries = append(deliveries, &found)
+		}
+	}
+	return deliveries, nil
+}
+
+func (m *mockWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	stored := *delivery
+	m.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (m *mockWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, delivery := range m.deliveries {
+		if delivery.WebhookID == webhookID {
+			delete(m.deliveries, id)
+		}
+	}
+	return nil
+}
+
+func (m *mockWebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, delivery := range m.deliveries {
+		if delivery.Status != domain.DeliveryPending && delivery.CreatedAt.Before(t) {
+			delete(m.deliveries, id)
+		}
+	}
+	return nil
+}
+
+// get returns the stored delivery without copying it
+func (m *mockWebhookDeliveryRepository) get(id string) *domain.WebhookDelivery {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.deliveries[id]
+}
+
+// only returns the single stored delivery
+func (m *mockWebhookDeliveryRepository) only(t *testing.T) *domain.WebhookDelivery {
+	t.Helper()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.deliveries) != 1 {
+		t.Fatalf("Expected 1 delivery, got %d", len(m.deliveries))
+	}
+	for _, delivery := range m.deliveries {
+		return delivery
+	}
+	return nil
+}
+
+// mockWebhookSender answers every delivery with status, failing unless it
+// is a 2xx, and records the deliveries it was given
+type mockWebhookSender struct {
+	mutex  sync.Mutex
+	status int
+	sent   []*domain.WebhookDelivery
+}
+
+func (m *mockWebhookSender) Send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sent = append(m.sent, delivery)
+	if m.status < 200 || m.status > 299 {
+		return m.status, errors.New("webhook failed")
+	}
+	return m.status, nil
+}
+
+func (m *mockWebhookSender) count() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.sent)
+}
+
+func newTestWebhookService(opts ...WebhookServiceOption) (*WebhookService, *mockWebhookDeliveryRepository, *mockWebhookSender, *domaintest.FakeClock) {
+	webhookRepo := &mockWebhookRepository{webhooks: make(map[string]*domain.Webhook)}
+	deliveryRepo := &mockWebhookDeliveryRepository{deliveries: make(map[string]*domain.WebhookDelivery)}
+	followRepo := &mockFollowRepository{follows: make(map[string][]string)}
+	sender := &mockWebhookSender{status: http.StatusOK}
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	opts = append([]WebhookServiceOption{WithWebhookIDGenerator(domaintest.NewSequentialIDs("id"))}, opts...)
+	return NewWebhookService(webhookRepo, deliveryRepo, followRepo, sender, clock, opts...), deliveryRepo, sender, clock
+}
+
+// deliverDue makes one delivery pass without running the workers
+func deliverDue(s *WebhookService) {
+	ctx := context.Background()
+	for _, delivery := range s.claimDue(ctx) {
+		s.attempt(ctx, delivery)
+	}
+}
+
+func TestWebhookService_CreateWebhook(t *testing.T) {
+	ctx := context.Background()
+	service, _, _, _ := newTestWebhookService()
+
+	tests := []struct {
+		name        string
+		req         CreateWebhookRequest
+		expectedErr error
+	}{
+		{
+			name: "valid webhook",
+			req:  CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: []string{domain.EventTweetCreated}},
+		},
+		{
+			name:        "relative URL",
+			req:         CreateWebhookRequest{UserID: "user1", URL: "/hook", Secret: testWebhookSecret, Events: []string{domain.EventTweetCreated}},
+			expectedErr: domain.ErrInvalidWebhookURL,
+		},
+		{
+			name:        "short secret",
+			req:         CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: "secret", Events: []string{domain.EventTweetCreated}},
+			expectedErr: domain.ErrWebhookSecretTooShort,
+		},
+		{
+			name:        "unknown event",
+			req:         CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: []string{"tweet.deleted"}},
+			expectedErr: domain.ErrInvalidWebhookEvents,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, err := service.CreateWebhook(ctx, tt.req)
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err == nil && webhook.Secret != tt.req.Secret {
+				t.Error("Expected the webhook to keep its secret")
+			}
+		})
+	}
+
+	for i := 1; i < domain.MaxWebhooksPerUser; i++ {
+		if _, err := service.CreateWebhook(ctx, tests[0].req); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := service.CreateWebhook(ctx, tests[0].req); err != domain.ErrTooManyWebhooks {
+		t.Errorf("Expected %v, got %v", domain.ErrTooManyWebhooks, err)
+	}
+}
+
+// recorded wraps an event as if it had been committed to the outbox
+func recorded(id string, event domain.Event) domain.RecordedEvent {
+	return domain.RecordedEvent{ID: id, OccurredAt: time.Now(), Event: event}
+}
+
+func TestWebhookService_HandleEventQueuesDeliveries(t *testing.T) {
+	ctx := context.Background()
+	service, deliveryRepo, sender, _ := newTestWebhookService()
+
+	tweets, _ := service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "user1", URL: "https://example.com/tweets", Secret: testWebhookSecret, Events: []string{domain.EventTweetCreated}})
+	service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "user3", URL: "https://example.com/follows", Secret: testWebhookSecret, Events: []string{domain.EventUserFollowed}})
+	service.followRepo.Follow(ctx, "user1", "user3")
+
+	tweet, _ := domain.NewTweet("tweet1", "user3", "Hello", time.Now())
+	for _, event := range []domain.RecordedEvent{
+		recorded("event1", domain.TweetCreated{Tweet: tweet}),
+		recorded("event2", domain.UserUnfollowed{FollowerID: "user3", FolloweeID: "user4"}),
+		recorded("event3", domain.UserCreated{User: &domain.User{ID: "user3"}}),
+	} {
+		if err := service.HandleEvent(ctx, event); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	delivery := deliveryRepo.only(t)
+	if delivery.WebhookID != tweets.ID {
+		t.Fatal("Expected the event to be queued for the subscribed webhook only")
+	}
+	if tweets.ID != "id-000001" || delivery.ID != "id-000003" {
+		t.Errorf("Expected IDs from the generator, got webhook %s and delivery %s", tweets.ID, delivery.ID)
+	}
+
+	var event struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(delivery.Payload, &event); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if event.ID != "event1" || event.Type != domain.EventTweetCreated || event.Data.ID != tweet.ID {
+		t.Errorf("Expected %s event event1 for tweet %s, got %+v", domain.EventTweetCreated, tweet.ID, event)
+	}
+
+	deliverDue(service)
+	if sender.count() != 1 {
+		t.Fatalf("Expected 1 delivery attempt, got %d", sender.count())
+	}
+	delivered := deliveryRepo.get(delivery.ID)
+	if delivered.Status != domain.DeliverySucceeded || delivered.Attempts != 1 || delivered.ResponseStatus != http.StatusOK {
+		t.Errorf("Expected a delivery succeeded in 1 attempt, got %s after %d", delivered.Status, delivered.Attempts)
+	}
+
+	deliverDue(service)
+	if sender.count() != 1 {
+		t.Error("Expected a succeeded delivery not to be sent again")
+	}
+
+	// Handling the event again, as the outbox relay does after another
+	// subscriber failed it, queues nothing new
+	if err := service.HandleEvent(ctx, recorded("event1", domain.TweetCreated{Tweet: tweet})); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deliveryRepo.only(t).ID != delivery.ID {
+		t.Error("Expected the event to keep its one delivery")
+	}
+}
+
+func TestWebhookService_HandleEventScopesToOwner(t *testing.T) {
+	ctx := context.Background()
+	service, deliveryRepo, _, _ := newTestWebhookService()
+
+	service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "alice", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: domain.WebhookEvents})
+	service.followRepo.Follow(ctx, "alice", "bob")
+
+	own, _ := domain.NewTweet("tweet1", "alice", "Hello", time.Now())
+	followed, _ := domain.NewTweet("tweet2", "bob", "Hello", time.Now())
+	stranger, _ := domain.NewTweet("tweet3", "carol", "Hello", time.Now())
+	tests := []struct {
+		name      string
+		event     domain.Event
+		delivered bool
+	}{
+		{"own tweet", domain.TweetCreated{Tweet: own}, true},
+		{"tweet by a followed account", domain.TweetCreated{Tweet: followed}, true},
+		{"tweet by anyone else", domain.TweetCreated{Tweet: stranger}, false},
+		{"follow by the owner", domain.UserFollowed{FollowerID: "alice", FolloweeID: "carol"}, true},
+		{"follow of the owner", domain.UserFollowed{FollowerID: "carol", FolloweeID: "alice"}, true},
+		{"unfollow of the owner", domain.UserUnfollowed{FollowerID: "carol", FolloweeID: "alice"}, true},
+		{"follow between others", domain.UserFollowed{FollowerID: "bob", FolloweeID: "carol"}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(deliveryRepo.deliveries)
+			if err := service.HandleEvent(ctx, recorded("event"+strconv.Itoa(i), tt.event)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if delivered := len(deliveryRepo.deliveries) > before; delivered != tt.delivered {
+				t.Errorf("Expected delivered to be %v, got %v", tt.delivered, delivered)
+			}
+		})
+	}
+}
+
+func TestWebhookService_RetriesAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	service, deliveryRepo, sender, clock := newTestWebhookService(WithWebhookRetries(3, 10*time.Second))
+	sender.status = http.StatusServiceUnavailable
+
+	webhook, _ := service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: []string{domain.EventUserFollowed}})
+	service.HandleEvent(ctx, recorded("event1", domain.UserFollowed{FollowerID: "user2", FolloweeID: "user1"}))
+	id := deliveryRepo.only(t).ID
+
+	// Backoff doubles after each failed attempt
+	for attempt, backoff := range []time.Duration{10 * time.Second, 20 * time.Second} {
+		deliverDue(service)
+		if sender.count() != attempt+1 {
+			t.Fatalf("Expected %d attempts, got %d", attempt+1, sender.count())
+		}
+		clock.Advance(backoff - time.Second)
+		deliverDue(service)
+		if sender.count() != attempt+1 {
+			t.Fatalf("Expected no retry before %s", backoff)
+		}
+		clock.Advance(time.Second)
+	}
+
+	deliverDue(service)
+	dead := deliveryRepo.get(id)
+	if dead.Status != domain.DeliveryDead || dead.Attempts != 3 || dead.ResponseStatus != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the delivery to be dead-lettered after 3 attempts, got %s after %d", dead.Status, dead.Attempts)
+	}
+	clock.Advance(time.Hour)
+	deliverDue(service)
+	if sender.count() != 3 {
+		t.Error("Expected a dead-lettered delivery not to be sent again")
+	}
+
+	if _, err := service.RetryDelivery(ctx, "user2", webhook.ID, id); err != domain.ErrWebhookNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookNotFound, err)
+	}
+	if _, err := service.RetryDelivery(ctx, "user1", webhook.ID, "missing"); err != domain.ErrWebhookDeliveryNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookDeliveryNotFound, err)
+	}
+
+	sender.status = http.StatusNoContent
+	retried, err := service.RetryDelivery(ctx, "user1", webhook.ID, id)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if retried.Status != domain.DeliveryPending || retried.Attempts != 0 {
+		t.Errorf("Expected the delivery to be pending with no attempts, got %s after %d", retried.Status, retried.Attempts)
+	}
+	if _, err := service.RetryDelivery(ctx, "user1", webhook.ID, id); err != domain.ErrDeliveryNotDeadLettered {
+		t.Errorf("Expected %v, got %v", domain.ErrDeliveryNotDeadLettered, err)
+	}
+
+	deliverDue(service)
+	if delivered := deliveryRepo.get(id); delivered.Status != domain.DeliverySucceeded {
+		t.Errorf("Expected the retried delivery to succeed, got %s", delivered.Status)
+	}
+}
+
+func TestWebhookService_DeleteWebhook(t *testing.T) {
+	ctx := context.Background()
+	service, deliveryRepo, _, _ := newTestWebhookService()
+
+	webhook, _ := service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: []string{domain.EventUserFollowed}})
+	service.HandleEvent(ctx, recorded("event1", domain.UserFollowed{FollowerID: "user2", FolloweeID: "user1"}))
+
+	if err := service.DeleteWebhook(ctx, "user2", webhook.ID); err != domain.ErrWebhookNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookNotFound, err)
+	}
+	if _, err := service.GetDeliveries(ctx, "user2", webhook.ID); err != domain.ErrWebhookNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookNotFound, err)
+	}
+	if deliveries, _ := service.GetDeliveries(ctx, "user1", webhook.ID); len(deliveries) != 1 {
+		t.Fatalf("Expected 1 logged delivery, got %d", len(deliveries))
+	}
+
+	if err := service.DeleteWebhook(ctx, "user1", webhook.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if webhooks, _ := service.GetWebhooks(ctx, "user1"); len(webhooks) != 0 {
+		t.Error("Expected the webhook to be deleted")
+	}
+	if len(deliveryRepo.deliveries) != 0 {
+		t.Error("Expected the webhook's deliveries to be deleted")
+	}
+}
+
+func TestWebhookService_Run(t *testing.T) {
+	ctx := context.Background()
+	webhookRepo := &mockWebhookRepository{webhooks: make(map[string]*domain.Webhook)}
+	deliveryRepo := &mockWebhookDeliveryRepository{deliveries: make(map[string]*domain.WebhookDelivery)}
+	followRepo := &mockFollowRepository{follows: make(map[string][]string)}
+	sender := &mockWebhookSender{status: http.StatusOK}
+	// A long poll interval shows that new events wake the workers up
+	service := NewWebhookService(webhookRepo, deliveryRepo, followRepo, sender, domain.SystemClock{}, WithWebhookPollInterval(time.Hour))
+
+	service.CreateWebhook(ctx, CreateWebhookRequest{UserID: "user1", URL: "https://example.com/hook", Secret: testWebhookSecret, Events: []string{domain.EventTweetCreated}})
+
+	runCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		service.Run(runCtx, 2)
+		close(done)
+	}()
+
+	for i := 0; i < 3; i++ {
+		tweet, _ := domain.NewTweet("tweet"+strconv.Itoa(i), "user1", "Hello", time.Now())
+		if err := service.HandleEvent(ctx, recorded(tweet.ID, domain.TweetCreated{Tweet: tweet})); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sender.count() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 deliveries, got %d", sender.count())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,286 @@
+// Package config loads the server configuration from defaults, an optional
+// YAML or JSON file, environment variables and command-line flags, in that
+// order of precedence, and validates the result.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Storage backends
+const (
+	StorageMemory       = "memory"
+	StorageFile         = "file"
+	StorageEventSourced = "eventsourced"
+	StorageSQLite       = "sqlite"
+)
+
+// redacted replaces secrets in printed configuration
+const redacted = "[REDACTED]"
+
+// minAuthSecretLength is the shortest accepted auth secret
+const minAuthSecretLength = 32
+
+// Config is the complete server configuration
+type Config struct {
+	Server     ServerConfig     `yaml:"server" json:"server"`
+	Storage    StorageConfig    `yaml:"storage" json:"storage"`
+	Tweets     TweetsConfig     `yaml:"tweets" json:"tweets"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit" json:"rate_limit"`
+	CORS       CORSConfig       `yaml:"cors" json:"cors"`
+	Auth       AuthConfig       `yaml:"auth" json:"auth"`
+	Log        LogConfig        `yaml:"log" json:"log"`
+	Tracing    TracingConfig    `yaml:"tracing" json:"tracing"`
+	Federation FederationConfig `yaml:"federation" json:"federation"`
+}
+
+// ServerConfig configures the HTTP and gRPC listeners
+type ServerConfig struct {
+	// Addr is the listen address, such as ":8080"
+	Addr string `yaml:"addr" json:"addr"`
+	// GRPCAddr is the gRPC listen address; empty disables gRPC
+	GRPCAddr     string   `yaml:"grpc_addr" json:"grpc_addr"`
+	ReadTimeout  Duration `yaml:"read_timeout" json:"read_timeout"`
+	WriteTimeout Duration `yaml:"write_timeout" json:"write_timeout"`
+	IdleTimeout  Duration `yaml:"idle_timeout" json:"idle_timeout"`
+	// ShutdownDelay keeps serving, with readiness failing, before draining
+	// starts so that load balancers can stop sending traffic
+	ShutdownDelay Duration `yaml:"shutdown_delay" json:"shutdown_delay"`
+	// ShutdownTimeout bounds draining connections and stopping workers
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" json:"shutdown_timeout"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in files
+type Duration time.Duration
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// StorageConfig selects where data is kept
+type StorageConfig struct {
+	// Backend is StorageMemory, StorageFile, StorageEventSourced or
+	// StorageSQLite. Load picks StorageFile when it is not set and DataDir
+	// is, and StorageMemory otherwise.
+	Backend string `yaml:"backend" json:"backend"`
+	// DataDir holds persisted data and uploaded media. Required by every
+	// backend but memory; with the memory backend media goes to a
+	// temporary directory unless it is set.
+	DataDir string `yaml:"data_dir" json:"data_dir"`
+}
+
+// Persistent reports whether the backend keeps data in DataDir across
+// restarts
+func (s StorageConfig) Persistent() bool {
+	switch s.Backend {
+	case StorageFile, StorageEventSourced, StorageSQLite:
+		return true
+	}
+	return false
+}
+
+// TweetsConfig holds tweet content rules
+type TweetsConfig struct {
+	MaxLength int `yaml:"max_length" json:"max_length"`
+}
+
+// RateLimitConfig holds per-client request quotas
+type RateLimitConfig struct {
+	Enabled         bool `yaml:"enabled" json:"enabled"`
+	ReadsPerMinute  int  `yaml:"reads_per_minute" json:"reads_per_minute"`
+	WritesPerMinute int  `yaml:"writes_per_minute" json:"writes_per_minute"`
+}
+
+// CORSConfig lists the origins allowed to call the API from a browser
+type CORSConfig struct {
+	AllowedOrigins []string `yaml:"allowed_origins" json:"allowed_origins"`
+}
+
+// AuthConfig holds authentication secrets
+type AuthConfig struct {
+	// Secret signs authentication tokens. Optional; when set it must be at
+	// least 32 characters long.
+	Secret string `yaml:"secret" json:"secret"`
+}
+
+// LogConfig configures structured logging
+type LogConfig struct {
+	Level  string `yaml:"level" json:"level"`
+	Format string `yaml:"format" json:"format"`
+}
+
+// TracingConfig configures span export
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp
+	Exporter string `yaml:"exporter" json:"exporter"`
+	// OTLPEndpoint is the collector's host:port; when empty the standard
+	// OTEL_EXPORTER_OTLP_* variables apply
+	OTLPEndpoint string `yaml:"otlp_endpoint" json:"otlp_endpoint"`
+}
+
+// FederationConfig configures ActivityPub federation
+type FederationConfig struct {
+	// BaseURL is the public URL remote servers reach this server at, such
+	// as https://social.example; empty disables federation. Actor IDs are
+	// built from it, so it must not change once accounts are followed.
+	BaseURL string `yaml:"base_url" json:"base_url"`
+}
+
+// Default returns the configuration used when nothing is overridden
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			GRPCAddr:        ":9090",
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(30 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(20 * time.Second),
+		},
+		Storage: StorageConfig{Backend: StorageMemory},
+		Tweets:  TweetsConfig{MaxLength: 280},
+		RateLimit: RateLimitConfig{
+			Enabled:         true,
+			ReadsPerMinute:  300,
+			WritesPerMinute: 30,
+		},
+		CORS:    CORSConfig{AllowedOrigins: []string{"*"}},
+		Log:     LogConfig{Level: "info", Format: "json"},
+		Tracing: TracingConfig{Exporter: "none"},
+	}
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if _, port, err := net.SplitHostPort(c.Server.Addr); err != nil || port == "" {
+		invalid("server.addr %q must be host:port or :port", c.Server.Addr)
+	}
+	if c.Server.GRPCAddr != "" {
+		if _, port, err := net.SplitHostPort(c.Server.GRPCAddr); err != nil || port == "" {
+			invalid("server.grpc_addr %q must be host:port or :port", c.Server.GRPCAddr)
+		} else if c.Server.GRPCAddr == c.Server.Addr {
+			invalid("server.grpc_addr %q must differ from server.addr", c.Server.GRPCAddr)
+		}
+	}
+
+	for _, timeout := range []struct {
+		name  string
+		value Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			invalid("%s must be positive, got %s", timeout.name, time.Duration(timeout.value))
+		}
+	}
+	if c.Server.ShutdownDelay < 0 {
+		invalid("server.shutdown_delay must not be negative, got %s", time.Duration(c.Server.ShutdownDelay))
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StorageFile, StorageEventSourced, StorageSQLite:
+		if c.Storage.DataDir == "" {
+			invalid("storage.data_dir is required by the %s backend", c.Storage.Backend)
+		}
+	default:
+		invalid("storage.backend %q must be %s, %s, %s or %s", c.Storage.Backend, StorageMemory, StorageFile, StorageEventSourced, StorageSQLite)
+	}
+
+	if c.Tweets.MaxLength < 1 {
+		invalid("tweets.max_length must be positive, got %d", c.Tweets.MaxLength)
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.ReadsPerMinute < 1 {
+			invalid("rate_limit.reads_per_minute must be positive, got %d", c.RateLimit.ReadsPerMinute)
+		}
+		if c.RateLimit.WritesPerMinute < 1 {
+			invalid("rate_limit.writes_per_minute must be positive, got %d", c.RateLimit.WritesPerMinute)
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			invalid("cors.allowed_origins entry %q must be \"*\" or an http(s) origin", origin)
+		}
+	}
+
+	if c.Auth.Secret != "" && len(c.Auth.Secret) < minAuthSecretLength {
+		invalid("auth.secret must be at least %d characters", minAuthSecretLength)
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log.level %q must be debug, info, warn or error", c.Log.Level)
+	}
+	switch c.Log.Format {
+	case "json", "text":
+	default:
+		invalid("log.format %q must be json or text", c.Log.Format)
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		invalid("tracing.exporter %q must be none, stdout or otlp", c.Tracing.Exporter)
+	}
+
+	if c.Federation.BaseURL != "" {
+		u, err := url.Parse(c.Federation.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			strings.TrimSuffix(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+			invalid("federation.base_url %q must be an http(s) URL without a path", c.Federation.BaseURL)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c *Config) Redacted() *Config {
+	copied := *c
+	copied.CORS.AllowedOrigins = append([]string(nil), c.CORS.AllowedOrigins...)
+	if copied.Auth.Secret != "" {
+		copied.Auth.Secret = redacted
+	}
+	return &copied
+}
+
+// Print writes the configuration as YAML with secrets redacted
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,362 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env returns a LookupEnv backed by the given map
+func env(vars map[string]string) LookupEnv {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, printConfig, err := Load(nil, env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if printConfig {
+		t.Error("Expected printConfig to be false")
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+server:
+  addr: ":7000"
+tweets:
+  max_length: 400
+rate_limit:
+  reads_per_minute: 100
+log:
+  level: debug
+`)
+
+	cfg, _, err := Load(
+		[]string{"--config", path, "--max-tweet-length", "500"},
+		env(map[string]string{"PORT": "9000", "MAX_TWEET_LENGTH": "450", "LOG_FORMAT": "text"}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Server.Addr != ":9000" {
+		t.Errorf("Expected env to override file addr, got %s", cfg.Server.Addr)
+	}
+	if cfg.Tweets.MaxLength != 500 {
+		t.Errorf("Expected flag to override env and file, got %d", cfg.Tweets.MaxLength)
+	}
+	if cfg.RateLimit.ReadsPerMinute != 100 {
+		t.Errorf("Expected file to override default, got %d", cfg.RateLimit.ReadsPerMinute)
+	}
+	if cfg.RateLimit.WritesPerMinute != 30 {
+		t.Errorf("Expected default writes per minute, got %d", cfg.RateLimit.WritesPerMinute)
+	}
+	if cfg.Log.Level != "debug" || cfg.Log.Format != "text" {
+		t.Errorf("Expected debug/text logging, got %s/%s", cfg.Log.Level, cfg.Log.Format)
+	}
+}
+
+func TestLoad_ListenAddrOverridesPort(t *testing.T) {
+	cfg, _, err := Load(nil, env(map[string]string{"PORT": "9000", "LISTEN_ADDR": "127.0.0.1:9100"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Server.Addr != "127.0.0.1:9100" {
+		t.Errorf("Expected LISTEN_ADDR to win, got %s", cfg.Server.Addr)
+	}
+}
+
+func TestLoad_GRPCAddr(t *testing.T) {
+	cfg, _, err := Load([]string{"--grpc-addr", ":9191"}, env(map[string]string{"GRPC_ADDR": ":9999"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Server.GRPCAddr != ":9191" {
+		t.Errorf("Expected flag to override env, got %s", cfg.Server.GRPCAddr)
+	}
+
+	cfg, _, err = Load(nil, env(map[string]string{"GRPC_ADDR": ""}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Server.GRPCAddr != "" {
+		t.Errorf("Expected empty GRPC_ADDR to disable gRPC, got %s", cfg.Server.GRPCAddr)
+	}
+}
+
+func TestLoad_FederationBaseURL(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+federation:
+  base_url: https://file.example
+`)
+
+	cfg, _, err := Load([]string{"--config", path}, env(map[string]string{"FEDERATION_BASE_URL": "https://env.example"}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Federation.BaseURL != "https://env.example" {
+		t.Errorf("Expected env to override file, got %s", cfg.Federation.BaseURL)
+	}
+
+	cfg, _, err = Load([]string{"--federation-base-url", "https://flag.example/"}, env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Federation.BaseURL != "https://flag.example/" {
+		t.Errorf("Expected base URL from flag, got %s", cfg.Federation.BaseURL)
+	}
+}
+
+func TestLoad_JSONFile(t *testing.T) {
+	path := writeFile(t, "config.json", `{
+		"storage": {"backend": "file", "data_dir": "/var/lib/uala"},
+		"cors": {"allowed_origins": ["https://app.example"]}
+	}`)
+
+	cfg, _, err := Load(nil, env(map[string]string{"CONFIG_FILE": path}))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Storage.Backend != StorageFile || cfg.Storage.DataDir != "/var/lib/uala" {
+		t.Errorf("Expected file storage in /var/lib/uala, got %+v", cfg.Storage)
+	}
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, []string{"https://app.example"}) {
+		t.Errorf("Expected origins from file, got %v", cfg.CORS.AllowedOrigins)
+	}
+}
+
+func TestLoad_DataDirDefaultsToFileBackend(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{"data dir alone", nil, map[string]string{"DATA_DIR": "/var/lib/uala"}, StorageFile},
+		{"data dir flag", []string{"--data-dir", "/var/lib/uala"}, nil, StorageFile},
+		{"explicit memory backend", nil, map[string]string{"DATA_DIR": "/var/lib/uala", "STORAGE_BACKEND": StorageMemory}, StorageMemory},
+		{"explicit sqlite backend", []string{"--storage-backend", StorageSQLite}, map[string]string{"DATA_DIR": "/var/lib/uala"}, StorageSQLite},
+		{"no data dir", nil, nil, StorageMemory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _, err := Load(tt.args, env(tt.env))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if cfg.Storage.Backend != tt.expected {
+				t.Errorf("Expected %s backend, got %s", tt.expected, cfg.Storage.Backend)
+			}
+		})
+	}
+
+	path := writeFile(t, "config.yaml", `
+storage:
+  backend: memory
+  data_dir: /var/lib/uala
+`)
+	cfg, _, err := Load([]string{"--config", path}, env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Storage.Backend != StorageMemory {
+		t.Errorf("Expected the file's memory backend to be kept, got %s", cfg.Storage.Backend)
+	}
+}
+
+func TestLoad_Durations(t *testing.T) {
+	path := writeFile(t, "config.json", `{"server": {"read_timeout": "5s", "shutdown_timeout": "1m"}}`)
+
+	cfg, _, err := Load(
+		[]string{"--config", path, "--shutdown-delay", "3s"},
+		env(map[string]string{"SERVER_WRITE_TIMEOUT": "45s"}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := ServerConfig{
+		Addr:            ":8080",
+		GRPCAddr:        ":9090",
+		ReadTimeout:     Duration(5 * time.Second),
+		WriteTimeout:    Duration(45 * time.Second),
+		IdleTimeout:     Duration(60 * time.Second),
+		ShutdownDelay:   Duration(3 * time.Second),
+		ShutdownTimeout: Duration(time.Minute),
+	}
+	if cfg.Server != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cfg.Server)
+	}
+}
+
+func TestLoad_Lists(t *testing.T) {
+	cfg, _, err := Load(
+		[]string{"--cors-origins", "https://a.example, https://b.example"},
+		env(map[string]string{"CORS_ALLOWED_ORIGINS": "https://c.example"}),
+	)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{"https://a.example", "https://b.example"}
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, expected) {
+		t.Errorf("Expected %v, got %v", expected, cfg.CORS.AllowedOrigins)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+	}{
+		{name: "unknown file key", file: "config.yaml:tweets:\n  max_lenght: 10\n"},
+		{name: "unknown json key", file: "config.json:{\"tweet\": {}}"},
+		{name: "unsupported extension", file: "config.toml:addr = 1"},
+		{name: "missing file", args: []string{"--config", "/does/not/exist.yaml"}},
+		{name: "non-integer env", env: map[string]string{"MAX_TWEET_LENGTH": "lots"}},
+		{name: "bad duration in file", file: "config.yaml:server:\n  read_timeout: soon\n"},
+		{name: "non-duration env", env: map[string]string{"SHUTDOWN_TIMEOUT": "10"}},
+		{name: "non-boolean env", env: map[string]string{"RATE_LIMIT_ENABLED": "maybe"}},
+		{name: "unknown flag", args: []string{"--verbose"}},
+		{name: "invalid value", args: []string{"--storage-backend", "postgres"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				name, content, _ := strings.Cut(tt.file, ":")
+				args = append(args, "--config", writeFile(t, name, content))
+			}
+
+			if _, _, err := Load(args, env(tt.env)); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestLoad_HelpAndPrintConfig(t *testing.T) {
+	if _, _, err := Load([]string{"-h"}, env(nil)); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp, got %v", err)
+	}
+
+	_, printConfig, err := Load([]string{"--print-config"}, env(nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !printConfig {
+		t.Error("Expected printConfig to be true")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		field  string
+	}{
+		{"bad addr", func(c *Config) { c.Server.Addr = "8080" }, "server.addr"},
+		{"bad grpc addr", func(c *Config) { c.Server.GRPCAddr = "9090" }, "server.grpc_addr"},
+		{"grpc addr same as addr", func(c *Config) { c.Server.GRPCAddr = c.Server.Addr }, "must differ"},
+		{"zero timeout", func(c *Config) { c.Server.WriteTimeout = 0 }, "server.write_timeout"},
+		{"negative delay", func(c *Config) { c.Server.ShutdownDelay = Duration(-time.Second) }, "server.shutdown_delay"},
+		{"unknown backend", func(c *Config) { c.Storage.Backend = "postgres" }, "storage.backend"},
+		{"file backend without data dir", func(c *Config) { c.Storage.Backend = StorageFile }, "storage.data_dir"},
+		{"eventsourced backend without data dir", func(c *Config) { c.Storage.Backend = StorageEventSourced }, "storage.data_dir"},
+		{"sqlite backend without data dir", func(c *Config) { c.Storage.Backend = StorageSQLite }, "storage.data_dir"},
+		{"zero max length", func(c *Config) { c.Tweets.MaxLength = 0 }, "tweets.max_length"},
+		{"zero reads", func(c *Config) { c.RateLimit.ReadsPerMinute = 0 }, "rate_limit.reads_per_minute"},
+		{"bad origin", func(c *Config) { c.CORS.AllowedOrigins = []string{"app.example"} }, "cors.allowed_origins"},
+		{"short secret", func(c *Config) { c.Auth.Secret = "hunter2" }, "auth.secret"},
+		{"bad log level", func(c *Config) { c.Log.Level = "loud" }, "log.level"},
+		{"bad log format", func(c *Config) { c.Log.Format = "xml" }, "log.format"},
+		{"bad exporter", func(c *Config) { c.Tracing.Exporter = "jaeger" }, "tracing.exporter"},
+		{"relative federation URL", func(c *Config) { c.Federation.BaseURL = "social.example" }, "federation.base_url"},
+		{"federation URL with path", func(c *Config) { c.Federation.BaseURL = "https://social.example/app" }, "federation.base_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default()
+			tt.modify(cfg)
+
+			err := cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.field) {
+				t.Errorf("Expected error mentioning %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := Default()
+	cfg.Tweets.MaxLength = -1
+	cfg.Log.Format = "xml"
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	for _, field := range []string{"tweets.max_length", "log.format"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("Expected error to mention %s, got %v", field, err)
+		}
+	}
+}
+
+func TestValidate_DisabledRateLimitSkipsQuotas(t *testing.T) {
+	cfg := Default()
+	cfg.RateLimit.Enabled = false
+	cfg.RateLimit.ReadsPerMinute = 0
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	secret := strings.Repeat("s", minAuthSecretLength)
+	cfg := Default()
+	cfg.Auth.Secret = secret
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if strings.Contains(buf.String(), secret) {
+		t.Errorf("Expected secret to be redacted, got:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), redacted) {
+		t.Errorf("Expected %s marker, got:\n%s", redacted, buf.String())
+	}
+	if cfg.Auth.Secret != secret {
+		t.Error("Expected Print to leave the original configuration untouched")
+	}
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LookupEnv reads an environment variable; os.LookupEnv satisfies it
+type LookupEnv func(key string) (string, bool)
+
+// Load builds the configuration from defaults, then the config file, then
+// environment variables, then command-line flags, and validates the result.
+// The file is named by --config or CONFIG_FILE. printConfig reports whether
+// --print-config was given. Load returns flag.ErrHelp for -h and --help.
+func Load(args []string, lookupEnv LookupEnv) (cfg *Config, printConfig bool, err error) {
+	cfg = Default()
+	// Left empty until every source is applied, so that a data directory
+	// without a backend can pick the file backend
+	cfg.Storage.Backend = ""
+
+	fs, flags := newFlagSet()
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	path, _ := lookupEnv("CONFIG_FILE")
+	if set["config"] {
+		path = flags.configFile
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := applyEnv(cfg, lookupEnv); err != nil {
+		return nil, false, err
+	}
+	flags.apply(cfg, set)
+	if cfg.Storage.Backend == "" {
+		cfg.Storage.Backend = defaultBackend(cfg.Storage.DataDir)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return cfg, flags.printConfig, nil
+}
+
+// defaultBackend is the backend used when none is configured: file when a
+// data directory is given, so that setting one alone persists scheduled
+// tweets and webhooks, and memory otherwise
+func defaultBackend(dataDir string) string {
+	if dataDir != "" {
+		return StorageFile
+	}
+	return StorageMemory
+}
+
+// loadFile overlays the YAML or JSON file at path onto cfg, picking the
+// format from the extension. Unknown keys are rejected so typos surface.
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .json", path)
+	}
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overlays environment variables onto cfg. PORT is honoured for
+// platforms that only provide a port; LISTEN_ADDR takes precedence over it.
+func applyEnv(cfg *Config, lookupEnv LookupEnv) error {
+	str := func(key string, dst *string) {
+		if v, ok := lookupEnv(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	integer := func(key string, dst *int) error {
+		v, ok := lookupEnv(key)
+		if !ok || v == "" {
+			return nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not an integer", key, v)
+		}
+		*dst = n
+		return nil
+	}
+
+	if port, ok := lookupEnv("PORT"); ok && port != "" {
+		cfg.Server.Addr = ":" + port
+	}
+	str("LISTEN_ADDR", &cfg.Server.Addr)
+	if v, ok := lookupEnv("GRPC_ADDR"); ok {
+		cfg.Server.GRPCAddr = v
+	}
+	for key, dst := range map[string]*Duration{
+		"SERVER_READ_TIMEOUT":  &cfg.Server.ReadTimeout,
+		"SERVER_WRITE_TIMEOUT": &cfg.Server.WriteTimeout,
+		"SERVER_IDLE_TIMEOUT":  &cfg.Server.IdleTimeout,
+		"SHUTDOWN_DELAY":       &cfg.Server.ShutdownDelay,
+		"SHUTDOWN_TIMEOUT":     &cfg.Server.ShutdownTimeout,
+	} {
+		if v, ok := lookupEnv(key); ok && v != "" {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				return fmt.Errorf("%s: %q is not a duration", key, v)
+			}
+		}
+	}
+	str("STORAGE_BACKEND", &cfg.Storage.Backend)
+	str("DATA_DIR", &cfg.Storage.DataDir)
+	if err := integer("MAX_TWEET_LENGTH", &cfg.Tweets.MaxLength); err != nil {
+		return err
+	}
+	if v, ok := lookupEnv("RATE_LIMIT_ENABLED"); ok && v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("RATE_LIMIT_ENABLED: %q is not a boolean", v)
+		}
+		cfg.RateLimit.Enabled = enabled
+	}
+	if err := integer("RATE_LIMIT_READS_PER_MINUTE", &cfg.RateLimit.ReadsPerMinute); err != nil {
+		return err
+	}
+	if err := integer("RATE_LIMIT_WRITES_PER_MINUTE", &cfg.RateLimit.WritesPerMinute); err != nil {
+		return err
+	}
+	if v, ok := lookupEnv("CORS_ALLOWED_ORIGINS"); ok && v != "" {
+		cfg.CORS.AllowedOrigins = splitList(v)
+	}
+	str("AUTH_SECRET", &cfg.Auth.Secret)
+	str("LOG_LEVEL", &cfg.Log.Level)
+	str("LOG_FORMAT", &cfg.Log.Format)
+	str("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	str("TRACING_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	str("FEDERATION_BASE_URL", &cfg.Federation.BaseURL)
+	return nil
+}
+
+// flagValues holds parsed command-line flags. Secrets have no flag because
+// command lines are visible to other processes.
+type flagValues struct {
+	configFile      string
+	printConfig     bool
+	addr            string
+	grpcAddr        string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	storageBackend  string
+	dataDir         string
+	maxTweetLength  int
+	rateLimit       bool
+	readsPerMinute  int
+	writesPerMinute int
+	corsOrigins     string
+	logLevel        string
+	logFormat       string
+	tracingExporter string
+	otlpEndpoint    string
+	federationURL   string
+}
+
+func newFlagSet() (*flag.FlagSet, *flagValues) {
+	v := &flagValues{}
+	fs := flag.NewFlagSet("uala-challenge", flag.ContinueOnError)
+	fs.StringVar(&v.configFile, "config", "", "path to a YAML or JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&v.printConfig, "print-config", false, "print the effective configuration with secrets redacted and exit")
+	fs.StringVar(&v.addr, "addr", "", "listen address, e.g. :8080 (env LISTEN_ADDR or PORT)")
+	fs.StringVar(&v.grpcAddr, "grpc-addr", "", "gRPC listen address, e.g. :9090; empty disables gRPC (env GRPC_ADDR)")
+	fs.DurationVar(&v.readTimeout, "read-timeout", 0, "maximum duration for reading a request (env SERVER_READ_TIMEOUT)")
+	fs.DurationVar(&v.writeTimeout, "write-timeout", 0, "maximum duration for writing a response (env SERVER_WRITE_TIMEOUT)")
+	fs.DurationVar(&v.idleTimeout, "idle-timeout", 0, "how long idle keep-alive connections stay open (env SERVER_IDLE_TIMEOUT)")
+	fs.DurationVar(&v.shutdownDelay, "shutdown-delay", 0, "how long to keep serving with readiness failing before draining (env SHUTDOWN_DELAY)")
+	fs.DurationVar(&v.shutdownTimeout, "shutdown-timeout", 0, "deadline for draining connections and stopping workers (env SHUTDOWN_TIMEOUT)")
+	fs.StringVar(&v.storageBackend, "storage-backend", "", "storage backend: memory, file, eventsourced or sqlite; file by default when a data dir is set (env STORAGE_BACKEND)")
+	fs.StringVar(&v.dataDir, "data-dir", "", "directory for persisted data and media (env DATA_DIR)")
+	fs.IntVar(&v.maxTweetLength, "max-tweet-length", 0, "maximum tweet length in characters (env MAX_TWEET_LENGTH)")
+	fs.BoolVar(&v.rateLimit, "rate-limit", true, "enable rate limiting (env RATE_LIMIT_ENABLED)")
+	fs.IntVar(&v.readsPerMinute, "rate-limit-reads", 0, "read requests per minute per client (env RATE_LIMIT_READS_PER_MINUTE)")
+	fs.IntVar(&v.writesPerMinute, "rate-limit-writes", 0, "write requests per minute per client (env RATE_LIMIT_WRITES_PER_MINUTE)")
+	fs.StringVar(&v.corsOrigins, "cors-origins", "", "comma-separated allowed CORS origins (env CORS_ALLOWED_ORIGINS)")
+	fs.StringVar(&v.logLevel, "log-level", "", "log level: debug, info, warn or error (env LOG_LEVEL)")
+	fs.StringVar(&v.logFormat, "log-format", "", "log format: json or text (env LOG_FORMAT)")
+	fs.StringVar(&v.tracingExporter, "tracing-exporter", "", "span exporter: none, stdout or otlp (env TRACING_EXPORTER)")
+	fs.StringVar(&v.otlpEndpoint, "otlp-endpoint", "", "OTLP collector host:port (env TRACING_OTLP_ENDPOINT)")
+	fs.StringVar(&v.federationURL, "federation-base-url", "", "public base URL for ActivityPub federation; empty disables it (env FEDERATION_BASE_URL)")
+	return fs, v
+}
+
+// apply overlays the flags that were explicitly set onto cfg
+func (v *flagValues) apply(cfg *Config, set map[string]bool) {
+	if set["addr"] {
+		cfg.Server.Addr = v.addr
+	}
+	if set["grpc-addr"] {
+		cfg.Server.GRPCAddr = v.grpcAddr
+	}
+	if set["read-timeout"] {
+		cfg.Server.ReadTimeout = Duration(v.readTimeout)
+	}
+	if set["write-timeout"] {
+		cfg.Server.WriteTimeout = Duration(v.writeTimeout)
+	}
+	if set["idle-timeout"] {
+		cfg.Server.IdleTimeout = Duration(v.idleTimeout)
+	}
+	if set["shutdown-delay"] {
+		cfg.Server.ShutdownDelay = Duration(v.shutdownDelay)
+	}
+	if set["shutdown-timeout"] {
+		cfg.Server.ShutdownTimeout = Duration(v.shutdownTimeout)
+	}
+	if set["storage-backend"] {
+		cfg.Storage.Backend = v.storageBackend
+	}
+	if set["data-dir"] {
+		cfg.Storage.DataDir = v.dataDir
+	}
+	if set["max-tweet-length"] {
+		cfg.Tweets.MaxLength = v.maxTweetLength
+	}
+	if set["rate-limit"] {
+		cfg.RateLimit.Enabled = v.rateLimit
+	}
+	if set["rate-limit-reads"] {
+		cfg.RateLimit.ReadsPerMinute = v.readsPerMinute
+	}
+	if set["rate-limit-writes"] {
+		cfg.RateLimit.WritesPerMinute = v.writesPerMinute
+	}
+	if set["cors-origins"] {
+		cfg.CORS.AllowedOrigins = splitList(v.corsOrigins)
+	}
+	if set["log-level"] {
+		cfg.Log.Level = v.logLevel
+	}
+	if set["log-format"] {
+		cfg.Log.Format = v.logFormat
+	}
+	if set["tracing-exporter"] {
+		cfg.Tracing.Exporter = v.tracingExporter
+	}
+	if set["otlp-endpoint"] {
+		cfg.Tracing.OTLPEndpoint = v.otlpEndpoint
+	}
+	if set["federation-base-url"] {
+		cfg.Federation.BaseURL = v.federationURL
+	}
+}
+
+// splitList splits a comma-separated list, dropping blanks
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
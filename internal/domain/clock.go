@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock provides the current time, so that services can be tested with a
+// clock they control
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock backed by time.Now
+type SystemClock struct{}
+
+// Now returns the current wall-clock time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// IDGenerator provides the IDs of new entities
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDv7Generator generates UUIDv7 IDs. They start with the time they were
+// generated at, to the millisecond, and increase within a process even
+// inside a millisecond, so they sort in the order they were generated.
+type UUIDv7Generator struct{}
+
+// NewID returns a new UUIDv7
+func (UUIDv7Generator) NewID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
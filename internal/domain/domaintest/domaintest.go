@@ -0,0 +1,64 @@
+// Package domaintest provides deterministic stand-ins for the domain's
+// Clock and IDGenerator, for tests that need exact times and IDs
+package domaintest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeClock is a domain.Clock that only moves when told to. It is safe for
+// concurrent use.
+type FakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewFakeClock creates a clock stopped at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	c.now = c.now.Add(d)
+	c.mutex.Unlock()
+}
+
+// Set moves the clock to now
+func (c *FakeClock) Set(now time.Time) {
+	c.mutex.Lock()
+	c.now = now
+	c.mutex.Unlock()
+}
+
+// SequentialIDs is a domain.IDGenerator that returns prefix-000001,
+// prefix-000002 and so on, which sort in the order they were generated like
+// the IDs of domain.UUIDv7Generator. It is safe for concurrent use.
+type SequentialIDs struct {
+	prefix string
+	mutex  sync.Mutex
+	next   int
+}
+
+// NewSequentialIDs creates a generator of IDs starting with prefix
+func NewSequentialIDs(prefix string) *SequentialIDs {
+	return &SequentialIDs{prefix: prefix}
+}
+
+// NewID returns the next ID
+func (g *SequentialIDs) NewID() string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.next++
+	return fmt.Sprintf("%s-%06d", g.prefix, g.next)
+}
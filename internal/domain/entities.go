@@ -1,11 +1,13 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
-
-	"github.com/google/uuid"
 )
 
 // Domain errors
@@ -14,10 +16,76 @@ var (
 	ErrTweetEmpty       = errors.New("tweet content cannot be empty")
 	ErrUserNotFound     = errors.New("user not found")
 	ErrCannotFollowSelf = errors.New("cannot follow yourself")
+	ErrTweetNotFound    = errors.New("tweet not found")
+
+	ErrPageCursorNotFound = errors.New("page cursor does not refer to an item")
+
+	ErrScheduledTweetNotFound = errors.New("scheduled tweet not found")
+	ErrPublishTimeInPast      = errors.New("publish time must be in the future")
+
+	ErrPollOptionCount   = errors.New("poll must have between 2 and 4 options")
+	ErrPollOptionInvalid = errors.New("poll options must be non-empty and at most 25 characters")
+	ErrPollDuration      = errors.New("poll duration must be between 5 minutes and 7 days")
+	ErrPollNotFound      = errors.New("poll not found")
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrInvalidPollOption = errors.New("invalid poll option")
+	ErrAlreadyVoted      = errors.New("user has already voted in this poll")
+
+	ErrMediaTooLarge        = errors.New("media exceeds size limit")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrInvalidMedia         = errors.New("media could not be decoded")
+	ErrMediaNotFound        = errors.New("media not found")
+	ErrUnknownMediaID       = errors.New("media ID does not refer to media uploaded by the author")
+	ErrTooManyMedia         = errors.New("tweet exceeds media attachment limit")
+	ErrBlobNotFound         = errors.New("blob not found")
+
+	ErrInvalidActorID = errors.New("actor ID must be an absolute http or https URL")
+
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrWebhookSecretTooShort   = errors.New("webhook secret is too short")
+	ErrInvalidWebhookEvents    = errors.New("webhook events must be one or more known event types")
+	ErrTooManyWebhooks         = errors.New("user has too many webhooks")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrDeliveryNotDeadLettered = errors.New("only dead-lettered deliveries can be retried")
 )
 
+// MaxTweetLength is the default tweet length limit
 const MaxTweetLength = 280
 
+// Poll limits
+const (
+	MinPollOptions      = 2
+	MaxPollOptions      = 4
+	MaxPollOptionLength = 25
+	MinPollDuration     = 5 * time.Minute
+	MaxPollDuration     = 7 * 24 * time.Hour
+)
+
+// Media limits
+const (
+	MaxMediaPerTweet = 4
+	MaxMediaSize     = 5 << 20 // 5 MiB
+)
+
+// Webhook limits
+const (
+	MinWebhookSecretLength = 16
+	MaxWebhooksPerUser     = 10
+)
+
+// WebhookEvents lists the event types webhooks can subscribe to
+var WebhookEvents = []string{EventTweetCreated, EventUserFollowed, EventUserUnfollowed}
+
+// Webhook delivery states
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	// DeliveryDead marks a delivery that failed every attempt and was
+	// dead-lettered; it is only sent again when retried by hand
+	DeliveryDead = "dead"
+)
+
 // User represents a user in the system
 type User struct {
 	ID   string `json:"id"`
@@ -26,45 +94,300 @@ type User struct {
 
 // Tweet represents a tweet/post
 type Tweet struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"user_id"`
+	Content   string       `json:"content"`
+	CreatedAt time.Time    `json:"created_at"`
+	Poll      *Poll        `json:"poll,omitempty"`
+	MediaIDs  []string     `json:"media_ids,omitempty"`
+	Card      *LinkPreview `json:"card,omitempty"`
+}
+
+// LinkPreview is the preview card unfurled from a URL's OpenGraph or
+// Twitter-card metadata
+type LinkPreview struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Media represents an uploaded image. Key and ThumbnailKey are the
+// content-addressed blob store keys of the stripped image and its thumbnail.
+type Media struct {
+	ID                   string    `json:"id"`
+	UserID               string    `json:"user_id"`
+	ContentType          string    `json:"content_type"`
+	Size                 int       `json:"size"`
+	Width                int       `json:"width"`
+	Height               int       `json:"height"`
+	Key                  string    `json:"-"`
+	ThumbnailKey         string    `json:"-"`
+	ThumbnailContentType string    `json:"-"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// PollOption is one of the choices of a poll. Votes is only set when the
+// results are visible to the viewer.
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+// Poll is an optional poll attached to a tweet. Results (TotalVotes and the
+// per-option Votes) are hidden until the viewer votes or the poll closes.
+type Poll struct {
+	Options     []PollOption `json:"options"`
+	EndsAt      time.Time    `json:"ends_at"`
+	Closed      bool         `json:"closed"`
+	TotalVotes  *int         `json:"total_votes,omitempty"`
+	VotedOption *int         `json:"voted_option,omitempty"`
+}
+
+// ScheduledTweet represents a tweet queued for publication at a later time
+type ScheduledTweet struct {
 	ID        string    `json:"id"`
 	UserID    string    `json:"user_id"`
 	Content   string    `json:"content"`
+	PublishAt time.Time `json:"publish_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Webhook is a subscription that receives events at URL. Payloads are signed
+// with Secret, which is never shown again after creation.
+type Webhook struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// WebhookEvent is the envelope posted to webhooks. Data depends on Type.
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is one event on its way to one webhook. Deliveries are
+// kept after they finish so that they can be listed in the delivery log.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
 // Follow represents a follow relationship between users
 type Follow struct {
 	FollowerID string `json:"follower_id"`
 	FolloweeID string `json:"followee_id"`
 }
 
-// NewUser creates a new user with generated ID
-func NewUser(name string) *User {
+// NewUser creates a new user with an ID from an IDGenerator
+func NewUser(id, name string) *User {
 	return &User{
-		ID:   uuid.New().String(),
+		ID:   id,
 		Name: name,
 	}
 }
 
-// NewTweet creates a new tweet with validation
-func NewTweet(userID, content string) (*Tweet, error) {
+// NewTweet creates a new tweet with validation. The ID comes from an
+// IDGenerator and now from a Clock, so that services control both.
+func NewTweet(id, userID, content string, now time.Time) (*Tweet, error) {
+	return NewTweetWithLimit(id, userID, content, now, MaxTweetLength)
+}
+
+// NewTweetWithLimit creates a new tweet whose content may be at most
+// maxLength long, for deployments that configure their own limit
+func NewTweetWithLimit(id, userID, content string, now time.Time, maxLength int) (*Tweet, error) {
 	// Validate content
 	if len(strings.TrimSpace(content)) == 0 {
 		return nil, ErrTweetEmpty
 	}
 	
-	if len(content) > MaxTweetLength {
+	if len(content) > maxLength {
 		return nil, ErrTweetTooLong
 	}
 
 	return &Tweet{
-		ID:        uuid.New().String(),
+		ID:        id,
 		UserID:    userID,
 		Content:   content,
-		CreatedAt: time.Now(),
+		CreatedAt: now,
 	}, nil
 }
 
+// NewerThan reports whether t comes before other in a timeline: it was
+// created later or, created at the same time, has the greater ID. IDs from
+// UUIDv7Generator increase, so ties go to the tweet created last.
+func (t *Tweet) NewerThan(other *Tweet) bool {
+	if !t.CreatedAt.Equal(other.CreatedAt) {
+		return t.CreatedAt.After(other.CreatedAt)
+	}
+	return t.ID > other.ID
+}
+
+// SortNewestFirst sorts tweets the way timelines list them
+func SortNewestFirst(tweets []*Tweet) {
+	sort.Slice(tweets, func(i, j int) bool {
+		return tweets[i].NewerThan(tweets[j])
+	})
+}
+
+// urlPattern matches http(s) URLs in tweet content
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// FirstURL returns the first http(s) URL in the tweet content, or an empty
+// string. Trailing punctuation is not considered part of the URL.
+func (t *Tweet) FirstURL() string {
+	url := urlPattern.FindString(t.Content)
+	return strings.TrimRight(url, ".,;:!?)]}'")
+}
+
+// AttachMedia attaches uploaded media to the tweet, ignoring duplicate IDs
+func (t *Tweet) AttachMedia(mediaIDs []string) error {
+	seen := make(map[string]bool)
+	var attached []string
+	for _, id := range mediaIDs {
+		if !seen[id] {
+			seen[id] = true
+			attached = append(attached, id)
+		}
+	}
+
+	if len(attached) > MaxMediaPerTweet {
+		return ErrTooManyMedia
+	}
+
+	t.MediaIDs = attached
+	return nil
+}
+
+// NewPoll creates a new poll with validation, open from now for the given duration
+func NewPoll(options []string, duration time.Duration, now time.Time) (*Poll, error) {
+	if len(options) < MinPollOptions || len(options) > MaxPollOptions {
+		return nil, ErrPollOptionCount
+	}
+
+	pollOptions := make([]PollOption, len(options))
+	for i, option := range options {
+		option = strings.TrimSpace(option)
+		if len(option) == 0 || len(option) > MaxPollOptionLength {
+			return nil, ErrPollOptionInvalid
+		}
+		pollOptions[i] = PollOption{Text: option}
+	}
+
+	if duration < MinPollDuration || duration > MaxPollDuration {
+		return nil, ErrPollDuration
+	}
+
+	return &Poll{
+		Options: pollOptions,
+		EndsAt:  now.Add(duration),
+	}, nil
+}
+
+// IsClosed reports whether voting has ended at the given time
+func (p *Poll) IsClosed(now time.Time) bool {
+	return !now.Before(p.EndsAt)
+}
+
+// ValidateVote checks that an option can be voted on at the given time
+func (p *Poll) ValidateVote(option int, now time.Time) error {
+	if p.IsClosed(now) {
+		return ErrPollClosed
+	}
+	if option < 0 || option >= len(p.Options) {
+		return ErrInvalidPollOption
+	}
+	return nil
+}
+
+// View returns a copy of the poll as seen by a viewer. Tallies are only
+// included when the viewer has voted (votedOption >= 0) or the poll is closed.
+func (p *Poll) View(tallies []int, votedOption int, now time.Time) *Poll {
+	view := &Poll{
+		Options: make([]PollOption, len(p.Options)),
+		EndsAt:  p.EndsAt,
+		Closed:  p.IsClosed(now),
+	}
+
+	if votedOption >= 0 {
+		voted := votedOption
+		view.VotedOption = &voted
+	}
+
+	showResults := view.Closed || view.VotedOption != nil
+	total := 0
+	for i, option := range p.Options {
+		view.Options[i] = PollOption{Text: option.Text}
+		if showResults {
+			votes := 0
+			if i < len(tallies) {
+				votes = tallies[i]
+			}
+			view.Options[i].Votes = &votes
+			total += votes
+		}
+	}
+	if showResults {
+		view.TotalVotes = &total
+	}
+
+	return view
+}
+
+// NewScheduledTweet creates a scheduled tweet, validating the content with
+// NewTweet so that invalid posts are rejected at submit time
+func NewScheduledTweet(id, userID, content string, publishAt, now time.Time) (*ScheduledTweet, error) {
+	return NewScheduledTweetWithLimit(id, userID, content, publishAt, now, MaxTweetLength)
+}
+
+// NewScheduledTweetWithLimit is NewScheduledTweet with a custom content length limit
+func NewScheduledTweetWithLimit(id, userID, content string, publishAt, now time.Time, maxLength int) (*ScheduledTweet, error) {
+	tweet, err := NewTweetWithLimit(id, userID, content, now, maxLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if !publishAt.After(now) {
+		return nil, ErrPublishTimeInPast
+	}
+
+	return &ScheduledTweet{
+		ID:        tweet.ID,
+		UserID:    tweet.UserID,
+		Content:   tweet.Content,
+		PublishAt: publishAt,
+		CreatedAt: now,
+	}, nil
+}
+
+// Tweet converts the scheduled tweet into the tweet published at the given time
+func (s *ScheduledTweet) Tweet(publishedAt time.Time) *Tweet {
+	return &Tweet{
+		ID:        s.ID,
+		UserID:    s.UserID,
+		Content:   s.Content,
+		CreatedAt: publishedAt,
+	}
+}
+
 // ValidateFollow checks if a follow relationship is valid
 func ValidateFollow(followerID, followeeID string) error {
 	if followerID == followeeID {
@@ -72,3 +395,118 @@ func ValidateFollow(followerID, followeeID string) error {
 	}
 	return nil
 }
+
+// NewWebhook creates a webhook subscription with validation. Duplicate
+// event types are ignored. The ID comes from an IDGenerator and now from a
+// Clock, like those of tweets.
+func NewWebhook(id, userID, rawURL, secret string, events []string, now time.Time) (*Webhook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidWebhookURL
+	}
+
+	if len(secret) < MinWebhookSecretLength {
+		return nil, ErrWebhookSecretTooShort
+	}
+
+	var subscribed []string
+	for _, event := range events {
+		if !isWebhookEvent(event) {
+			return nil, ErrInvalidWebhookEvents
+		}
+		if !containsString(subscribed, event) {
+			subscribed = append(subscribed, event)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil, ErrInvalidWebhookEvents
+	}
+
+	return &Webhook{
+		ID:        id,
+		UserID:    userID,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    subscribed,
+		CreatedAt: now,
+	}, nil
+}
+
+// Subscribes reports whether the webhook receives events of eventType
+func (w *Webhook) Subscribes(eventType string) bool {
+	return containsString(w.Events, eventType)
+}
+
+func isWebhookEvent(eventType string) bool {
+	return containsString(WebhookEvents, eventType)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewWebhookEvent creates an event of the given type
+func NewWebhookEvent(id, eventType string, data interface{}, now time.Time) *WebhookEvent {
+	return &WebhookEvent{
+		ID:        id,
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	}
+}
+
+// NewWebhookDelivery creates a pending delivery of an encoded event to a
+// webhook, due immediately
+func NewWebhookDelivery(id, webhookID string, event *WebhookEvent, payload []byte, now time.Time) *WebhookDelivery {
+	return &WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhookID,
+		EventID:       event.ID,
+		EventType:     event.Type,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// RecordSuccess records an attempt made at now that the webhook accepted
+func (d *WebhookDelivery) RecordSuccess(responseStatus int, now time.Time) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = responseStatus
+	d.LastError = ""
+	d.Status = DeliverySucceeded
+}
+
+// RecordFailure records a failed attempt made at now. The delivery is tried
+// again at retryAt, or dead-lettered once it has been attempted maxAttempts
+// times. responseStatus is zero when no response was received.
+func (d *WebhookDelivery) RecordFailure(responseStatus int, reason string, now, retryAt time.Time, maxAttempts int) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus = responseStatus
+	d.LastError = reason
+	if d.Attempts >= maxAttempts {
+		d.Status = DeliveryDead
+		return
+	}
+	d.NextAttemptAt = retryAt
+}
+
+// Redeliver puts a dead-lettered delivery back in the queue, due at now,
+// with a fresh set of attempts
+func (d *WebhookDelivery) Redeliver(now time.Time) error {
+	if d.Status != DeliveryDead {
+		return ErrDeliveryNotDeadLettered
+	}
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	return nil
+}
@@ -1,16 +1,18 @@
 package domain
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewUser(t *testing.T) {
 	name := "John Doe"
 
-	user := NewUser(name)
+	user := NewUser("user1", name)
 
-	if user.ID == "" {
-		t.Error("User ID should not be empty")
+	if user.ID != "user1" {
+		t.Errorf("Expected ID user1, got %s", user.ID)
 	}
 
 	if user.Name != name {
@@ -20,6 +22,7 @@ func TestNewUser(t *testing.T) {
 
 func TestNewTweet(t *testing.T) {
 	userID := "user123"
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
 	
 	tests := []struct {
 		name        string
@@ -59,7 +62,7 @@ func TestNewTweet(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tweet, err := NewTweet(userID, tt.content)
+			tweet, err := NewTweet("tweet1", userID, tt.content, now)
 
 			if tt.expectError {
 				if err == nil {
@@ -78,14 +81,58 @@ func TestNewTweet(t *testing.T) {
 				if tweet.Content != tt.content {
 					t.Errorf("Expected Content %s, got %s", tt.content, tweet.Content)
 				}
-				if tweet.ID == "" {
-					t.Error("Tweet ID should not be empty")
+				if tweet.ID != "tweet1" || !tweet.CreatedAt.Equal(now) {
+					t.Errorf("Expected tweet1 created at %v, got %s at %v", now, tweet.ID, tweet.CreatedAt)
 				}
 			}
 		})
 	}
 }
 
+func TestNewTweetWithLimit(t *testing.T) {
+	now := time.Now()
+	if _, err := NewTweetWithLimit("tweet1", "user123", string(make([]byte, 500)), now, 500); err != nil {
+		t.Errorf("Expected no error at the limit, got %v", err)
+	}
+	if _, err := NewTweetWithLimit("tweet2", "user123", string(make([]byte, 101)), now, 100); err != ErrTweetTooLong {
+		t.Errorf("Expected error %v, got %v", ErrTweetTooLong, err)
+	}
+}
+
+func TestSortNewestFirst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tweets := []*Tweet{
+		{ID: "a", CreatedAt: now},
+		{ID: "b", CreatedAt: now.Add(time.Second)},
+		{ID: "d", CreatedAt: now},
+		{ID: "c", CreatedAt: now},
+	}
+
+	// Tweets created at the same time are ordered by descending ID
+	SortNewestFirst(tweets)
+	var ids []string
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.ID)
+	}
+	if got := strings.Join(ids, ","); got != "b,d,c,a" {
+		t.Errorf("Expected b,d,c,a, got %s", got)
+	}
+}
+
+func TestUUIDv7Generator(t *testing.T) {
+	ids := UUIDv7Generator{}
+
+	// IDs generated within the same millisecond still increase
+	previous := ids.NewID()
+	for i := 0; i < 1000; i++ {
+		id := ids.NewID()
+		if id <= previous {
+			t.Fatalf("Expected %s to sort after %s", id, previous)
+		}
+		previous = id
+	}
+}
+
 func TestValidateFollow(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -128,3 +175,297 @@ func TestValidateFollow(t *testing.T) {
 		})
 	}
 }
+
+func TestNewScheduledTweet(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		content     string
+		publishAt   time.Time
+		expectError bool
+		errorType   error
+	}{
+		{
+			name:        "valid scheduled tweet",
+			content:     "See you tomorrow!",
+			publishAt:   now.Add(time.Hour),
+			expectError: false,
+		},
+		{
+			name:        "empty content",
+			content:     "",
+			publishAt:   now.Add(time.Hour),
+			expectError: true,
+			errorType:   ErrTweetEmpty,
+		},
+		{
+			name:        "content exceeding character limit",
+			content:     string(make([]byte, MaxTweetLength+1)),
+			publishAt:   now.Add(time.Hour),
+			expectError: true,
+			errorType:   ErrTweetTooLong,
+		},
+		{
+			name:        "publish time in the past",
+			content:     "Too late",
+			publishAt:   now.Add(-time.Minute),
+			expectError: true,
+			errorType:   ErrPublishTimeInPast,
+		},
+		{
+			name:        "publish time equal to now",
+			content:     "Right now",
+			publishAt:   now,
+			expectError: true,
+			errorType:   ErrPublishTimeInPast,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduled, err := NewScheduledTweet("scheduled1", "user123", tt.content, tt.publishAt, now)
+
+			if tt.expectError {
+				if err != tt.errorType {
+					t.Errorf("Expected error %v, got %v", tt.errorType, err)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				if scheduled.ID == "" {
+					t.Error("Scheduled tweet ID should not be empty")
+				}
+				if !scheduled.PublishAt.Equal(tt.publishAt) {
+					t.Errorf("Expected PublishAt %v, got %v", tt.publishAt, scheduled.PublishAt)
+				}
+				if !scheduled.CreatedAt.Equal(now) {
+					t.Errorf("Expected CreatedAt %v, got %v", now, scheduled.CreatedAt)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPoll(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		options   []string
+		duration  time.Duration
+		errorType error
+	}{
+		{
+			name:     "valid poll",
+			options:  []string{"Yes", "No"},
+			duration: time.Hour,
+		},
+		{
+			name:     "four options",
+			options:  []string{"A", "B", "C", "D"},
+			duration: MaxPollDuration,
+		},
+		{
+			name:      "too few options",
+			options:   []string{"Only"},
+			duration:  time.Hour,
+			errorType: ErrPollOptionCount,
+		},
+		{
+			name:      "too many options",
+			options:   []string{"A", "B", "C", "D", "E"},
+			duration:  time.Hour,
+			errorType: ErrPollOptionCount,
+		},
+		{
+			name:      "empty option",
+			options:   []string{"Yes", "  "},
+			duration:  time.Hour,
+			errorType: ErrPollOptionInvalid,
+		},
+		{
+			name:      "option too long",
+			options:   []string{"Yes", string(make([]byte, MaxPollOptionLength+1))},
+			duration:  time.Hour,
+			errorType: ErrPollOptionInvalid,
+		},
+		{
+			name:      "duration too short",
+			options:   []string{"Yes", "No"},
+			duration:  time.Minute,
+			errorType: ErrPollDuration,
+		},
+		{
+			name:      "duration too long",
+			options:   []string{"Yes", "No"},
+			duration:  MaxPollDuration + time.Minute,
+			errorType: ErrPollDuration,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poll, err := NewPoll(tt.options, tt.duration, now)
+
+			if err != tt.errorType {
+				t.Fatalf("Expected error %v, got %v", tt.errorType, err)
+			}
+			if tt.errorType == nil {
+				if len(poll.Options) != len(tt.options) {
+					t.Errorf("Expected %d options, got %d", len(tt.options), len(poll.Options))
+				}
+				if !poll.EndsAt.Equal(now.Add(tt.duration)) {
+					t.Errorf("Expected EndsAt %v, got %v", now.Add(tt.duration), poll.EndsAt)
+				}
+			}
+		})
+	}
+}
+
+func TestPoll_View(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	poll, err := NewPoll([]string{"Yes", "No", "Maybe"}, time.Hour, now)
+	if err != nil {
+		t.Fatalf("Failed to create poll: %v", err)
+	}
+	tallies := []int{2, 1}
+
+	// Results are hidden from viewers that have not voted while the poll is open
+	view := poll.View(tallies, -1, now)
+	if view.Closed || view.TotalVotes != nil || view.VotedOption != nil {
+		t.Error("Expected hidden results for open poll without a vote")
+	}
+	for _, option := range view.Options {
+		if option.Votes != nil {
+			t.Errorf("Expected hidden votes for option %s", option.Text)
+		}
+	}
+
+	// Voters see the tallies
+	view = poll.View(tallies, 1, now)
+	if view.VotedOption == nil || *view.VotedOption != 1 {
+		t.Error("Expected voted option to be 1")
+	}
+	if view.TotalVotes == nil || *view.TotalVotes != 3 {
+		t.Error("Expected 3 total votes")
+	}
+	if *view.Options[0].Votes != 2 || *view.Options[1].Votes != 1 || *view.Options[2].Votes != 0 {
+		t.Error("Expected tallies 2, 1, 0")
+	}
+
+	// Everyone sees the tallies once the poll closes
+	view = poll.View(tallies, -1, now.Add(time.Hour))
+	if !view.Closed || view.TotalVotes == nil {
+		t.Error("Expected visible results for closed poll")
+	}
+
+	// The stored poll is never mutated
+	if poll.Options[0].Votes != nil || poll.TotalVotes != nil {
+		t.Error("Expected View to leave the poll unchanged")
+	}
+}
+
+func TestTweet_FirstURL(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"no links here", ""},
+		{"see https://example.com/a?b=c for details", "https://example.com/a?b=c"},
+		{"first http://one.example and https://two.example", "http://one.example"},
+		{"trailing punctuation https://example.com/post.", "https://example.com/post"},
+		{"(in parens https://example.com/x)", "https://example.com/x"},
+		{"ftp://example.com is not unfurled", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			tweet := &Tweet{Content: tt.content}
+			if got := tweet.FirstURL(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewWebhook(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	secret := "0123456789abcdef"
+
+	tests := []struct {
+		name      string
+		url       string
+		secret    string
+		events    []string
+		errorType error
+	}{
+		{"valid webhook", "https://partner.example/hooks", secret, []string{EventTweetCreated, EventUserFollowed}, nil},
+		{"relative URL", "/hooks", secret, []string{EventTweetCreated}, ErrInvalidWebhookURL},
+		{"unsupported scheme", "ftp://partner.example/hooks", secret, []string{EventTweetCreated}, ErrInvalidWebhookURL},
+		{"short secret", "https://partner.example/hooks", "secret", []string{EventTweetCreated}, ErrWebhookSecretTooShort},
+		{"no events", "https://partner.example/hooks", secret, nil, ErrInvalidWebhookEvents},
+		{"unknown event", "https://partner.example/hooks", secret, []string{EventTweetCreated, "tweet.deleted"}, ErrInvalidWebhookEvents},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, err := NewWebhook("webhook1", "user123", tt.url, tt.secret, tt.events, now)
+			if err != tt.errorType {
+				t.Fatalf("Expected error %v, got %v", tt.errorType, err)
+			}
+			if err != nil {
+				return
+			}
+			if webhook.ID == "" || webhook.Secret != tt.secret || !webhook.CreatedAt.Equal(now) {
+				t.Errorf("Unexpected webhook: %+v", webhook)
+			}
+			if !webhook.Subscribes(EventUserFollowed) || webhook.Subscribes(EventUserUnfollowed) {
+				t.Errorf("Expected subscriptions %v, got %v", tt.events, webhook.Events)
+			}
+		})
+	}
+
+	webhook, _ := NewWebhook("webhook1", "user123", "https://partner.example/hooks", secret, []string{EventTweetCreated, EventTweetCreated}, now)
+	if len(webhook.Events) != 1 {
+		t.Errorf("Expected duplicate events to be ignored, got %v", webhook.Events)
+	}
+}
+
+func TestWebhookDelivery_Attempts(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	event := NewWebhookEvent("event1", EventTweetCreated, map[string]string{"id": "1"}, now)
+	delivery := NewWebhookDelivery("delivery1", "webhook1", event, []byte(`{}`), now)
+
+	if delivery.Status != DeliveryPending || !delivery.NextAttemptAt.Equal(now) || delivery.EventID != event.ID {
+		t.Fatalf("Expected a pending delivery due now, got %+v", delivery)
+	}
+
+	retryAt := now.Add(time.Minute)
+	delivery.RecordFailure(500, "server error", now, retryAt, 2)
+	if delivery.Status != DeliveryPending || delivery.Attempts != 1 || !delivery.NextAttemptAt.Equal(retryAt) {
+		t.Errorf("Expected a retry at %v, got %+v", retryAt, delivery)
+	}
+	if err := delivery.Redeliver(now); err != ErrDeliveryNotDeadLettered {
+		t.Errorf("Expected %v for a pending delivery, got %v", ErrDeliveryNotDeadLettered, err)
+	}
+
+	delivery.RecordFailure(0, "connection refused", retryAt, retryAt.Add(time.Minute), 2)
+	if delivery.Status != DeliveryDead || delivery.Attempts != 2 || delivery.LastError != "connection refused" {
+		t.Errorf("Expected the delivery to be dead-lettered, got %+v", delivery)
+	}
+
+	later := now.Add(time.Hour)
+	if err := delivery.Redeliver(later); err != nil {
+		t.Fatalf("Expected a dead-lettered delivery to be redelivered, got %v", err)
+	}
+	if delivery.Status != DeliveryPending || delivery.Attempts != 0 || !delivery.NextAttemptAt.Equal(later) {
+		t.Errorf("Expected a fresh pending delivery, got %+v", delivery)
+	}
+
+	delivery.RecordSuccess(204, later)
+	if delivery.Status != DeliverySucceeded || delivery.ResponseStatus != 204 || delivery.LastError != "" {
+		t.Errorf("Expected a succeeded delivery, got %+v", delivery)
+	}
+}
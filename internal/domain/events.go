@@ -0,0 +1,133 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnknownEventType is returned when decoding an event of a type this
+// version does not know
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Domain event types
+const (
+	EventUserCreated    = "user.created"
+	EventTweetCreated   = "tweet.created"
+	EventUserFollowed   = "user.followed"
+	EventUserUnfollowed = "user.unfollowed"
+)
+
+// Event is something that happened in the domain. Events are recorded in the
+// same transaction as the change they describe and delivered to subscribers
+// once it is committed.
+type Event interface {
+	EventType() string
+}
+
+// UserCreated is recorded when a user is created
+type UserCreated struct {
+	User *User `json:"user"`
+}
+
+// TweetCreated is recorded when a tweet is posted, including when a
+// scheduled tweet is published
+type TweetCreated struct {
+	Tweet *Tweet `json:"tweet"`
+}
+
+// UserFollowed is recorded when a user follows another
+type UserFollowed struct {
+	FollowerID string `json:"follower_id"`
+	FolloweeID string `json:"followee_id"`
+}
+
+// UserUnfollowed is recorded when a user unfollows another
+type UserUnfollowed struct {
+	FollowerID string `json:"follower_id"`
+	FolloweeID string `json:"followee_id"`
+}
+
+func (UserCreated) EventType() string    { return EventUserCreated }
+func (TweetCreated) EventType() string   { return EventTweetCreated }
+func (UserFollowed) EventType() string   { return EventUserFollowed }
+func (UserUnfollowed) EventType() string { return EventUserUnfollowed }
+
+// RecordedEvent is an event with the ID and time it was recorded with. The
+// ID is the same every time the event is delivered, so subscribers can use
+// it to ignore repeats.
+type RecordedEvent struct {
+	ID         string
+	OccurredAt time.Time
+	Event      Event
+}
+
+// OutboxEvent is an encoded event waiting in the outbox until it has been
+// dispatched to every subscriber
+type OutboxEvent struct {
+	ID           string          `json:"id"`
+	Type         string          `json:"type"`
+	Payload      json.RawMessage `json:"payload"`
+	OccurredAt   time.Time       `json:"occurred_at"`
+	DispatchedAt *time.Time      `json:"dispatched_at,omitempty"`
+}
+
+// NewOutboxEvent encodes an event that occurred at now. The ID comes from an
+// IDGenerator and now from a Clock, like those of tweets.
+func NewOutboxEvent(id string, event Event, now time.Time) (*OutboxEvent, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("encoding %s event: %w", event.EventType(), err)
+	}
+	return &OutboxEvent{
+		ID:         id,
+		Type:       event.EventType(),
+		Payload:    payload,
+		OccurredAt: now,
+	}, nil
+}
+
+// Dispatched reports whether the event has been handled by every subscriber
+func (e *OutboxEvent) Dispatched() bool {
+	return e.DispatchedAt != nil
+}
+
+// Decode decodes the stored event
+func (e *OutboxEvent) Decode() (RecordedEvent, error) {
+	event, err := DecodeEvent(e.Type, e.Payload)
+	if err != nil {
+		return RecordedEvent{}, err
+	}
+	return RecordedEvent{ID: e.ID, OccurredAt: e.OccurredAt, Event: event}, nil
+}
+
+// DecodeEvent decodes the JSON payload of an event of the given type
+func DecodeEvent(eventType string, payload []byte) (Event, error) {
+	var event Event
+	var err error
+	switch eventType {
+	case EventUserCreated:
+		var e UserCreated
+		err = json.Unmarshal(payload, &e)
+		event = e
+	case EventTweetCreated:
+		var e TweetCreated
+		err = json.Unmarshal(payload, &e)
+		event = e
+	case EventUserFollowed:
+		var e UserFollowed
+		err = json.Unmarshal(payload, &e)
+		event = e
+	case EventUserUnfollowed:
+		var e UserUnfollowed
+		err = json.Unmarshal(payload, &e)
+		event = e
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownEventType, eventType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s event: %w", eventType, err)
+	}
+	return event, nil
+}
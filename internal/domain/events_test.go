@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestOutboxEvent_Decode(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tweet, _ := NewTweet("tweet1", "alice", "Hello", now)
+
+	events := []Event{
+		UserCreated{User: &User{ID: "alice", Name: "Alice"}},
+		TweetCreated{Tweet: tweet},
+		UserFollowed{FollowerID: "bob", FolloweeID: "alice"},
+		UserUnfollowed{FollowerID: "bob", FolloweeID: "alice"},
+	}
+	for i, event := range events {
+		stored, err := NewOutboxEvent(fmt.Sprintf("event%d", i), event, now)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if stored.Type != event.EventType() || stored.Dispatched() {
+			t.Errorf("Expected a pending %s event, got %s", event.EventType(), stored.Type)
+		}
+
+		decoded, err := stored.Decode()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if decoded.ID != stored.ID || !decoded.OccurredAt.Equal(now) || decoded.Event.EventType() != event.EventType() {
+			t.Errorf("Expected %s to round-trip, got %+v", event.EventType(), decoded)
+		}
+	}
+
+	if _, err := DecodeEvent("tweet.deleted", []byte(`{}`)); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Expected %v, got %v", ErrUnknownEventType, err)
+	}
+	if _, err := DecodeEvent(EventUserFollowed, []byte(`[]`)); err == nil {
+		t.Error("Expected a malformed payload to be rejected")
+	}
+}
@@ -1,23 +1,147 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"io"
+	"time"
+)
 
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
+	// GetByIDs returns the users that exist among ids, in no particular order
+	GetByIDs(ctx context.Context, ids []string) ([]*User, error)
+}
+
+// Page selects part of a list, so that backends can limit the query rather
+// than load the whole list
+type Page struct {
+	// Limit is the most items to return
+	Limit int
+	// After is the ID of the last item of the previous page; empty starts
+	// at the beginning of the list
+	After string
 }
 
 // TweetRepository defines the interface for tweet data operations
 type TweetRepository interface {
 	Create(ctx context.Context, tweet *Tweet) error
+	GetByID(ctx context.Context, id string) (*Tweet, error)
 	GetByUserID(ctx context.Context, userID string) ([]*Tweet, error)
 	GetByUserIDs(ctx context.Context, userIDs []string) ([]*Tweet, error)
+	// GetPageByUserIDs returns a page of the tweets by userIDs, newest first
+	// with ties broken by descending ID. It returns ErrPageCursorNotFound
+	// when page.After is not a tweet.
+	GetPageByUserIDs(ctx context.Context, userIDs []string, page Page) ([]*Tweet, error)
 }
 
 // FollowRepository defines the interface for follow relationship operations
 type FollowRepository interface {
-	Follow(ctx context.Context, followerID, followeeID string) error
-	Unfollow(ctx context.Context, followerID, followeeID string) error
+	// Follow adds a follow and reports whether it is new
+	Follow(ctx context.Context, followerID, followeeID string) (bool, error)
+	// Unfollow removes a follow and reports whether there was one
+	Unfollow(ctx context.Context, followerID, followeeID string) (bool, error)
 	GetFollowees(ctx context.Context, followerID string) ([]string, error)
+	// GetFolloweesByFollowerIDs returns who each of followerIDs follows,
+	// keyed by follower
+	GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error)
+	// GetFollowersByFolloweeIDs returns who follows each of followeeIDs,
+	// keyed by followee
+	GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error)
+}
+
+// ScheduledTweetRepository defines the interface for pending scheduled tweets
+type ScheduledTweetRepository interface {
+	Create(ctx context.Context, tweet *ScheduledTweet) error
+	GetByID(ctx context.Context, id string) (*ScheduledTweet, error)
+	GetByUserID(ctx context.Context, userID string) ([]*ScheduledTweet, error)
+	GetDue(ctx context.Context, now time.Time) ([]*ScheduledTweet, error)
+	Update(ctx context.Context, tweet *ScheduledTweet) error
+	Delete(ctx context.Context, id string) error
+}
+
+// PollRepository defines the interface for poll vote operations.
+// Implementations must enforce one vote per user per poll atomically.
+type PollRepository interface {
+	Vote(ctx context.Context, tweetID, userID string, option int) error
+	GetVote(ctx context.Context, tweetID, userID string) (option int, voted bool, err error)
+	GetTallies(ctx context.Context, tweetID string) ([]int, error)
+}
+
+// MediaRepository defines the interface for uploaded media metadata
+type MediaRepository interface {
+	Create(ctx context.Context, media *Media) error
+	GetByID(ctx context.Context, id string) (*Media, error)
+}
+
+// BlobStore stores binary objects under content-addressed keys
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// LinkPreviewRepository caches unfurled link previews by URL
+type LinkPreviewRepository interface {
+	Get(ctx context.Context, url string) (*LinkPreview, error)
+	Save(ctx context.Context, preview *LinkPreview) error
+}
+
+// WebhookRepository stores webhook subscriptions
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *Webhook) error
+	GetByID(ctx context.Context, id string) (*Webhook, error)
+	GetByUserID(ctx context.Context, userID string) ([]*Webhook, error)
+	// GetByEvent returns the webhooks subscribed to eventType
+	GetByEvent(ctx context.Context, eventType string) ([]*Webhook, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// WebhookDeliveryRepository is the queue and log of webhook deliveries.
+// Pending deliveries must survive restarts for the queue to be durable.
+type WebhookDeliveryRepository interface {
+	// Create queues a delivery. It does nothing when the webhook already has
+	// a delivery of the same event, so that an event handled again is not
+	// sent twice.
+	Create(ctx context.Context, delivery *WebhookDelivery) error
+	GetByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	// GetByWebhookID returns up to limit of a webhook's deliveries, newest
+	// first
+	GetByWebhookID(ctx context.Context, webhookID string, limit int) ([]*WebhookDelivery, error)
+	// GetDue returns up to limit pending deliveries whose next attempt is at
+	// or before now, oldest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*WebhookDelivery, error)
+	Update(ctx context.Context, delivery *WebhookDelivery) error
+	DeleteByWebhookID(ctx context.Context, webhookID string) error
+	// DeleteFinishedBefore removes succeeded and dead-lettered deliveries
+	// created before t
+	DeleteFinishedBefore(ctx context.Context, t time.Time) error
+}
+
+// UnitOfWork commits repository writes together with the events they raise
+type UnitOfWork interface {
+	// Do calls fn with a new transaction. When fn returns nil its writes and
+	// recorded events are committed atomically; when it returns an error
+	// nothing is stored.
+	Do(ctx context.Context, fn func(ctx context.Context, tx Transaction) error) error
+}
+
+// Transaction is a unit of work in progress. Its repositories stage writes
+// until the transaction commits.
+type Transaction interface {
+	Users() UserRepository
+	Tweets() TweetRepository
+	Follows() FollowRepository
+	// Record adds events to the outbox when the transaction commits
+	Record(events ...Event)
+}
+
+// OutboxRepository holds the events committed by units of work until they
+// are dispatched to subscribers
+type OutboxRepository interface {
+	// GetPending returns up to limit undispatched events, oldest first
+	GetPending(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	MarkDispatched(ctx context.Context, id string, at time.Time) error
+	// DeleteDispatchedBefore removes events dispatched before t
+	DeleteDispatchedBefore(ctx context.Context, t time.Time) error
 }
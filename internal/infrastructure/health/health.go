@@ -0,0 +1,179 @@
+// Package health keeps a registry of liveness and readiness checks that
+// components register at startup, and runs them to answer probes.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a single check run
+const DefaultTimeout = 2 * time.Second
+
+// Check reports a component's health; a nil error means healthy
+type Check func(ctx context.Context) error
+
+// Check statuses
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// Result is the outcome of one check in a report
+type Result struct {
+	Name    string
+	Status  string
+	Latency time.Duration
+	// Error is the failure from this run, if any
+	Error string
+	// LastError and LastErrorAt describe the most recent failure, which may
+	// predate this run
+	LastError   string
+	LastErrorAt time.Time
+}
+
+// Report aggregates the results of a probe
+type Report struct {
+	Status string
+	Checks []Result
+}
+
+// Healthy reports whether every check passed
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+type registration struct {
+	name      string
+	check     Check
+	liveness  bool
+	lastError string
+	lastAt    time.Time
+}
+
+// Registry holds the registered checks
+type Registry struct {
+	mutex   sync.Mutex
+	checks  []*registration
+	timeout time.Duration
+	now     func() time.Time
+}
+
+// NewRegistry creates an empty registry whose checks time out after timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+// AddLivenessCheck registers a check that fails only when the process is
+// broken beyond recovery and should be restarted. Liveness checks count
+// towards readiness too.
+func (r *Registry) AddLivenessCheck(name string, check Check) {
+	r.add(name, check, true)
+}
+
+// AddReadinessCheck registers a check that fails while the process should
+// not receive traffic, such as while a dependency is unavailable
+func (r *Registry) AddReadinessCheck(name string, check Check) {
+	r.add(name, check, false)
+}
+
+func (r *Registry) add(name string, check Check, liveness bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.checks {
+		if existing.name == name {
+			panic(fmt.Sprintf("health: check %q registered twice", name))
+		}
+	}
+	r.checks = append(r.checks, &registration{name: name, check: check, liveness: liveness})
+}
+
+// Live runs the liveness checks
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, true)
+}
+
+// Ready runs the liveness and readiness checks
+func (r *Registry) Ready(ctx context.Context) Report {
+	return r.run(ctx, false)
+}
+
+// run executes the selected checks concurrently, each bounded by the
+// registry timeout, and returns their results sorted by name
+func (r *Registry) run(ctx context.Context, livenessOnly bool) Report {
+	r.mutex.Lock()
+	var selected []*registration
+	for _, reg := range r.checks {
+		if reg.liveness || !livenessOnly {
+			selected = append(selected, reg)
+		}
+	}
+	r.mutex.Unlock()
+
+	results := make([]Result, len(selected))
+	var wg sync.WaitGroup
+	for i, reg := range selected {
+		wg.Add(1)
+		go func(i int, reg *registration) {
+			defer wg.Done()
+			results[i] = r.runOne(ctx, reg)
+		}(i, reg)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	report := Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+	return report
+}
+
+func (r *Registry) runOne(ctx context.Context, reg *registration) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := r.now()
+	err := runCheck(ctx, reg.check)
+	latency := r.now().Sub(start)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := Result{Name: reg.name, Status: StatusOK, Latency: latency}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+		reg.lastError = err.Error()
+		reg.lastAt = start
+	}
+	result.LastError = reg.lastError
+	result.LastErrorAt = reg.lastAt
+	return result
+}
+
+// runCheck runs check but stops waiting once ctx is done, so a check that
+// ignores its context cannot hang the probe
+func runCheck(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("check timed out: %w", ctx.Err())
+	}
+}
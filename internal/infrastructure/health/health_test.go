@@ -0,0 +1,99 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_LiveAndReady(t *testing.T) {
+	r := NewRegistry(DefaultTimeout)
+	r.AddLivenessCheck("worker", func(context.Context) error { return nil })
+	r.AddReadinessCheck("storage", func(context.Context) error { return errors.New("disk unavailable") })
+
+	live := r.Live(context.Background())
+	if !live.Healthy() {
+		t.Errorf("Expected liveness to pass, got %+v", live)
+	}
+	if len(live.Checks) != 1 || live.Checks[0].Name != "worker" {
+		t.Errorf("Expected only liveness checks, got %+v", live.Checks)
+	}
+
+	ready := r.Ready(context.Background())
+	if ready.Healthy() {
+		t.Error("Expected readiness to fail")
+	}
+	if len(ready.Checks) != 2 {
+		t.Fatalf("Expected liveness and readiness checks, got %d", len(ready.Checks))
+	}
+	storage := ready.Checks[0]
+	if storage.Name != "storage" || storage.Status != StatusFailing || storage.Error != "disk unavailable" {
+		t.Errorf("Expected failing storage check, got %+v", storage)
+	}
+}
+
+func TestRegistry_RemembersLastError(t *testing.T) {
+	r := NewRegistry(DefaultTimeout)
+	failedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return failedAt }
+
+	fail := true
+	r.AddReadinessCheck("flaky", func(context.Context) error {
+		if fail {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	r.Ready(context.Background())
+	fail = false
+	r.now = func() time.Time { return failedAt.Add(time.Minute) }
+
+	report := r.Ready(context.Background())
+	result := report.Checks[0]
+	if result.Status != StatusOK || result.Error != "" {
+		t.Errorf("Expected check to recover, got %+v", result)
+	}
+	if result.LastError != "connection refused" || !result.LastErrorAt.Equal(failedAt) {
+		t.Errorf("Expected last error to be remembered, got %q at %v", result.LastError, result.LastErrorAt)
+	}
+}
+
+func TestRegistry_Timeout(t *testing.T) {
+	r := NewRegistry(20 * time.Millisecond)
+	block := make(chan struct{})
+	defer close(block)
+	r.AddLivenessCheck("stuck", func(context.Context) error {
+		<-block
+		return nil
+	})
+
+	start := time.Now()
+	report := r.Live(context.Background())
+	if time.Since(start) > time.Second {
+		t.Error("Expected the probe not to wait for a stuck check")
+	}
+	if report.Healthy() || !strings.Contains(report.Checks[0].Error, "timed out") {
+		t.Errorf("Expected timed out check to fail, got %+v", report.Checks[0])
+	}
+}
+
+func TestRegistry_EmptyIsHealthy(t *testing.T) {
+	if report := NewRegistry(DefaultTimeout).Ready(context.Background()); !report.Healthy() {
+		t.Errorf("Expected empty registry to be healthy, got %+v", report)
+	}
+}
+
+func TestRegistry_DuplicateNamePanics(t *testing.T) {
+	r := NewRegistry(DefaultTimeout)
+	r.AddLivenessCheck("worker", func(context.Context) error { return nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected duplicate registration to panic")
+		}
+	}()
+	r.AddReadinessCheck("worker", func(context.Context) error { return nil })
+}
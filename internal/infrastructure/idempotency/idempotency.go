@@ -0,0 +1,46 @@
+// Package idempotency stores the outcome of requests made with an
+// Idempotency-Key so retries can be answered without repeating side effects.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultTTL is how long a key and its response are remembered
+const DefaultTTL = 24 * time.Hour
+
+// Response is a recorded HTTP response
+type Response struct {
+	Status int
+	Header map[string][]string
+	Body   []byte
+}
+
+// Record is the state of an idempotency key. A record without a response
+// belongs to a request that is still being processed.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Completed reports whether the original request has finished
+func (r *Record) Completed() bool {
+	return r.Response != nil
+}
+
+// Store keeps idempotency records. Implementations must be safe for
+// concurrent use and must make Begin atomic, so that only one request can
+// claim a key.
+type Store interface {
+	// Begin claims key for a request with the given fingerprint. It returns
+	// nil if the key was free, or the existing record if it is already taken.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete stores the response for a claimed key
+	Complete(ctx context.Context, key string, response Response) error
+	// Release frees a claimed key without storing a response, so the
+	// request can be retried
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryStore_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+
+	record, err := store.Begin(ctx, "user123:key-1", "fp-1", time.Hour)
+	if err != nil || record != nil {
+		t.Fatalf("Expected key to be claimed, got %+v, %v", record, err)
+	}
+
+	// A second request sees the pending record
+	record, _ = store.Begin(ctx, "user123:key-1", "fp-1", time.Hour)
+	if record == nil || record.Completed() {
+		t.Fatalf("Expected a pending record, got %+v", record)
+	}
+
+	response := Response{Status: 201, Header: map[string][]string{"Content-Type": {"application/json"}}, Body: []byte(`{}`)}
+	if err := store.Complete(ctx, "user123:key-1", response); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	record, _ = store.Begin(ctx, "user123:key-1", "fp-2", time.Hour)
+	if record == nil || !record.Completed() {
+		t.Fatalf("Expected a completed record, got %+v", record)
+	}
+	if record.Fingerprint != "fp-1" {
+		t.Errorf("Expected original fingerprint fp-1, got %s", record.Fingerprint)
+	}
+	if record.Response.Status != 201 || string(record.Response.Body) != `{}` {
+		t.Errorf("Unexpected response: %+v", record.Response)
+	}
+
+	// Keys expire after their TTL
+	now = now.Add(time.Hour)
+	if record, _ := store.Begin(ctx, "user123:key-1", "fp-2", time.Hour); record != nil {
+		t.Errorf("Expected expired key to be claimable, got %+v", record)
+	}
+}
+
+func TestMemoryStore_Release(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+
+	store.Begin(ctx, "key", "fp", time.Hour)
+	if err := store.Release(ctx, "key"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if record, _ := store.Begin(ctx, "key", "fp", time.Hour); record != nil {
+		t.Errorf("Expected released key to be claimable, got %+v", record)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+
+	store.Begin(ctx, "old", "fp", time.Minute)
+	now = now.Add(2 * sweepInterval)
+	store.Begin(ctx, "new", "fp", time.Minute)
+
+	if _, exists := store.records["old"]; exists {
+		t.Error("Expected expired record to be swept")
+	}
+	if _, exists := store.records["new"]; !exists {
+		t.Error("Expected live record to be kept")
+	}
+}
@@ -0,0 +1,170 @@
+// Package lifecycle runs the HTTP server and background workers and shuts
+// them down in order: readiness fails first, then in-flight requests drain,
+// then workers stop, then shutdown hooks such as storage flushes run.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options controls how the server is drained
+type Options struct {
+	// ShutdownDelay keeps serving after readiness starts failing, giving
+	// load balancers time to stop routing new traffic here
+	ShutdownDelay time.Duration
+	// ShutdownTimeout bounds connection draining, worker shutdown and hooks
+	// together. It starts once the delay is over.
+	ShutdownTimeout time.Duration
+}
+
+// Lifecycle coordinates startup and ordered shutdown
+type Lifecycle struct {
+	draining atomic.Bool
+
+	workerCtx    context.Context
+	cancelWorker context.CancelFunc
+	workers      sync.WaitGroup
+
+	mutex sync.Mutex
+	hooks []hook
+}
+
+type hook struct {
+	name string
+	fn   func(context.Context) error
+}
+
+// New creates a lifecycle that reports ready until shutdown begins
+func New() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		workerCtx:    ctx,
+		cancelWorker: cancel,
+	}
+}
+
+// Ready reports false once shutdown has begun
+func (l *Lifecycle) Ready() bool {
+	return !l.draining.Load()
+}
+
+// Go starts a background worker. Its context is cancelled after the HTTP
+// server has drained, and shutdown waits for it to return.
+func (l *Lifecycle) Go(name string, run func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		run(l.workerCtx)
+		slog.Debug("worker stopped", "worker", name)
+	}()
+}
+
+// OnShutdown registers fn to run after workers have stopped. Hooks run in
+// reverse registration order, so resources close before those they depend on.
+func (l *Lifecycle) OnShutdown(name string, fn func(context.Context) error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.hooks = append(l.hooks, hook{name: name, fn: fn})
+}
+
+// Run serves HTTP until ctx is cancelled or the server fails, then shuts
+// everything down. It returns the serve error, if any, joined with any
+// shutdown errors.
+func (l *Lifecycle) Run(ctx context.Context, server *http.Server, opts Options) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return errors.Join(err, l.Shutdown(opts.ShutdownTimeout))
+	}
+	return l.Serve(ctx, server, listener, opts)
+}
+
+// Serve is Run on an existing listener
+func (l *Lifecycle) Serve(ctx context.Context, server *http.Server, listener net.Listener, opts Options) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	slog.Info("server started", "addr", listener.Addr().String())
+
+	var errs []error
+	var shutdownCtx context.Context
+	var cancel context.CancelFunc
+	select {
+	case err := <-serveErr:
+		errs = append(errs, err)
+		l.draining.Store(true)
+		shutdownCtx, cancel = context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	case <-ctx.Done():
+		l.draining.Store(true)
+		slog.Info("shutdown started", "delay", opts.ShutdownDelay, "timeout", opts.ShutdownTimeout)
+
+		if opts.ShutdownDelay > 0 {
+			server.SetKeepAlivesEnabled(false)
+			time.Sleep(opts.ShutdownDelay)
+		}
+
+		// Draining, workers and hooks share one deadline, so the process
+		// is done within the timeout however the time is split between them
+		shutdownCtx, cancel = context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("drain connections: %w", err))
+			server.Close()
+		}
+		if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, err)
+		}
+	}
+	defer cancel()
+
+	errs = append(errs, l.shutdown(shutdownCtx))
+	return errors.Join(errs...)
+}
+
+// Shutdown marks the lifecycle as draining, stops workers and runs the
+// shutdown hooks, all within timeout
+func (l *Lifecycle) Shutdown(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.shutdown(ctx)
+}
+
+// shutdown is Shutdown with the deadline in ctx
+func (l *Lifecycle) shutdown(ctx context.Context) error {
+	l.draining.Store(true)
+
+	var errs []error
+
+	l.cancelWorker()
+	stopped := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("stop workers: %w", ctx.Err()))
+	}
+
+	l.mutex.Lock()
+	hooks := l.hooks
+	l.hooks = nil
+	l.mutex.Unlock()
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+
+	slog.Info("shutdown complete")
+	return errors.Join(errs...)
+}
@@ -0,0 +1,216 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	return listener
+}
+
+func TestServe_DrainsInFlightRequestsThenStopsInOrder(t *testing.T) {
+	l := New()
+
+	var mutex sync.Mutex
+	var events []string
+	record := func(event string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		events = append(events, event)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		record("request finished")
+		io.WriteString(w, "done")
+	})}
+
+	l.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		record("worker stopped")
+	})
+	l.OnShutdown("registered first", func(context.Context) error {
+		record("registered first")
+		return nil
+	})
+	l.OnShutdown("registered second", func(context.Context) error {
+		record("registered second")
+		return nil
+	})
+
+	listener := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Serve(ctx, server, listener, Options{ShutdownTimeout: 5 * time.Second})
+	}()
+
+	response := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		response <- string(body)
+	}()
+
+	<-started
+	if !l.Ready() {
+		t.Error("Expected lifecycle to be ready while serving")
+	}
+	cancel()
+
+	// Readiness flips as soon as draining starts
+	deadline := time.Now().Add(time.Second)
+	for l.Ready() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if l.Ready() {
+		t.Error("Expected lifecycle to stop being ready once draining starts")
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if body := <-response; body != "done" {
+		t.Errorf("Expected in-flight request to complete, got %q", body)
+	}
+
+	expected := []string{"request finished", "worker stopped", "registered second", "registered first"}
+	if strings.Join(events, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected shutdown order %v, got %v", expected, events)
+	}
+}
+
+func TestServe_DrainDeadline(t *testing.T) {
+	l := New()
+
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})}
+
+	listener := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Serve(ctx, server, listener, Options{ShutdownTimeout: 50 * time.Millisecond})
+	}()
+
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected shutdown to give up after the deadline")
+	}
+}
+
+func TestServe_DrainAndHooksShareDeadline(t *testing.T) {
+	l := New()
+	const timeout = 500 * time.Millisecond
+
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+	})}
+
+	var hookDeadline time.Time
+	l.OnShutdown("flush", func(ctx context.Context) error {
+		hookDeadline, _ = ctx.Deadline()
+		return nil
+	})
+
+	listener := listen(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- l.Serve(ctx, server, listener, Options{ShutdownTimeout: timeout})
+	}()
+
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+	shutdownStarted := time.Now()
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The hook gets what draining left of the timeout, not a fresh one
+	if latest := shutdownStarted.Add(timeout + 100*time.Millisecond); hookDeadline.IsZero() || hookDeadline.After(latest) {
+		t.Errorf("Expected the hook deadline by %v, got %v", latest, hookDeadline)
+	}
+}
+
+func TestRun_ListenFailureStillShutsDown(t *testing.T) {
+	l := New()
+	occupied := listen(t)
+	defer occupied.Close()
+
+	stopped := make(chan struct{})
+	l.Go("worker", func(ctx context.Context) {
+		<-ctx.Done()
+		close(stopped)
+	})
+
+	err := l.Run(context.Background(), &http.Server{Addr: occupied.Addr().String()}, Options{ShutdownTimeout: time.Second})
+	if err == nil {
+		t.Error("Expected listen error, got nil")
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Error("Expected worker to be stopped")
+	}
+	if l.Ready() {
+		t.Error("Expected lifecycle not to be ready after shutdown")
+	}
+}
+
+func TestShutdown_JoinsHookErrors(t *testing.T) {
+	l := New()
+	failure := errors.New("disk full")
+	l.OnShutdown("flush", func(context.Context) error { return failure })
+
+	ran := false
+	l.OnShutdown("close", func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	err := l.Shutdown(time.Second)
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected %v, got %v", failure, err)
+	}
+	if !ran {
+		t.Error("Expected every hook to run despite earlier failures")
+	}
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+func TestNew_ContextAttributes(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.With("component", "test").DebugContext(ctx, "hello", "user_id", "alice")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected a JSON line, got %q", buf.String())
+	}
+
+	expected := map[string]string{
+		"msg":        "hello",
+		"level":      "DEBUG",
+		"component":  "test",
+		"user_id":    "alice",
+		"request_id": "req-123",
+		"trace_id":   "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":    "00f067aa0ba902b7",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%q, got %v", key, value, entry[key])
+		}
+	}
+}
+
+func TestNew_WithoutContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Options{Format: "text"})
+
+	logger.Info("started")
+
+	line := buf.String()
+	if !strings.Contains(line, "msg=started") {
+		t.Errorf("Expected a text line, got %q", line)
+	}
+	if strings.Contains(line, "request_id") || strings.Contains(line, "trace_id") {
+		t.Errorf("Expected no context attributes, got %q", line)
+	}
+}
+
+func TestNew_Level(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := New(&buf, Options{Level: "warn"})
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(buf.String(), "hidden") || !strings.Contains(buf.String(), "shown") {
+		t.Errorf("Expected only warnings, got %q", buf.String())
+	}
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, Options{Level: "loud"}); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+	if _, err := New(&bytes.Buffer{}, Options{Format: "xml"}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	for name, expected := range tests {
+		if level, err := ParseLevel(name); err != nil || level != expected {
+			t.Errorf("ParseLevel(%q) = %v, %v, expected %v", name, level, err, expected)
+		}
+	}
+}
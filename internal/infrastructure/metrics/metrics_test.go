@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+type failingUserRepository struct{}
+
+func (r failingUserRepository) Create(ctx context.Context, user *domain.User) error {
+	return errors.New("storage unavailable")
+}
+
+func (r failingUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func (r failingUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func TestRepositoryDecorators(t *testing.T) {
+	m := New()
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetRepo := InstrumentTweetRepository(storage.NewTweetRepository(inMemoryStorage), m)
+
+	tweet, _ := domain.NewTweet("tweet1", "user123", "Hello", time.Now())
+	if err := tweetRepo.Create(context.Background(), tweet); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tweets, err := tweetRepo.GetByUserID(context.Background(), "user123")
+	if err != nil || len(tweets) != 1 {
+		t.Fatalf("Expected decorated repository to delegate, got %d tweets, %v", len(tweets), err)
+	}
+
+	userRepo := InstrumentUserRepository(failingUserRepository{}, m)
+	if _, err := userRepo.GetByID(context.Background(), "user123"); err == nil {
+		t.Error("Expected error to be passed through")
+	}
+
+	if count := testutil.CollectAndCount(m.repositoryDuration); count != 3 {
+		t.Errorf("Expected 3 repository series, got %d", count)
+	}
+
+	expected := []string{
+		`uala_repository_operation_duration_seconds_count{operation="create",outcome="ok",repository="tweet"} 1`,
+		`uala_repository_operation_duration_seconds_count{operation="get_by_user_id",outcome="ok",repository="tweet"} 1`,
+		`uala_repository_operation_duration_seconds_count{operation="get_by_id",outcome="error",repository="user"} 1`,
+	}
+	assertExposition(t, m, expected)
+}
+
+func TestServiceDecorators(t *testing.T) {
+	m := New()
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	tweetService := InstrumentTweetService(services.NewTweetService(tweetRepo, storage.NewUserRepository(inMemoryStorage)), m)
+	followService := InstrumentFollowService(services.NewFollowService(storage.NewFollowRepository(inMemoryStorage), tweetRepo), m)
+	ctx := context.Background()
+
+	tweetService.CreateTweet(ctx, services.CreateTweetRequest{UserID: "alice", Content: "Hello"})
+	tweetService.CreateTweet(ctx, services.CreateTweetRequest{UserID: "alice", Content: ""})
+	followService.FollowUser(ctx, services.FollowUserRequest{FollowerID: "bob", FolloweeID: "alice"})
+	followService.FollowUser(ctx, services.FollowUserRequest{FollowerID: "bob", FolloweeID: "bob"})
+	followService.GetTimeline(ctx, "bob")
+	followService.UnfollowUser(ctx, services.FollowUserRequest{FollowerID: "bob", FolloweeID: "alice"})
+
+	if got := testutil.ToFloat64(m.tweetsCreated); got != 1 {
+		t.Errorf("Expected 1 tweet created, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.follows.WithLabelValues("follow")); got != 1 {
+		t.Errorf("Expected 1 follow, got %v", got)
+	}
+	if got := testutil.ToFloat64(m.follows.WithLabelValues("unfollow")); got != 1 {
+		t.Errorf("Expected 1 unfollow, got %v", got)
+	}
+
+	assertExposition(t, m, []string{
+		`uala_timeline_size_bucket{le="1"} 1`,
+		`uala_timeline_size_bucket{le="0"} 0`,
+		`uala_timeline_size_count 1`,
+	})
+}
+
+func TestHTTPMetrics(t *testing.T) {
+	m := New()
+
+	done := m.RequestStarted()
+	if got := testutil.ToFloat64(m.httpInFlight); got != 1 {
+		t.Errorf("Expected 1 request in flight, got %v", got)
+	}
+	m.ObserveRequest("GET", "/api/v1/media/{id}", 200, 25*time.Millisecond)
+	done()
+
+	if got := testutil.ToFloat64(m.httpInFlight); got != 0 {
+		t.Errorf("Expected no requests in flight, got %v", got)
+	}
+	assertExposition(t, m, []string{
+		`uala_http_requests_total{method="GET",route="/api/v1/media/{id}",status="200"} 1`,
+		`uala_http_request_duration_seconds_bucket{method="GET",route="/api/v1/media/{id}",le="0.025"} 1`,
+		`go_goroutines`,
+	})
+}
+
+// assertExposition scrapes the metrics handler and checks for the given lines
+func assertExposition(t *testing.T, m *Metrics, expected []string) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected exposition to contain %q", line)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// The decorators below time every repository call and delegate to the
+// wrapped implementation unchanged.
+
+type userRepository struct {
+	next    domain.UserRepository
+	metrics *Metrics
+}
+
+// InstrumentUserRepository records operation latencies for a user repository
+func InstrumentUserRepository(next domain.UserRepository, m *Metrics) domain.UserRepository {
+	return &userRepository{next: next, metrics: m}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	start := time.Now()
+	err := r.next.Create(ctx, user)
+	r.metrics.observeRepository("user", "create", start, err)
+	return err
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	start := time.Now()
+	user, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("user", "get_by_id", start, err)
+	return user, err
+}
+
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	start := time.Now()
+	users, err := r.next.GetByIDs(ctx, ids)
+	r.metrics.observeRepository("user", "get_by_ids", start, err)
+	return users, err
+}
+
+type tweetRepository struct {
+	next    domain.TweetRepository
+	metrics *Metrics
+}
+
+// InstrumentTweetRepository records operation latencies for a tweet repository
+func InstrumentTweetRepository(next domain.TweetRepository, m *Metrics) domain.TweetRepository {
+	return &tweetRepository{next: next, metrics: m}
+}
+
+func (r *tweetRepository) Create(ctx context.Context, tweet *domain.Tweet) error {
+	start := time.Now()
+	err := r.next.Create(ctx, tweet)
+	r.metrics.observeRepository("tweet", "create", start, err)
+	return err
+}
+
+func (r *tweetRepository) GetByID(ctx context.Context, id string) (*domain.Tweet, error) {
+	start := time.Now()
+	tweet, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("tweet", "get_by_id", start, err)
+	return tweet, err
+}
+
+func (r *tweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
+	start := time.Now()
+	tweets, err := r.next.GetByUserID(ctx, userID)
+	r.metrics.observeRepository("tweet", "get_by_user_id", start, err)
+	return tweets, err
+}
+
+func (r *tweetRepository) GetByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Tweet, error) {
+	start := time.Now()
+	tweets, err := r.next.GetByUserIDs(ctx, userIDs)
+	r.metrics.observeRepository("tweet", "get_by_user_ids", start, err)
+	return tweets, err
+}
+
+func (r *tweetRepository) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	start := time.Now()
+	tweets, err := r.next.GetPageByUserIDs(ctx, userIDs, page)
+	r.metrics.observeRepository("tweet", "get_page_by_user_ids", start, err)
+	return tweets, err
+}
+
+type followRepository struct {
+	next    domain.FollowRepository
+	metrics *Metrics
+}
+
+// InstrumentFollowRepository records operation latencies for a follow repository
+func InstrumentFollowRepository(next domain.FollowRepository, m *Metrics) domain.FollowRepository {
+	return &followRepository{next: next, metrics: m}
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	start := time.Now()
+	followed, err := r.next.Follow(ctx, followerID, followeeID)
+	r.metrics.observeRepository("follow", "follow", start, err)
+	return followed, err
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	start := time.Now()
+	unfollowed, err := r.next.Unfollow(ctx, followerID, followeeID)
+	r.metrics.observeRepository("follow", "unfollow", start, err)
+	return unfollowed, err
+}
+
+func (r *followRepository) GetFollowees(ctx context.Context, followerID string) ([]string, error) {
+	start := time.Now()
+	followees, err := r.next.GetFollowees(ctx, followerID)
+	r.metrics.observeRepository("follow", "get_followees", start, err)
+	return followees, err
+}
+
+func (r *followRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error) {
+	start := time.Now()
+	followees, err := r.next.GetFolloweesByFollowerIDs(ctx, followerIDs)
+	r.metrics.observeRepository("follow", "get_followees_by_follower_ids", start, err)
+	return followees, err
+}
+
+func (r *followRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error) {
+	start := time.Now()
+	followers, err := r.next.GetFollowersByFolloweeIDs(ctx, followeeIDs)
+	r.metrics.observeRepository("follow", "get_followers_by_followee_ids", start, err)
+	return followers, err
+}
+
+type scheduledTweetRepository struct {
+	next    domain.ScheduledTweetRepository
+	metrics *Metrics
+}
+
+// InstrumentScheduledTweetRepository records operation latencies for a
+// scheduled tweet repository
+func InstrumentScheduledTweetRepository(next domain.ScheduledTweetRepository, m *Metrics) domain.ScheduledTweetRepository {
+	return &scheduledTweetRepository{next: next, metrics: m}
+}
+
+func (r *scheduledTweetRepository) Create(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	start := time.Now()
+	err := r.next.Create(ctx, tweet)
+	r.metrics.observeRepository("scheduled_tweet", "create", start, err)
+	return err
+}
+
+func (r *scheduledTweetRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledTweet, error) {
+	start := time.Now()
+	tweet, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("scheduled_tweet", "get_by_id", start, err)
+	return tweet, err
+}
+
+func (r *scheduledTweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	start := time.Now()
+	tweets, err := r.next.GetByUserID(ctx, userID)
+	r.metrics.observeRepository("scheduled_tweet", "get_by_user_id", start, err)
+	return tweets, err
+}
+
+func (r *scheduledTweetRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ScheduledTweet, error) {
+	start := time.Now()
+	tweets, err := r.next.GetDue(ctx, now)
+	r.metrics.observeRepository("scheduled_tweet", "get_due", start, err)
+	return tweets, err
+}
+
+func (r *scheduledTweetRepository) Update(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	start := time.Now()
+	err := r.next.Update(ctx, tweet)
+	r.metrics.observeRepository("scheduled_tweet", "update", start, err)
+	return err
+}
+
+func (r *scheduledTweetRepository) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id)
+	r.metrics.observeRepository("scheduled_tweet", "delete", start, err)
+	return err
+}
+
+type pollRepository struct {
+	next    domain.PollRepository
+	metrics *Metrics
+}
+
+// InstrumentPollRepository records operation latencies for a poll repository
+func InstrumentPollRepository(next domain.PollRepository, m *Metrics) domain.PollRepository {
+	return &pollRepository{next: next, metrics: m}
+}
+
+func (r *pollRepository) Vote(ctx context.Context, tweetID, userID string, option int) error {
+	start := time.Now()
+	err := r.next.Vote(ctx, tweetID, userID, option)
+	r.metrics.observeRepository("poll", "vote", start, err)
+	return err
+}
+
+func (r *pollRepository) GetVote(ctx context.Context, tweetID, userID string) (int, bool, error) {
+	start := time.Now()
+	option, voted, err := r.next.GetVote(ctx, tweetID, userID)
+	r.metrics.observeRepository("poll", "get_vote", start, err)
+	return option, voted, err
+}
+
+func (r *pollRepository) GetTallies(ctx context.Context, tweetID string) ([]int, error) {
+	start := time.Now()
+	tallies, err := r.next.GetTallies(ctx, tweetID)
+	r.metrics.observeRepository("poll", "get_tallies", start, err)
+	return tallies, err
+}
+
+type mediaRepository struct {
+	next    domain.MediaRepository
+	metrics *Metrics
+}
+
+// InstrumentMediaRepository records operation latencies for a media repository
+func InstrumentMediaRepository(next domain.MediaRepository, m *Metrics) domain.MediaRepository {
+	return &mediaRepository{next: next, metrics: m}
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	start := time.Now()
+	err := r.next.Create(ctx, media)
+	r.metrics.observeRepository("media", "create", start, err)
+	return err
+}
+
+func (r *mediaRepository) GetByID(ctx context.Context, id string) (*domain.Media, error) {
+	start := time.Now()
+	media, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("media", "get_by_id", start, err)
+	return media, err
+}
+
+type linkPreviewRepository struct {
+	next    domain.LinkPreviewRepository
+	metrics *Metrics
+}
+
+// InstrumentLinkPreviewRepository records operation latencies for a link
+// preview repository
+func InstrumentLinkPreviewRepository(next domain.LinkPreviewRepository, m *Metrics) domain.LinkPreviewRepository {
+	return &linkPreviewRepository{next: next, metrics: m}
+}
+
+func (r *linkPreviewRepository) Get(ctx context.Context, url string) (*domain.LinkPreview, error) {
+	start := time.Now()
+	preview, err := r.next.Get(ctx, url)
+	r.metrics.observeRepository("link_preview", "get", start, err)
+	return preview, err
+}
+
+func (r *linkPreviewRepository) Save(ctx context.Context, preview *domain.LinkPreview) error {
+	start := time.Now()
+	err := r.next.Save(ctx, preview)
+	r.metrics.observeRepository("link_preview", "save", start, err)
+	return err
+}
+
+type webhookRepository struct {
+	next    domain.WebhookRepository
+	metrics *Metrics
+}
+
+// InstrumentWebhookRepository records operation latencies for a webhook
+// repository
+func InstrumentWebhookRepository(next domain.WebhookRepository, m *Metrics) domain.WebhookRepository {
+	return &webhookRepository{next: next, metrics: m}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	start := time.Now()
+	err := r.next.Create(ctx, webhook)
+	r.metrics.observeRepository("webhook", "create", start, err)
+	return err
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id string) (*domain.Webhook, error) {
+	start := time.Now()
+	webhook, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("webhook", "get_by_id", start, err)
+	return webhook, err
+}
+
+func (r *webhookRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	start := time.Now()
+	webhooks, err := r.next.GetByUserID(ctx, userID)
+	r.metrics.observeRepository("webhook", "get_by_user_id", start, err)
+	return webhooks, err
+}
+
+func (r *webhookRepository) GetByEvent(ctx context.Context, eventType string) ([]*domain.Webhook, error) {
+	start := time.Now()
+	webhooks, err := r.next.GetByEvent(ctx, eventType)
+	r.metrics.observeRepository("webhook", "get_by_event", start, err)
+	return webhooks, err
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id string) error {
+	start := time.Now()
+	err := r.next.Delete(ctx, id)
+	r.metrics.observeRepository("webhook", "delete", start, err)
+	return err
+}
+
+type webhookDeliveryRepository struct {
+	next    domain.WebhookDeliveryRepository
+	metrics *Metrics
+}
+
+// InstrumentWebhookDeliveryRepository records operation latencies for a
+// webhook delivery repository
+func InstrumentWebhookDeliveryRepository(next domain.WebhookDeliveryRepository, m *Metrics) domain.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{next: next, metrics: m}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	start := time.Now()
+	err := r.next.Create(ctx, delivery)
+	r.metrics.observeRepository("webhook_delivery", "create", start, err)
+	return err
+}
+
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	start := time.Now()
+	delivery, err := r.next.GetByID(ctx, id)
+	r.metrics.observeRepository("webhook_delivery", "get_by_id", start, err)
+	return delivery, err
+}
+
+func (r *webhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	start := time.Now()
+	deliveries, err := r.next.GetByWebhookID(ctx, webhookID, limit)
+	r.metrics.observeRepository("webhook_delivery", "get_by_webhook_id", start, err)
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	start := time.Now()
+	deliveries, err := r.next.GetDue(ctx, now, limit)
+	r.metrics.observeRepository("webhook_delivery", "get_due", start, err)
+	return deliveries, err
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	start := time.Now()
+	err := r.next.Update(ctx, delivery)
+	r.metrics.observeRepository("webhook_delivery", "update", start, err)
+	return err
+}
+
+func (r *webhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) error {
+	start := time.Now()
+	err := r.next.DeleteByWebhookID(ctx, webhookID)
+	r.metrics.observeRepository("webhook_delivery", "delete_by_webhook_id", start, err)
+	return err
+}
+
+func (r *webhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) error {
+	start := time.Now()
+	err := r.next.DeleteFinishedBefore(ctx, t)
+	r.metrics.observeRepository("webhook_delivery", "delete_finished_before", start, err)
+	return err
+}
+
+type outboxRepository struct {
+	next    domain.OutboxRepository
+	metrics *Metrics
+}
+
+// InstrumentOutboxRepository records operation latencies for an outbox
+// repository
+func InstrumentOutboxRepository(next domain.OutboxRepository, m *Metrics) domain.OutboxRepository {
+	return &outboxRepository{next: next, metrics: m}
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	start := time.Now()
+	events, err := r.next.GetPending(ctx, limit)
+	r.metrics.observeRepository("outbox", "get_pending", start, err)
+	return events, err
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	start := time.Now()
+	err := r.next.MarkDispatched(ctx, id, at)
+	r.metrics.observeRepository("outbox", "mark_dispatched", start, err)
+	return err
+}
+
+func (r *outboxRepository) DeleteDispatchedBefore(ctx context.Context, t time.Time) error {
+	start := time.Now()
+	err := r.next.DeleteDispatchedBefore(ctx, t)
+	r.metrics.observeRepository("outbox", "delete_dispatched_before", start, err)
+	return err
+}
+
+type unitOfWork struct {
+	next    domain.UnitOfWork
+	metrics *Metrics
+}
+
+// InstrumentUnitOfWork records how long transactions take, and operation
+// latencies for the repositories used within them
+func InstrumentUnitOfWork(next domain.UnitOfWork, m *Metrics) domain.UnitOfWork {
+	return &unitOfWork{next: next, metrics: m}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	start := time.Now()
+	err := u.next.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		return fn(ctx, &transaction{Transaction: tx, metrics: u.metrics})
+	})
+	u.metrics.observeRepository("unit_of_work", "do", start, err)
+	return err
+}
+
+type transaction struct {
+	domain.Transaction
+	metrics *Metrics
+}
+
+func (t *transaction) Users() domain.UserRepository {
+	return InstrumentUserRepository(t.Transaction.Users(), t.metrics)
+}
+
+func (t *transaction) Tweets() domain.TweetRepository {
+	return InstrumentTweetRepository(t.Transaction.Tweets(), t.metrics)
+}
+
+func (t *transaction) Follows() domain.FollowRepository {
+	return InstrumentFollowRepository(t.Transaction.Follows(), t.metrics)
+}
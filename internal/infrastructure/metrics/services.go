@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+)
+
+type tweetService struct {
+	application.TweetServiceInterface
+	metrics *Metrics
+}
+
+// InstrumentTweetService counts tweets created through the service
+func InstrumentTweetService(next application.TweetServiceInterface, m *Metrics) application.TweetServiceInterface {
+	return &tweetService{TweetServiceInterface: next, metrics: m}
+}
+
+func (s *tweetService) CreateTweet(ctx context.Context, req services.CreateTweetRequest) (*domain.Tweet, error) {
+	tweet, err := s.TweetServiceInterface.CreateTweet(ctx, req)
+	if err == nil {
+		s.metrics.tweetsCreated.Inc()
+	}
+	return tweet, err
+}
+
+type followService struct {
+	application.FollowServiceInterface
+	metrics *Metrics
+}
+
+// InstrumentFollowService counts follows and unfollows and records the
+// distribution of timeline sizes
+func InstrumentFollowService(next application.FollowServiceInterface, m *Metrics) application.FollowServiceInterface {
+	return &followService{FollowServiceInterface: next, metrics: m}
+}
+
+func (s *followService) FollowUser(ctx context.Context, req services.FollowUserRequest) error {
+	err := s.FollowServiceInterface.FollowUser(ctx, req)
+	if err == nil {
+		s.metrics.follows.WithLabelValues("follow").Inc()
+	}
+	return err
+}
+
+func (s *followService) UnfollowUser(ctx context.Context, req services.FollowUserRequest) error {
+	err := s.FollowServiceInterface.UnfollowUser(ctx, req)
+	if err == nil {
+		s.metrics.follows.WithLabelValues("unfollow").Inc()
+	}
+	return err
+}
+
+func (s *followService) GetTimeline(ctx context.Context, userID string) ([]*domain.Tweet, error) {
+	tweets, err := s.FollowServiceInterface.GetTimeline(ctx, userID)
+	if err == nil {
+		s.metrics.timelineSize.Observe(float64(len(tweets)))
+	}
+	return tweets, err
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are evicted from a MemoryStore
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Limits are per instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	// full is when the bucket refills completely and can be forgotten
+	full time.Time
+}
+
+// NewMemoryStore creates an empty in-memory bucket store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take consumes a token from the bucket for key, creating a full bucket if needed
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b := s.bucket(key, limit, now)
+	result := b.take(limit, now)
+	b.full = result.Reset
+	return result, nil
+}
+
+// TakeAll consumes a token from each charged bucket if all of them have one
+func (s *MemoryStore) TakeAll(ctx context.Context, charges []Charge) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	buckets := make([]*memoryBucket, len(charges))
+	allowed := true
+	for i, charge := range charges {
+		buckets[i] = s.bucket(charge.Key, charge.Limit, now)
+		buckets[i].refill(charge.Limit, now)
+		allowed = allowed && buckets[i].hasToken()
+	}
+
+	results := make([]Result, len(charges))
+	for i, b := range buckets {
+		if allowed {
+			b.tokens--
+		}
+		results[i] = b.result(charges[i].Limit, now, allowed)
+		b.full = results[i].Reset
+	}
+	return results, nil
+}
+
+// bucket returns the bucket for key, creating a full one if needed.
+// Callers must hold the mutex.
+func (s *MemoryStore) bucket(key string, limit Limit, now time.Time) *memoryBucket {
+	b, exists := s.buckets[key]
+	if !exists {
+		b = &memoryBucket{bucket: bucket{tokens: float64(limit.Burst), updated: now}}
+		s.buckets[key] = b
+	}
+	return b
+}
+
+// sweep drops buckets that have refilled, since a new bucket is equivalent
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestStore(now *time.Time) *MemoryStore {
+	store := NewMemoryStore()
+	store.now = func() time.Time { return *now }
+	return store
+}
+
+func TestMemoryStore_Take(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+	limit := Limit{Burst: 3, Per: 3 * time.Second}
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "user:alice", limit)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !result.Allowed || result.Remaining != i {
+			t.Errorf("Expected allowed with %d remaining, got %+v", i, result)
+		}
+		if result.Limit != 3 {
+			t.Errorf("Expected limit 3, got %d", result.Limit)
+		}
+	}
+
+	result, _ := store.Take(ctx, "user:alice", limit)
+	if result.Allowed {
+		t.Error("Expected request over the burst to be rejected")
+	}
+	if result.RetryAfter != time.Second {
+		t.Errorf("Expected retry after 1s, got %v", result.RetryAfter)
+	}
+	if !result.Reset.Equal(now.Add(3 * time.Second)) {
+		t.Errorf("Expected reset at %v, got %v", now.Add(3*time.Second), result.Reset)
+	}
+
+	// Other keys have their own bucket
+	if result, _ := store.Take(ctx, "user:bob", limit); !result.Allowed {
+		t.Error("Expected a different key to be allowed")
+	}
+
+	// One token refills per second
+	now = now.Add(time.Second)
+	if result, _ := store.Take(ctx, "user:alice", limit); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Expected refilled token to be allowed, got %+v", result)
+	}
+
+	// Refill never exceeds the burst
+	now = now.Add(time.Hour)
+	if result, _ := store.Take(ctx, "user:alice", limit); result.Remaining != 2 {
+		t.Errorf("Expected 2 remaining after a full refill, got %d", result.Remaining)
+	}
+}
+
+func TestMemoryStore_TakeAll(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+	user := Charge{Key: "user:alice", Limit: Limit{Burst: 1, Per: time.Second}}
+	ip := Charge{Key: "ip:192.0.2.1", Limit: Limit{Burst: 3, Per: 3 * time.Second}}
+
+	results, err := store.TakeAll(ctx, []Charge{user, ip})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !results[0].Allowed || !results[1].Allowed || results[0].Remaining != 0 || results[1].Remaining != 2 {
+		t.Errorf("Expected both buckets to be charged, got %+v", results)
+	}
+
+	// The empty user bucket refuses the request, and the IP bucket is not
+	// charged for it
+	results, _ = store.TakeAll(ctx, []Charge{user, ip})
+	if results[0].Allowed || results[1].Allowed {
+		t.Errorf("Expected the request to be refused, got %+v", results)
+	}
+	if results[0].RetryAfter != time.Second || results[1].RetryAfter != 0 {
+		t.Errorf("Expected only the empty bucket to set a retry, got %+v", results)
+	}
+	if results[1].Remaining != 2 {
+		t.Errorf("Expected the IP bucket to keep 2 tokens, got %d", results[1].Remaining)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newTestStore(&now)
+
+	store.Take(ctx, "idle", PerMinute(10))
+	now = now.Add(2 * sweepInterval)
+	store.Take(ctx, "active", PerMinute(10))
+
+	if _, exists := store.buckets["idle"]; exists {
+		t.Error("Expected refilled bucket to be swept")
+	}
+	if _, exists := store.buckets["active"]; !exists {
+		t.Error("Expected active bucket to be kept")
+	}
+}
@@ -0,0 +1,107 @@
+// Package ratelimit implements token-bucket rate limiting behind a pluggable
+// Store, so buckets can live in process memory or in a shared backend.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket: Burst tokens, refilled evenly over Per.
+// A client may make Burst requests at once and Burst requests per Per on average.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// PerMinute returns a limit of n requests per minute
+func PerMinute(n int) Limit {
+	return Limit{Burst: n, Per: time.Minute}
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Burst > 0 && l.Per > 0
+}
+
+// interval is the time it takes to refill a single token
+func (l Limit) interval() time.Duration {
+	return l.Per / time.Duration(l.Burst)
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Limit is the bucket capacity
+	Limit int
+	// Remaining is the number of whole tokens left after this request
+	Remaining int
+	// Reset is when the bucket will be full again
+	Reset time.Time
+	// RetryAfter is how long to wait for the next token when not allowed
+	RetryAfter time.Duration
+}
+
+// Charge is a request's claim on the bucket for Key
+type Charge struct {
+	Key   string
+	Limit Limit
+}
+
+// Store keeps token buckets by key. Implementations must be safe for
+// concurrent use; a shared store makes limits apply across instances.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// TakeAll takes a token from every charged bucket if each of them has
+	// one, and from none otherwise, so that a request one bucket refuses
+	// costs nothing in the others. Keys must be distinct. Results are in
+	// the order of charges.
+	TakeAll(ctx context.Context, charges []Charge) ([]Result, error)
+}
+
+// bucket is the persisted state of a token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket up to now and tries to consume one token
+func (b *bucket) take(limit Limit, now time.Time) Result {
+	b.refill(limit, now)
+	allowed := b.hasToken()
+	if allowed {
+		b.tokens--
+	}
+	return b.result(limit, now, allowed)
+}
+
+// refill adds the tokens that accrued up to now
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()/limit.interval().Seconds())
+	}
+	b.updated = now
+}
+
+// hasToken reports whether a request can be allowed
+func (b *bucket) hasToken() bool {
+	return b.tokens >= 1
+}
+
+// result describes the bucket after a request was allowed or refused.
+// RetryAfter is only set when the bucket itself is out of tokens.
+func (b *bucket) result(limit Limit, now time.Time, allowed bool) Result {
+	result := Result{Allowed: allowed, Limit: limit.Burst}
+	if !b.hasToken() && !allowed {
+		result.RetryAfter = tokenWait(1-b.tokens, limit)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = now.Add(tokenWait(float64(limit.Burst)-b.tokens, limit))
+	return result
+}
+
+// tokenWait is how long it takes to refill the given number of tokens
+func tokenWait(tokens float64, limit Limit) time.Duration {
+	return time.Duration(math.Ceil(tokens * float64(limit.interval())))
+}
@@ -0,0 +1,47 @@
+// Package requestid carries the per-request correlation ID through
+// context.Context so that every layer can include it in responses and logs.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header used to propagate request IDs
+const Header = "X-Request-ID"
+
+// maxLength bounds propagated request IDs so clients cannot bloat logs
+const maxLength = 128
+
+type contextKey struct{}
+
+// New generates a new request ID
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether a client-supplied request ID can be propagated:
+// non-empty, at most 128 characters and printable ASCII without spaces
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying the request ID
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,45 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"3f2b8c1e-0d4a-4c5e-9a7b-1f2e3d4c5b6a", true},
+		{"client-trace_42", true},
+		{"", false},
+		{"has space", false},
+		{"new\nline", false},
+		{"ünïcode", false},
+		{strings.Repeat("a", maxLength), true},
+		{strings.Repeat("a", maxLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.valid {
+			t.Errorf("Valid(%q) = %v, expected %v", tt.id, got, tt.valid)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if id := FromContext(context.Background()); id != "" {
+		t.Errorf("Expected empty request ID, got %q", id)
+	}
+
+	id := New()
+	if !Valid(id) {
+		t.Errorf("Expected generated ID %q to be valid", id)
+	}
+
+	ctx := NewContext(context.Background(), id)
+	if got := FromContext(ctx); got != id {
+		t.Errorf("Expected %q, got %q", id, got)
+	}
+}
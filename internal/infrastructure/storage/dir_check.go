@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkWritableDir verifies that dir exists and accepts new files, which is
+// what the file-backed stores need to keep working
+func checkWritableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".ping-*")
+	if err != nil {
+		return fmt.Errorf("data dir not writable: %w", err)
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
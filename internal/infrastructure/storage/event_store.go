@@ -0,0 +1,726 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+const (
+	eventLogFile      = "events.log"
+	eventSnapshotFile = "events.snapshot.json"
+
+	// DefaultSnapshotInterval is how many log entries are appended between
+	// snapshots of the projections
+	DefaultSnapshotInterval = 1000
+
+	// snapshotVersion changes whenever the projections do, so that older
+	// snapshots are ignored and the projections are rebuilt from the log
+	snapshotVersion = 2
+)
+
+// ErrEventStoreClosed is returned for writes after the event store is closed
+var ErrEventStoreClosed = errors.New("event store closed")
+
+// EventStore is a storage backend where every change to users, tweets,
+// follows, media and poll votes is appended to a log in the data directory. The log is
+// never rewritten. The repositories read from projections of it kept in an
+// InMemoryRepository, which are rebuilt on startup by replaying the log from
+// the latest snapshot. The outbox is kept in the log too, so events recorded
+// in a transaction are committed in the same entry as its changes.
+type EventStore struct {
+	dir              string
+	projections      *InMemoryRepository
+	snapshotInterval int
+	clock            domain.Clock
+	ids              domain.IDGenerator
+
+	// txMutex serializes transactions and the follow writes made outside
+	// them
+	txMutex sync.Mutex
+
+	// mutex serializes appends, so that entries reach the log and the
+	// projections in the same order, and snapshots
+	mutex         sync.Mutex
+	log           *os.File
+	seq           int64
+	offset        int64
+	sinceSnapshot int
+	closed        bool
+}
+
+// EventStoreOption configures optional event store settings
+type EventStoreOption func(*EventStore)
+
+// WithSnapshotInterval overrides how many entries are appended between
+// snapshots
+func WithSnapshotInterval(entries int) EventStoreOption {
+	return func(s *EventStore) {
+		s.snapshotInterval = entries
+	}
+}
+
+// WithEventClock overrides the clock that stamps log entries and outbox
+// events
+func WithEventClock(clock domain.Clock) EventStoreOption {
+	return func(s *EventStore) {
+		s.clock = clock
+	}
+}
+
+// WithEventIDGenerator overrides the generator of outbox event IDs
+func WithEventIDGenerator(ids domain.IDGenerator) EventStoreOption {
+	return func(s *EventStore) {
+		s.ids = ids
+	}
+}
+
+// logEntry is one line of the event log. A commit carries the events that
+// change the projections and the outbox events recorded with them, so that
+// both are replayed or neither is. Media and poll votes are not domain
+// events and have entries of their own, as do the changes to the outbox.
+type logEntry struct {
+	Seq          int64                 `json:"seq"`
+	At           time.Time             `json:"at"`
+	Events       []loggedEvent         `json:"events,omitempty"`
+	Outbox       []*domain.OutboxEvent `json:"outbox,omitempty"`
+	Dispatched   string                `json:"dispatched,omitempty"`
+	PrunedBefore *time.Time            `json:"pruned_before,omitempty"`
+	Media        *loggedMedia          `json:"media,omitempty"`
+	Vote         *loggedVote           `json:"vote,omitempty"`
+}
+
+type loggedEvent struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// loggedMedia is a domain.Media with the blob keys, which its JSON leaves out
+type loggedMedia struct {
+	ID                   string    `json:"id"`
+	UserID               string    `json:"user_id"`
+	ContentType          string    `json:"content_type"`
+	Size                 int       `json:"size"`
+	Width                int       `json:"width"`
+	Height               int       `json:"height"`
+	Key                  string    `json:"key"`
+	ThumbnailKey         string    `json:"thumbnail_key"`
+	ThumbnailContentType string    `json:"thumbnail_content_type"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+func toLoggedMedia(media *domain.Media) *loggedMedia {
+	logged := loggedMedia(*media)
+	return &logged
+}
+
+func (m *loggedMedia) media() *domain.Media {
+	media := domain.Media(*m)
+	return &media
+}
+
+type loggedVote struct {
+	TweetID string `json:"tweet_id"`
+	UserID  string `json:"user_id"`
+	Option  int    `json:"option"`
+}
+
+// eventSnapshot is the state of the projections after the entry numbered
+// Seq, which ends Offset bytes into the log
+type eventSnapshot struct {
+	Version   int                       `json:"version"`
+	Seq       int64                     `json:"seq"`
+	Offset    int64                     `json:"offset"`
+	Users     []*domain.User            `json:"users"`
+	Tweets    []*domain.Tweet           `json:"tweets"`
+	Follows   map[string][]string       `json:"follows"`
+	Outbox    []*domain.OutboxEvent     `json:"outbox"`
+	Media     []*loggedMedia            `json:"media"`
+	PollVotes map[string]map[string]int `json:"poll_votes"` // tweetID -> userID -> option
+}
+
+// NewEventStore opens the event log in dataDir, creating it if needed, and
+// replays it into projections, which must be empty. Other repositories may
+// share projections, but users, tweets, follows, media, poll votes and the
+// outbox must only be written through the event store.
+func NewEventStore(dataDir string, projections *InMemoryRepository, opts ...EventStoreOption) (*EventStore, error) {
+	return openEventStore(dataDir, projections, true, opts...)
+}
+
+// RebuildProjections replays the whole event log in dataDir, ignoring any
+// snapshot, and replaces the snapshot with the result. It returns how many
+// entries were replayed. The server must not be running on dataDir.
+func RebuildProjections(dataDir string) (int64, error) {
+	s, err := openEventStore(dataDir, NewInMemoryRepository(), false)
+	if err != nil {
+		return 0, err
+	}
+	return s.seq, s.Close()
+}
+
+func openEventStore(dataDir string, projections *InMemoryRepository, useSnapshot bool, opts ...EventStoreOption) (*EventStore, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	s := &EventStore{
+		dir:              dataDir,
+		projections:      projections,
+		snapshotInterval: DefaultSnapshotInterval,
+		clock:            domain.SystemClock{},
+		ids:              domain.UUIDv7Generator{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	log, err := os.OpenFile(filepath.Join(dataDir, eventLogFile), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open event log: %w", err)
+	}
+	s.log = log
+
+	if useSnapshot {
+		err = s.loadSnapshot()
+	}
+	if err == nil {
+		err = s.replay()
+	}
+	if err != nil {
+		log.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadSnapshot restores the projections from the snapshot, if there is a
+// usable one. A snapshot that cannot be decoded, such as one cut short by a
+// power loss, is ignored: the log alone rebuilds the projections.
+func (s *EventStore) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, eventSnapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read event snapshot: %w", err)
+	}
+
+	var snapshot eventSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		slog.Warn("ignoring undecodable event snapshot, replaying the whole log", "error", err)
+		return nil
+	}
+	if snapshot.Version != snapshotVersion {
+		slog.Info("ignoring event snapshot from another version, replaying the whole log", "version", snapshot.Version)
+		return nil
+	}
+
+	info, err := s.log.Stat()
+	if err != nil {
+		return fmt.Errorf("stat event log: %w", err)
+	}
+	if snapshot.Offset > info.Size() {
+		return fmt.Errorf("event snapshot at entry %d is ahead of the event log", snapshot.Seq)
+	}
+
+	p := s.projections
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, user := range snapshot.Users {
+		p.users[user.ID] = user
+	}
+	for _, tweet := range snapshot.Tweets {
+		p.tweets[tweet.ID] = tweet
+	}
+	for followerID, followees := range snapshot.Follows {
+		p.follows[followerID] = followees
+	}
+	p.outbox = snapshot.Outbox
+	for _, media := range snapshot.Media {
+		p.media[media.ID] = media.media()
+	}
+	for tweetID, votes := range snapshot.PollVotes {
+		p.pollVotes[tweetID] = votes
+	}
+
+	s.seq, s.offset = snapshot.Seq, snapshot.Offset
+	return nil
+}
+
+// replay applies the entries after the current offset to the projections.
+// An unterminated last line is what a crash mid-append leaves behind, so it
+// is cut off; any other undecodable entry fails the replay.
+func (s *EventStore) replay() error {
+	if _, err := s.log.Seek(s.offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seek event log: %w", err)
+	}
+
+	reader := bufio.NewReader(s.log)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				slog.Warn("truncating partly written event log entry", "seq", s.seq+1, "bytes", len(line))
+				if err := s.log.Truncate(s.offset); err != nil {
+					return fmt.Errorf("truncate event log: %w", err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read event log: %w", err)
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("decode event log entry %d: %w", s.seq+1, err)
+		}
+		if entry.Seq != s.seq+1 {
+			return fmt.Errorf("event log entry %d follows entry %d", entry.Seq, s.seq)
+		}
+		if err := s.applyEntry(&entry); err != nil {
+			return fmt.Errorf("replay event log entry %d: %w", entry.Seq, err)
+		}
+		s.seq = entry.Seq
+		s.offset += int64(len(line))
+	}
+}
+
+// applyEntry applies a decoded log entry to the projections
+func (s *EventStore) applyEntry(entry *logEntry) error {
+	writes := make([]func(*InMemoryRepository), 0, len(entry.Events))
+	for _, logged := range entry.Events {
+		event, err := domain.DecodeEvent(logged.Type, logged.Data)
+		if err != nil {
+			return err
+		}
+		write, err := project(event)
+		if err != nil {
+			return err
+		}
+		writes = append(writes, write)
+	}
+	s.projections.commit(writes, entry.Outbox)
+
+	ctx := context.Background()
+	if entry.Dispatched != "" {
+		s.projections.MarkOutboxEventDispatched(ctx, entry.Dispatched, entry.At)
+	}
+	if entry.PrunedBefore != nil {
+		s.projections.DeleteDispatchedOutboxEvents(ctx, *entry.PrunedBefore)
+	}
+	if entry.Media != nil {
+		if err := s.projections.CreateMedia(ctx, entry.Media.media()); err != nil {
+			return err
+		}
+	}
+	if vote := entry.Vote; vote != nil {
+		if err := s.projections.VotePoll(ctx, vote.TweetID, vote.UserID, vote.Option); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// project returns the write that applies an event to the projections. New
+// read models start here; bumping snapshotVersion makes the next startup
+// build them from the whole log.
+func project(event domain.Event) (func(*InMemoryRepository), error) {
+	switch e := event.(type) {
+	case domain.UserCreated:
+		return func(p *InMemoryRepository) { p.users[e.User.ID] = e.User }, nil
+	case domain.TweetCreated:
+		return func(p *InMemoryRepository) { p.tweets[e.Tweet.ID] = e.Tweet }, nil
+	case domain.UserFollowed:
+		return func(p *InMemoryRepository) { p.followUser(e.FollowerID, e.FolloweeID) }, nil
+	case domain.UserUnfollowed:
+		return func(p *InMemoryRepository) { p.unfollowUser(e.FollowerID, e.FolloweeID) }, nil
+	}
+	return nil, fmt.Errorf("%w: %q has no projection", domain.ErrUnknownEventType, event.EventType())
+}
+
+// commit appends the events and the outbox events recorded with them as one
+// entry, then applies it to the projections
+func (s *EventStore) commit(ctx context.Context, events []domain.Event, outbox []*domain.OutboxEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(events) == 0 && len(outbox) == 0 {
+		return nil
+	}
+
+	entry := &logEntry{At: s.clock.Now(), Outbox: outbox}
+	writes := make([]func(*InMemoryRepository), 0, len(events))
+	for _, event := range events {
+		write, err := project(event)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encoding %s event: %w", event.EventType(), err)
+		}
+		entry.Events = append(entry.Events, loggedEvent{Type: event.EventType(), Data: data})
+		writes = append(writes, write)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.append(entry); err != nil {
+		return err
+	}
+	s.projections.commit(writes, outbox)
+	s.snapshotIfDue()
+	return nil
+}
+
+// record appends an entry that carries no events and applies it
+func (s *EventStore) record(ctx context.Context, entry *logEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.append(entry); err != nil {
+		return err
+	}
+	if err := s.applyEntry(entry); err != nil {
+		return err
+	}
+	s.snapshotIfDue()
+	return nil
+}
+
+// append numbers an entry, writes it to the log and syncs it to disk.
+// Callers must hold the mutex.
+func (s *EventStore) append(entry *logEntry) error {
+	if s.closed {
+		return ErrEventStoreClosed
+	}
+
+	entry.Seq = s.seq + 1
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode event log entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	_, err = s.log.Write(line)
+	if err == nil {
+		err = s.log.Sync()
+	}
+	if err != nil {
+		// Cut off whatever part was written so that the next entry starts
+		// on a line of its own
+		s.log.Truncate(s.offset)
+		return fmt.Errorf("append to event log: %w", err)
+	}
+	s.seq = entry.Seq
+	s.offset += int64(len(line))
+	s.sinceSnapshot++
+	return nil
+}
+
+// snapshotIfDue takes a snapshot once enough entries have been applied since
+// the last one. Callers must hold the mutex.
+func (s *EventStore) snapshotIfDue() {
+	if s.sinceSnapshot < s.snapshotInterval {
+		return
+	}
+	// The log has the entries either way; the next snapshot catches up
+	if err := s.snapshot(); err != nil {
+		slog.Warn("failed to snapshot event store", "seq", s.seq, "error", err)
+	}
+}
+
+// snapshot writes the projections and the log position they reflect.
+// Callers must hold the mutex, which keeps the projections still.
+func (s *EventStore) snapshot() error {
+	p := s.projections
+	p.mutex.RLock()
+	snapshot := eventSnapshot{
+		Version:   snapshotVersion,
+		Seq:       s.seq,
+		Offset:    s.offset,
+		Users:     make([]*domain.User, 0, len(p.users)),
+		Tweets:    make([]*domain.Tweet, 0, len(p.tweets)),
+		Follows:   make(map[string][]string, len(p.follows)),
+		Outbox:    p.outbox,
+		Media:     make([]*loggedMedia, 0, len(p.media)),
+		PollVotes: make(map[string]map[string]int, len(p.pollVotes)),
+	}
+	for _, user := range p.users {
+		snapshot.Users = append(snapshot.Users, user)
+	}
+	for _, tweet := range p.tweets {
+		snapshot.Tweets = append(snapshot.Tweets, tweet)
+	}
+	for followerID, followees := range p.follows {
+		if len(followees) > 0 {
+			snapshot.Follows[followerID] = followees
+		}
+	}
+	for _, media := range p.media {
+		snapshot.Media = append(snapshot.Media, toLoggedMedia(media))
+	}
+	for tweetID, votes := range p.pollVotes {
+		snapshot.PollVotes[tweetID] = maps.Clone(votes)
+	}
+	p.mutex.RUnlock()
+
+	if err := writeJSONFile(filepath.Join(s.dir, eventSnapshotFile), snapshot, "event snapshot"); err != nil {
+		return err
+	}
+	s.sinceSnapshot = 0
+	return nil
+}
+
+// Ping reports whether the log is open and the data directory writable
+func (s *EventStore) Ping(ctx context.Context) error {
+	s.mutex.Lock()
+	closed := s.closed
+	s.mutex.Unlock()
+	if closed {
+		return ErrEventStoreClosed
+	}
+	return checkWritableDir(s.dir)
+}
+
+// Close snapshots the projections, so the next startup replays nothing, and
+// closes the log
+func (s *EventStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	err := s.snapshot()
+	if closeErr := s.log.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("close event log: %w", closeErr)
+	}
+	return err
+}
+
+// Users returns a user repository that appends every write to the log
+func (s *EventStore) Users() domain.UserRepository {
+	return &eventUserRepository{UserRepository: NewUserRepository(s.projections), write: s.commitEvent}
+}
+
+// Tweets returns a tweet repository that appends every write to the log
+func (s *EventStore) Tweets() domain.TweetRepository {
+	return &eventTweetRepository{TweetRepository: NewTweetRepository(s.projections), write: s.commitEvent}
+}
+
+// Follows returns a follow repository that appends every write to the log
+func (s *EventStore) Follows() domain.FollowRepository {
+	return &eventFollowRepository{FollowRepository: NewFollowRepository(s.projections), write: s.commitEvent, lock: &s.txMutex}
+}
+
+// Media returns a media repository that appends every write to the log
+func (s *EventStore) Media() domain.MediaRepository {
+	return &eventMediaRepository{MediaRepository: NewMediaRepository(s.projections), store: s}
+}
+
+// Polls returns a poll repository that appends every vote to the log
+func (s *EventStore) Polls() domain.PollRepository {
+	return &eventPollRepository{PollRepository: NewPollRepository(s.projections), store: s}
+}
+
+// Outbox returns the outbox of the events committed with transactions
+func (s *EventStore) Outbox() domain.OutboxRepository {
+	return &eventOutboxRepository{OutboxRepository: NewOutboxRepository(s.projections), store: s}
+}
+
+// UnitOfWork returns a unit of work whose transactions are appended to the
+// log as single entries. Reads in a transaction see committed data only, and
+// transactions run one at a time so that what one reads stays true until it
+// commits.
+func (s *EventStore) UnitOfWork() domain.UnitOfWork {
+	return &eventUnitOfWork{store: s}
+}
+
+func (s *EventStore) commitEvent(ctx context.Context, event domain.Event) error {
+	return s.commit(ctx, []domain.Event{event}, nil)
+}
+
+// eventWriter takes an event that changes the projections: the event store
+// commits it straight away and a transaction stages it
+type eventWriter func(ctx context.Context, event domain.Event) error
+
+type eventUserRepository struct {
+	*UserRepository
+	write eventWriter
+}
+
+func (r *eventUserRepository) Create(ctx context.Context, user *domain.User) error {
+	return r.write(ctx, domain.UserCreated{User: user})
+}
+
+type eventTweetRepository struct {
+	*TweetRepository
+	write eventWriter
+}
+
+func (r *eventTweetRepository) Create(ctx context.Context, tweet *domain.Tweet) error {
+	return r.write(ctx, domain.TweetCreated{Tweet: tweet})
+}
+
+type eventFollowRepository struct {
+	*FollowRepository
+	write eventWriter
+	// lock is nil in transactions, which hold it already
+	lock sync.Locker
+}
+
+// Follows that change nothing are not logged. Writes outside transactions
+// hold the store's transaction lock, so that the follow they check for
+// cannot change before they commit.
+
+func (r *eventFollowRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if r.lock != nil {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+	}
+
+	if r.storage.IsFollowing(followerID, followeeID) {
+		return false, nil
+	}
+	return true, r.write(ctx, domain.UserFollowed{FollowerID: followerID, FolloweeID: followeeID})
+}
+
+func (r *eventFollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if r.lock != nil {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+	}
+
+	if !r.storage.IsFollowing(followerID, followeeID) {
+		return false, nil
+	}
+	return true, r.write(ctx, domain.UserUnfollowed{FollowerID: followerID, FolloweeID: followeeID})
+}
+
+type eventMediaRepository struct {
+	*MediaRepository
+	store *EventStore
+}
+
+func (r *eventMediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	return r.store.record(ctx, &logEntry{At: r.store.clock.Now(), Media: toLoggedMedia(media)})
+}
+
+type eventPollRepository struct {
+	*PollRepository
+	store *EventStore
+}
+
+// Vote holds the store's transaction lock, so that a second vote by the same
+// user cannot be logged between the check and the first one
+func (r *eventPollRepository) Vote(ctx context.Context, tweetID, userID string, option int) error {
+	r.store.txMutex.Lock()
+	defer r.store.txMutex.Unlock()
+
+	if _, voted, _ := r.storage.GetPollVote(ctx, tweetID, userID); voted {
+		return domain.ErrAlreadyVoted
+	}
+	vote := &loggedVote{TweetID: tweetID, UserID: userID, Option: option}
+	return r.store.record(ctx, &logEntry{At: r.store.clock.Now(), Vote: vote})
+}
+
+type eventOutboxRepository struct {
+	*OutboxRepository
+	store *EventStore
+}
+
+func (r *eventOutboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	return r.store.record(ctx, &logEntry{At: at, Dispatched: id})
+}
+
+func (r *eventOutboxRepository) DeleteDispatchedBefore(ctx context.Context, t time.Time) error {
+	// The relay prunes on every start and hourly; only pruning that removes
+	// something is worth an entry
+	p := r.store.projections
+	p.mutex.RLock()
+	prunable := slices.ContainsFunc(p.outbox, func(event *domain.OutboxEvent) bool {
+		return event.Dispatched() && event.DispatchedAt.Before(t)
+	})
+	p.mutex.RUnlock()
+	if !prunable {
+		return nil
+	}
+	return r.store.record(ctx, &logEntry{At: r.store.clock.Now(), PrunedBefore: &t})
+}
+
+type eventUnitOfWork struct {
+	store *EventStore
+}
+
+func (u *eventUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	u.store.txMutex.Lock()
+	defer u.store.txMutex.Unlock()
+
+	tx := &eventTransaction{store: u.store}
+	err := fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	now := u.store.clock.Now()
+	outbox := make([]*domain.OutboxEvent, 0, len(tx.recorded))
+	for _, event := range tx.recorded {
+		stored, err := domain.NewOutboxEvent(u.store.ids.NewID(), event, now)
+		if err != nil {
+			return err
+		}
+		outbox = append(outbox, stored)
+	}
+
+	return u.store.commit(ctx, tx.changes, outbox)
+}
+
+// eventTransaction stages the events of its writes until the unit of work
+// commits them
+type eventTransaction struct {
+	store    *EventStore
+	changes  []domain.Event
+	recorded []domain.Event
+}
+
+func (t *eventTransaction) stage(ctx context.Context, event domain.Event) error {
+	t.changes = append(t.changes, event)
+	return nil
+}
+
+func (t *eventTransaction) Users() domain.UserRepository {
+	return &eventUserRepository{UserRepository: NewUserRepository(t.store.projections), write: t.stage}
+}
+
+func (t *eventTransaction) Tweets() domain.TweetRepository {
+	return &eventTweetRepository{TweetRepository: NewTweetRepository(t.store.projections), write: t.stage}
+}
+
+func (t *eventTransaction) Follows() domain.FollowRepository {
+	return &eventFollowRepository{FollowRepository: NewFollowRepository(t.store.projections), write: t.stage}
+}
+
+func (t *eventTransaction) Record(events ...domain.Event) {
+	t.recorded = append(t.recorded, events...)
+}
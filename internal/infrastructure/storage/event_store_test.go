@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+	"uala-challenge/internal/infrastructure/storage/storagetest"
+)
+
+// writeHistory commits a user, a tweet with its event, and a follow and
+// unfollow, then dispatches the event: six log entries
+func writeHistory(t *testing.T, store *EventStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if err := store.Users().Create(ctx, &domain.User{ID: "alice", Name: "alice"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tweet := &domain.Tweet{ID: "tweet1", UserID: "alice", Content: "Hello", CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	err := store.UnitOfWork().Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		if err := tx.Tweets().Create(ctx, tweet); err != nil {
+			return err
+		}
+		tx.Record(domain.TweetCreated{Tweet: tweet})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	follows := store.Follows()
+	for _, followeeID := range []string{"bob", "carol"} {
+		if _, err := follows.Follow(ctx, "alice", followeeID); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if _, err := follows.Unfollow(ctx, "alice", "bob"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pending, _ := store.Outbox().GetPending(ctx, 10)
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending event, got %d", len(pending))
+	}
+	if err := store.Outbox().MarkDispatched(ctx, pending[0].ID, time.Now()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
+
+// checkHistory verifies the projections of writeHistory
+func checkHistory(t *testing.T, projections *InMemoryRepository) {
+	t.Helper()
+	ctx := context.Background()
+
+	if user, _ := projections.GetUser(ctx, "alice"); user == nil || user.Name != "alice" {
+		t.Errorf("Expected alice to be projected, got %v", user)
+	}
+	if tweet, _ := projections.GetTweet(ctx, "tweet1"); tweet == nil || tweet.Content != "Hello" {
+		t.Errorf("Expected the tweet to be projected, got %v", tweet)
+	}
+	if followees, _ := projections.GetFollowees(ctx, "alice"); len(followees) != 1 || followees[0] != "carol" {
+		t.Errorf("Expected alice to follow only carol, got %v", followees)
+	}
+	if pending, _ := projections.GetPendingOutboxEvents(ctx, 10); len(pending) != 0 {
+		t.Errorf("Expected the dispatched event not to be pending, got %d", len(pending))
+	}
+}
+
+func TestEventStore_Contract(t *testing.T) {
+	storagetest.RunRepositoryContract(t, func(t *testing.T) storagetest.Repositories {
+		store, err := NewEventStore(t.TempDir(), NewInMemoryRepository())
+		if err != nil {
+			t.Fatalf("Failed to open event store: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return storagetest.Repositories{
+			Users:   store.Users(),
+			Tweets:  store.Tweets(),
+			Follows: store.Follows(),
+			Media:   store.Media(),
+			Polls:   store.Polls(),
+		}
+	})
+}
+
+func TestEventStore_ReplaysLog(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewEventStore(dir, NewInMemoryRepository())
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	writeHistory(t, store)
+	checkHistory(t, store.projections)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Users().Create(context.Background(), &domain.User{ID: "bob"}); !errors.Is(err, ErrEventStoreClosed) {
+		t.Errorf("Expected %v, got %v", ErrEventStoreClosed, err)
+	}
+
+	tests := []struct {
+		name  string
+		setup func()
+	}{
+		{"from the snapshot", func() {}},
+		{"from the whole log", func() {
+			os.Remove(filepath.Join(dir, eventSnapshotFile))
+		}},
+		{"past an empty snapshot", func() {
+			os.WriteFile(filepath.Join(dir, eventSnapshotFile), nil, 0o644)
+		}},
+		{"past a truncated snapshot", func() {
+			path := filepath.Join(dir, eventSnapshotFile)
+			data, _ := os.ReadFile(path)
+			os.WriteFile(path, data[:len(data)/2], 0o644)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setup()
+			projections := NewInMemoryRepository()
+			store, err := NewEventStore(dir, projections)
+			if err != nil {
+				t.Fatalf("Failed to reopen event store: %v", err)
+			}
+			defer store.Close()
+
+			checkHistory(t, projections)
+			if store.seq != 6 {
+				t.Errorf("Expected 6 entries, got %d", store.seq)
+			}
+		})
+	}
+}
+
+func TestEventStore_ReplaysMediaAndVotes(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewEventStore(dir, NewInMemoryRepository())
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	media := &domain.Media{ID: "media1", UserID: "alice", ContentType: "image/png", Key: "blob1", ThumbnailKey: "blob2"}
+	if err := store.Media().Create(ctx, media); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Polls().Vote(ctx, "tweet1", "alice", 1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"from the snapshot", "from the whole log"} {
+		t.Run(name, func(t *testing.T) {
+			projections := NewInMemoryRepository()
+			store, err := NewEventStore(dir, projections)
+			if err != nil {
+				t.Fatalf("Failed to reopen event store: %v", err)
+			}
+			defer store.Close()
+
+			if found, _ := store.Media().GetByID(ctx, "media1"); found == nil || found.Key != "blob1" || found.ThumbnailKey != "blob2" {
+				t.Errorf("Expected the media and its blob keys to be replayed, got %+v", found)
+			}
+			if tallies, _ := store.Polls().GetTallies(ctx, "tweet1"); !slices.Equal(tallies, []int{0, 1}) {
+				t.Errorf("Expected tallies [0 1], got %v", tallies)
+			}
+			if err := store.Polls().Vote(ctx, "tweet1", "alice", 0); !errors.Is(err, domain.ErrAlreadyVoted) {
+				t.Errorf("Expected %v, got %v", domain.ErrAlreadyVoted, err)
+			}
+		})
+		os.Remove(filepath.Join(dir, eventSnapshotFile))
+	}
+}
+
+func TestEventStore_RecoversFromCrash(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewEventStore(dir, NewInMemoryRepository(), WithSnapshotInterval(4))
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	writeHistory(t, store)
+
+	// Crash without closing, partway through appending a seventh entry: the
+	// snapshot is two entries behind and the log ends in a torn line
+	store.log.Close()
+	logPath := filepath.Join(dir, eventLogFile)
+	f, err := os.OpenFile(logPath, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("Failed to open event log: %v", err)
+	}
+	f.WriteString(`{"seq":7,"at":"2024-01-01T12:00:00Z","events":[{"type":"user.cre`)
+	f.Close()
+
+	projections := NewInMemoryRepository()
+	store, err = NewEventStore(dir, projections)
+	if err != nil {
+		t.Fatalf("Failed to reopen event store: %v", err)
+	}
+	checkHistory(t, projections)
+	if store.seq != 6 {
+		t.Errorf("Expected the torn entry to be dropped, got %d entries", store.seq)
+	}
+
+	// Appending carries on from the last complete entry
+	if err := store.Users().Create(ctx, &domain.User{ID: "bob", Name: "bob"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.log.Close()
+	projections = NewInMemoryRepository()
+	store, err = NewEventStore(dir, projections)
+	if err != nil {
+		t.Fatalf("Failed to reopen event store: %v", err)
+	}
+	defer store.Close()
+	if user, _ := projections.GetUser(ctx, "bob"); user == nil {
+		t.Error("Expected the entry after the torn one to be replayed")
+	}
+}
+
+func TestEventStore_RollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewEventStore(t.TempDir(), NewInMemoryRepository())
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	defer store.Close()
+
+	errFailed := errors.New("failed")
+	err = store.UnitOfWork().Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Users().Create(ctx, &domain.User{ID: "alice"})
+		tx.Record(domain.UserCreated{User: &domain.User{ID: "alice"}})
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected %v, got %v", errFailed, err)
+	}
+	if user, _ := store.Users().GetByID(ctx, "alice"); user != nil {
+		t.Error("Expected the user not to be committed")
+	}
+	if store.seq != 0 {
+		t.Errorf("Expected nothing appended, got %d entries", store.seq)
+	}
+}
+
+func TestRebuildProjections(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewEventStore(dir, NewInMemoryRepository())
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	writeHistory(t, store)
+	if err := store.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A snapshot that disagrees with the log is replaced by a full replay
+	snapshotPath := filepath.Join(dir, eventSnapshotFile)
+	stale := eventSnapshot{Version: snapshotVersion, Seq: 6, Offset: store.offset, Users: []*domain.User{{ID: "mallory"}}}
+	if err := writeJSONFile(snapshotPath, stale, "event snapshot"); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+
+	replayed, err := RebuildProjections(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if replayed != 6 {
+		t.Errorf("Expected 6 entries replayed, got %d", replayed)
+	}
+
+	projections := NewInMemoryRepository()
+	store, err = NewEventStore(dir, projections)
+	if err != nil {
+		t.Fatalf("Failed to reopen event store: %v", err)
+	}
+	defer store.Close()
+	checkHistory(t, projections)
+	if user, _ := projections.GetUser(context.Background(), "mallory"); user != nil {
+		t.Error("Expected the stale snapshot to be replaced")
+	}
+}
+
+func TestEventStore_StampsEventsWithClockAndIDs(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	store, err := NewEventStore(t.TempDir(), NewInMemoryRepository(), WithEventClock(clock), WithEventIDGenerator(domaintest.NewSequentialIDs("event")))
+	if err != nil {
+		t.Fatalf("Failed to open event store: %v", err)
+	}
+	defer store.Close()
+	uow, outbox := store.UnitOfWork(), store.Outbox()
+
+	err = uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Record(domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 10)
+	if len(pending) != 1 || pending[0].ID != "event-000001" || !pending[0].OccurredAt.Equal(clock.Now()) {
+		t.Errorf("Expected the event stamped by the clock and ID generator, got %+v", pending)
+	}
+}
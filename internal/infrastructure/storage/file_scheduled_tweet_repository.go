@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+const scheduledTweetsFile = "scheduled_tweets.json"
+
+// FileScheduledTweetRepository implements domain.ScheduledTweetRepository on top
+// of a JSON file so that pending scheduled tweets survive restarts
+type FileScheduledTweetRepository struct {
+	path      string
+	scheduled map[string]*domain.ScheduledTweet
+	mutex     sync.RWMutex
+}
+
+// NewFileScheduledTweetRepository creates a file-backed scheduled tweet repository
+// in dataDir, loading any pending tweets persisted by a previous run
+func NewFileScheduledTweetRepository(dataDir string) (*FileScheduledTweetRepository, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	r := &FileScheduledTweetRepository{
+		path:      filepath.Join(dataDir, scheduledTweetsFile),
+		scheduled: make(map[string]*domain.ScheduledTweet),
+	}
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scheduled tweets: %w", err)
+	}
+
+	var tweets []*domain.ScheduledTweet
+	if err := json.Unmarshal(data, &tweets); err != nil {
+		return nil, fmt.Errorf("decode scheduled tweets: %w", err)
+	}
+	for _, tweet := range tweets {
+		r.scheduled[tweet.ID] = tweet
+	}
+
+	return r, nil
+}
+
+func (r *FileScheduledTweetRepository) Create(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *tweet
+	r.scheduled[tweet.ID] = &stored
+	return r.save()
+}
+
+func (r *FileScheduledTweetRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweet, exists := r.scheduled[id]
+	if !exists {
+		return nil, nil
+	}
+
+	found := *tweet
+	return &found, nil
+}
+
+func (r *FileScheduledTweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweets := []*domain.ScheduledTweet{}
+	for _, tweet := range r.scheduled {
+		if tweet.UserID == userID {
+			found := *tweet
+			tweets = append(tweets, &found)
+		}
+	}
+
+	sortScheduledTweets(tweets)
+	return tweets, nil
+}
+
+func (r *FileScheduledTweetRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweets := []*domain.ScheduledTweet{}
+	for _, tweet := range r.scheduled {
+		if !tweet.PublishAt.After(now) {
+			found := *tweet
+			tweets = append(tweets, &found)
+		}
+	}
+
+	sortScheduledTweets(tweets)
+	return tweets, nil
+}
+
+func (r *FileScheduledTweetRepository) Update(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.scheduled[tweet.ID]; !exists {
+		return domain.ErrScheduledTweetNotFound
+	}
+
+	stored := *tweet
+	r.scheduled[tweet.ID] = &stored
+	return r.save()
+}
+
+func (r *FileScheduledTweetRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.scheduled[id]; !exists {
+		return domain.ErrScheduledTweetNotFound
+	}
+
+	delete(r.scheduled, id)
+	return r.save()
+}
+
+// Ping reports whether the data directory is still writable
+func (r *FileScheduledTweetRepository) Ping(ctx context.Context) error {
+	return checkWritableDir(filepath.Dir(r.path))
+}
+
+// Close writes the pending tweets one last time and syncs the data file to
+// disk, so nothing is lost if the host goes down right after shutdown
+func (r *FileScheduledTweetRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.save(); err != nil {
+		return err
+	}
+
+	f, err := os.Open(r.path)
+	if err != nil {
+		return fmt.Errorf("open scheduled tweets: %w", err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync scheduled tweets: %w", err)
+	}
+	return nil
+}
+
+// save writes all pending tweets to a temporary file and renames it over the
+// data file, so a crash mid-write never leaves a truncated file behind.
+// Callers must hold the write lock.
+func (r *FileScheduledTweetRepository) save() error {
+	tweets := make([]*domain.ScheduledTweet, 0, len(r.scheduled))
+	for _, tweet := range r.scheduled {
+		tweets = append(tweets, tweet)
+	}
+	sortScheduledTweets(tweets)
+
+	data, err := json.MarshalIndent(tweets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode scheduled tweets: %w", err)
+	}
+
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write scheduled tweets: %w", err)
+	}
+
+	return os.Rename(tmp, r.path)
+}
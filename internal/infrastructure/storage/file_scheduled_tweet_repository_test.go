@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestFileScheduledTweetRepository_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileScheduledTweetRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	first, _ := domain.NewScheduledTweet("scheduled1", "user123", "First", now.Add(time.Hour), now)
+	second, _ := domain.NewScheduledTweet("scheduled2", "user123", "Second", now.Add(2*time.Hour), now)
+	cancelled, _ := domain.NewScheduledTweet("scheduled3", "user123", "Cancelled", now.Add(3*time.Hour), now)
+
+	for _, tweet := range []*domain.ScheduledTweet{second, first, cancelled} {
+		if err := repo.Create(ctx, tweet); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := repo.Delete(ctx, cancelled.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Reopen the repository as a restarted process would
+	reopened, err := NewFileScheduledTweetRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+
+	tweets, err := reopened.GetByUserID(ctx, "user123")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(tweets) != 2 {
+		t.Fatalf("Expected 2 pending tweets after restart, got %d", len(tweets))
+	}
+	if tweets[0].ID != first.ID || tweets[1].ID != second.ID {
+		t.Error("Expected pending tweets ordered by publish time")
+	}
+
+	due, err := reopened.GetDue(ctx, now.Add(time.Hour))
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(due) != 1 || due[0].ID != first.ID {
+		t.Errorf("Expected only %s to be due", first.ID)
+	}
+
+	if err := reopened.Update(ctx, cancelled); err != domain.ErrScheduledTweetNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrScheduledTweetNotFound, err)
+	}
+}
+
+func TestFileScheduledTweetRepository_Close(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	repo, err := NewFileScheduledTweetRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	tweet, _ := domain.NewScheduledTweet("scheduled1", "user123", "Pending", now.Add(time.Hour), now)
+	if err := repo.Create(ctx, tweet); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	reopened, err := NewFileScheduledTweetRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	if got, _ := reopened.GetByID(ctx, tweet.ID); got == nil {
+		t.Error("Expected pending tweet to survive Close")
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+const webhookDeliveriesFile = "webhook_deliveries.json"
+
+// FileWebhookDeliveryRepository implements domain.WebhookDeliveryRepository
+// on top of a JSON file so that queued deliveries survive restarts
+type FileWebhookDeliveryRepository struct {
+	path       string
+	deliveries map[string]*domain.WebhookDelivery
+	mutex      sync.RWMutex
+}
+
+// NewFileWebhookDeliveryRepository creates a file-backed webhook delivery
+// repository in dataDir, loading the deliveries persisted by a previous run
+func NewFileWebhookDeliveryRepository(dataDir string) (*FileWebhookDeliveryRepository, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	r := &FileWebhookDeliveryRepository{
+		path:       filepath.Join(dataDir, webhookDeliveriesFile),
+		deliveries: make(map[string]*domain.WebhookDelivery),
+	}
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read webhook deliveries: %w", err)
+	}
+
+	var deliveries []*domain.WebhookDelivery
+	if err := json.Unmarshal(data, &deliveries); err != nil {
+		return nil, fmt.Errorf("decode webhook deliveries: %w", err)
+	}
+	for _, delivery := range deliveries {
+		r.deliveries[delivery.ID] = delivery
+	}
+
+	return r, nil
+}
+
+func (r *FileWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if hasDelivery(r.deliveries, delivery.WebhookID, delivery.EventID) {
+		return nil
+	}
+
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return r.save()
+}
+
+func (r *FileWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	delivery, exists := r.deliveries[id]
+	if !exists {
+		return nil, nil
+	}
+
+	found := *delivery
+	return &found, nil
+}
+
+func (r *FileWebhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return webhookLog(r.deliveries, webhookID, limit), nil
+}
+
+func (r *FileWebhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return dueDeliveries(r.deliveries, now, limit), nil
+}
+
+func (r *FileWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.deliveries[delivery.ID]; !exists {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return r.save()
+}
+
+func (r *FileWebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := deleteDeliveries(r.deliveries, func(delivery *domain.WebhookDelivery) bool {
+		return delivery.WebhookID == webhookID
+	})
+	if !removed {
+		return nil
+	}
+	return r.save()
+}
+
+func (r *FileWebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	removed := deleteDeliveries(r.deliveries, func(delivery *domain.WebhookDelivery) bool {
+		return isFinished(delivery, t)
+	})
+	if !removed {
+		return nil
+	}
+	return r.save()
+}
+
+// Ping reports whether the data directory is still writable
+func (r *FileWebhookDeliveryRepository) Ping(ctx context.Context) error {
+	return checkWritableDir(filepath.Dir(r.path))
+}
+
+// Close writes the deliveries one last time and syncs the data file to disk,
+// so no queued delivery is lost if the host goes down right after shutdown
+func (r *FileWebhookDeliveryRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.save(); err != nil {
+		return err
+	}
+	return syncFile(r.path, "webhook deliveries")
+}
+
+// save writes all deliveries to the data file, oldest first. Callers must
+// hold the write lock.
+func (r *FileWebhookDeliveryRepository) save() error {
+	deliveries := make([]*domain.WebhookDelivery, 0, len(r.deliveries))
+	for _, delivery := range r.deliveries {
+		deliveries = append(deliveries, delivery)
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		if !deliveries[i].CreatedAt.Equal(deliveries[j].CreatedAt) {
+			return deliveries[i].CreatedAt.Before(deliveries[j].CreatedAt)
+		}
+		return deliveries[i].ID < deliveries[j].ID
+	})
+	return writeJSONFile(r.path, deliveries, "webhook deliveries")
+}
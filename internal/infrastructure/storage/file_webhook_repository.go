@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"uala-challenge/internal/domain"
+)
+
+const webhooksFile = "webhooks.json"
+
+// webhookRecord is how a webhook is persisted. Unlike the API
+// representation it includes the signing secret.
+type webhookRecord struct {
+	*domain.Webhook
+	Secret string `json:"secret"`
+}
+
+// FileWebhookRepository implements domain.WebhookRepository on top of a JSON
+// file so that subscriptions survive restarts
+type FileWebhookRepository struct {
+	path     string
+	webhooks map[string]*domain.Webhook
+	mutex    sync.RWMutex
+}
+
+// NewFileWebhookRepository creates a file-backed webhook repository in
+// dataDir, loading the webhooks persisted by a previous run
+func NewFileWebhookRepository(dataDir string) (*FileWebhookRepository, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	r := &FileWebhookRepository{
+		path:     filepath.Join(dataDir, webhooksFile),
+		webhooks: make(map[string]*domain.Webhook),
+	}
+
+	data, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read webhooks: %w", err)
+	}
+
+	var records []webhookRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("decode webhooks: %w", err)
+	}
+	for _, record := range records {
+		webhook := record.Webhook
+		webhook.Secret = record.Secret
+		r.webhooks[webhook.ID] = webhook
+	}
+
+	return r, nil
+}
+
+func (r *FileWebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.webhooks[webhook.ID] = copyWebhook(webhook)
+	return r.save()
+}
+
+func (r *FileWebhookRepository) GetByID(ctx context.Context, id string) (*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, exists := r.webhooks[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return copyWebhook(webhook), nil
+}
+
+func (r *FileWebhookRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return filterWebhooks(r.webhooks, func(webhook *domain.Webhook) bool {
+		return webhook.UserID == userID
+	}), nil
+}
+
+func (r *FileWebhookRepository) GetByEvent(ctx context.Context, eventType string) ([]*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return filterWebhooks(r.webhooks, func(webhook *domain.Webhook) bool {
+		return webhook.Subscribes(eventType)
+	}), nil
+}
+
+func (r *FileWebhookRepository) Delete(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[id]; !exists {
+		return domain.ErrWebhookNotFound
+	}
+
+	delete(r.webhooks, id)
+	return r.save()
+}
+
+// Ping reports whether the data directory is still writable
+func (r *FileWebhookRepository) Ping(ctx context.Context) error {
+	return checkWritableDir(filepath.Dir(r.path))
+}
+
+// Close writes the webhooks one last time and syncs the data file to disk
+func (r *FileWebhookRepository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if err := r.save(); err != nil {
+		return err
+	}
+	return syncFile(r.path, "webhooks")
+}
+
+// save writes all webhooks to the data file. Callers must hold the write
+// lock.
+func (r *FileWebhookRepository) save() error {
+	webhooks := filterWebhooks(r.webhooks, func(*domain.Webhook) bool { return true })
+	records := make([]webhookRecord, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		records = append(records, webhookRecord{Webhook: webhook, Secret: webhook.Secret})
+	}
+	return writeJSONFile(r.path, records, "webhooks")
+}
+
+// writeJSONFile encodes v to a temporary file, flushes it to disk and renames
+// it over path, so neither a crash nor a power loss mid-write leaves a
+// truncated file behind. The encoding is
+// compact so that raw JSON fields, like delivery payloads, are stored byte
+// for byte. what names the contents in errors.
+func writeJSONFile(path string, v interface{}, what string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", what, err)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	// Without the sync, the rename can reach the disk before the data does
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %w", what, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// syncFile flushes path to disk
+func syncFile(path, what string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", what, err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %w", what, err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestFileWebhookRepositories_SurviveRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	webhooks, err := NewFileWebhookRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	deliveries, err := NewFileWebhookDeliveryRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+
+	webhook, _ := domain.NewWebhook("webhook1", "user123", "https://example.com/hook", "0123456789abcdef", []string{domain.EventUserFollowed}, now)
+	if err := webhooks.Create(ctx, webhook); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	event := domain.NewWebhookEvent("event1", domain.EventUserFollowed, nil, now)
+	delivery := domain.NewWebhookDelivery("delivery1", webhook.ID, event, []byte(`{"id":"1"}`), now)
+	if err := deliveries.Create(ctx, delivery); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	delivery.RecordFailure(500, "server error", now, now.Add(time.Minute), 3)
+	if err := deliveries.Update(ctx, delivery); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := deliveries.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Reopen the repositories as a restarted process would
+	webhooks, err = NewFileWebhookRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+	deliveries, err = NewFileWebhookDeliveryRepository(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen repository: %v", err)
+	}
+
+	subscribed, err := webhooks.GetByEvent(ctx, domain.EventUserFollowed)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(subscribed) != 1 || subscribed[0].Secret != webhook.Secret {
+		t.Fatal("Expected the webhook to be restored with its secret")
+	}
+	if other, _ := webhooks.GetByEvent(ctx, domain.EventTweetCreated); len(other) != 0 {
+		t.Error("Expected no webhooks subscribed to other events")
+	}
+
+	if due, _ := deliveries.GetDue(ctx, now, 10); len(due) != 0 {
+		t.Error("Expected the retried delivery not to be due yet")
+	}
+	due, err := deliveries.GetDue(ctx, now.Add(time.Minute), 10)
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(due) != 1 || due[0].Attempts != 1 || string(due[0].Payload) != `{"id":"1"}` {
+		t.Fatal("Expected the queued delivery to be restored")
+	}
+
+	if err := deliveries.DeleteByWebhookID(ctx, webhook.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if found, _ := deliveries.GetByID(ctx, delivery.ID); found != nil {
+		t.Error("Expected the delivery to be deleted with its webhook")
+	}
+	if err := deliveries.Update(ctx, delivery); err != domain.ErrWebhookDeliveryNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookDeliveryNotFound, err)
+	}
+}
@@ -16,14 +16,22 @@ func NewFollowRepository(storage *InMemoryRepository) *FollowRepository {
 	}
 }
 
-func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID string) error {
+func (r *FollowRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
 	return r.storage.FollowUser(ctx, followerID, followeeID)
 }
 
-func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) error {
+func (r *FollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
 	return r.storage.UnfollowUser(ctx, followerID, followeeID)
 }
 
 func (r *FollowRepository) GetFollowees(ctx context.Context, followerID string) ([]string, error) {
 	return r.storage.GetFollowees(ctx, followerID)
 }
+
+func (r *FollowRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error) {
+	return r.storage.GetFolloweesByFollowerIDs(ctx, followerIDs)
+}
+
+func (r *FollowRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error) {
+	return r.storage.GetFollowersByFolloweeIDs(ctx, followeeIDs)
+}
@@ -2,31 +2,53 @@ package storage
 
 import (
 	"context"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	"uala-challenge/internal/domain"
 )
 
-// InMemoryRepository implements all domain repositories using in-memory storage
+// InMemoryRepository implements all domain repositories using in-memory storage.
+// Like a database, user, tweet and follow operations fail once their context
+// is cancelled.
 type InMemoryRepository struct {
-	users   map[string]*domain.User
-	tweets  map[string]*domain.Tweet
-	follows map[string][]string // followerID -> []followeeID
-	mutex   sync.RWMutex
+	users     map[string]*domain.User
+	tweets    map[string]*domain.Tweet
+	follows   map[string][]string // followerID -> []followeeID
+	scheduled map[string]*domain.ScheduledTweet
+	pollVotes map[string]map[string]int // tweetID -> userID -> option
+	media     map[string]*domain.Media
+	previews  map[string]*domain.LinkPreview // URL -> preview
+	webhooks  map[string]*domain.Webhook
+	hookQueue map[string]*domain.WebhookDelivery // deliveries by ID
+	outbox    []*domain.OutboxEvent              // oldest first
+	mutex     sync.RWMutex
 }
 
 // NewInMemoryRepository creates a new in-memory repository
 func NewInMemoryRepository() *InMemoryRepository {
 	return &InMemoryRepository{
-		users:   make(map[string]*domain.User),
-		tweets:  make(map[string]*domain.Tweet),
-		follows: make(map[string][]string),
+		users:     make(map[string]*domain.User),
+		tweets:    make(map[string]*domain.Tweet),
+		follows:   make(map[string][]string),
+		scheduled: make(map[string]*domain.ScheduledTweet),
+		pollVotes: make(map[string]map[string]int),
+		media:     make(map[string]*domain.Media),
+		previews:  make(map[string]*domain.LinkPreview),
+		webhooks:  make(map[string]*domain.Webhook),
+		hookQueue: make(map[string]*domain.WebhookDelivery),
 	}
 }
 
 // User Repository Implementation
 
 func (r *InMemoryRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
@@ -35,6 +57,10 @@ func (r *InMemoryRepository) CreateUser(ctx context.Context, user *domain.User)
 }
 
 func (r *InMemoryRepository) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
@@ -46,9 +72,31 @@ func (r *InMemoryRepository) GetUser(ctx context.Context, id string) (*domain.Us
 	return user, nil
 }
 
+func (r *InMemoryRepository) GetUsers(ctx context.Context, ids []string) ([]*domain.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := make([]*domain.User, 0, len(ids))
+	for _, id := range ids {
+		if user, exists := r.users[id]; exists {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
 // Tweet Repository Implementation
 
 func (r *InMemoryRepository) CreateTweet(ctx context.Context, tweet *domain.Tweet) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
@@ -56,7 +104,27 @@ func (r *InMemoryRepository) CreateTweet(ctx context.Context, tweet *domain.Twee
 	return nil
 }
 
+func (r *InMemoryRepository) GetTweet(ctx context.Context, id string) (*domain.Tweet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweet, exists := r.tweets[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return tweet, nil
+}
+
 func (r *InMemoryRepository) GetTweetsByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
@@ -71,6 +139,10 @@ func (r *InMemoryRepository) GetTweetsByUserID(ctx context.Context, userID strin
 }
 
 func (r *InMemoryRepository) GetTweetsByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Tweet, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
@@ -89,40 +161,104 @@ func (r *InMemoryRepository) GetTweetsByUserIDs(ctx context.Context, userIDs []s
 	return tweets, nil
 }
 
+func (r *InMemoryRepository) GetTweetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	tweets, err := r.GetTweetsByUserIDs(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(tweets, func(i, j int) bool {
+		return tweets[i].NewerThan(tweets[j])
+	})
+
+	start := 0
+	if page.After != "" {
+		r.mutex.RLock()
+		cursor, exists := r.tweets[page.After]
+		r.mutex.RUnlock()
+		if !exists {
+			return nil, domain.ErrPageCursorNotFound
+		}
+		start = sort.Search(len(tweets), func(i int) bool {
+			return cursor.NewerThan(tweets[i])
+		})
+	}
+
+	end := min(start+max(page.Limit, 0), len(tweets))
+	return append(make([]*domain.Tweet, 0, end-start), tweets[start:end]...), nil
+}
+
 // Follow Repository Implementation
 
-func (r *InMemoryRepository) FollowUser(ctx context.Context, followerID, followeeID string) error {
+func (r *InMemoryRepository) FollowUser(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
+
+	return r.followUser(followerID, followeeID), nil
+}
+
+// followUser adds a follow unless it exists and reports whether it did; the
+// caller holds the lock
+func (r *InMemoryRepository) followUser(followerID, followeeID string) bool {
+	if r.isFollowing(followerID, followeeID) {
+		return false
+	}
 	
-	// Check if already following
+	r.follows[followerID] = append(r.follows[followerID], followeeID)
+	return true
+}
+
+// isFollowing reports whether a follow exists; the caller holds the lock
+func (r *InMemoryRepository) isFollowing(followerID, followeeID string) bool {
 	for _, existingFollowee := range r.follows[followerID] {
 		if existingFollowee == followeeID {
-			return nil // Already following
+			return true
 		}
 	}
-	
-	r.follows[followerID] = append(r.follows[followerID], followeeID)
-	return nil
+	return false
 }
 
-func (r *InMemoryRepository) UnfollowUser(ctx context.Context, followerID, followeeID string) error {
+func (r *InMemoryRepository) UnfollowUser(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	
+
+	return r.unfollowUser(followerID, followeeID), nil
+}
+
+// unfollowUser removes a follow if it exists and reports whether it did; the
+// caller holds the lock
+func (r *InMemoryRepository) unfollowUser(followerID, followeeID string) bool {
 	followees := r.follows[followerID]
 	for i, followee := range followees {
 		if followee == followeeID {
 			// Remove the followee
 			r.follows[followerID] = append(followees[:i], followees[i+1:]...)
-			break
+			return true
 		}
 	}
-	
-	return nil
+	return false
+}
+
+// IsFollowing reports whether followerID follows followeeID
+func (r *InMemoryRepository) IsFollowing(followerID, followeeID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.isFollowing(followerID, followeeID)
 }
 
 func (r *InMemoryRepository) GetFollowees(ctx context.Context, followerID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
@@ -133,3 +269,426 @@ func (r *InMemoryRepository) GetFollowees(ctx context.Context, followerID string
 	
 	return followees, nil
 }
+
+func (r *InMemoryRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	followees := make(map[string][]string, len(followerIDs))
+	for _, followerID := range followerIDs {
+		followees[followerID] = append([]string{}, r.follows[followerID]...)
+	}
+
+	return followees, nil
+}
+
+func (r *InMemoryRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	followers := make(map[string][]string, len(followeeIDs))
+	for _, followeeID := range followeeIDs {
+		followers[followeeID] = []string{}
+	}
+	for followerID, followees := range r.follows {
+		for _, followeeID := range followees {
+			if _, wanted := followers[followeeID]; wanted {
+				followers[followeeID] = append(followers[followeeID], followerID)
+			}
+		}
+	}
+	for _, ids := range followers {
+		sort.Strings(ids)
+	}
+
+	return followers, nil
+}
+
+// Poll Repository Implementation
+
+func (r *InMemoryRepository) VotePoll(ctx context.Context, tweetID, userID string, option int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	votes, exists := r.pollVotes[tweetID]
+	if !exists {
+		votes = make(map[string]int)
+		r.pollVotes[tweetID] = votes
+	}
+
+	if _, voted := votes[userID]; voted {
+		return domain.ErrAlreadyVoted
+	}
+
+	votes[userID] = option
+	return nil
+}
+
+func (r *InMemoryRepository) GetPollVote(ctx context.Context, tweetID, userID string) (int, bool, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	option, voted := r.pollVotes[tweetID][userID]
+	return option, voted, nil
+}
+
+func (r *InMemoryRepository) GetPollTallies(ctx context.Context, tweetID string) ([]int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tallies := []int{}
+	for _, option := range r.pollVotes[tweetID] {
+		for len(tallies) <= option {
+			tallies = append(tallies, 0)
+		}
+		tallies[option]++
+	}
+
+	return tallies, nil
+}
+
+// Media Repository Implementation
+
+func (r *InMemoryRepository) CreateMedia(ctx context.Context, media *domain.Media) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.media[media.ID] = media
+	return nil
+}
+
+func (r *InMemoryRepository) GetMedia(ctx context.Context, id string) (*domain.Media, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	media, exists := r.media[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return media, nil
+}
+
+// Link Preview Repository Implementation
+
+// maxLinkPreviews bounds the cached previews, so that tweets full of unique
+// URLs cannot grow memory without limit
+const maxLinkPreviews = 10000
+
+func (r *InMemoryRepository) GetLinkPreview(ctx context.Context, url string) (*domain.LinkPreview, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	preview, exists := r.previews[url]
+	if !exists {
+		return nil, nil
+	}
+
+	return preview, nil
+}
+
+func (r *InMemoryRepository) SaveLinkPreview(ctx context.Context, preview *domain.LinkPreview) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.previews[preview.URL]; !exists && len(r.previews) >= maxLinkPreviews {
+		r.evictOldestLinkPreview()
+	}
+	r.previews[preview.URL] = preview
+	return nil
+}
+
+// evictOldestLinkPreview drops the preview fetched longest ago. Callers must
+// hold the mutex.
+func (r *InMemoryRepository) evictOldestLinkPreview() {
+	var oldest *domain.LinkPreview
+	for _, preview := range r.previews {
+		if oldest == nil || preview.FetchedAt.Before(oldest.FetchedAt) {
+			oldest = preview
+		}
+	}
+	if oldest != nil {
+		delete(r.previews, oldest.URL)
+	}
+}
+
+// Scheduled Tweet Repository Implementation
+
+func (r *InMemoryRepository) CreateScheduledTweet(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *tweet
+	r.scheduled[tweet.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryRepository) GetScheduledTweet(ctx context.Context, id string) (*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweet, exists := r.scheduled[id]
+	if !exists {
+		return nil, nil
+	}
+
+	found := *tweet
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetScheduledTweetsByUserID(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweets := []*domain.ScheduledTweet{}
+	for _, tweet := range r.scheduled {
+		if tweet.UserID == userID {
+			found := *tweet
+			tweets = append(tweets, &found)
+		}
+	}
+
+	sortScheduledTweets(tweets)
+	return tweets, nil
+}
+
+func (r *InMemoryRepository) GetDueScheduledTweets(ctx context.Context, now time.Time) ([]*domain.ScheduledTweet, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	tweets := []*domain.ScheduledTweet{}
+	for _, tweet := range r.scheduled {
+		if !tweet.PublishAt.After(now) {
+			found := *tweet
+			tweets = append(tweets, &found)
+		}
+	}
+
+	sortScheduledTweets(tweets)
+	return tweets, nil
+}
+
+func (r *InMemoryRepository) UpdateScheduledTweet(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.scheduled[tweet.ID]; !exists {
+		return domain.ErrScheduledTweetNotFound
+	}
+
+	stored := *tweet
+	r.scheduled[tweet.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryRepository) DeleteScheduledTweet(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.scheduled[id]; !exists {
+		return domain.ErrScheduledTweetNotFound
+	}
+
+	delete(r.scheduled, id)
+	return nil
+}
+
+// sortScheduledTweets orders scheduled tweets by publish time (soonest first)
+func sortScheduledTweets(tweets []*domain.ScheduledTweet) {
+	sort.Slice(tweets, func(i, j int) bool {
+		return tweets[i].PublishAt.Before(tweets[j].PublishAt)
+	})
+}
+
+// Webhook Repository Implementation
+
+func (r *InMemoryRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.webhooks[webhook.ID] = copyWebhook(webhook)
+	return nil
+}
+
+func (r *InMemoryRepository) GetWebhook(ctx context.Context, id string) (*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, exists := r.webhooks[id]
+	if !exists {
+		return nil, nil
+	}
+
+	return copyWebhook(webhook), nil
+}
+
+func (r *InMemoryRepository) GetWebhooksByUserID(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return filterWebhooks(r.webhooks, func(webhook *domain.Webhook) bool {
+		return webhook.UserID == userID
+	}), nil
+}
+
+func (r *InMemoryRepository) GetWebhooksByEvent(ctx context.Context, eventType string) ([]*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return filterWebhooks(r.webhooks, func(webhook *domain.Webhook) bool {
+		return webhook.Subscribes(eventType)
+	}), nil
+}
+
+func (r *InMemoryRepository) DeleteWebhook(ctx context.Context, id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.webhooks[id]; !exists {
+		return domain.ErrWebhookNotFound
+	}
+
+	delete(r.webhooks, id)
+	return nil
+}
+
+// Webhook Delivery Repository Implementation
+
+func (r *InMemoryRepository) CreateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if hasDelivery(r.hookQueue, delivery.WebhookID, delivery.EventID) {
+		return nil
+	}
+
+	stored := *delivery
+	r.hookQueue[delivery.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryRepository) GetWebhookDelivery(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	delivery, exists := r.hookQueue[id]
+	if !exists {
+		return nil, nil
+	}
+
+	found := *delivery
+	return &found, nil
+}
+
+func (r *InMemoryRepository) GetWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return webhookLog(r.hookQueue, webhookID, limit), nil
+}
+
+func (r *InMemoryRepository) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return dueDeliveries(r.hookQueue, now, limit), nil
+}
+
+func (r *InMemoryRepository) UpdateWebhookDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.hookQueue[delivery.ID]; !exists {
+		return domain.ErrWebhookDeliveryNotFound
+	}
+
+	stored := *delivery
+	r.hookQueue[delivery.ID] = &stored
+	return nil
+}
+
+func (r *InMemoryRepository) DeleteWebhookDeliveriesByWebhookID(ctx context.Context, webhookID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleteDeliveries(r.hookQueue, func(delivery *domain.WebhookDelivery) bool {
+		return delivery.WebhookID == webhookID
+	})
+	return nil
+}
+
+func (r *InMemoryRepository) DeleteFinishedWebhookDeliveries(ctx context.Context, before time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleteDeliveries(r.hookQueue, func(delivery *domain.WebhookDelivery) bool {
+		return isFinished(delivery, before)
+	})
+	return nil
+}
+
+// Outbox Implementation
+
+// commit applies a transaction's staged writes and adds its events to the
+// outbox under a single lock, so that both are visible together or not at
+// all
+func (r *InMemoryRepository) commit(writes []func(*InMemoryRepository), events []*domain.OutboxEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, write := range writes {
+		write(r)
+	}
+	for _, event := range events {
+		stored := *event
+		r.outbox = append(r.outbox, &stored)
+	}
+}
+
+func (r *InMemoryRepository) GetPendingOutboxEvents(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var pending []*domain.OutboxEvent
+	for _, event := range r.outbox {
+		if len(pending) == limit {
+			break
+		}
+		if !event.Dispatched() {
+			stored := *event
+			pending = append(pending, &stored)
+		}
+	}
+	return pending, nil
+}
+
+func (r *InMemoryRepository) MarkOutboxEventDispatched(ctx context.Context, id string, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, event := range r.outbox {
+		if event.ID == id {
+			event.DispatchedAt = &at
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) DeleteDispatchedOutboxEvents(ctx context.Context, before time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.outbox = slices.DeleteFunc(r.outbox, func(event *domain.OutboxEvent) bool {
+		return event.Dispatched() && event.DispatchedAt.Before(before)
+	})
+	return nil
+}
@@ -2,17 +2,34 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage/storagetest"
 )
 
+func TestInMemoryRepository_Contract(t *testing.T) {
+	storagetest.RunRepositoryContract(t, func(t *testing.T) storagetest.Repositories {
+		repo := NewInMemoryRepository()
+		return storagetest.Repositories{
+			Users:   NewUserRepository(repo),
+			Tweets:  NewTweetRepository(repo),
+			Follows: NewFollowRepository(repo),
+			Media:   NewMediaRepository(repo),
+			Polls:   NewPollRepository(repo),
+		}
+	})
+}
+
 func TestInMemoryRepository_UserOperations(t *testing.T) {
 	repo := NewInMemoryRepository()
 	ctx := context.Background()
 
 	// Test create user
-	user := domain.NewUser("John Doe")
+	user := domain.NewUser("user1", "John Doe")
 	err := repo.CreateUser(ctx, user)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
@@ -45,7 +62,7 @@ func TestInMemoryRepository_TweetOperations(t *testing.T) {
 	ctx := context.Background()
 
 	// Test create tweet
-	tweet, err := domain.NewTweet("user123", "Hello, world!")
+	tweet, err := domain.NewTweet("tweet1", "user123", "Hello, world!", time.Now())
 	if err != nil {
 		t.Fatalf("Failed to create tweet: %v", err)
 	}
@@ -85,9 +102,9 @@ func TestInMemoryRepository_FollowOperations(t *testing.T) {
 	followeeID := "user2"
 
 	// Test follow
-	err := repo.FollowUser(ctx, followerID, followeeID)
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	followed, err := repo.FollowUser(ctx, followerID, followeeID)
+	if err != nil || !followed {
+		t.Errorf("Expected a new follow, got %v, %v", followed, err)
 	}
 
 	// Test get followees
@@ -103,15 +120,15 @@ func TestInMemoryRepository_FollowOperations(t *testing.T) {
 	}
 
 	// Test duplicate follow (should not error)
-	err = repo.FollowUser(ctx, followerID, followeeID)
-	if err != nil {
-		t.Errorf("Expected no error on duplicate follow, got %v", err)
+	followed, err = repo.FollowUser(ctx, followerID, followeeID)
+	if err != nil || followed {
+		t.Errorf("Expected no error and no new follow on duplicate follow, got %v, %v", followed, err)
 	}
 
 	// Test unfollow
-	err = repo.UnfollowUser(ctx, followerID, followeeID)
-	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+	unfollowed, err := repo.UnfollowUser(ctx, followerID, followeeID)
+	if err != nil || !unfollowed {
+		t.Errorf("Expected the follow to be removed, got %v, %v", unfollowed, err)
 	}
 
 	// Test get followees after unfollow
@@ -124,30 +141,200 @@ func TestInMemoryRepository_FollowOperations(t *testing.T) {
 	}
 }
 
+func TestInMemoryRepository_BatchLookups(t *testing.T) {
+	repo := NewInMemoryRepository()
+	ctx := context.Background()
+
+	repo.CreateUser(ctx, &domain.User{ID: "alice", Name: "Alice"})
+	repo.CreateUser(ctx, &domain.User{ID: "bob", Name: "Bob"})
+	repo.FollowUser(ctx, "alice", "bob")
+	repo.FollowUser(ctx, "carol", "bob")
+	repo.FollowUser(ctx, "bob", "alice")
+
+	users, err := repo.GetUsers(ctx, []string{"alice", "bob", "nobody"})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(users) != 2 {
+		t.Errorf("Expected 2 users, got %d", len(users))
+	}
+
+	followees, err := repo.GetFolloweesByFollowerIDs(ctx, []string{"alice", "dave"})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(followees["alice"]) != 1 || followees["alice"][0] != "bob" {
+		t.Errorf("Expected alice to follow bob, got %v", followees["alice"])
+	}
+	if followees["dave"] == nil || len(followees["dave"]) != 0 {
+		t.Errorf("Expected an empty list for dave, got %v", followees["dave"])
+	}
+
+	followers, err := repo.GetFollowersByFolloweeIDs(ctx, []string{"bob", "alice"})
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(followers["bob"]) != 2 || followers["bob"][0] != "alice" || followers["bob"][1] != "carol" {
+		t.Errorf("Expected bob to be followed by alice and carol, got %v", followers["bob"])
+	}
+	if len(followers["alice"]) != 1 {
+		t.Errorf("Expected alice to have 1 follower, got %v", followers["alice"])
+	}
+}
+
 func TestInMemoryRepository_Concurrency(t *testing.T) {
 	repo := NewInMemoryRepository()
 	ctx := context.Background()
 
-	// Test concurrent operations
-	done := make(chan bool, 10)
-	
-	for i := 0; i < 10; i++ {
+	// Create users and tweets from concurrent goroutines
+	const writers = 10
+	userIDs := make(chan string, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
 		go func(i int) {
-			user := domain.NewUser("User" + string(rune(i)))
+			defer wg.Done()
+			user := domain.NewUser(fmt.Sprintf("user%d", i), fmt.Sprintf("User%d", i))
 			repo.CreateUser(ctx, user)
-			
-			tweet, _ := domain.NewTweet(user.ID, "Tweet from user")
+
+			tweet, _ := domain.NewTweet(fmt.Sprintf("tweet%d", i), user.ID, "Tweet from user", time.Now())
 			repo.CreateTweet(ctx, tweet)
-			
-			done <- true
+			repo.FollowUser(ctx, user.ID, "hub")
+
+			userIDs <- user.ID
 		}(i)
 	}
+	wg.Wait()
+	close(userIDs)
+
+	// Verify no write was lost
+	var ids []string
+	for id := range userIDs {
+		ids = append(ids, id)
+	}
+	if users, _ := repo.GetUsers(ctx, ids); len(users) != writers {
+		t.Errorf("Expected %d users, got %d", writers, len(users))
+	}
+	if tweets, _ := repo.GetTweetsByUserIDs(ctx, ids); len(tweets) != writers {
+		t.Errorf("Expected %d tweets, got %d", writers, len(tweets))
+	}
+	if followers, _ := repo.GetFollowersByFolloweeIDs(ctx, []string{"hub"}); len(followers["hub"]) != writers {
+		t.Errorf("Expected %d followers, got %d", writers, len(followers["hub"]))
+	}
+}
+
+func TestInMemoryRepository_PollVotes(t *testing.T) {
+	repo := NewInMemoryRepository()
+	ctx := context.Background()
+
+	if err := repo.VotePoll(ctx, "tweet1", "user1", 0); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if err := repo.VotePoll(ctx, "tweet1", "user2", 2); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	// One vote per user per poll
+	if err := repo.VotePoll(ctx, "tweet1", "user1", 1); err != domain.ErrAlreadyVoted {
+		t.Errorf("Expected %v, got %v", domain.ErrAlreadyVoted, err)
+	}
+
+	option, voted, err := repo.GetPollVote(ctx, "tweet1", "user2")
+	if err != nil || !voted || option != 2 {
+		t.Errorf("Expected user2 to have voted for option 2, got %d (voted=%v, err=%v)", option, voted, err)
+	}
+
+	_, voted, _ = repo.GetPollVote(ctx, "tweet1", "user3")
+	if voted {
+		t.Error("Expected user3 not to have voted")
+	}
+
+	tallies, err := repo.GetPollTallies(ctx, "tweet1")
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	if len(tallies) != 3 || tallies[0] != 1 || tallies[1] != 0 || tallies[2] != 1 {
+		t.Errorf("Expected tallies [1 0 1], got %v", tallies)
+	}
+}
+
+func TestInMemoryRepository_LinkPreviewsAreBounded(t *testing.T) {
+	ctx := context.Background()
+	repo := NewInMemoryRepository()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= maxLinkPreviews; i++ {
+		preview := &domain.LinkPreview{URL: fmt.Sprintf("https://example.com/%d", i), FetchedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := repo.SaveLinkPreview(ctx, preview); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if len(repo.previews) != maxLinkPreviews {
+		t.Errorf("Expected at most %d previews, got %d", maxLinkPreviews, len(repo.previews))
+	}
+	if preview, _ := repo.GetLinkPreview(ctx, "https://example.com/0"); preview != nil {
+		t.Error("Expected the oldest preview to be evicted")
+	}
 
-	// Wait for all goroutines to complete
-	for i := 0; i < 10; i++ {
-		<-done
+	// Refreshing a cached preview evicts nothing
+	refreshed := &domain.LinkPreview{URL: "https://example.com/1", FetchedAt: start.Add(time.Hour)}
+	repo.SaveLinkPreview(ctx, refreshed)
+	if preview, _ := repo.GetLinkPreview(ctx, "https://example.com/2"); preview == nil {
+		t.Error("Expected a refresh to keep the other previews")
 	}
+}
+
+func TestInMemoryRepository_WebhookDeliveries(t *testing.T) {
+	repo := NewInMemoryRepository()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
 
-	// Verify all users and tweets were created
-	// This is a basic concurrency test - in a real scenario you'd want more thorough testing
+	webhook, _ := domain.NewWebhook("webhook1", "user123", "https://example.com/hook", "0123456789abcdef", []string{domain.EventTweetCreated}, now)
+	if err := repo.CreateWebhook(ctx, webhook); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	event := domain.NewWebhookEvent("event1", domain.EventTweetCreated, nil, now)
+	older := domain.NewWebhookDelivery("delivery1", webhook.ID, event, []byte(`{}`), now)
+	newer := domain.NewWebhookDelivery("delivery2", webhook.ID, domain.NewWebhookEvent("event2", domain.EventTweetCreated, nil, now), []byte(`{}`), now.Add(time.Minute))
+	repeated := domain.NewWebhookDelivery("delivery3", webhook.ID, event, []byte(`{}`), now)
+	for _, delivery := range []*domain.WebhookDelivery{newer, older, repeated} {
+		if err := repo.CreateWebhookDelivery(ctx, delivery); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if found, _ := repo.GetWebhookDelivery(ctx, repeated.ID); found != nil {
+		t.Error("Expected a second delivery of the same event to be ignored")
+	}
+
+	due, _ := repo.GetDueWebhookDeliveries(ctx, now, 10)
+	if len(due) != 1 || due[0].ID != older.ID {
+		t.Errorf("Expected only the older delivery to be due, got %d", len(due))
+	}
+
+	log, _ := repo.GetWebhookDeliveriesByWebhookID(ctx, webhook.ID, 10)
+	if len(log) != 2 || log[0].ID != newer.ID {
+		t.Error("Expected the delivery log newest first")
+	}
+
+	older.RecordSuccess(200, now)
+	if err := repo.UpdateWebhookDelivery(ctx, older); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repo.DeleteFinishedWebhookDeliveries(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if found, _ := repo.GetWebhookDelivery(ctx, older.ID); found != nil {
+		t.Error("Expected the finished delivery to be pruned")
+	}
+	if found, _ := repo.GetWebhookDelivery(ctx, newer.ID); found == nil {
+		t.Error("Expected the pending delivery to be kept")
+	}
+
+	if err := repo.DeleteWebhook(ctx, webhook.ID); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repo.DeleteWebhook(ctx, webhook.ID); err != domain.ErrWebhookNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrWebhookNotFound, err)
+	}
 }
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+)
+
+// LinkPreviewRepository implements domain.LinkPreviewRepository
+type LinkPreviewRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewLinkPreviewRepository creates a new link preview repository
+func NewLinkPreviewRepository(storage *InMemoryRepository) *LinkPreviewRepository {
+	return &LinkPreviewRepository{
+		storage: storage,
+	}
+}
+
+func (r *LinkPreviewRepository) Get(ctx context.Context, url string) (*domain.LinkPreview, error) {
+	return r.storage.GetLinkPreview(ctx, url)
+}
+
+func (r *LinkPreviewRepository) Save(ctx context.Context, preview *domain.LinkPreview) error {
+	return r.storage.SaveLinkPreview(ctx, preview)
+}
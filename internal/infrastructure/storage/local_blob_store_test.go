@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"uala-challenge/internal/domain"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	key := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if err := store.Put(ctx, key, []byte("hello")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Putting the same content-addressed key again is a no-op
+	if err := store.Put(ctx, key, []byte("hello")); err != nil {
+		t.Errorf("Expected no error on duplicate put, got %v", err)
+	}
+
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "hello" {
+		t.Errorf("Expected %q, got %q", "hello", data)
+	}
+
+	if _, err := store.Get(ctx, "ffffffff"); err != domain.ErrBlobNotFound {
+		t.Errorf("Expected %v, got %v", domain.ErrBlobNotFound, err)
+	}
+
+	for _, invalid := range []string{"", "ab", "../../etc/passwd", "ABCDEF"} {
+		if err := store.Put(ctx, invalid, []byte("x")); err == nil {
+			t.Errorf("Expected error for invalid key %q", invalid)
+		}
+	}
+}
+
+func TestLocalBlobStore_Ping(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalBlobStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	if err := store.Ping(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	os.RemoveAll(dir)
+	if err := store.Ping(context.Background()); err == nil {
+		t.Error("Expected error once the directory is gone, got nil")
+	}
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+)
+
+// MediaRepository implements domain.MediaRepository
+type MediaRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewMediaRepository creates a new media repository
+func NewMediaRepository(storage *InMemoryRepository) *MediaRepository {
+	return &MediaRepository{
+		storage: storage,
+	}
+}
+
+func (r *MediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	return r.storage.CreateMedia(ctx, media)
+}
+
+func (r *MediaRepository) GetByID(ctx context.Context, id string) (*domain.Media, error) {
+	return r.storage.GetMedia(ctx, id)
+}
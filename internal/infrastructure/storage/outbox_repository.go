@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// OutboxRepository implements domain.OutboxRepository
+type OutboxRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(storage *InMemoryRepository) *OutboxRepository {
+	return &OutboxRepository{
+		storage: storage,
+	}
+}
+
+func (r *OutboxRepository) GetPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	return r.storage.GetPendingOutboxEvents(ctx, limit)
+}
+
+func (r *OutboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	return r.storage.MarkOutboxEventDispatched(ctx, id, at)
+}
+
+func (r *OutboxRepository) DeleteDispatchedBefore(ctx context.Context, t time.Time) error {
+	return r.storage.DeleteDispatchedOutboxEvents(ctx, t)
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+)
+
+// PollRepository implements domain.PollRepository
+type PollRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewPollRepository creates a new poll repository
+func NewPollRepository(storage *InMemoryRepository) *PollRepository {
+	return &PollRepository{
+		storage: storage,
+	}
+}
+
+func (r *PollRepository) Vote(ctx context.Context, tweetID, userID string, option int) error {
+	return r.storage.VotePoll(ctx, tweetID, userID, option)
+}
+
+func (r *PollRepository) GetVote(ctx context.Context, tweetID, userID string) (int, bool, error) {
+	return r.storage.GetPollVote(ctx, tweetID, userID)
+}
+
+func (r *PollRepository) GetTallies(ctx context.Context, tweetID string) ([]int, error) {
+	return r.storage.GetPollTallies(ctx, tweetID)
+}
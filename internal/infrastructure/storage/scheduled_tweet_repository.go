@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// ScheduledTweetRepository implements domain.ScheduledTweetRepository
+type ScheduledTweetRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewScheduledTweetRepository creates a new scheduled tweet repository
+func NewScheduledTweetRepository(storage *InMemoryRepository) *ScheduledTweetRepository {
+	return &ScheduledTweetRepository{
+		storage: storage,
+	}
+}
+
+func (r *ScheduledTweetRepository) Create(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	return r.storage.CreateScheduledTweet(ctx, tweet)
+}
+
+func (r *ScheduledTweetRepository) GetByID(ctx context.Context, id string) (*domain.ScheduledTweet, error) {
+	return r.storage.GetScheduledTweet(ctx, id)
+}
+
+func (r *ScheduledTweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.ScheduledTweet, error) {
+	return r.storage.GetScheduledTweetsByUserID(ctx, userID)
+}
+
+func (r *ScheduledTweetRepository) GetDue(ctx context.Context, now time.Time) ([]*domain.ScheduledTweet, error) {
+	return r.storage.GetDueScheduledTweets(ctx, now)
+}
+
+func (r *ScheduledTweetRepository) Update(ctx context.Context, tweet *domain.ScheduledTweet) error {
+	return r.storage.UpdateScheduledTweet(ctx, tweet)
+}
+
+func (r *ScheduledTweetRepository) Delete(ctx context.Context, id string) error {
+	return r.storage.DeleteScheduledTweet(ctx, id)
+}
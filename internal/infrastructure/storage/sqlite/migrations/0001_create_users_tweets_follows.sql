@@ -0,0 +1,27 @@
+CREATE TABLE users (
+    id   TEXT PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+-- created_at is in Unix nanoseconds. poll and media_ids hold JSON.
+CREATE TABLE tweets (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL,
+    content    TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    poll       TEXT,
+    media_ids  TEXT
+);
+
+-- Serves a user's tweets and timeline pages, newest first
+CREATE INDEX tweets_user_id_created_at ON tweets (user_id, created_at DESC, id DESC);
+
+-- The rowid keeps followees in the order they were followed. The primary
+-- key serves lookups by follower.
+CREATE TABLE follows (
+    follower_id TEXT NOT NULL,
+    followee_id TEXT NOT NULL,
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+CREATE INDEX follows_followee_id ON follows (followee_id, follower_id);
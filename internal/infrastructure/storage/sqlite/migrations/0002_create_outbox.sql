@@ -0,0 +1,12 @@
+-- seq orders events as they were committed. Times are in Unix nanoseconds.
+CREATE TABLE outbox (
+    seq           INTEGER PRIMARY KEY AUTOINCREMENT,
+    id            TEXT NOT NULL UNIQUE,
+    type          TEXT NOT NULL,
+    payload       TEXT NOT NULL,
+    occurred_at   INTEGER NOT NULL,
+    dispatched_at INTEGER
+);
+
+CREATE INDEX outbox_pending ON outbox (seq) WHERE dispatched_at IS NULL;
+CREATE INDEX outbox_dispatched_at ON outbox (dispatched_at) WHERE dispatched_at IS NOT NULL;
@@ -0,0 +1,21 @@
+-- key and thumbnail_key locate the blobs. created_at is in Unix nanoseconds.
+CREATE TABLE media (
+    id                     TEXT PRIMARY KEY,
+    user_id                TEXT NOT NULL,
+    content_type           TEXT NOT NULL,
+    size                   INTEGER NOT NULL,
+    width                  INTEGER NOT NULL,
+    height                 INTEGER NOT NULL,
+    key                    TEXT NOT NULL,
+    thumbnail_key          TEXT NOT NULL,
+    thumbnail_content_type TEXT NOT NULL,
+    created_at             INTEGER NOT NULL
+);
+
+-- The primary key allows one vote per user in a poll
+CREATE TABLE poll_votes (
+    tweet_id    TEXT NOT NULL,
+    user_id     TEXT NOT NULL,
+    option      INTEGER NOT NULL,
+    PRIMARY KEY (tweet_id, user_id)
+);
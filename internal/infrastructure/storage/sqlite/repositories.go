@@ -0,0 +1,452 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// ID lists are bound as a single JSON array and expanded with json_each, so
+// that a query has the same text whatever the number of IDs
+
+func idList(ids []string) (string, error) {
+	data, err := json.Marshal(ids)
+	return string(data), err
+}
+
+// Times are stored as Unix nanoseconds so that they sort as integers
+
+func unixNano(t time.Time) int64 {
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	return time.Unix(0, n).UTC()
+}
+
+type userRepository struct {
+	db querier
+}
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO users (id, name) VALUES (?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name`, user.ID, user.Name)
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user := &domain.User{}
+	err := r.db.QueryRowContext(ctx, `SELECT id, name FROM users WHERE id = ?`, id).Scan(&user.ID, &user.Name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get user: %w", err)
+	}
+	return user, nil
+}
+
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	list, err := idList(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT id, name FROM users
+		WHERE id IN (SELECT value FROM json_each(?))`, list)
+	if err != nil {
+		return nil, fmt.Errorf("get users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]*domain.User, 0, len(ids))
+	for rows.Next() {
+		user := &domain.User{}
+		if err := rows.Scan(&user.ID, &user.Name); err != nil {
+			return nil, fmt.Errorf("get users: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get users: %w", err)
+	}
+	return users, nil
+}
+
+type tweetRepository struct {
+	db querier
+}
+
+const tweetColumns = `id, user_id, content, created_at, poll, media_ids`
+
+func (r *tweetRepository) Create(ctx context.Context, tweet *domain.Tweet) error {
+	var poll, mediaIDs sql.NullString
+	if tweet.Poll != nil {
+		data, err := json.Marshal(tweet.Poll)
+		if err != nil {
+			return fmt.Errorf("encode poll: %w", err)
+		}
+		poll = sql.NullString{String: string(data), Valid: true}
+	}
+	if len(tweet.MediaIDs) > 0 {
+		data, err := json.Marshal(tweet.MediaIDs)
+		if err != nil {
+			return fmt.Errorf("encode media IDs: %w", err)
+		}
+		mediaIDs = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, `INSERT INTO tweets (`+tweetColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			user_id = excluded.user_id,
+			content = excluded.content,
+			created_at = excluded.created_at,
+			poll = excluded.poll,
+			media_ids = excluded.media_ids`,
+		tweet.ID, tweet.UserID, tweet.Content, unixNano(tweet.CreatedAt), poll, mediaIDs)
+	if err != nil {
+		return fmt.Errorf("create tweet: %w", err)
+	}
+	return nil
+}
+
+func (r *tweetRepository) GetByID(ctx context.Context, id string) (*domain.Tweet, error) {
+	tweets, err := r.query(ctx, `SELECT `+tweetColumns+` FROM tweets WHERE id = ?`, id)
+	if err != nil || len(tweets) == 0 {
+		return nil, err
+	}
+	return tweets[0], nil
+}
+
+func (r *tweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
+	return r.query(ctx, `SELECT `+tweetColumns+` FROM tweets WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC`, userID)
+}
+
+func (r *tweetRepository) GetByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Tweet, error) {
+	list, err := idList(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	return r.query(ctx, `SELECT `+tweetColumns+` FROM tweets
+		WHERE user_id IN (SELECT value FROM json_each(?))
+		ORDER BY created_at DESC, id DESC`, list)
+}
+
+// GetPageByUserIDs seeks past the cursor's (created_at, id) on the
+// tweets_user_id_created_at index rather than skipping an offset, so later
+// pages cost the same as the first
+func (r *tweetRepository) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	list, err := idList(userIDs)
+	if err != nil {
+		return nil, err
+	}
+	limit := max(page.Limit, 0)
+
+	if page.After == "" {
+		return r.query(ctx, `SELECT `+tweetColumns+` FROM tweets
+			WHERE user_id IN (SELECT value FROM json_each(?))
+			ORDER BY created_at DESC, id DESC
+			LIMIT ?`, list, limit)
+	}
+
+	var createdAt int64
+	err = r.db.QueryRowContext(ctx, `SELECT created_at FROM tweets WHERE id = ?`, page.After).Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrPageCursorNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get page cursor: %w", err)
+	}
+	return r.query(ctx, `SELECT `+tweetColumns+` FROM tweets
+		WHERE user_id IN (SELECT value FROM json_each(?))
+			AND (created_at, id) < (?, ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, list, createdAt, page.After, limit)
+}
+
+// query returns the tweets a query selects with tweetColumns
+func (r *tweetRepository) query(ctx context.Context, query string, args ...any) ([]*domain.Tweet, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("get tweets: %w", err)
+	}
+	defer rows.Close()
+
+	tweets := []*domain.Tweet{}
+	for rows.Next() {
+		var (
+			tweet          domain.Tweet
+			createdAt      int64
+			poll, mediaIDs sql.NullString
+		)
+		err := rows.Scan(&tweet.ID, &tweet.UserID, &tweet.Content, &createdAt, &poll, &mediaIDs)
+		if err != nil {
+			return nil, fmt.Errorf("get tweets: %w", err)
+		}
+		tweet.CreatedAt = fromUnixNano(createdAt)
+		if poll.Valid {
+			if err := json.Unmarshal([]byte(poll.String), &tweet.Poll); err != nil {
+				return nil, fmt.Errorf("decode poll of tweet %s: %w", tweet.ID, err)
+			}
+		}
+		if mediaIDs.Valid {
+			if err := json.Unmarshal([]byte(mediaIDs.String), &tweet.MediaIDs); err != nil {
+				return nil, fmt.Errorf("decode media IDs of tweet %s: %w", tweet.ID, err)
+			}
+		}
+		tweets = append(tweets, &tweet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get tweets: %w", err)
+	}
+	return tweets, nil
+}
+
+type followRepository struct {
+	db querier
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO follows (follower_id, followee_id) VALUES (?, ?)
+		ON CONFLICT DO NOTHING`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("follow: %w", err)
+	}
+	return changed(result, "follow")
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM follows WHERE follower_id = ? AND followee_id = ?`, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("unfollow: %w", err)
+	}
+	return changed(result, "unfollow")
+}
+
+// changed reports whether a statement affected any row
+func changed(result sql.Result, op string) (bool, error) {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	return rows > 0, nil
+}
+
+// Followees are listed in the order they were followed, which is rowid order
+
+func (r *followRepository) GetFollowees(ctx context.Context, followerID string) ([]string, error) {
+	followees, err := r.GetFolloweesByFollowerIDs(ctx, []string{followerID})
+	if err != nil {
+		return nil, err
+	}
+	return followees[followerID], nil
+}
+
+func (r *followRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (map[string][]string, error) {
+	return r.query(ctx, followerIDs, `SELECT follower_id, followee_id FROM follows
+		WHERE follower_id IN (SELECT value FROM json_each(?))
+		ORDER BY rowid`)
+}
+
+func (r *followRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (map[string][]string, error) {
+	return r.query(ctx, followeeIDs, `SELECT followee_id, follower_id FROM follows
+		WHERE followee_id IN (SELECT value FROM json_each(?))
+		ORDER BY followee_id, follower_id`)
+}
+
+// query groups the (key, value) rows a query selects for ids by key, with
+// an empty list for every ID that has none
+func (r *followRepository) query(ctx context.Context, ids []string, query string) (map[string][]string, error) {
+	list, err := idList(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.QueryContext(ctx, query, list)
+	if err != nil {
+		return nil, fmt.Errorf("get follows: %w", err)
+	}
+	defer rows.Close()
+
+	grouped := make(map[string][]string, len(ids))
+	for _, id := range ids {
+		grouped[id] = []string{}
+	}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("get follows: %w", err)
+		}
+		grouped[key] = append(grouped[key], value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get follows: %w", err)
+	}
+	return grouped, nil
+}
+
+type outboxRepository struct {
+	db querier
+}
+
+// insert adds events to the outbox; seq keeps them in insertion order
+func (r *outboxRepository) insert(ctx context.Context, events []*domain.OutboxEvent) error {
+	for _, event := range events {
+		_, err := r.db.ExecContext(ctx, `INSERT INTO outbox (id, type, payload, occurred_at) VALUES (?, ?, ?, ?)`,
+			event.ID, event.Type, string(event.Payload), unixNano(event.OccurredAt))
+		if err != nil {
+			return fmt.Errorf("insert outbox event: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, limit int) ([]*domain.OutboxEvent, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT id, type, payload, occurred_at FROM outbox
+		WHERE dispatched_at IS NULL
+		ORDER BY seq
+		LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get pending events: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []*domain.OutboxEvent
+	for rows.Next() {
+		var (
+			event      domain.OutboxEvent
+			payload    string
+			occurredAt int64
+		)
+		if err := rows.Scan(&event.ID, &event.Type, &payload, &occurredAt); err != nil {
+			return nil, fmt.Errorf("get pending events: %w", err)
+		}
+		event.Payload = json.RawMessage(payload)
+		event.OccurredAt = fromUnixNano(occurredAt)
+		pending = append(pending, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get pending events: %w", err)
+	}
+	return pending, nil
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE outbox SET dispatched_at = ? WHERE id = ?`, unixNano(at), id)
+	if err != nil {
+		return fmt.Errorf("mark event dispatched: %w", err)
+	}
+	return nil
+}
+
+func (r *outboxRepository) DeleteDispatchedBefore(ctx context.Context, t time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM outbox WHERE dispatched_at IS NOT NULL AND dispatched_at < ?`, unixNano(t))
+	if err != nil {
+		return fmt.Errorf("prune outbox: %w", err)
+	}
+	return nil
+}
+
+type mediaRepository struct {
+	db querier
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *domain.Media) error {
+	_, err := r.db.ExecContext(ctx, `INSERT INTO media (id, user_id, content_type, size, width, height,
+			key, thumbnail_key, thumbnail_content_type, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		media.ID, media.UserID, media.ContentType, media.Size, media.Width, media.Height,
+		media.Key, media.ThumbnailKey, media.ThumbnailContentType, unixNano(media.CreatedAt))
+	if err != nil {
+		return fmt.Errorf("create media: %w", err)
+	}
+	return nil
+}
+
+func (r *mediaRepository) GetByID(ctx context.Context, id string) (*domain.Media, error) {
+	var (
+		media     domain.Media
+		createdAt int64
+	)
+	err := r.db.QueryRowContext(ctx, `SELECT id, user_id, content_type, size, width, height,
+			key, thumbnail_key, thumbnail_content_type, created_at
+		FROM media WHERE id = ?`, id).Scan(
+		&media.ID, &media.UserID, &media.ContentType, &media.Size, &media.Width, &media.Height,
+		&media.Key, &media.ThumbnailKey, &media.ThumbnailContentType, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get media: %w", err)
+	}
+	media.CreatedAt = fromUnixNano(createdAt)
+	return &media, nil
+}
+
+type pollRepository struct {
+	db querier
+}
+
+// Vote relies on the primary key, so that of two concurrent votes by the
+// same user only one is stored
+func (r *pollRepository) Vote(ctx context.Context, tweetID, userID string, option int) error {
+	result, err := r.db.ExecContext(ctx, `INSERT INTO poll_votes (tweet_id, user_id, option) VALUES (?, ?, ?)
+		ON CONFLICT DO NOTHING`, tweetID, userID, option)
+	if err != nil {
+		return fmt.Errorf("vote: %w", err)
+	}
+	voted, err := changed(result, "vote")
+	if err != nil {
+		return err
+	}
+	if !voted {
+		return domain.ErrAlreadyVoted
+	}
+	return nil
+}
+
+func (r *pollRepository) GetVote(ctx context.Context, tweetID, userID string) (int, bool, error) {
+	var option int
+	err := r.db.QueryRowContext(ctx, `SELECT option FROM poll_votes WHERE tweet_id = ? AND user_id = ?`,
+		tweetID, userID).Scan(&option)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("get vote: %w", err)
+	}
+	return option, true, nil
+}
+
+// GetTallies counts the votes for each option, up to the highest voted for
+func (r *pollRepository) GetTallies(ctx context.Context, tweetID string) ([]int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT option, COUNT(*) FROM poll_votes WHERE tweet_id = ?
+		GROUP BY option`, tweetID)
+	if err != nil {
+		return nil, fmt.Errorf("get tallies: %w", err)
+	}
+	defer rows.Close()
+
+	tallies := []int{}
+	for rows.Next() {
+		var option, votes int
+		if err := rows.Scan(&option, &votes); err != nil {
+			return nil, fmt.Errorf("get tallies: %w", err)
+		}
+		for len(tallies) <= option {
+			tallies = append(tallies, 0)
+		}
+		tallies[option] = votes
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get tallies: %w", err)
+	}
+	return tallies, nil
+}
@@ -0,0 +1,124 @@
+// Package sqlite is a storage backend that keeps users, tweets, follows,
+// media, poll votes and the outbox in an embedded SQLite database, using a
+// pure-Go driver so that it needs neither cgo nor an external service.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"uala-challenge/internal/domain"
+
+	// Registers the "sqlite" driver
+	_ "modernc.org/sqlite"
+)
+
+// DatabaseFile is the name of the database in the data directory
+const DatabaseFile = "uala.db"
+
+// Store is a SQLite database holding the repositories that the unit of work
+// commits together. Open brings its schema up to date.
+type Store struct {
+	db    *sql.DB
+	clock domain.Clock
+	ids   domain.IDGenerator
+}
+
+// Option configures optional store settings
+type Option func(*Store)
+
+// WithClock overrides the clock that stamps outbox events
+func WithClock(clock domain.Clock) Option {
+	return func(s *Store) {
+		s.clock = clock
+	}
+}
+
+// WithIDGenerator overrides the generator of outbox event IDs
+func WithIDGenerator(ids domain.IDGenerator) Option {
+	return func(s *Store) {
+		s.ids = ids
+	}
+}
+
+// querier is what the repositories need of a database or a transaction
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Open opens the database in dataDir, creating it if needed, and applies the
+// migrations it is missing
+func Open(dataDir string, opts ...Option) (*Store, error) {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create data dir: %w", err)
+	}
+
+	// WAL lets reads proceed while a write commits, and transactions take
+	// the write lock up front so that concurrent ones wait for each other
+	// rather than fail when they first write
+	query := url.Values{}
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Add("_pragma", "synchronous(NORMAL)")
+	query.Set("_txlock", "immediate")
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(dataDir, DatabaseFile)+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := migrate(context.Background(), db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	s := &Store{db: db, clock: domain.SystemClock{}, ids: domain.UUIDv7Generator{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// Ping reports whether the database can be reached
+func (s *Store) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Users() domain.UserRepository {
+	return &userRepository{db: s.db}
+}
+
+func (s *Store) Tweets() domain.TweetRepository {
+	return &tweetRepository{db: s.db}
+}
+
+func (s *Store) Follows() domain.FollowRepository {
+	return &followRepository{db: s.db}
+}
+
+func (s *Store) Media() domain.MediaRepository {
+	return &mediaRepository{db: s.db}
+}
+
+func (s *Store) Polls() domain.PollRepository {
+	return &pollRepository{db: s.db}
+}
+
+func (s *Store) Outbox() domain.OutboxRepository {
+	return &outboxRepository{db: s.db}
+}
+
+// UnitOfWork returns a unit of work that runs each Do in a database
+// transaction, with its recorded events inserted into the outbox
+func (s *Store) UnitOfWork() domain.UnitOfWork {
+	return &unitOfWork{db: s.db, clock: s.clock, ids: s.ids}
+}
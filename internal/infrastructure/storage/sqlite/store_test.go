@@ -0,0 +1,166 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+	"uala-challenge/internal/infrastructure/storage/storagetest"
+)
+
+func openStore(t *testing.T, dataDir string) *Store {
+	t.Helper()
+	store, err := Open(dataDir)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_Contract(t *testing.T) {
+	storagetest.RunRepositoryContract(t, func(t *testing.T) storagetest.Repositories {
+		store := openStore(t, t.TempDir())
+		return storagetest.Repositories{
+			Users:   store.Users(),
+			Tweets:  store.Tweets(),
+			Follows: store.Follows(),
+			Media:   store.Media(),
+			Polls:   store.Polls(),
+		}
+	})
+}
+
+func TestStore_Migrations(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	all, err := migrations()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	latest := all[len(all)-1].version
+
+	store := openStore(t, dir)
+	if err := store.Users().Create(ctx, &domain.User{ID: "alice", Name: "alice"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.Close()
+
+	// Reopening applies nothing and keeps the data
+	store = openStore(t, dir)
+	if version, _ := schemaVersion(ctx, store.db); version != latest {
+		t.Errorf("Expected schema version %d, got %d", latest, version)
+	}
+	var applied int
+	store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&applied)
+	if applied != len(all) {
+		t.Errorf("Expected %d migrations applied once each, got %d", len(all), applied)
+	}
+	if user, _ := store.Users().GetByID(ctx, "alice"); user == nil {
+		t.Error("Expected the user to survive reopening")
+	}
+
+	// A database migrated by a newer build is refused
+	_, err = store.db.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, 'future', 0)`, latest+1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	store.Close()
+	if _, err := Open(dir); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("Expected a newer schema to be refused, got %v", err)
+	}
+}
+
+func TestStore_UnitOfWork(t *testing.T) {
+	ctx := context.Background()
+	store := openStore(t, t.TempDir())
+	uow, outbox := store.UnitOfWork(), store.Outbox()
+
+	tweet := &domain.Tweet{ID: "tweet1", UserID: "alice", Content: "Hello", CreatedAt: time.Now()}
+	err := uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		if err := tx.Tweets().Create(ctx, tweet); err != nil {
+			return err
+		}
+		if followed, err := tx.Follows().Follow(ctx, "alice", "bob"); err != nil || !followed {
+			return fmt.Errorf("expected a new follow, got %v, %w", followed, err)
+		}
+		// Reads in a transaction see its own writes
+		if followed, err := tx.Follows().Follow(ctx, "alice", "bob"); err != nil || followed {
+			return fmt.Errorf("expected the follow to exist, got %v, %w", followed, err)
+		}
+		tx.Record(domain.TweetCreated{Tweet: tweet}, domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if found, _ := store.Tweets().GetByID(ctx, tweet.ID); found == nil {
+		t.Error("Expected the tweet to be committed")
+	}
+
+	pending, _ := outbox.GetPending(ctx, 10)
+	if len(pending) != 2 || pending[0].Type != domain.EventTweetCreated || pending[1].Type != domain.EventUserFollowed {
+		t.Fatalf("Expected both events in the outbox in order, got %d", len(pending))
+	}
+	if _, err := pending[0].Decode(); err != nil {
+		t.Errorf("Expected the event to round-trip, got %v", err)
+	}
+
+	// Dispatched events are no longer pending and are pruned once old
+	now := time.Now()
+	if err := outbox.MarkDispatched(ctx, pending[0].ID, now); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := outbox.DeleteDispatchedBefore(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pending, _ = outbox.GetPending(ctx, 10); len(pending) != 1 || pending[0].Type != domain.EventUserFollowed {
+		t.Errorf("Expected only the undispatched event to be left, got %d", len(pending))
+	}
+
+	// A failed transaction stores neither its writes nor its events
+	errFailed := errors.New("failed")
+	err = uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Users().Create(ctx, &domain.User{ID: "carol"})
+		tx.Record(domain.UserCreated{User: &domain.User{ID: "carol"}})
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected %v, got %v", errFailed, err)
+	}
+	if user, _ := store.Users().GetByID(ctx, "carol"); user != nil {
+		t.Error("Expected the user not to be committed")
+	}
+	if pending, _ = outbox.GetPending(ctx, 10); len(pending) != 1 {
+		t.Errorf("Expected no event from the failed transaction, got %d pending", len(pending))
+	}
+}
+
+func TestStore_StampsEventsWithClockAndIDs(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	store, err := Open(t.TempDir(), WithClock(clock), WithIDGenerator(domaintest.NewSequentialIDs("event")))
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer store.Close()
+	uow, outbox := store.UnitOfWork(), store.Outbox()
+
+	err = uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Record(domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 10)
+	if len(pending) != 1 || pending[0].ID != "event-000001" || !pending[0].OccurredAt.Equal(clock.Now()) {
+		t.Errorf("Expected the event stamped by the clock and ID generator, got %+v", pending)
+	}
+}
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"uala-challenge/internal/domain"
+)
+
+// unitOfWork implements domain.UnitOfWork with a database transaction. Reads
+// in a transaction see its own writes.
+type unitOfWork struct {
+	db    *sql.DB
+	clock domain.Clock
+	ids   domain.IDGenerator
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	sqlTx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer sqlTx.Rollback()
+
+	tx := &transaction{tx: sqlTx}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+
+	now := u.clock.Now()
+	events := make([]*domain.OutboxEvent, 0, len(tx.events))
+	for _, event := range tx.events {
+		stored, err := domain.NewOutboxEvent(u.ids.NewID(), event, now)
+		if err != nil {
+			return err
+		}
+		events = append(events, stored)
+	}
+	outbox := &outboxRepository{db: sqlTx}
+	if err := outbox.insert(ctx, events); err != nil {
+		return err
+	}
+
+	if err := sqlTx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+type transaction struct {
+	tx     *sql.Tx
+	events []domain.Event
+}
+
+func (t *transaction) Users() domain.UserRepository {
+	return &userRepository{db: t.tx}
+}
+
+func (t *transaction) Tweets() domain.TweetRepository {
+	return &tweetRepository{db: t.tx}
+}
+
+func (t *transaction) Follows() domain.FollowRepository {
+	return &followRepository{db: t.tx}
+}
+
+func (t *transaction) Record(events ...domain.Event) {
+	t.events = append(t.events, events...)
+}
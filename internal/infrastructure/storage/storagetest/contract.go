@@ -0,0 +1,599 @@
+// Package storagetest is the conformance suite for storage backends. Every
+// backend runs RunRepositoryContract from its own tests, so that services
+// can rely on the same behaviour whichever one is configured.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// Repositories are the repositories of one backend, sharing storage
+type Repositories struct {
+	Users   domain.UserRepository
+	Tweets  domain.TweetRepository
+	Follows domain.FollowRepository
+	Media   domain.MediaRepository
+	Polls   domain.PollRepository
+}
+
+// Factory returns the repositories of a new, empty backend. It registers
+// any cleanup the backend needs with t.
+type Factory func(t *testing.T) Repositories
+
+// RunRepositoryContract verifies the backend newRepositories creates against
+// the contract of domain.UserRepository, TweetRepository, FollowRepository,
+// MediaRepository and PollRepository, with a new backend for every case:
+//
+//   - Missing users and tweets are nil rather than an error, and lookups that
+//     find nothing return empty results.
+//   - Tweet pages are newest first, ties broken by descending ID.
+//   - Followees are listed in the order they were followed and followers
+//     sorted by ID. Following twice, or unfollowing someone not followed, has
+//     no effect.
+//   - Media keep the keys of their blobs.
+//   - A user votes once in a poll, even when voting concurrently.
+//   - Concurrent writers do not lose each other's writes.
+//   - Operations with a cancelled context fail with an error wrapping the
+//     context's, and writes then store nothing.
+func RunRepositoryContract(t *testing.T, newRepositories Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repos Repositories)
+	}{
+		{"users", testUsers},
+		{"tweets", testTweets},
+		{"tweet page ordering", testTweetPages},
+		{"idempotent follow", testIdempotentFollow},
+		{"unfollow", testUnfollow},
+		{"follow lookups", testFollowLookups},
+		{"empty results", testEmptyResults},
+		{"media", testMedia},
+		{"poll votes", testPollVotes},
+		{"concurrent writers", testConcurrentWriters},
+		{"context cancellation", testContextCancellation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepositories(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	for _, user := range []*domain.User{{ID: "alice", Name: "Alice"}, {ID: "bob", Name: "Bob"}} {
+		if err := repos.Users.Create(ctx, user); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	user, err := repos.Users.GetByID(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user == nil || user.Name != "Alice" {
+		t.Errorf("Expected alice, got %v", user)
+	}
+
+	user, err = repos.Users.GetByID(ctx, "nonexistent")
+	if err != nil || user != nil {
+		t.Errorf("Expected nil for a missing user, got %v, %v", user, err)
+	}
+
+	users, err := repos.Users.GetByIDs(ctx, []string{"alice", "nonexistent", "bob"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if ids := userIDs(users); !slices.Equal(ids, []string{"alice", "bob"}) {
+		t.Errorf("Expected the 2 existing users, got %v", ids)
+	}
+}
+
+func testTweets(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tweet := &domain.Tweet{ID: "tweet1", UserID: "alice", Content: "Hello", CreatedAt: now, MediaIDs: []string{"media1"}}
+	tweets := []*domain.Tweet{
+		tweet,
+		{ID: "tweet2", UserID: "alice", Content: "Again", CreatedAt: now.Add(time.Minute)},
+		{ID: "tweet3", UserID: "bob", Content: "Hi", CreatedAt: now},
+	}
+	for _, tweet := range tweets {
+		if err := repos.Tweets.Create(ctx, tweet); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	found, err := repos.Tweets.GetByID(ctx, "tweet1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if found == nil || found.UserID != "alice" || found.Content != "Hello" || !found.CreatedAt.Equal(now) {
+		t.Fatalf("Expected tweet1, got %v", found)
+	}
+	if !slices.Equal(found.MediaIDs, []string{"media1"}) {
+		t.Errorf("Expected media IDs to be kept, got %v", found.MediaIDs)
+	}
+
+	found, err = repos.Tweets.GetByID(ctx, "nonexistent")
+	if err != nil || found != nil {
+		t.Errorf("Expected nil for a missing tweet, got %v, %v", found, err)
+	}
+
+	tests := []struct {
+		name     string
+		get      func() ([]*domain.Tweet, error)
+		expected []string
+	}{
+		{"by user", func() ([]*domain.Tweet, error) {
+			return repos.Tweets.GetByUserID(ctx, "alice")
+		}, []string{"tweet1", "tweet2"}},
+		{"by users", func() ([]*domain.Tweet, error) {
+			return repos.Tweets.GetByUserIDs(ctx, []string{"alice", "bob", "carol"})
+		}, []string{"tweet1", "tweet2", "tweet3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tweets, err := tt.get()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if ids := tweetIDs(tweets); !slices.Equal(sorted(ids), tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
+
+func testTweetPages(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// tweet2 and tweet3 were created at the same time, so the higher ID
+	// comes first
+	tweets := []*domain.Tweet{
+		{ID: "tweet1", UserID: "alice", Content: "1", CreatedAt: now},
+		{ID: "tweet2", UserID: "bob", Content: "2", CreatedAt: now.Add(time.Minute)},
+		{ID: "tweet3", UserID: "alice", Content: "3", CreatedAt: now.Add(time.Minute)},
+		{ID: "tweet4", UserID: "bob", Content: "4", CreatedAt: now.Add(2 * time.Minute)},
+		{ID: "tweet5", UserID: "carol", Content: "5", CreatedAt: now.Add(3 * time.Minute)},
+	}
+	for _, tweet := range tweets {
+		if err := repos.Tweets.Create(ctx, tweet); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	userIDs := []string{"alice", "bob"}
+	tests := []struct {
+		name     string
+		page     domain.Page
+		expected []string
+	}{
+		{"first page", domain.Page{Limit: 2}, []string{"tweet4", "tweet3"}},
+		{"next page", domain.Page{Limit: 2, After: "tweet3"}, []string{"tweet2", "tweet1"}},
+		{"past the end", domain.Page{Limit: 2, After: "tweet1"}, []string{}},
+		{"cursor by another user", domain.Page{Limit: 10, After: "tweet5"}, []string{"tweet4", "tweet3", "tweet2", "tweet1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := repos.Tweets.GetPageByUserIDs(ctx, userIDs, tt.page)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if ids := tweetIDs(page); !slices.Equal(ids, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+
+	_, err := repos.Tweets.GetPageByUserIDs(ctx, userIDs, domain.Page{Limit: 2, After: "nonexistent"})
+	if !errors.Is(err, domain.ErrPageCursorNotFound) {
+		t.Errorf("Expected %v, got %v", domain.ErrPageCursorNotFound, err)
+	}
+}
+
+func testIdempotentFollow(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	follow(t, repos, [2]string{"alice", "carol"}, [2]string{"alice", "bob"})
+	followed, err := repos.Follows.Follow(ctx, "alice", "carol")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if followed {
+		t.Error("Expected following twice not to report a new follow")
+	}
+
+	followees, err := repos.Follows.GetFollowees(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !slices.Equal(followees, []string{"carol", "bob"}) {
+		t.Errorf("Expected following twice to keep the first follow, got %v", followees)
+	}
+
+	followers, _ := repos.Follows.GetFollowersByFolloweeIDs(ctx, []string{"carol"})
+	if !slices.Equal(followers["carol"], []string{"alice"}) {
+		t.Errorf("Expected a single follower, got %v", followers["carol"])
+	}
+}
+
+func testUnfollow(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	follow(t, repos, [2]string{"alice", "bob"}, [2]string{"alice", "carol"}, [2]string{"alice", "dave"})
+	unfollows := []struct {
+		followerID, followeeID string
+		removed                bool
+	}{
+		{"alice", "carol", true},
+		{"alice", "carol", false},
+		{"alice", "erin", false},
+		{"erin", "alice", false},
+	}
+	for _, unfollow := range unfollows {
+		removed, err := repos.Follows.Unfollow(ctx, unfollow.followerID, unfollow.followeeID)
+		if err != nil {
+			t.Fatalf("Expected unfollowing %s to succeed, got %v", unfollow.followeeID, err)
+		}
+		if removed != unfollow.removed {
+			t.Errorf("Expected %s unfollowing %s to report %v, got %v", unfollow.followerID, unfollow.followeeID, unfollow.removed, removed)
+		}
+	}
+
+	followees, _ := repos.Follows.GetFollowees(ctx, "alice")
+	if !slices.Equal(followees, []string{"bob", "dave"}) {
+		t.Errorf("Expected only carol to be unfollowed, got %v", followees)
+	}
+
+	// Following again counts as a new follow
+	follow(t, repos, [2]string{"alice", "carol"})
+	followees, _ = repos.Follows.GetFollowees(ctx, "alice")
+	if !slices.Equal(followees, []string{"bob", "dave", "carol"}) {
+		t.Errorf("Expected a follow after unfollowing to come last, got %v", followees)
+	}
+}
+
+func testFollowLookups(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	follow(t, repos, [2]string{"dave", "bob"}, [2]string{"alice", "carol"}, [2]string{"alice", "bob"})
+
+	byFollower, err := repos.Follows.GetFolloweesByFollowerIDs(ctx, []string{"alice", "dave", "erin"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := map[string][]string{"alice": {"carol", "bob"}, "dave": {"bob"}, "erin": {}}
+	if !equalLists(byFollower, expected) {
+		t.Errorf("Expected followees %v, got %v", expected, byFollower)
+	}
+
+	byFollowee, err := repos.Follows.GetFollowersByFolloweeIDs(ctx, []string{"bob", "carol", "erin"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected = map[string][]string{"bob": {"alice", "dave"}, "carol": {"alice"}, "erin": {}}
+	if !equalLists(byFollowee, expected) {
+		t.Errorf("Expected followers %v, got %v", expected, byFollowee)
+	}
+}
+
+func testEmptyResults(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		count func() (int, error)
+	}{
+		{"users by no IDs", func() (int, error) {
+			users, err := repos.Users.GetByIDs(ctx, nil)
+			return len(users), err
+		}},
+		{"users by unknown IDs", func() (int, error) {
+			users, err := repos.Users.GetByIDs(ctx, []string{"nonexistent"})
+			return len(users), err
+		}},
+		{"tweets by a user without any", func() (int, error) {
+			tweets, err := repos.Tweets.GetByUserID(ctx, "alice")
+			return len(tweets), err
+		}},
+		{"tweets by no users", func() (int, error) {
+			tweets, err := repos.Tweets.GetByUserIDs(ctx, nil)
+			return len(tweets), err
+		}},
+		{"page by no users", func() (int, error) {
+			tweets, err := repos.Tweets.GetPageByUserIDs(ctx, nil, domain.Page{Limit: 10})
+			return len(tweets), err
+		}},
+		{"followees of no followers", func() (int, error) {
+			followees, err := repos.Follows.GetFolloweesByFollowerIDs(ctx, nil)
+			return len(followees), err
+		}},
+		{"followers of no followees", func() (int, error) {
+			followers, err := repos.Follows.GetFollowersByFolloweeIDs(ctx, nil)
+			return len(followers), err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := tt.count()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if count != 0 {
+				t.Errorf("Expected no results, got %d", count)
+			}
+		})
+	}
+
+	// An empty list of followees is never nil, so that it encodes as []
+	followees, err := repos.Follows.GetFollowees(ctx, "alice")
+	if err != nil || followees == nil || len(followees) != 0 {
+		t.Errorf("Expected an empty list for a user following nobody, got %#v, %v", followees, err)
+	}
+}
+
+func testMedia(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	media := &domain.Media{
+		ID: "media1", UserID: "alice", ContentType: "image/png", Size: 1024, Width: 640, Height: 480,
+		Key: "blob1", ThumbnailKey: "blob2", ThumbnailContentType: "image/jpeg",
+		CreatedAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := repos.Media.Create(ctx, media); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	found, err := repos.Media.GetByID(ctx, "media1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected to find the media, got %v, %v", found, err)
+	}
+	if *found != *media {
+		t.Errorf("Expected %+v, got %+v", media, found)
+	}
+	if missing, err := repos.Media.GetByID(ctx, "missing"); missing != nil || err != nil {
+		t.Errorf("Expected nil for missing media, got %v, %v", missing, err)
+	}
+}
+
+func testPollVotes(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	if err := repos.Polls.Vote(ctx, "tweet1", "alice", 2); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := repos.Polls.Vote(ctx, "tweet1", "alice", 0); !errors.Is(err, domain.ErrAlreadyVoted) {
+		t.Errorf("Expected %v, got %v", domain.ErrAlreadyVoted, err)
+	}
+
+	// Of concurrent votes by one user, one is counted
+	const voters = 10
+	var wg sync.WaitGroup
+	votes := make(chan error, voters)
+	for i := 0; i < voters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			votes <- repos.Polls.Vote(ctx, "tweet1", "bob", 0)
+		}()
+	}
+	wg.Wait()
+	close(votes)
+	var counted int
+	for err := range votes {
+		switch {
+		case err == nil:
+			counted++
+		case !errors.Is(err, domain.ErrAlreadyVoted):
+			t.Errorf("Expected %v, got %v", domain.ErrAlreadyVoted, err)
+		}
+	}
+	if counted != 1 {
+		t.Errorf("Expected one of bob's votes to count, got %d", counted)
+	}
+
+	option, voted, err := repos.Polls.GetVote(ctx, "tweet1", "alice")
+	if err != nil || !voted || option != 2 {
+		t.Errorf("Expected alice's vote for option 2, got %d, %v, %v", option, voted, err)
+	}
+	if _, voted, err := repos.Polls.GetVote(ctx, "tweet1", "carol"); voted || err != nil {
+		t.Errorf("Expected carol not to have voted, got %v, %v", voted, err)
+	}
+	tallies, err := repos.Polls.GetTallies(ctx, "tweet1")
+	if err != nil || !slices.Equal(tallies, []int{1, 0, 1}) {
+		t.Errorf("Expected tallies [1 0 1], got %v, %v", tallies, err)
+	}
+	tallies, err = repos.Polls.GetTallies(ctx, "tweet2")
+	if err != nil || tallies == nil || len(tallies) != 0 {
+		t.Errorf("Expected no tallies for a poll without votes, got %#v, %v", tallies, err)
+	}
+}
+
+func testConcurrentWriters(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	const writers = 20
+
+	// Every writer creates a user and a tweet, and follows and is followed
+	// by the same hub user
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	ids := make([]string, writers)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("user%02d", i)
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			tweet := &domain.Tweet{ID: "tweet-" + id, UserID: id, Content: "Hello", CreatedAt: now}
+			_, followErr := repos.Follows.Follow(ctx, id, "hub")
+			_, followBackErr := repos.Follows.Follow(ctx, "hub", id)
+			errs <- errors.Join(
+				repos.Users.Create(ctx, &domain.User{ID: id, Name: id}),
+				repos.Tweets.Create(ctx, tweet),
+				followErr,
+				followBackErr,
+			)
+		}(ids[i])
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	users, err := repos.Users.GetByIDs(ctx, ids)
+	if err != nil || len(users) != writers {
+		t.Errorf("Expected %d users, got %d, %v", writers, len(users), err)
+	}
+	tweets, err := repos.Tweets.GetByUserIDs(ctx, ids)
+	if err != nil || len(tweets) != writers {
+		t.Errorf("Expected %d tweets, got %d, %v", writers, len(tweets), err)
+	}
+	followers, err := repos.Follows.GetFollowersByFolloweeIDs(ctx, []string{"hub"})
+	if err != nil || !slices.Equal(followers["hub"], ids) {
+		t.Errorf("Expected every writer to follow the hub, got %v, %v", followers["hub"], err)
+	}
+	followees, err := repos.Follows.GetFollowees(ctx, "hub")
+	if err != nil || !slices.Equal(sorted(followees), ids) {
+		t.Errorf("Expected the hub to follow every writer, got %v, %v", followees, err)
+	}
+}
+
+func testContextCancellation(t *testing.T, repos Repositories) {
+	background := context.Background()
+	follow(t, repos, [2]string{"alice", "bob"})
+
+	ctx, cancel := context.WithCancel(background)
+	cancel()
+	tweet := &domain.Tweet{ID: "tweet1", UserID: "alice", Content: "Hello", CreatedAt: time.Now()}
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"Users.Create", func() error {
+			return repos.Users.Create(ctx, &domain.User{ID: "alice", Name: "alice"})
+		}},
+		{"Users.GetByID", func() error {
+			_, err := repos.Users.GetByID(ctx, "alice")
+			return err
+		}},
+		{"Users.GetByIDs", func() error {
+			_, err := repos.Users.GetByIDs(ctx, []string{"alice"})
+			return err
+		}},
+		{"Tweets.Create", func() error {
+			return repos.Tweets.Create(ctx, tweet)
+		}},
+		{"Tweets.GetByID", func() error {
+			_, err := repos.Tweets.GetByID(ctx, tweet.ID)
+			return err
+		}},
+		{"Tweets.GetByUserID", func() error {
+			_, err := repos.Tweets.GetByUserID(ctx, "alice")
+			return err
+		}},
+		{"Tweets.GetByUserIDs", func() error {
+			_, err := repos.Tweets.GetByUserIDs(ctx, []string{"alice"})
+			return err
+		}},
+		{"Tweets.GetPageByUserIDs", func() error {
+			_, err := repos.Tweets.GetPageByUserIDs(ctx, []string{"alice"}, domain.Page{Limit: 10})
+			return err
+		}},
+		{"Follows.Follow", func() error {
+			_, err := repos.Follows.Follow(ctx, "alice", "carol")
+			return err
+		}},
+		{"Follows.Unfollow", func() error {
+			_, err := repos.Follows.Unfollow(ctx, "alice", "bob")
+			return err
+		}},
+		{"Follows.GetFollowees", func() error {
+			_, err := repos.Follows.GetFollowees(ctx, "alice")
+			return err
+		}},
+		{"Follows.GetFolloweesByFollowerIDs", func() error {
+			_, err := repos.Follows.GetFolloweesByFollowerIDs(ctx, []string{"alice"})
+			return err
+		}},
+		{"Follows.GetFollowersByFolloweeIDs", func() error {
+			_, err := repos.Follows.GetFollowersByFolloweeIDs(ctx, []string{"bob"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("Expected %v, got %v", context.Canceled, err)
+			}
+		})
+	}
+
+	if user, _ := repos.Users.GetByID(background, "alice"); user != nil {
+		t.Error("Expected the user not to be created")
+	}
+	if found, _ := repos.Tweets.GetByID(background, tweet.ID); found != nil {
+		t.Error("Expected the tweet not to be created")
+	}
+	if followees, _ := repos.Follows.GetFollowees(background, "alice"); !slices.Equal(followees, []string{"bob"}) {
+		t.Errorf("Expected follows to be unchanged, got %v", followees)
+	}
+}
+
+// follow stores follows as [follower, followee] pairs, in order
+func follow(t *testing.T, repos Repositories, follows ...[2]string) {
+	t.Helper()
+	for _, f := range follows {
+		if _, err := repos.Follows.Follow(context.Background(), f[0], f[1]); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+}
+
+func userIDs(users []*domain.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return sorted(ids)
+}
+
+func tweetIDs(tweets []*domain.Tweet) []string {
+	ids := make([]string, 0, len(tweets))
+	for _, tweet := range tweets {
+		ids = append(ids, tweet.ID)
+	}
+	return ids
+}
+
+func sorted(ids []string) []string {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	return ids
+}
+
+// equalLists reports whether two maps hold the same lists, in the same order
+func equalLists(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, list := range a {
+		expected, ok := b[key]
+		if !ok || list == nil || !slices.Equal(list, expected) {
+			return false
+		}
+	}
+	return true
+}
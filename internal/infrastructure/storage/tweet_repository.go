@@ -22,6 +22,10 @@ func (r *TweetRepository) Create(ctx context.Context, tweet *domain.Tweet) error
 	return r.storage.CreateTweet(ctx, tweet)
 }
 
+func (r *TweetRepository) GetByID(ctx context.Context, id string) (*domain.Tweet, error) {
+	return r.storage.GetTweet(ctx, id)
+}
+
 func (r *TweetRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Tweet, error) {
 	return r.storage.GetTweetsByUserID(ctx, userID)
 }
@@ -29,3 +33,7 @@ func (r *TweetRepository) GetByUserID(ctx context.Context, userID string) ([]*do
 func (r *TweetRepository) GetByUserIDs(ctx context.Context, userIDs []string) ([]*domain.Tweet, error) {
 	return r.storage.GetTweetsByUserIDs(ctx, userIDs)
 }
+
+func (r *TweetRepository) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) ([]*domain.Tweet, error) {
+	return r.storage.GetTweetPageByUserIDs(ctx, userIDs, page)
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"sync"
+
+	"uala-challenge/internal/domain"
+)
+
+// UnitOfWork implements domain.UnitOfWork over in-memory storage. Writes are
+// staged and applied together with the transaction's events under the
+// storage lock. Reads in a transaction see committed data only, and
+// transactions run one at a time so that what one reads stays true until it
+// commits.
+type UnitOfWork struct {
+	storage *InMemoryRepository
+	clock   domain.Clock
+	ids     domain.IDGenerator
+	mutex   sync.Mutex
+}
+
+// UnitOfWorkOption configures optional unit of work settings
+type UnitOfWorkOption func(*UnitOfWork)
+
+// WithUnitOfWorkClock overrides the clock that stamps outbox events
+func WithUnitOfWorkClock(clock domain.Clock) UnitOfWorkOption {
+	return func(u *UnitOfWork) {
+		u.clock = clock
+	}
+}
+
+// WithUnitOfWorkIDGenerator overrides the generator of outbox event IDs
+func WithUnitOfWorkIDGenerator(ids domain.IDGenerator) UnitOfWorkOption {
+	return func(u *UnitOfWork) {
+		u.ids = ids
+	}
+}
+
+// NewUnitOfWork creates a new unit of work
+func NewUnitOfWork(storage *InMemoryRepository, opts ...UnitOfWorkOption) *UnitOfWork {
+	u := &UnitOfWork{
+		storage: storage,
+		clock:   domain.SystemClock{},
+		ids:     domain.UUIDv7Generator{},
+	}
+	for _, opt := range opts {
+		opt(u)
+	}
+	return u
+}
+
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) error {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	tx := &transaction{storage: u.storage}
+	err := fn(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := u.clock.Now()
+	events := make([]*domain.OutboxEvent, 0, len(tx.events))
+	for _, event := range tx.events {
+		stored, err := domain.NewOutboxEvent(u.ids.NewID(), event, now)
+		if err != nil {
+			return err
+		}
+		events = append(events, stored)
+	}
+
+	u.storage.commit(tx.writes, events)
+	return nil
+}
+
+// transaction stages writes as functions applied by commit
+type transaction struct {
+	storage *InMemoryRepository
+	writes  []func(*InMemoryRepository)
+	events  []domain.Event
+}
+
+func (t *transaction) stage(write func(*InMemoryRepository)) {
+	t.writes = append(t.writes, write)
+}
+
+func (t *transaction) Users() domain.UserRepository {
+	return &txUserRepository{UserRepository: NewUserRepository(t.storage), tx: t}
+}
+
+func (t *transaction) Tweets() domain.TweetRepository {
+	return &txTweetRepository{TweetRepository: NewTweetRepository(t.storage), tx: t}
+}
+
+func (t *transaction) Follows() domain.FollowRepository {
+	return &txFollowRepository{FollowRepository: NewFollowRepository(t.storage), tx: t}
+}
+
+func (t *transaction) Record(events ...domain.Event) {
+	t.events = append(t.events, events...)
+}
+
+type txUserRepository struct {
+	*UserRepository
+	tx *transaction
+}
+
+func (r *txUserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.tx.stage(func(s *InMemoryRepository) {
+		s.users[user.ID] = user
+	})
+	return nil
+}
+
+type txTweetRepository struct {
+	*TweetRepository
+	tx *transaction
+}
+
+func (r *txTweetRepository) Create(ctx context.Context, tweet *domain.Tweet) error {
+	r.tx.stage(func(s *InMemoryRepository) {
+		s.tweets[tweet.ID] = tweet
+	})
+	return nil
+}
+
+type txFollowRepository struct {
+	*FollowRepository
+	tx *transaction
+}
+
+func (r *txFollowRepository) Follow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if r.tx.storage.IsFollowing(followerID, followeeID) {
+		return false, nil
+	}
+	r.tx.stage(func(s *InMemoryRepository) {
+		s.followUser(followerID, followeeID)
+	})
+	return true, nil
+}
+
+func (r *txFollowRepository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if !r.tx.storage.IsFollowing(followerID, followeeID) {
+		return false, nil
+	}
+	r.tx.stage(func(s *InMemoryRepository) {
+		s.unfollowUser(followerID, followeeID)
+	})
+	return true, nil
+}
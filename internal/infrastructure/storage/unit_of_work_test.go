@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/domain/domaintest"
+)
+
+func TestUnitOfWork_CommitsWritesWithEvents(t *testing.T) {
+	repo := NewInMemoryRepository()
+	uow := NewUnitOfWork(repo)
+	outbox := NewOutboxRepository(repo)
+	ctx := context.Background()
+
+	tweet := &domain.Tweet{ID: "tweet1", UserID: "alice", Content: "Hello"}
+	err := uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		if err := tx.Tweets().Create(ctx, tweet); err != nil {
+			return err
+		}
+		if followed, err := tx.Follows().Follow(ctx, "alice", "bob"); err != nil || !followed {
+			return fmt.Errorf("expected a new follow, got %v, %w", followed, err)
+		}
+
+		// Staged writes are not visible until the transaction commits
+		if found, _ := tx.Tweets().GetByID(ctx, tweet.ID); found != nil {
+			t.Error("Expected the tweet to be invisible before commit")
+		}
+		tx.Record(domain.TweetCreated{Tweet: tweet}, domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if found, _ := repo.GetTweet(ctx, tweet.ID); found == nil {
+		t.Error("Expected the tweet to be committed")
+	}
+	if followees, _ := repo.GetFollowees(ctx, "alice"); len(followees) != 1 {
+		t.Errorf("Expected the follow to be committed, got %v", followees)
+	}
+	uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		if followed, _ := tx.Follows().Follow(ctx, "alice", "bob"); followed {
+			t.Error("Expected a committed follow not to be reported as new")
+		}
+		if unfollowed, _ := tx.Follows().Unfollow(ctx, "alice", "carol"); unfollowed {
+			t.Error("Expected a missing follow not to be reported as removed")
+		}
+		return nil
+	})
+
+	pending, _ := outbox.GetPending(ctx, 10)
+	if len(pending) != 2 || pending[0].Type != domain.EventTweetCreated || pending[1].Type != domain.EventUserFollowed {
+		t.Fatalf("Expected both events in the outbox in order, got %d", len(pending))
+	}
+	event, err := pending[0].Decode()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if created, ok := event.Event.(domain.TweetCreated); !ok || created.Tweet.ID != tweet.ID {
+		t.Errorf("Expected the TweetCreated event to round-trip, got %#v", event.Event)
+	}
+
+	now := time.Now()
+	if err := outbox.MarkDispatched(ctx, pending[0].ID, now); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if pending, _ = outbox.GetPending(ctx, 10); len(pending) != 1 || pending[0].Type != domain.EventUserFollowed {
+		t.Errorf("Expected only the undispatched event to be pending, got %d", len(pending))
+	}
+	if err := outbox.DeleteDispatchedBefore(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(repo.outbox) != 1 {
+		t.Errorf("Expected the dispatched event to be pruned, got %d events", len(repo.outbox))
+	}
+}
+
+func TestUnitOfWork_RollsBackOnError(t *testing.T) {
+	repo := NewInMemoryRepository()
+	uow := NewUnitOfWork(repo)
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+
+	err := uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Users().Create(ctx, &domain.User{ID: "alice"})
+		tx.Record(domain.UserCreated{User: &domain.User{ID: "alice"}})
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("Expected %v, got %v", errFailed, err)
+	}
+
+	if user, _ := repo.GetUser(ctx, "alice"); user != nil {
+		t.Error("Expected the user not to be stored")
+	}
+	if pending, _ := NewOutboxRepository(repo).GetPending(ctx, 10); len(pending) != 0 {
+		t.Errorf("Expected no events in the outbox, got %d", len(pending))
+	}
+}
+
+func TestUnitOfWork_StampsEventsWithClockAndIDs(t *testing.T) {
+	ctx := context.Background()
+	clock := domaintest.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	repo := NewInMemoryRepository()
+	uow := NewUnitOfWork(repo, WithUnitOfWorkClock(clock), WithUnitOfWorkIDGenerator(domaintest.NewSequentialIDs("event")))
+	outbox := NewOutboxRepository(repo)
+
+	err := uow.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		tx.Record(domain.UserFollowed{FollowerID: "alice", FolloweeID: "bob"})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	pending, _ := outbox.GetPending(ctx, 10)
+	if len(pending) != 1 || pending[0].ID != "event-000001" || !pending[0].OccurredAt.Equal(clock.Now()) {
+		t.Errorf("Expected the event stamped by the clock and ID generator, got %+v", pending)
+	}
+}
@@ -25,3 +25,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	return r.storage.GetUser(ctx, id)
 }
+
+func (r *UserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	return r.storage.GetUsers(ctx, ids)
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// WebhookRepository implements domain.WebhookRepository
+type WebhookRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(storage *InMemoryRepository) *WebhookRepository {
+	return &WebhookRepository{
+		storage: storage,
+	}
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	return r.storage.CreateWebhook(ctx, webhook)
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id string) (*domain.Webhook, error) {
+	return r.storage.GetWebhook(ctx, id)
+}
+
+func (r *WebhookRepository) GetByUserID(ctx context.Context, userID string) ([]*domain.Webhook, error) {
+	return r.storage.GetWebhooksByUserID(ctx, userID)
+}
+
+func (r *WebhookRepository) GetByEvent(ctx context.Context, eventType string) ([]*domain.Webhook, error) {
+	return r.storage.GetWebhooksByEvent(ctx, eventType)
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	return r.storage.DeleteWebhook(ctx, id)
+}
+
+// WebhookDeliveryRepository implements domain.WebhookDeliveryRepository
+type WebhookDeliveryRepository struct {
+	storage *InMemoryRepository
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(storage *InMemoryRepository) *WebhookDeliveryRepository {
+	return &WebhookDeliveryRepository{
+		storage: storage,
+	}
+}
+
+func (r *WebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.storage.CreateWebhookDelivery(ctx, delivery)
+}
+
+func (r *WebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	return r.storage.GetWebhookDelivery(ctx, id)
+}
+
+func (r *WebhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit int) ([]*domain.WebhookDelivery, error) {
+	return r.storage.GetWebhookDeliveriesByWebhookID(ctx, webhookID, limit)
+}
+
+func (r *WebhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.WebhookDelivery, error) {
+	return r.storage.GetDueWebhookDeliveries(ctx, now, limit)
+}
+
+func (r *WebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	return r.storage.UpdateWebhookDelivery(ctx, delivery)
+}
+
+func (r *WebhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) error {
+	return r.storage.DeleteWebhookDeliveriesByWebhookID(ctx, webhookID)
+}
+
+func (r *WebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) error {
+	return r.storage.DeleteFinishedWebhookDeliveries(ctx, t)
+}
+
+// The helpers below are shared by the in-memory and file-backed webhook
+// repositories. Callers must hold the repository's lock.
+
+// copyWebhook returns a copy that shares no memory with webhook
+func copyWebhook(webhook *domain.Webhook) *domain.Webhook {
+	copied := *webhook
+	copied.Events = append([]string(nil), webhook.Events...)
+	return &copied
+}
+
+// filterWebhooks returns copies of the webhooks matching keep, oldest first
+func filterWebhooks(webhooks map[string]*domain.Webhook, keep func(*domain.Webhook) bool) []*domain.Webhook {
+	found := []*domain.Webhook{}
+	for _, webhook := range webhooks {
+		if keep(webhook) {
+			found = append(found, copyWebhook(webhook))
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].CreatedAt.Equal(found[j].CreatedAt) {
+			return found[i].CreatedAt.Before(found[j].CreatedAt)
+		}
+		return found[i].ID < found[j].ID
+	})
+	return found
+}
+
+// webhookLog returns copies of up to limit of a webhook's deliveries, newest
+// first
+func webhookLog(deliveries map[string]*domain.WebhookDelivery, webhookID string, limit int) []*domain.WebhookDelivery {
+	found := []*domain.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		if delivery.WebhookID == webhookID {
+			copied := *delivery
+			found = append(found, &copied)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].CreatedAt.Equal(found[j].CreatedAt) {
+			return found[i].CreatedAt.After(found[j].CreatedAt)
+		}
+		return found[i].ID > found[j].ID
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// hasDelivery reports whether a webhook already has a delivery of an event
+func hasDelivery(deliveries map[string]*domain.WebhookDelivery, webhookID, eventID string) bool {
+	for _, delivery := range deliveries {
+		if delivery.WebhookID == webhookID && delivery.EventID == eventID {
+			return true
+		}
+	}
+	return false
+}
+
+// dueDeliveries returns copies of up to limit pending deliveries due at now,
+// the longest overdue first
+func dueDeliveries(deliveries map[string]*domain.WebhookDelivery, now time.Time, limit int) []*domain.WebhookDelivery {
+	found := []*domain.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		if delivery.Status == domain.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			copied := *delivery
+			found = append(found, &copied)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].NextAttemptAt.Equal(found[j].NextAttemptAt) {
+			return found[i].NextAttemptAt.Before(found[j].NextAttemptAt)
+		}
+		return found[i].ID < found[j].ID
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// deleteDeliveries removes the deliveries matching remove and reports
+// whether any were removed
+func deleteDeliveries(deliveries map[string]*domain.WebhookDelivery, remove func(*domain.WebhookDelivery) bool) bool {
+	removed := false
+	for id, delivery := range deliveries {
+		if remove(delivery) {
+			delete(deliveries, id)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// isFinished reports whether a delivery succeeded or was dead-lettered and
+// was created before t
+func isFinished(delivery *domain.WebhookDelivery, before time.Time) bool {
+	return delivery.Status != domain.DeliveryPending && delivery.CreatedAt.Before(before)
+}
@@ -0,0 +1,463 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/domain"
+)
+
+// The decorators below wrap every repository call in a client span named
+// after the interface and method, and delegate to the wrapped implementation.
+
+type userRepository struct {
+	next   domain.UserRepository
+	tracer trace.Tracer
+}
+
+// TraceUserRepository adds spans to a user repository
+func TraceUserRepository(next domain.UserRepository, tp trace.TracerProvider) domain.UserRepository {
+	return &userRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", user.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, user)
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id string) (user *domain.User, err error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *userRepository) GetByIDs(ctx context.Context, ids []string) (users []*domain.User, err error) {
+	ctx, span := r.tracer.Start(ctx, "UserRepository.GetByIDs", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("users.count", len(ids))))
+	defer func() { end(span, err) }()
+	return r.next.GetByIDs(ctx, ids)
+}
+
+type tweetRepository struct {
+	next   domain.TweetRepository
+	tracer trace.Tracer
+}
+
+// TraceTweetRepository adds spans to a tweet repository
+func TraceTweetRepository(next domain.TweetRepository, tp trace.TracerProvider) domain.TweetRepository {
+	return &tweetRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *tweetRepository) Create(ctx context.Context, tweet *domain.Tweet) (err error) {
+	ctx, span := r.tracer.Start(ctx, "TweetRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tweet.id", tweet.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, tweet)
+}
+
+func (r *tweetRepository) GetByID(ctx context.Context, id string) (tweet *domain.Tweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "TweetRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tweet.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *tweetRepository) GetByUserID(ctx context.Context, userID string) (tweets []*domain.Tweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "TweetRepository.GetByUserID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() {
+		span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return r.next.GetByUserID(ctx, userID)
+}
+
+func (r *tweetRepository) GetByUserIDs(ctx context.Context, userIDs []string) (tweets []*domain.Tweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "TweetRepository.GetByUserIDs", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("users.count", len(userIDs))))
+	defer func() {
+		span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return r.next.GetByUserIDs(ctx, userIDs)
+}
+
+func (r *tweetRepository) GetPageByUserIDs(ctx context.Context, userIDs []string, page domain.Page) (tweets []*domain.Tweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "TweetRepository.GetPageByUserIDs", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("users.count", len(userIDs)), attribute.Int("page.limit", page.Limit)))
+	defer func() {
+		span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return r.next.GetPageByUserIDs(ctx, userIDs, page)
+}
+
+type followRepository struct {
+	next   domain.FollowRepository
+	tracer trace.Tracer
+}
+
+// TraceFollowRepository adds spans to a follow repository
+func TraceFollowRepository(next domain.FollowRepository, tp trace.TracerProvider) domain.FollowRepository {
+	return &followRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *followRepository) Follow(ctx context.Context, followerID, followeeID string) (followed bool, err error) {
+	ctx, span := r.tracer.Start(ctx, "FollowRepository.Follow", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Follow(ctx, followerID, followeeID)
+}
+
+func (r *followRepository) Unfollow(ctx context.Context, followerID, followeeID string) (unfollowed bool, err error) {
+	ctx, span := r.tracer.Start(ctx, "FollowRepository.Unfollow", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Unfollow(ctx, followerID, followeeID)
+}
+
+func (r *followRepository) GetFollowees(ctx context.Context, followerID string) (followees []string, err error) {
+	ctx, span := r.tracer.Start(ctx, "FollowRepository.GetFollowees", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", followerID)))
+	defer func() {
+		span.SetAttributes(attribute.Int("followees.count", len(followees)))
+		end(span, err)
+	}()
+	return r.next.GetFollowees(ctx, followerID)
+}
+
+func (r *followRepository) GetFolloweesByFollowerIDs(ctx context.Context, followerIDs []string) (followees map[string][]string, err error) {
+	ctx, span := r.tracer.Start(ctx, "FollowRepository.GetFolloweesByFollowerIDs", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("users.count", len(followerIDs))))
+	defer func() { end(span, err) }()
+	return r.next.GetFolloweesByFollowerIDs(ctx, followerIDs)
+}
+
+func (r *followRepository) GetFollowersByFolloweeIDs(ctx context.Context, followeeIDs []string) (followers map[string][]string, err error) {
+	ctx, span := r.tracer.Start(ctx, "FollowRepository.GetFollowersByFolloweeIDs", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("users.count", len(followeeIDs))))
+	defer func() { end(span, err) }()
+	return r.next.GetFollowersByFolloweeIDs(ctx, followeeIDs)
+}
+
+type scheduledTweetRepository struct {
+	next   domain.ScheduledTweetRepository
+	tracer trace.Tracer
+}
+
+// TraceScheduledTweetRepository adds spans to a scheduled tweet repository
+func TraceScheduledTweetRepository(next domain.ScheduledTweetRepository, tp trace.TracerProvider) domain.ScheduledTweetRepository {
+	return &scheduledTweetRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *scheduledTweetRepository) Create(ctx context.Context, tweet *domain.ScheduledTweet) (err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("scheduled_tweet.id", tweet.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, tweet)
+}
+
+func (r *scheduledTweetRepository) GetByID(ctx context.Context, id string) (tweet *domain.ScheduledTweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("scheduled_tweet.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *scheduledTweetRepository) GetByUserID(ctx context.Context, userID string) (tweets []*domain.ScheduledTweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.GetByUserID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { end(span, err) }()
+	return r.next.GetByUserID(ctx, userID)
+}
+
+func (r *scheduledTweetRepository) GetDue(ctx context.Context, now time.Time) (tweets []*domain.ScheduledTweet, err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.GetDue", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		span.SetAttributes(attribute.Int("scheduled_tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return r.next.GetDue(ctx, now)
+}
+
+func (r *scheduledTweetRepository) Update(ctx context.Context, tweet *domain.ScheduledTweet) (err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.Update", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("scheduled_tweet.id", tweet.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, tweet)
+}
+
+func (r *scheduledTweetRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := r.tracer.Start(ctx, "ScheduledTweetRepository.Delete", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("scheduled_tweet.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+type pollRepository struct {
+	next   domain.PollRepository
+	tracer trace.Tracer
+}
+
+// TracePollRepository adds spans to a poll repository
+func TracePollRepository(next domain.PollRepository, tp trace.TracerProvider) domain.PollRepository {
+	return &pollRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *pollRepository) Vote(ctx context.Context, tweetID, userID string, option int) (err error) {
+	ctx, span := r.tracer.Start(ctx, "PollRepository.Vote", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tweet.id", tweetID)))
+	defer func() { end(span, err) }()
+	return r.next.Vote(ctx, tweetID, userID, option)
+}
+
+func (r *pollRepository) GetVote(ctx context.Context, tweetID, userID string) (option int, voted bool, err error) {
+	ctx, span := r.tracer.Start(ctx, "PollRepository.GetVote", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tweet.id", tweetID)))
+	defer func() { end(span, err) }()
+	return r.next.GetVote(ctx, tweetID, userID)
+}
+
+func (r *pollRepository) GetTallies(ctx context.Context, tweetID string) (tallies []int, err error) {
+	ctx, span := r.tracer.Start(ctx, "PollRepository.GetTallies", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tweet.id", tweetID)))
+	defer func() { end(span, err) }()
+	return r.next.GetTallies(ctx, tweetID)
+}
+
+type mediaRepository struct {
+	next   domain.MediaRepository
+	tracer trace.Tracer
+}
+
+// TraceMediaRepository adds spans to a media repository
+func TraceMediaRepository(next domain.MediaRepository, tp trace.TracerProvider) domain.MediaRepository {
+	return &mediaRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *domain.Media) (err error) {
+	ctx, span := r.tracer.Start(ctx, "MediaRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("media.id", media.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, media)
+}
+
+func (r *mediaRepository) GetByID(ctx context.Context, id string) (media *domain.Media, err error) {
+	ctx, span := r.tracer.Start(ctx, "MediaRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("media.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+type linkPreviewRepository struct {
+	next   domain.LinkPreviewRepository
+	tracer trace.Tracer
+}
+
+// TraceLinkPreviewRepository adds spans to a link preview repository
+func TraceLinkPreviewRepository(next domain.LinkPreviewRepository, tp trace.TracerProvider) domain.LinkPreviewRepository {
+	return &linkPreviewRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *linkPreviewRepository) Get(ctx context.Context, url string) (preview *domain.LinkPreview, err error) {
+	ctx, span := r.tracer.Start(ctx, "LinkPreviewRepository.Get", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Get(ctx, url)
+}
+
+func (r *linkPreviewRepository) Save(ctx context.Context, preview *domain.LinkPreview) (err error) {
+	ctx, span := r.tracer.Start(ctx, "LinkPreviewRepository.Save", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.Save(ctx, preview)
+}
+
+type webhookRepository struct {
+	next   domain.WebhookRepository
+	tracer trace.Tracer
+}
+
+// TraceWebhookRepository adds spans to a webhook repository
+func TraceWebhookRepository(next domain.WebhookRepository, tp trace.TracerProvider) domain.WebhookRepository {
+	return &webhookRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *domain.Webhook) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.id", webhook.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, webhook)
+}
+
+func (r *webhookRepository) GetByID(ctx context.Context, id string) (webhook *domain.Webhook, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *webhookRepository) GetByUserID(ctx context.Context, userID string) (webhooks []*domain.Webhook, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookRepository.GetByUserID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { end(span, err) }()
+	return r.next.GetByUserID(ctx, userID)
+}
+
+func (r *webhookRepository) GetByEvent(ctx context.Context, eventType string) (webhooks []*domain.Webhook, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookRepository.GetByEvent", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.event", eventType)))
+	defer func() {
+		span.SetAttributes(attribute.Int("webhooks.count", len(webhooks)))
+		end(span, err)
+	}()
+	return r.next.GetByEvent(ctx, eventType)
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookRepository.Delete", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+type webhookDeliveryRepository struct {
+	next   domain.WebhookDeliveryRepository
+	tracer trace.Tracer
+}
+
+// TraceWebhookDeliveryRepository adds spans to a webhook delivery repository
+func TraceWebhookDeliveryRepository(next domain.WebhookDeliveryRepository, tp trace.TracerProvider) domain.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *webhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.Create", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook_delivery.id", delivery.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, delivery)
+}
+
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, id string) (delivery *domain.WebhookDelivery, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.GetByID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook_delivery.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *webhookDeliveryRepository) GetByWebhookID(ctx context.Context, webhookID string, limit int) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.GetByWebhookID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.id", webhookID)))
+	defer func() { end(span, err) }()
+	return r.next.GetByWebhookID(ctx, webhookID, limit)
+}
+
+func (r *webhookDeliveryRepository) GetDue(ctx context.Context, now time.Time, limit int) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.GetDue", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		span.SetAttributes(attribute.Int("webhook_deliveries.count", len(deliveries)))
+		end(span, err)
+	}()
+	return r.next.GetDue(ctx, now, limit)
+}
+
+func (r *webhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.Update", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook_delivery.id", delivery.ID)))
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, delivery)
+}
+
+func (r *webhookDeliveryRepository) DeleteByWebhookID(ctx context.Context, webhookID string) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.DeleteByWebhookID", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.id", webhookID)))
+	defer func() { end(span, err) }()
+	return r.next.DeleteByWebhookID(ctx, webhookID)
+}
+
+func (r *webhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, t time.Time) (err error) {
+	ctx, span := r.tracer.Start(ctx, "WebhookDeliveryRepository.DeleteFinishedBefore", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.DeleteFinishedBefore(ctx, t)
+}
+
+type outboxRepository struct {
+	next   domain.OutboxRepository
+	tracer trace.Tracer
+}
+
+// TraceOutboxRepository adds spans to an outbox repository
+func TraceOutboxRepository(next domain.OutboxRepository, tp trace.TracerProvider) domain.OutboxRepository {
+	return &outboxRepository{next: next, tracer: tracer(tp)}
+}
+
+func (r *outboxRepository) GetPending(ctx context.Context, limit int) (events []*domain.OutboxEvent, err error) {
+	ctx, span := r.tracer.Start(ctx, "OutboxRepository.GetPending", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() {
+		span.SetAttributes(attribute.Int("outbox_events.count", len(events)))
+		end(span, err)
+	}()
+	return r.next.GetPending(ctx, limit)
+}
+
+func (r *outboxRepository) MarkDispatched(ctx context.Context, id string, at time.Time) (err error) {
+	ctx, span := r.tracer.Start(ctx, "OutboxRepository.MarkDispatched", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("event.id", id)))
+	defer func() { end(span, err) }()
+	return r.next.MarkDispatched(ctx, id, at)
+}
+
+func (r *outboxRepository) DeleteDispatchedBefore(ctx context.Context, t time.Time) (err error) {
+	ctx, span := r.tracer.Start(ctx, "OutboxRepository.DeleteDispatchedBefore", trace.WithSpanKind(trace.SpanKindClient))
+	defer func() { end(span, err) }()
+	return r.next.DeleteDispatchedBefore(ctx, t)
+}
+
+type unitOfWork struct {
+	next   domain.UnitOfWork
+	tp     trace.TracerProvider
+	tracer trace.Tracer
+}
+
+// TraceUnitOfWork adds a span to every transaction, and spans to the
+// repositories used within it
+func TraceUnitOfWork(next domain.UnitOfWork, tp trace.TracerProvider) domain.UnitOfWork {
+	return &unitOfWork{next: next, tp: tp, tracer: tracer(tp)}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx domain.Transaction) error) (err error) {
+	ctx, span := u.tracer.Start(ctx, "UnitOfWork.Do", trace.WithSpanKind(trace.SpanKindClient))
+	events := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("events.count", events))
+		end(span, err)
+	}()
+	return u.next.Do(ctx, func(ctx context.Context, tx domain.Transaction) error {
+		return fn(ctx, &transaction{Transaction: tx, tp: u.tp, events: &events})
+	})
+}
+
+type transaction struct {
+	domain.Transaction
+	tp     trace.TracerProvider
+	events *int
+}
+
+func (t *transaction) Users() domain.UserRepository {
+	return TraceUserRepository(t.Transaction.Users(), t.tp)
+}
+
+func (t *transaction) Tweets() domain.TweetRepository {
+	return TraceTweetRepository(t.Transaction.Tweets(), t.tp)
+}
+
+func (t *transaction) Follows() domain.FollowRepository {
+	return TraceFollowRepository(t.Transaction.Follows(), t.tp)
+}
+
+func (t *transaction) Record(events ...domain.Event) {
+	*t.events += len(events)
+	t.Transaction.Record(events...)
+}
@@ -0,0 +1,287 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+)
+
+// The decorators below wrap every service call in an internal span named
+// after the service and method, and delegate to the wrapped implementation.
+
+type tweetService struct {
+	next   application.TweetServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceTweetService adds spans to a tweet service
+func TraceTweetService(next application.TweetServiceInterface, tp trace.TracerProvider) application.TweetServiceInterface {
+	return &tweetService{next: next, tracer: tracer(tp)}
+}
+
+func (s *tweetService) CreateTweet(ctx context.Context, req services.CreateTweetRequest) (tweet *domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "TweetService.CreateTweet", trace.WithAttributes(
+		attribute.String("user.id", req.UserID),
+		attribute.Int("media.count", len(req.MediaIDs)),
+		attribute.Bool("tweet.has_poll", req.Poll != nil),
+	))
+	defer func() { end(span, err) }()
+	return s.next.CreateTweet(ctx, req)
+}
+
+func (s *tweetService) GetTweet(ctx context.Context, id string) (tweet *domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "TweetService.GetTweet", trace.WithAttributes(attribute.String("tweet.id", id)))
+	defer func() { end(span, err) }()
+	return s.next.GetTweet(ctx, id)
+}
+
+func (s *tweetService) GetUserTweets(ctx context.Context, userID string) (tweets []*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "TweetService.GetUserTweets", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { end(span, err) }()
+	return s.next.GetUserTweets(ctx, userID)
+}
+
+func (s *tweetService) GetTweetsByUserIDs(ctx context.Context, userIDs []string) (tweets map[string][]*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "TweetService.GetTweetsByUserIDs", trace.WithAttributes(attribute.Int("users.count", len(userIDs))))
+	defer func() { end(span, err) }()
+	return s.next.GetTweetsByUserIDs(ctx, userIDs)
+}
+
+type followService struct {
+	next   application.FollowServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceFollowService adds spans to a follow service
+func TraceFollowService(next application.FollowServiceInterface, tp trace.TracerProvider) application.FollowServiceInterface {
+	return &followService{next: next, tracer: tracer(tp)}
+}
+
+func (s *followService) FollowUser(ctx context.Context, req services.FollowUserRequest) (err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.FollowUser", trace.WithAttributes(
+		attribute.String("user.id", req.FollowerID),
+		attribute.String("followee.id", req.FolloweeID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.FollowUser(ctx, req)
+}
+
+func (s *followService) UnfollowUser(ctx context.Context, req services.FollowUserRequest) (err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.UnfollowUser", trace.WithAttributes(
+		attribute.String("user.id", req.FollowerID),
+		attribute.String("followee.id", req.FolloweeID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.UnfollowUser(ctx, req)
+}
+
+func (s *followService) GetTimeline(ctx context.Context, userID string) (tweets []*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.GetTimeline", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() {
+		span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return s.next.GetTimeline(ctx, userID)
+}
+
+// TraceTimelineSorter adds a span to a timeline sort, so that traces tell its
+// cost apart from the repository calls
+func TraceTimelineSorter(next services.TimelineSorter, tp trace.TracerProvider) services.TimelineSorter {
+	t := tracer(tp)
+	return func(ctx context.Context, tweets []*domain.Tweet) {
+		ctx, span := t.Start(ctx, "FollowService.sortTimeline", trace.WithAttributes(attribute.Int("tweets.count", len(tweets))))
+		defer span.End()
+		next(ctx, tweets)
+	}
+}
+
+func (s *followService) GetTimelinePage(ctx context.Context, userID string, page domain.Page) (tweets []*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.GetTimelinePage", trace.WithAttributes(attribute.String("user.id", userID), attribute.Int("page.limit", page.Limit)))
+	defer func() {
+		span.SetAttributes(attribute.Int("tweets.count", len(tweets)))
+		end(span, err)
+	}()
+	return s.next.GetTimelinePage(ctx, userID, page)
+}
+
+func (s *followService) GetFollowees(ctx context.Context, userIDs []string) (followees map[string][]string, err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.GetFollowees", trace.WithAttributes(attribute.Int("users.count", len(userIDs))))
+	defer func() { end(span, err) }()
+	return s.next.GetFollowees(ctx, userIDs)
+}
+
+func (s *followService) GetFollowers(ctx context.Context, userIDs []string) (followers map[string][]string, err error) {
+	ctx, span := s.tracer.Start(ctx, "FollowService.GetFollowers", trace.WithAttributes(attribute.Int("users.count", len(userIDs))))
+	defer func() { end(span, err) }()
+	return s.next.GetFollowers(ctx, userIDs)
+}
+
+type scheduleService struct {
+	next   application.ScheduleServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceScheduleService adds spans to a scheduled tweet service
+func TraceScheduleService(next application.ScheduleServiceInterface, tp trace.TracerProvider) application.ScheduleServiceInterface {
+	return &scheduleService{next: next, tracer: tracer(tp)}
+}
+
+func (s *scheduleService) ScheduleTweet(ctx context.Context, req services.ScheduleTweetRequest) (tweet *domain.ScheduledTweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "ScheduleService.ScheduleTweet", trace.WithAttributes(attribute.String("user.id", req.UserID)))
+	defer func() { end(span, err) }()
+	return s.next.ScheduleTweet(ctx, req)
+}
+
+func (s *scheduleService) GetScheduledTweets(ctx context.Context, userID string) (tweets []*domain.ScheduledTweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "ScheduleService.GetScheduledTweets", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { end(span, err) }()
+	return s.next.GetScheduledTweets(ctx, userID)
+}
+
+func (s *scheduleService) RescheduleTweet(ctx context.Context, req services.RescheduleTweetRequest) (tweet *domain.ScheduledTweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "ScheduleService.RescheduleTweet", trace.WithAttributes(
+		attribute.String("user.id", req.UserID),
+		attribute.String("scheduled_tweet.id", req.ID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.RescheduleTweet(ctx, req)
+}
+
+func (s *scheduleService) CancelScheduledTweet(ctx context.Context, userID, id string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "ScheduleService.CancelScheduledTweet", trace.WithAttributes(
+		attribute.String("user.id", userID),
+		attribute.String("scheduled_tweet.id", id),
+	))
+	defer func() { end(span, err) }()
+	return s.next.CancelScheduledTweet(ctx, userID, id)
+}
+
+type pollService struct {
+	next   application.PollServiceInterface
+	tracer trace.Tracer
+}
+
+// TracePollService adds spans to a poll service
+func TracePollService(next application.PollServiceInterface, tp trace.TracerProvider) application.PollServiceInterface {
+	return &pollService{next: next, tracer: tracer(tp)}
+}
+
+func (s *pollService) Vote(ctx context.Context, req services.VotePollRequest) (tweet *domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.Vote", trace.WithAttributes(
+		attribute.String("user.id", req.UserID),
+		attribute.String("tweet.id", req.TweetID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.Vote(ctx, req)
+}
+
+func (s *pollService) WithResults(ctx context.Context, viewerID string, tweets []*domain.Tweet) (results []*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "PollService.WithResults", trace.WithAttributes(attribute.Int("tweets.count", len(tweets))))
+	defer func() { end(span, err) }()
+	return s.next.WithResults(ctx, viewerID, tweets)
+}
+
+type mediaService struct {
+	next   application.MediaServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceMediaService adds spans to a media service
+func TraceMediaService(next application.MediaServiceInterface, tp trace.TracerProvider) application.MediaServiceInterface {
+	return &mediaService{next: next, tracer: tracer(tp)}
+}
+
+func (s *mediaService) Upload(ctx context.Context, req services.UploadMediaRequest) (media *domain.Media, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.Upload", trace.WithAttributes(
+		attribute.String("user.id", req.UserID),
+		attribute.Int("media.size", len(req.Data)),
+	))
+	defer func() { end(span, err) }()
+	return s.next.Upload(ctx, req)
+}
+
+func (s *mediaService) GetMedia(ctx context.Context, id string) (media *domain.Media, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.GetMedia", trace.WithAttributes(attribute.String("media.id", id)))
+	defer func() { end(span, err) }()
+	return s.next.GetMedia(ctx, id)
+}
+
+func (s *mediaService) OpenMedia(ctx context.Context, id string, thumbnail bool) (content *services.MediaContent, err error) {
+	ctx, span := s.tracer.Start(ctx, "MediaService.OpenMedia", trace.WithAttributes(
+		attribute.String("media.id", id),
+		attribute.Bool("media.thumbnail", thumbnail),
+	))
+	defer func() { end(span, err) }()
+	return s.next.OpenMedia(ctx, id, thumbnail)
+}
+
+type linkPreviewService struct {
+	next   application.LinkPreviewServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceLinkPreviewService adds spans to a link preview service
+func TraceLinkPreviewService(next application.LinkPreviewServiceInterface, tp trace.TracerProvider) application.LinkPreviewServiceInterface {
+	return &linkPreviewService{next: next, tracer: tracer(tp)}
+}
+
+func (s *linkPreviewService) WithCards(ctx context.Context, tweets []*domain.Tweet) (results []*domain.Tweet, err error) {
+	ctx, span := s.tracer.Start(ctx, "LinkPreviewService.WithCards", trace.WithAttributes(attribute.Int("tweets.count", len(tweets))))
+	defer func() { end(span, err) }()
+	return s.next.WithCards(ctx, tweets)
+}
+
+type webhookService struct {
+	next   application.WebhookServiceInterface
+	tracer trace.Tracer
+}
+
+// TraceWebhookService adds spans to a webhook service
+func TraceWebhookService(next application.WebhookServiceInterface, tp trace.TracerProvider) application.WebhookServiceInterface {
+	return &webhookService{next: next, tracer: tracer(tp)}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, req services.CreateWebhookRequest) (webhook *domain.Webhook, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.CreateWebhook", trace.WithAttributes(attribute.String("user.id", req.UserID)))
+	defer func() { end(span, err) }()
+	return s.next.CreateWebhook(ctx, req)
+}
+
+func (s *webhookService) GetWebhooks(ctx context.Context, userID string) (webhooks []*domain.Webhook, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.GetWebhooks", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer func() { end(span, err) }()
+	return s.next.GetWebhooks(ctx, userID)
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.DeleteWebhook", trace.WithAttributes(
+		attribute.String("user.id", userID),
+		attribute.String("webhook.id", id),
+	))
+	defer func() { end(span, err) }()
+	return s.next.DeleteWebhook(ctx, userID, id)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, userID, webhookID string) (deliveries []*domain.WebhookDelivery, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.GetDeliveries", trace.WithAttributes(
+		attribute.String("user.id", userID),
+		attribute.String("webhook.id", webhookID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.GetDeliveries(ctx, userID, webhookID)
+}
+
+func (s *webhookService) RetryDelivery(ctx context.Context, userID, webhookID, deliveryID string) (delivery *domain.WebhookDelivery, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.RetryDelivery", trace.WithAttributes(
+		attribute.String("user.id", userID),
+		attribute.String("webhook.id", webhookID),
+		attribute.String("webhook_delivery.id", deliveryID),
+	))
+	defer func() { end(span, err) }()
+	return s.next.RetryDelivery(ctx, userID, webhookID, deliveryID)
+}
@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"time"
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+func newTestProvider() (*sdktrace.TracerProvider, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	return sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)), exporter
+}
+
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	byName := make(map[string]tracetest.SpanStub)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	return byName
+}
+
+func TestDecorators_SpanHierarchy(t *testing.T) {
+	tp, exporter := newTestProvider()
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetRepo := TraceTweetRepository(storage.NewTweetRepository(inMemoryStorage), tp)
+	followRepo := TraceFollowRepository(storage.NewFollowRepository(inMemoryStorage), tp)
+	followService := TraceFollowService(services.NewFollowService(followRepo, tweetRepo,
+		services.WithTimelineSorter(TraceTimelineSorter(services.SortTimeline, tp))), tp)
+	ctx := context.Background()
+
+	tweet, _ := domain.NewTweet("tweet1", "alice", "Hello", time.Now())
+	tweetRepo.Create(ctx, tweet)
+	followService.FollowUser(ctx, services.FollowUserRequest{FollowerID: "bob", FolloweeID: "alice"})
+	exporter.Reset()
+
+	timeline, err := followService.GetTimeline(ctx, "bob")
+	if err != nil || len(timeline) != 1 {
+		t.Fatalf("Expected decorated service to delegate, got %d tweets, %v", len(timeline), err)
+	}
+
+	spans := spansByName(exporter.GetSpans())
+	root, ok := spans["FollowService.GetTimeline"]
+	if !ok {
+		t.Fatalf("Expected a service span, got %v", spans)
+	}
+	for _, name := range []string{"FollowRepository.GetFollowees", "TweetRepository.GetByUserIDs", "FollowService.sortTimeline"} {
+		child, ok := spans[name]
+		if !ok {
+			t.Errorf("Expected a %s span", name)
+			continue
+		}
+		if child.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("Expected %s to be a child of the service span", name)
+		}
+		if child.SpanContext.TraceID() != root.SpanContext.TraceID() {
+			t.Errorf("Expected %s to share the trace", name)
+		}
+	}
+
+	var count int64
+	for _, attr := range root.Attributes {
+		if attr.Key == "tweets.count" {
+			count = attr.Value.AsInt64()
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected tweets.count 1, got %d", count)
+	}
+}
+
+func TestTraceUnitOfWork(t *testing.T) {
+	tp, exporter := newTestProvider()
+	inMemoryStorage := storage.NewInMemoryRepository()
+	uow := TraceUnitOfWork(storage.NewUnitOfWork(inMemoryStorage), tp)
+	tweetService := services.NewTweetService(storage.NewTweetRepository(inMemoryStorage), storage.NewUserRepository(inMemoryStorage),
+		services.WithUnitOfWork(uow))
+
+	if _, err := tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice", Content: "Hello"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spans := spansByName(exporter.GetSpans())
+	root, ok := spans["UnitOfWork.Do"]
+	if !ok {
+		t.Fatalf("Expected a unit of work span, got %v", spans)
+	}
+	for _, name := range []string{"UserRepository.Create", "TweetRepository.Create"} {
+		if child, ok := spans[name]; !ok || child.Parent.SpanID() != root.SpanContext.SpanID() {
+			t.Errorf("Expected a %s span in the unit of work", name)
+		}
+	}
+	for _, attr := range root.Attributes {
+		if attr.Key == "events.count" && attr.Value.AsInt64() != 2 {
+			t.Errorf("Expected events.count 2, got %d", attr.Value.AsInt64())
+		}
+	}
+}
+
+func TestDecorators_RecordErrors(t *testing.T) {
+	tp, exporter := newTestProvider()
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetService := TraceTweetService(services.NewTweetService(storage.NewTweetRepository(inMemoryStorage), storage.NewUserRepository(inMemoryStorage)), tp)
+
+	if _, err := tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice"}); err == nil {
+		t.Fatal("Expected an error for an empty tweet")
+	}
+
+	span, ok := spansByName(exporter.GetSpans())["TweetService.CreateTweet"]
+	if !ok {
+		t.Fatal("Expected a CreateTweet span")
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("Expected error status, got %v", span.Status.Code)
+	}
+	if len(span.Events) == 0 || span.Events[0].Name != "exception" {
+		t.Errorf("Expected the error to be recorded as an event, got %v", span.Events)
+	}
+}
+
+func TestSetup(t *testing.T) {
+	t.Run("none", func(t *testing.T) {
+		tp, shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+		if err != nil || tp == nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Errorf("Unexpected shutdown error: %v", err)
+		}
+	})
+
+	t.Run("stdout", func(t *testing.T) {
+		var output bytes.Buffer
+		tp, shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "test", Output: &output})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		_, span := tp.Tracer("test").Start(context.Background(), "test-span")
+		span.End()
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("Unexpected shutdown error: %v", err)
+		}
+
+		if !strings.Contains(output.String(), `"Name":"test-span"`) {
+			t.Errorf("Expected span on stdout, got %s", output.String())
+		}
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		if _, _, err := Setup(context.Background(), Config{Exporter: "zipkin"}); err == nil {
+			t.Error("Expected an error for an unknown exporter")
+		}
+	})
+}
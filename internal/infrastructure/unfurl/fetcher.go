@@ -0,0 +1,299 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+
+	"uala-challenge/internal/domain"
+)
+
+// Fetch errors
+var (
+	ErrUnsupportedScheme = errors.New("only http and https URLs can be unfurled")
+	ErrDisallowedAddress = errors.New("destination address is not allowed")
+	ErrNotHTML           = errors.New("response is not an HTML document")
+	ErrUnexpectedStatus  = errors.New("unexpected response status")
+	ErrNoPreviewMetadata = errors.New("document has no preview metadata")
+)
+
+// Options configures the HTTP fetcher
+type Options struct {
+	// Timeout bounds the whole fetch, including redirects and reading the body
+	Timeout time.Duration
+	// MaxBodySize is the maximum number of bytes read from a response
+	MaxBodySize int64
+	// MaxRedirects is the maximum number of redirects followed
+	MaxRedirects int
+	// AllowPrivateNetworks disables SSRF protection. Only tests should set it.
+	AllowPrivateNetworks bool
+}
+
+// DefaultOptions returns strict defaults suitable for fetching untrusted URLs
+func DefaultOptions() Options {
+	return Options{
+		Timeout:      5 * time.Second,
+		MaxBodySize:  512 << 10,
+		MaxRedirects: 3,
+	}
+}
+
+// HTTPFetcher fetches OpenGraph and Twitter-card metadata over HTTP. It
+// refuses to connect to private, loopback and link-local addresses; the check
+// runs on the resolved address at dial time so DNS rebinding cannot bypass it.
+type HTTPFetcher struct {
+	client  *http.Client
+	options Options
+}
+
+// NewHTTPFetcher creates a new HTTP preview fetcher
+func NewHTTPFetcher(options Options) *HTTPFetcher {
+	return &HTTPFetcher{
+		client:  NewClient(options),
+		options: options,
+	}
+}
+
+// NewClient creates an HTTP client for requests to untrusted URLs. It
+// enforces the timeout and redirect limit of options, only follows
+// redirects to http and https URLs and, unless AllowPrivateNetworks is set,
+// refuses to connect to non-public addresses.
+func NewClient(options Options) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: options.Timeout,
+	}
+	if !options.AllowPrivateNetworks {
+		dialer.Control = guardAddress
+	}
+
+	transport := &http.Transport{
+		// Never use a proxy: the dial-time guard must see the real destination
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   options.Timeout,
+		ResponseHeaderTimeout: options.Timeout,
+		MaxIdleConns:          16,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   options.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > options.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", options.MaxRedirects)
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+// Fetch retrieves the document at rawURL and extracts its preview metadata
+func (f *HTTPFetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScheme(target); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", "uala-microblog-unfurler/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedStatus, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return nil, ErrNotHTML
+	}
+
+	preview := parseMetadata(io.LimitReader(resp.Body, f.options.MaxBodySize), resp.Request.URL)
+	if preview.Title == "" && preview.Description == "" && preview.ImageURL == "" {
+		return nil, ErrNoPreviewMetadata
+	}
+
+	preview.URL = rawURL
+	return preview, nil
+}
+
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ErrUnsupportedScheme
+	}
+	return nil
+}
+
+// guardAddress rejects connections to non-public addresses
+func guardAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrDisallowedAddress, host)
+	}
+	return nil
+}
+
+// deniedRanges are the IANA special-purpose ranges (RFC 6890 and its
+// registries) that are not globally reachable, plus the translation prefixes
+// that embed an IPv4 address and so could reach any of them
+var deniedRanges = []netip.Prefix{
+	// IPv4
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("10.0.0.0/8"),      // private
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT
+	netip.MustParsePrefix("127.0.0.0/8"),     // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // link-local, cloud metadata
+	netip.MustParsePrefix("172.16.0.0/12"),   // private
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // private
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("224.0.0.0/4"),     // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved, broadcast
+
+	// IPv6
+	netip.MustParsePrefix("::/96"),          // unspecified, loopback, IPv4-compatible
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("100::/64"),       // discard-only
+	netip.MustParsePrefix("2001::/23"),      // IETF protocol assignments, Teredo
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4
+	netip.MustParsePrefix("3fff::/20"),      // documentation
+	netip.MustParsePrefix("5f00::/16"),      // segment routing
+	netip.MustParsePrefix("fc00::/7"),       // unique local
+	netip.MustParsePrefix("fe80::/10"),      // link-local
+	netip.MustParsePrefix("fec0::/10"),      // site-local
+	netip.MustParsePrefix("ff00::/8"),       // multicast
+}
+
+// isPublicIP reports whether ip is outside every denied range. IPv4-mapped
+// IPv6 addresses are checked as the IPv4 address they carry.
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range deniedRanges {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseMetadata extracts OpenGraph metadata, falling back to Twitter-card
+// metadata and then to the document title and description. Only the head of
+// the document is tokenized.
+func parseMetadata(body io.Reader, base *url.URL) *domain.LinkPreview {
+	meta := make(map[string]string)
+	var title string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return buildPreview(meta, title, base)
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "meta":
+				key, content := metaAttributes(token)
+				if key != "" && meta[key] == "" {
+					meta[key] = strings.TrimSpace(content)
+				}
+			case "title":
+				inTitle = title == ""
+			case "body":
+				return buildPreview(meta, title, base)
+			}
+		case html.TextToken:
+			if inTitle {
+				title = strings.TrimSpace(string(tokenizer.Text()))
+				inTitle = false
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			if string(name) == "head" {
+				return buildPreview(meta, title, base)
+			}
+			inTitle = false
+		}
+	}
+}
+
+func metaAttributes(token html.Token) (key, content string) {
+	for _, attr := range token.Attr {
+		switch attr.Key {
+		case "property", "name":
+			if key == "" {
+				key = strings.ToLower(attr.Val)
+			}
+		case "content":
+			content = attr.Val
+		}
+	}
+	return key, content
+}
+
+func buildPreview(meta map[string]string, title string, base *url.URL) *domain.LinkPreview {
+	first := func(values ...string) string {
+		for _, v := range values {
+			if v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	preview := &domain.LinkPreview{
+		Title:       first(meta["og:title"], meta["twitter:title"], title),
+		Description: first(meta["og:description"], meta["twitter:description"], meta["description"]),
+		SiteName:    meta["og:site_name"],
+	}
+
+	if image := first(meta["og:image"], meta["og:image:url"], meta["twitter:image"], meta["twitter:image:src"]); image != "" {
+		if ref, err := url.Parse(image); err == nil {
+			resolved := base.ResolveReference(ref)
+			if resolved.Scheme == "http" || resolved.Scheme == "https" {
+				preview.ImageURL = resolved.String()
+			}
+		}
+	}
+
+	return preview
+}
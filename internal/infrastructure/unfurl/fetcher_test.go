@@ -0,0 +1,196 @@
+package unfurl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// testOptions allows fetching from httptest servers on loopback
+func testOptions() Options {
+	options := DefaultOptions()
+	options.AllowPrivateNetworks = true
+	return options
+}
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/og", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<!doctype html><html><head>
+			<title>Fallback title</title>
+			<meta property="og:title" content="Open Graph title">
+			<meta property="og:description" content="A &amp; B">
+			<meta property="og:image" content="/images/card.png">
+			<meta property="og:site_name" content="Example">
+			<meta name="twitter:title" content="Twitter title">
+		</head><body><meta property="og:title" content="ignored"></body></html>`))
+	})
+	mux.HandleFunc("/twitter", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head>
+			<meta name="twitter:title" content="Twitter title">
+			<meta name="twitter:description" content="Twitter description">
+			<meta name="twitter:image" content="https://cdn.example.com/t.png">
+		</head></html>`))
+	})
+	mux.HandleFunc("/title-only", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Just a title</title><meta name="description" content="Plain description"></head></html>`))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!-- " + strings.Repeat("x", 2<<20) + ` --><meta property="og:title" content="Too far"></head></html>`))
+	})
+	mux.HandleFunc("/redirect-file", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "file:///etc/passwd", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(testOptions())
+	ctx := context.Background()
+
+	t.Run("open graph metadata", func(t *testing.T) {
+		preview, err := fetcher.Fetch(ctx, server.URL+"/og")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if preview.Title != "Open Graph title" {
+			t.Errorf("Expected Open Graph title, got %q", preview.Title)
+		}
+		if preview.Description != "A & B" {
+			t.Errorf("Expected unescaped description, got %q", preview.Description)
+		}
+		if preview.ImageURL != server.URL+"/images/card.png" {
+			t.Errorf("Expected resolved image URL, got %q", preview.ImageURL)
+		}
+		if preview.SiteName != "Example" {
+			t.Errorf("Expected site name Example, got %q", preview.SiteName)
+		}
+	})
+
+	t.Run("twitter card fallback", func(t *testing.T) {
+		preview, err := fetcher.Fetch(ctx, server.URL+"/twitter")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if preview.Title != "Twitter title" || preview.Description != "Twitter description" || preview.ImageURL != "https://cdn.example.com/t.png" {
+			t.Errorf("Unexpected preview: %+v", preview)
+		}
+	})
+
+	t.Run("title and description fallback", func(t *testing.T) {
+		preview, err := fetcher.Fetch(ctx, server.URL+"/title-only")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if preview.Title != "Just a title" || preview.Description != "Plain description" {
+			t.Errorf("Unexpected preview: %+v", preview)
+		}
+	})
+
+	t.Run("non html is rejected", func(t *testing.T) {
+		if _, err := fetcher.Fetch(ctx, server.URL+"/json"); !errors.Is(err, ErrNotHTML) {
+			t.Errorf("Expected %v, got %v", ErrNotHTML, err)
+		}
+	})
+
+	t.Run("error status is rejected", func(t *testing.T) {
+		if _, err := fetcher.Fetch(ctx, server.URL+"/missing"); !errors.Is(err, ErrUnexpectedStatus) {
+			t.Errorf("Expected %v, got %v", ErrUnexpectedStatus, err)
+		}
+	})
+
+	t.Run("body beyond size limit is ignored", func(t *testing.T) {
+		if _, err := fetcher.Fetch(ctx, server.URL+"/huge"); !errors.Is(err, ErrNoPreviewMetadata) {
+			t.Errorf("Expected %v, got %v", ErrNoPreviewMetadata, err)
+		}
+	})
+
+	t.Run("unsupported schemes are rejected", func(t *testing.T) {
+		if _, err := fetcher.Fetch(ctx, "ftp://example.com/file"); !errors.Is(err, ErrUnsupportedScheme) {
+			t.Errorf("Expected %v, got %v", ErrUnsupportedScheme, err)
+		}
+		if _, err := fetcher.Fetch(ctx, server.URL+"/redirect-file"); !errors.Is(err, ErrUnsupportedScheme) {
+			t.Errorf("Expected %v for redirect, got %v", ErrUnsupportedScheme, err)
+		}
+	})
+}
+
+func TestHTTPFetcher_BlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Request should never reach a loopback server")
+	}))
+	defer server.Close()
+
+	fetcher := NewHTTPFetcher(DefaultOptions())
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	if !errors.Is(err, ErrDisallowedAddress) {
+		t.Errorf("Expected %v, got %v", ErrDisallowedAddress, err)
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip     string
+		public bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"192.0.0.170", false},
+		{"192.0.2.1", false},
+		{"192.88.99.1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"198.51.100.1", false},
+		{"203.0.113.1", false},
+		{"224.0.0.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"198.20.0.1", true},
+		{"::", false},
+		{"::1", false},
+		{"::127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b:1::1", false},
+		{"100::1", false},
+		{"2001::1", false},
+		{"2001:db8::1", false},
+		{"2002:a00:1::1", false},
+		{"3fff::1", false},
+		{"5f00::1", false},
+		{"fc00::1", false},
+		{"fe80::1", false},
+		{"fec0::1", false},
+		{"ff02::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:198.18.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.public {
+				t.Errorf("Expected public=%v for %s, got %v", tt.public, tt.ip, got)
+			}
+		})
+	}
+}
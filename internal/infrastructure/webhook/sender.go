@@ -0,0 +1,125 @@
+// Package webhook posts signed webhook deliveries over HTTP.
+//
+// Every request carries the delivery's event as its JSON body and these
+// headers:
+//
+//	X-Webhook-ID         the delivery ID, one per event and webhook
+//	X-Webhook-Event      the event type, such as tweet.created
+//	X-Webhook-Timestamp  the Unix time of the attempt, in seconds
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>">
+//
+// Receivers should recompute the signature with their secret, compare it in
+// constant time and reject old timestamps to prevent replays.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/unfurl"
+)
+
+// Request headers set on every delivery
+const (
+	HeaderID        = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const signaturePrefix = "sha256="
+
+// maxResponseBody is how much of a response is read before the connection
+// is reused
+const maxResponseBody = 64 << 10
+
+// Sender posts webhook deliveries. It implements services.WebhookSender.
+type Sender struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// Option configures optional sender behaviour
+type Option func(*Sender)
+
+// WithHTTPClient overrides the client used to post deliveries. The default
+// refuses to connect to private networks.
+func WithHTTPClient(client *http.Client) Option {
+	return func(s *Sender) {
+		s.client = client
+	}
+}
+
+// NewSender creates a new webhook sender. Redirects are never followed,
+// whatever the client, since a redirected POST would be replayed as a GET;
+// they fail the attempt instead.
+func NewSender(opts ...Option) *Sender {
+	s := &Sender{
+		client: unfurl.NewClient(unfurl.DefaultOptions()),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	client := *s.client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	s.client = &client
+	return s
+}
+
+// Send posts a delivery to its webhook. It returns the response status, or
+// zero when no response was received, and an error unless the status is
+// 2xx.
+func (s *Sender) Send(ctx context.Context, webhook *domain.Webhook, delivery *domain.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "uala-microblog-webhooks/1.0")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Webhook-Signature value of a payload sent at timestamp
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the X-Webhook-Signature of a payload
+// sent at timestamp. It does not check how old timestamp is.
+func Verify(secret, timestamp string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, payload)), []byte(signature))
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+var testClient = &http.Client{Timeout: 5 * time.Second}
+
+func testDelivery(t *testing.T, url string) (*domain.Webhook, *domain.WebhookDelivery) {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	webhook, err := domain.NewWebhook("webhook1", "user1", url, "0123456789abcdef", []string{domain.EventTweetCreated}, now)
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	event := domain.NewWebhookEvent("event1", domain.EventTweetCreated, nil, now)
+	return webhook, domain.NewWebhookDelivery("delivery1", webhook.ID, event, []byte(`{"type":"tweet.created"}`), now)
+}
+
+func TestSender_Send(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	webhook, delivery := testDelivery(t, server.URL)
+	sender := NewSender(WithHTTPClient(testClient))
+	status, err := sender.Send(context.Background(), webhook, delivery)
+	if err != nil || status != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d, %v", http.StatusNoContent, status, err)
+	}
+
+	if string(body) != string(delivery.Payload) {
+		t.Errorf("Expected the payload as body, got %s", body)
+	}
+	if received.Header.Get(HeaderID) != delivery.ID || received.Header.Get(HeaderEvent) != domain.EventTweetCreated {
+		t.Error("Expected the delivery ID and event type headers")
+	}
+	timestamp := received.Header.Get(HeaderTimestamp)
+	signature := received.Header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, "sha256=") || !Verify(webhook.Secret, timestamp, body, signature) {
+		t.Errorf("Expected a valid signature, got %q", signature)
+	}
+	if Verify("another secret!!", timestamp, body, signature) || Verify(webhook.Secret, timestamp, []byte(`{}`), signature) {
+		t.Error("Expected the signature to depend on the secret and payload")
+	}
+}
+
+func TestSender_Failures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		url    string
+		sender *Sender
+		status int
+	}{
+		{"server error", server.URL, NewSender(WithHTTPClient(testClient)), http.StatusInternalServerError},
+		{"redirect", server.URL + "/moved", NewSender(WithHTTPClient(testClient)), http.StatusFound},
+		{"private network", server.URL, NewSender(), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook, delivery := testDelivery(t, tt.url)
+			status, err := tt.sender.Send(context.Background(), webhook, delivery)
+			if err == nil || status != tt.status {
+				t.Errorf("Expected status %d and an error, got %d, %v", tt.status, status, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"html"
+	"strings"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// Media types of ActivityPub objects and WebFinger documents
+const (
+	activityContentType  = "application/activity+json"
+	ldJSONContentType    = `application/ld+json; profile="https://www.w3.org/ns/activitystreams"`
+	webFingerContentType = "application/jrd+json"
+)
+
+// JSON-LD contexts and the special collection addressing everyone
+const (
+	activityStreamsContext = "https://www.w3.org/ns/activitystreams"
+	securityContext        = "https://w3id.org/security/v1"
+	publicCollection       = "https://www.w3.org/ns/activitystreams#Public"
+)
+
+// Activity types handled by the inbox
+const (
+	typeFollow = "Follow"
+	typeUndo   = "Undo"
+	typeAccept = "Accept"
+	typeCreate = "Create"
+)
+
+// Actor is the ActivityPub representation of a local user
+type Actor struct {
+	Context           []string  `json:"@context"`
+	ID                string    `json:"id"`
+	Type              string    `json:"type"`
+	PreferredUsername string    `json:"preferredUsername"`
+	Name              string    `json:"name,omitempty"`
+	Inbox             string    `json:"inbox"`
+	Outbox            string    `json:"outbox"`
+	PublicKey         PublicKey `json:"publicKey"`
+}
+
+// PublicKey is the key that verifies an actor's signed requests
+type PublicKey struct {
+	ID           string `json:"id"`
+	Owner        string `json:"owner"`
+	PublicKeyPEM string `json:"publicKeyPem"`
+}
+
+// Note is the ActivityPub representation of a tweet
+type Note struct {
+	Context      string   `json:"@context,omitempty"`
+	ID           string   `json:"id"`
+	Type         string   `json:"type"`
+	AttributedTo string   `json:"attributedTo"`
+	Content      string   `json:"content"`
+	Published    string   `json:"published"`
+	To           []string `json:"to"`
+	CC           []string `json:"cc"`
+}
+
+// Activity is an activity sent by a local actor
+type Activity struct {
+	Context   string      `json:"@context,omitempty"`
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	Actor     string      `json:"actor"`
+	Object    interface{} `json:"object"`
+	Published string      `json:"published,omitempty"`
+	To        []string    `json:"to,omitempty"`
+	CC        []string    `json:"cc,omitempty"`
+}
+
+// OrderedCollection lists a local actor's activities, newest first
+type OrderedCollection struct {
+	Context      string     `json:"@context"`
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	TotalItems   int        `json:"totalItems"`
+	OrderedItems []Activity `json:"orderedItems"`
+}
+
+// WebFinger is the JSON Resource Descriptor (RFC 7033) that maps an
+// acct: URI to an actor
+type WebFinger struct {
+	Subject string          `json:"subject"`
+	Aliases []string        `json:"aliases"`
+	Links   []WebFingerLink `json:"links"`
+}
+
+// WebFingerLink is a link of a WebFinger document
+type WebFingerLink struct {
+	Rel  string `json:"rel"`
+	Type string `json:"type"`
+	Href string `json:"href"`
+}
+
+// incomingActivity is an activity received in an inbox. Actor and object
+// may be IDs or embedded objects, so both are decoded lazily.
+type incomingActivity struct {
+	ID     string          `json:"id"`
+	Type   string          `json:"type"`
+	Actor  json.RawMessage `json:"actor"`
+	Object json.RawMessage `json:"object"`
+}
+
+// objectID returns the ID of a reference that is either an ID string or an
+// object with an id property
+func objectID(raw json.RawMessage) string {
+	var id string
+	if json.Unmarshal(raw, &id) == nil {
+		return id
+	}
+	var object struct {
+		ID string `json:"id"`
+	}
+	if json.Unmarshal(raw, &object) == nil {
+		return object.ID
+	}
+	return ""
+}
+
+// noteContent renders tweet text as the HTML content of a note
+func noteContent(text string) string {
+	escaped := html.EscapeString(text)
+	return "<p>" + strings.ReplaceAll(escaped, "\n", "<br>") + "</p>"
+}
+
+// note converts a tweet into a public note
+func (f *Federation) note(tweet *domain.Tweet) Note {
+	return Note{
+		ID:           f.noteURL(tweet.ID),
+		Type:         "Note",
+		AttributedTo: f.actorURL(tweet.UserID),
+		Content:      noteContent(tweet.Content),
+		Published:    tweet.CreatedAt.UTC().Format(time.RFC3339),
+		To:           []string{publicCollection},
+		CC:           []string{},
+	}
+}
+
+// create wraps a tweet's note in the Create activity that announces it
+func (f *Federation) create(tweet *domain.Tweet) Activity {
+	note := f.note(tweet)
+	return Activity{
+		ID:        note.ID + "/activity",
+		Type:      typeCreate,
+		Actor:     note.AttributedTo,
+		Object:    note,
+		Published: note.Published,
+		To:        note.To,
+		CC:        note.CC,
+	}
+}
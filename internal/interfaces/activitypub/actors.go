@@ -0,0 +1,152 @@
+package activitypub
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"uala-challenge/internal/application/services"
+)
+
+// ErrInvalidActor is returned when a remote actor cannot be fetched or its
+// document is unusable
+var ErrInvalidActor = errors.New("remote actor could not be resolved")
+
+const (
+	// actorCacheTTL is how long a fetched remote actor is used before it is
+	// fetched again
+	actorCacheTTL = 24 * time.Hour
+	// maxDocumentSize bounds the size of fetched and received documents
+	maxDocumentSize = 1 << 20
+	// userAgent identifies the server in requests to remote servers
+	userAgent = "uala-microblog-activitypub/1.0"
+)
+
+// remoteActor is the part of a remote actor document the server uses
+type remoteActor struct {
+	id          string
+	inbox       string
+	sharedInbox string
+	keyID       string
+	publicKey   *rsa.PublicKey
+	fetchedAt   time.Time
+}
+
+// deliveryInbox is where activities addressed to the actor's followers are
+// sent. Servers with a shared inbox receive them once for all their users.
+func (a *remoteActor) deliveryInbox() string {
+	if a.sharedInbox != "" {
+		return a.sharedInbox
+	}
+	return a.inbox
+}
+
+// actorResolver fetches remote actor documents and caches them by ID
+type actorResolver struct {
+	client *http.Client
+	now    func() time.Time
+
+	mutex  sync.Mutex
+	actors map[string]*remoteActor
+}
+
+func newActorResolver(client *http.Client, now func() time.Time) *actorResolver {
+	return &actorResolver{
+		client: client,
+		now:    now,
+		actors: make(map[string]*remoteActor),
+	}
+}
+
+// resolve returns the remote actor with the given ID. A cached copy is used
+// unless it is stale or refresh is set.
+func (r *actorResolver) resolve(ctx context.Context, id string, refresh bool) (*remoteActor, error) {
+	r.mutex.Lock()
+	cached, ok := r.actors[id]
+	r.mutex.Unlock()
+	if ok && !refresh && r.now().Sub(cached.fetchedAt) < actorCacheTTL {
+		return cached, nil
+	}
+
+	actor, err := r.fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.Lock()
+	r.actors[id] = actor
+	r.mutex.Unlock()
+	return actor, nil
+}
+
+// fetch retrieves and checks the actor document at id
+func (r *actorResolver) fetch(ctx context.Context, id string) (*remoteActor, error) {
+	if !services.IsRemoteActorID(id) {
+		return nil, fmt.Errorf("%w: %q is not an http or https URL", ErrInvalidActor, id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, id, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidActor, err)
+	}
+	req.Header.Set("Accept", activityContentType+", "+ldJSONContentType)
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidActor, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fetching %s: %s", ErrInvalidActor, id, resp.Status)
+	}
+
+	var doc struct {
+		ID        string `json:"id"`
+		Inbox     string `json:"inbox"`
+		Endpoints struct {
+			SharedInbox string `json:"sharedInbox"`
+		} `json:"endpoints"`
+		PublicKey struct {
+			ID           string `json:"id"`
+			Owner        string `json:"owner"`
+			PublicKeyPEM string `json:"publicKeyPem"`
+		} `json:"publicKey"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDocumentSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidActor, err)
+	}
+
+	// A document served at one URL must not speak for another actor
+	if doc.ID != id {
+		return nil, fmt.Errorf("%w: document at %s describes %q", ErrInvalidActor, id, doc.ID)
+	}
+	if !services.IsRemoteActorID(doc.Inbox) {
+		return nil, fmt.Errorf("%w: %s has no inbox", ErrInvalidActor, id)
+	}
+	if doc.PublicKey.Owner != id {
+		return nil, fmt.Errorf("%w: %s does not own its public key", ErrInvalidActor, id)
+	}
+	publicKey, err := decodePublicKey(doc.PublicKey.PublicKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidActor, id, err)
+	}
+
+	actor := &remoteActor{
+		id:        id,
+		inbox:     doc.Inbox,
+		keyID:     doc.PublicKey.ID,
+		publicKey: publicKey,
+		fetchedAt: r.now(),
+	}
+	if services.IsRemoteActorID(doc.Endpoints.SharedInbox) {
+		actor.sharedInbox = doc.Endpoints.SharedInbox
+	}
+	return actor, nil
+}
@@ -0,0 +1,105 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+// apiError is an error with a stable code, rendered like the errors of the
+// REST API: {"error":{"code":...,"message":...,"details":...,"request_id":...}}
+type apiError struct {
+	Status  int                    `json:"-"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+// Request errors raised by the handlers themselves
+var (
+	errMissingResource = &apiError{Status: http.StatusBadRequest, Code: "missing_resource", Message: "WebFinger resource required in resource query parameter"}
+	errUnknownResource = &apiError{Status: http.StatusNotFound, Code: "resource_not_found", Message: "No local account matches the resource"}
+	errActorMismatch   = &apiError{Status: http.StatusForbidden, Code: "actor_mismatch", Message: "Activity actor does not match the request signer"}
+	errFollowTarget    = &apiError{Status: http.StatusBadRequest, Code: "invalid_follow", Message: "Follow activities must target the inbox's owner"}
+	errInternal        = &apiError{Status: http.StatusInternalServerError, Code: "internal_error", Message: "An unexpected error occurred"}
+)
+
+// invalidActivity reports a request body that is not an activity
+func invalidActivity(err error) *apiError {
+	apiErr := &apiError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_activity",
+		Message: "Request body is not a valid activity",
+	}
+	if err != nil {
+		apiErr.Details = map[string]interface{}{"reason": err.Error()}
+	}
+	return apiErr
+}
+
+// knownErrors maps domain and signature errors to their HTTP
+// representation. Errors are matched with errors.Is, so wrapped errors map
+// correctly.
+var knownErrors = []struct {
+	err    error
+	apiErr *apiError
+}{
+	{domain.ErrUserNotFound, &apiError{Status: http.StatusNotFound, Code: "user_not_found", Message: "User not found"}},
+	{domain.ErrTweetNotFound, &apiError{Status: http.StatusNotFound, Code: "tweet_not_found", Message: "Tweet not found"}},
+	{domain.ErrInvalidActorID, &apiError{Status: http.StatusBadRequest, Code: "invalid_actor_id", Message: "Actor ID must be an absolute http or https URL"}},
+
+	{ErrMissingSignature, &apiError{Status: http.StatusUnauthorized, Code: "missing_signature", Message: "Request must carry an HTTP signature"}},
+	{ErrInvalidSignature, &apiError{Status: http.StatusUnauthorized, Code: "invalid_signature", Message: "HTTP signature is invalid"}},
+	{ErrSignatureExpired, &apiError{Status: http.StatusUnauthorized, Code: "signature_expired", Message: "HTTP signature date is outside the accepted window"}},
+	{ErrDigestMismatch, &apiError{Status: http.StatusUnauthorized, Code: "digest_mismatch", Message: "Digest header does not match the request body"}},
+	{ErrInvalidActor, &apiError{Status: http.StatusUnauthorized, Code: "unknown_actor", Message: "Signing actor could not be resolved"}},
+}
+
+// toAPIError converts any error into an apiError, falling back to a
+// generic internal error so that internal details never leak
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, known := range knownErrors {
+		if errors.Is(err, known.err) {
+			return known.apiErr
+		}
+	}
+	return errInternal
+}
+
+// writeError renders err as a JSON error response
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(err)
+	switch {
+	case apiErr.Status >= http.StatusInternalServerError:
+		slog.ErrorContext(r.Context(), "request failed", "error", err)
+	case apiErr.Status == http.StatusUnauthorized:
+		// Signature failures are worth a look when a server cannot federate
+		slog.InfoContext(r.Context(), "activity rejected", "error", err)
+	}
+
+	writeJSON(w, apiErr.Status, "application/json", struct {
+		Error interface{} `json:"error"`
+	}{struct {
+		*apiError
+		RequestID string `json:"request_id,omitempty"`
+	}{apiErr, requestid.FromContext(r.Context())}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, contentType string, body interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,360 @@
+// Package activitypub federates local accounts with Mastodon and the rest of
+// the fediverse. Remote servers discover accounts with WebFinger, fetch
+// actor documents and outboxes, and follow accounts by posting signed Follow
+// activities to their inboxes. New tweets are delivered to remote followers
+// as Create{Note} activities through a queue that retries failed
+// deliveries.
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/unfurl"
+)
+
+// Delivery defaults
+const (
+	// DefaultDeliveryWorkers is the number of concurrent deliveries
+	DefaultDeliveryWorkers = 4
+	// DefaultMaxDeliveryAttempts is how many times an activity is sent to an
+	// inbox before it is dropped
+	DefaultMaxDeliveryAttempts = 8
+	// DefaultRetryBackoff is the wait before the first retry. It doubles
+	// with every further attempt, up to maxRetryBackoff.
+	DefaultRetryBackoff = 30 * time.Second
+	maxRetryBackoff     = time.Hour
+	// deliveryQueueSize bounds pending deliveries; further activities are
+	// dropped with a warning
+	deliveryQueueSize = 1024
+)
+
+// Federation publishes local accounts to remote servers: it signs and
+// delivers activities, verifies the signatures of received ones and keeps
+// track of remote actors
+type Federation struct {
+	baseURL           string
+	host              string
+	federationService application.FederationServiceInterface
+	client            *http.Client
+	keys              *keyring
+	keyDir            string
+	actors            *actorResolver
+	maxAttempts       int
+	retryBackoff      time.Duration
+	now               func() time.Time
+
+	deliveries chan *delivery
+}
+
+// delivery is an activity on its way to a remote inbox, signed by a local
+// user
+type delivery struct {
+	userID   string
+	inbox    string
+	body     []byte
+	attempts int
+}
+
+// Option configures optional federation behaviour
+type Option func(*Federation)
+
+// WithHTTPClient overrides the client used for requests to remote servers.
+// The default refuses to connect to private networks.
+func WithHTTPClient(client *http.Client) Option {
+	return func(f *Federation) {
+		f.client = client
+	}
+}
+
+// WithDeliveryRetries overrides how many times a delivery is attempted and
+// the wait before the first retry
+func WithDeliveryRetries(maxAttempts int, backoff time.Duration) Option {
+	return func(f *Federation) {
+		f.maxAttempts = maxAttempts
+		f.retryBackoff = backoff
+	}
+}
+
+// WithKeyDir keeps local actors' signing keys in dir, which is created if
+// needed, so that remote servers can still verify their signatures after a
+// restart. Without it keys are generated anew by every process.
+func WithKeyDir(dir string) Option {
+	return func(f *Federation) {
+		f.keyDir = dir
+	}
+}
+
+// New creates a federation for the server publicly reachable at baseURL,
+// such as https://social.example. Actor and object IDs are built from it, so
+// it must not change once remote servers know the accounts.
+func New(baseURL string, federationService application.FederationServiceInterface, opts ...Option) (*Federation, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("activitypub: base URL %q must be an absolute http or https URL", baseURL)
+	}
+	// Hosts are case-insensitive, but IDs are compared as strings
+	u.Host = strings.ToLower(u.Host)
+
+	f := &Federation{
+		baseURL:           strings.TrimSuffix(u.String(), "/"),
+		host:              u.Host,
+		federationService: federationService,
+		client:            unfurl.NewClient(unfurl.DefaultOptions()),
+		maxAttempts:       DefaultMaxDeliveryAttempts,
+		retryBackoff:      DefaultRetryBackoff,
+		now:               time.Now,
+		deliveries:        make(chan *delivery, deliveryQueueSize),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.keyDir != "" {
+		if err := os.MkdirAll(f.keyDir, 0o700); err != nil {
+			return nil, fmt.Errorf("activitypub: create key dir: %w", err)
+		}
+	}
+	f.keys = newKeyring(f.keyDir)
+	f.actors = newActorResolver(f.client, f.now)
+	return f, nil
+}
+
+// actorURL is the ID of a local user's actor
+func (f *Federation) actorURL(userID string) string {
+	return f.baseURL + "/ap/users/" + url.PathEscape(userID)
+}
+
+// keyID is the ID of a local user's public key
+func (f *Federation) keyID(userID string) string {
+	return f.actorURL(userID) + "#main-key"
+}
+
+// noteURL is the ID of a tweet's note
+func (f *Federation) noteURL(tweetID string) string {
+	return f.baseURL + "/ap/tweets/" + url.PathEscape(tweetID)
+}
+
+// userForResource returns the local user a WebFinger resource names, either
+// as acct:user@host or as the user's actor ID
+func (f *Federation) userForResource(resource string) (string, bool) {
+	if account, ok := strings.CutPrefix(resource, "acct:"); ok {
+		i := strings.LastIndex(account, "@")
+		if i <= 0 || !strings.EqualFold(account[i+1:], f.host) {
+			return "", false
+		}
+		return account[:i], true
+	}
+
+	if escaped, ok := strings.CutPrefix(resource, f.baseURL+"/ap/users/"); ok && !strings.Contains(escaped, "/") {
+		userID, err := url.PathUnescape(escaped)
+		return userID, err == nil && userID != ""
+	}
+	return "", false
+}
+
+// HandleEvent delivers created tweets, including published scheduled
+// tweets, to their author's remote followers. Resolving the followers takes
+// requests to remote servers, so it is meant to be an asynchronous event bus
+// subscriber.
+func (f *Federation) HandleEvent(ctx context.Context, event domain.RecordedEvent) error {
+	if created, ok := event.Event.(domain.TweetCreated); ok {
+		return f.fanOut(ctx, created.Tweet)
+	}
+	return nil
+}
+
+// Run delivers queued activities with the given number of workers until the
+// context is cancelled. Failed deliveries are retried with exponential
+// backoff; retries still waiting when the context ends are dropped.
+func (f *Federation) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case d := <-f.deliveries:
+					f.attempt(ctx, d, &wg)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// fanOut queues a tweet's Create activity for every inbox of its author's
+// remote followers, once per shared inbox
+func (f *Federation) fanOut(ctx context.Context, tweet *domain.Tweet) error {
+	followers, err := f.federationService.GetRemoteFollowers(ctx, tweet.UserID)
+	if err != nil {
+		return err
+	}
+	if len(followers) == 0 {
+		return nil
+	}
+
+	activity := f.create(tweet)
+	activity.Context = activityStreamsContext
+	body, err := json.Marshal(activity)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, followerID := range followers {
+		actor, err := f.actors.resolve(ctx, followerID, false)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to resolve remote follower", "actor_id", followerID, "error", err)
+			continue
+		}
+		inbox := actor.deliveryInbox()
+		if seen[inbox] {
+			continue
+		}
+		seen[inbox] = true
+		f.enqueue(&delivery{userID: tweet.UserID, inbox: inbox, body: body})
+	}
+	return nil
+}
+
+// accept queues the Accept activity that confirms a remote follow
+func (f *Federation) accept(userID string, follower *remoteActor, follow json.RawMessage) error {
+	body, err := json.Marshal(Activity{
+		Context: activityStreamsContext,
+		ID:      f.actorURL(userID) + "#accepts/" + uuid.NewString(),
+		Type:    typeAccept,
+		Actor:   f.actorURL(userID),
+		Object:  follow,
+	})
+	if err != nil {
+		return err
+	}
+	f.enqueue(&delivery{userID: userID, inbox: follower.inbox, body: body})
+	return nil
+}
+
+// enqueue queues a delivery without blocking
+func (f *Federation) enqueue(d *delivery) {
+	select {
+	case f.deliveries <- d:
+	default:
+		slog.Warn("federation queue full, activity dropped", "inbox", d.inbox)
+	}
+}
+
+// attempt sends a delivery and, if it failed with an error worth retrying,
+// queues it again after a backoff. Waiting retries are tracked by wg so that
+// Run returns only once they are dropped.
+func (f *Federation) attempt(ctx context.Context, d *delivery, wg *sync.WaitGroup) {
+	retry, err := f.deliver(ctx, d)
+	if err == nil {
+		return
+	}
+	d.attempts++
+	if !retry || d.attempts >= f.maxAttempts {
+		slog.WarnContext(ctx, "federation delivery failed", "inbox", d.inbox, "attempts", d.attempts, "error", err)
+		return
+	}
+
+	backoff := f.retryBackoff << (d.attempts - 1)
+	if backoff > maxRetryBackoff || backoff <= 0 {
+		backoff = maxRetryBackoff
+	}
+	slog.DebugContext(ctx, "federation delivery will be retried", "inbox", d.inbox, "attempts", d.attempts, "backoff", backoff, "error", err)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		timer := time.NewTimer(backoff)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			f.enqueue(d)
+		}
+	}()
+}
+
+// errDeliveryRejected reports a response status that retrying cannot change
+var errDeliveryRejected = errors.New("inbox rejected the activity")
+
+// deliver signs and posts a delivery. retry reports whether a failure may
+// be temporary: network errors, server errors, 408 and 429.
+func (f *Federation) deliver(ctx context.Context, d *delivery) (retry bool, err error) {
+	key, err := f.keys.key(d.userID)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.inbox, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", activityContentType)
+	req.Header.Set("Accept", activityContentType)
+	req.Header.Set("User-Agent", userAgent)
+	if err := signRequest(req, d.body, f.keyID(d.userID), key, f.now()); err != nil {
+		return false, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDocumentSize))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true, fmt.Errorf("inbox answered %s", resp.Status)
+	default:
+		return false, fmt.Errorf("%w: %s", errDeliveryRejected, resp.Status)
+	}
+}
+
+// verify checks the signature of a request received in an inbox and returns
+// the remote actor that signed it. The signer's key is refetched once if
+// the cached one does not match, in case it was rotated.
+func (f *Federation) verify(req *http.Request, body []byte) (*remoteActor, error) {
+	sig, err := parseSignature(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFreshness(req, body, sig, f.now()); err != nil {
+		return nil, err
+	}
+
+	// Key IDs are the actor ID with a fragment, such as actor#main-key
+	actorID, _, _ := strings.Cut(sig.keyID, "#")
+	for _, refresh := range []bool{false, true} {
+		signer, err := f.actors.resolve(req.Context(), actorID, refresh)
+		if err != nil {
+			return nil, err
+		}
+		if signer.keyID == sig.keyID && verifySignature(req, sig, signer.publicKey) == nil {
+			return signer, nil
+		}
+	}
+	return nil, ErrInvalidSignature
+}
@@ -0,0 +1,360 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+// testClient talks to the httptest servers, which listen on loopback
+var testClient = &http.Client{Timeout: 5 * time.Second}
+
+// localServer serves the federation endpoints over real services and
+// in-memory storage, and runs the delivery workers
+type localServer struct {
+	*httptest.Server
+	federation        *Federation
+	tweetService      *services.TweetService
+	federationService *services.FederationService
+}
+
+func newLocalServer(t *testing.T, opts ...Option) *localServer {
+	t.Helper()
+
+	var router http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		router.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	federationService := services.NewFederationService(storage.NewFollowRepository(inMemoryStorage), userRepo)
+
+	opts = append([]Option{WithHTTPClient(testClient), WithDeliveryRetries(3, 5*time.Millisecond)}, opts...)
+	federation, err := New(server.URL, federationService, opts...)
+	if err != nil {
+		t.Fatalf("Failed to create federation: %v", err)
+	}
+	// Created tweets reach the federation through the outbox and event bus
+	bus := services.NewEventBus(services.DefaultEventBuffer)
+	bus.SubscribeAsync("federation", federation.HandleEvent)
+	relay := services.NewOutboxRelay(storage.NewOutboxRepository(inMemoryStorage), bus, domain.SystemClock{})
+	tweetService := services.NewTweetService(storage.NewTweetRepository(inMemoryStorage), userRepo,
+		services.WithUnitOfWork(relay.UnitOfWork(storage.NewUnitOfWork(inMemoryStorage))),
+	)
+
+	r := mux.NewRouter()
+	NewHandler(federation, tweetService, services.NewUserService(userRepo)).Register(r)
+	router = r
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		federation.Run(ctx, 2)
+		close(done)
+	}()
+	busDone := make(chan struct{})
+	go func() {
+		bus.Run(ctx)
+		close(busDone)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		<-busDone
+	})
+
+	// Users exist once they have posted
+	if _, err := tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice", Content: "First post"}); err != nil {
+		t.Fatalf("Failed to create tweet: %v", err)
+	}
+
+	return &localServer{
+		Server:            server,
+		federation:        federation,
+		tweetService:      tweetService,
+		federationService: federationService,
+	}
+}
+
+func (s *localServer) actorURL(userID string) string {
+	return s.URL + "/ap/users/" + userID
+}
+
+// received is an activity posted to the remote server
+type received struct {
+	path     string
+	signer   string
+	activity map[string]interface{}
+}
+
+// remoteServer stands in for a Mastodon instance. It serves an actor
+// document for any name under /users/, verifies the signatures of the
+// activities posted to its inboxes and records them.
+type remoteServer struct {
+	*httptest.Server
+	t           *testing.T
+	verifier    *Federation
+	sharedInbox bool
+
+	mutex    sync.Mutex
+	statuses []int
+	received []received
+}
+
+func newRemoteServer(t *testing.T) *remoteServer {
+	t.Helper()
+
+	s := &remoteServer{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+
+	// The remote verifies deliveries with the same code as the local inbox
+	verifier, err := New(s.URL, nil, WithHTTPClient(testClient))
+	if err != nil {
+		t.Fatalf("Failed to create verifier: %v", err)
+	}
+	s.verifier = verifier
+	return s
+}
+
+func (s *remoteServer) actorID(name string) string {
+	return s.URL + "/users/" + name
+}
+
+func (s *remoteServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost && (r.URL.Path == "/inbox" || strings.HasSuffix(r.URL.Path, "/inbox")) {
+		s.receive(w, r)
+		return
+	}
+
+	name, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/users/"), "/")
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, "/users/") || rest != "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	publicKey, err := encodePublicKey(&testKey().PublicKey)
+	if err != nil {
+		s.t.Errorf("Failed to encode key: %v", err)
+	}
+	id := s.actorID(name)
+	doc := map[string]interface{}{
+		"@context":  []string{activityStreamsContext, securityContext},
+		"id":        id,
+		"type":      "Person",
+		"inbox":     id + "/inbox",
+		"publicKey": map[string]string{"id": id + "#main-key", "owner": id, "publicKeyPem": publicKey},
+	}
+	if s.sharedInbox {
+		doc["endpoints"] = map[string]string{"sharedInbox": s.URL + "/inbox"}
+	}
+	// The impostor's document claims to be someone else
+	if name == "impostor" {
+		doc["id"] = s.actorID("bob")
+	}
+	w.Header().Set("Content-Type", activityContentType)
+	json.NewEncoder(w).Encode(doc)
+}
+
+func (s *remoteServer) receive(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	signer, err := s.verifier.verify(r, body)
+	if err != nil {
+		s.t.Errorf("Delivery to %s failed verification: %v", r.URL.Path, err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var activity map[string]interface{}
+	if err := json.Unmarshal(body, &activity); err != nil {
+		s.t.Errorf("Delivery to %s is not JSON: %v", r.URL.Path, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received = append(s.received, received{path: r.URL.Path, signer: signer.id, activity: activity})
+
+	status := http.StatusAccepted
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// answer makes the inboxes answer with the given statuses, in order, before
+// going back to 202
+func (s *remoteServer) answer(statuses ...int) {
+	s.mutex.Lock()
+	s.statuses = statuses
+	s.mutex.Unlock()
+}
+
+// waitFor waits until n activities have been received and returns them
+func (s *remoteServer) waitFor(n int) []received {
+	s.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mutex.Lock()
+		got := append([]received(nil), s.received...)
+		s.mutex.Unlock()
+		if len(got) >= n {
+			return got
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("Expected %d deliveries, got %d", n, len(got))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// settle waits long enough for retries and stray deliveries to arrive and
+// returns everything received
+func (s *remoteServer) settle() []received {
+	time.Sleep(100 * time.Millisecond)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]received(nil), s.received...)
+}
+
+// signedPost builds a request from a remote actor, signed at now
+func (s *remoteServer) signedPost(t *testing.T, target, name string, body []byte, now time.Time) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", activityContentType)
+	if err := signRequest(req, body, s.actorID(name)+"#main-key", testKey(), now); err != nil {
+		t.Fatalf("Failed to sign request: %v", err)
+	}
+	return req
+}
+
+// follow makes a remote actor follow a local user and returns the response
+func (s *remoteServer) follow(t *testing.T, local *localServer, name, userID string) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{
+		"@context": activityStreamsContext,
+		"id":       s.actorID(name) + "#follows/" + userID,
+		"type":     "Follow",
+		"actor":    s.actorID(name),
+		"object":   local.actorURL(userID),
+	})
+	resp, err := testClient.Do(s.signedPost(t, local.actorURL(userID)+"/inbox", name, body, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to post follow: %v", err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestFederation_DeliversTweetsToSharedInboxOnce(t *testing.T) {
+	local := newLocalServer(t)
+	remote := newRemoteServer(t)
+	remote.sharedInbox = true
+
+	for _, name := range []string{"bob", "carol"} {
+		if resp := remote.follow(t, local, name, "alice"); resp.StatusCode != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d", http.StatusAccepted, resp.StatusCode)
+		}
+	}
+	// One Accept per follow, sent to each follower's own inbox
+	accepts := remote.waitFor(2)
+	for _, accept := range accepts {
+		if accept.activity["type"] != "Accept" || accept.path == "/inbox" {
+			t.Errorf("Expected an Accept in a personal inbox, got %s at %s", accept.activity["type"], accept.path)
+		}
+	}
+
+	if _, err := local.tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice", Content: "Hello"}); err != nil {
+		t.Fatalf("Failed to create tweet: %v", err)
+	}
+	remote.waitFor(3)
+
+	got := remote.settle()
+	if len(got) != 3 {
+		t.Fatalf("Expected the tweet to be delivered once, got %d deliveries", len(got)-2)
+	}
+	if got[2].path != "/inbox" || got[2].activity["type"] != "Create" {
+		t.Errorf("Expected a Create in the shared inbox, got %s at %s", got[2].activity["type"], got[2].path)
+	}
+}
+
+func TestFederation_RetriesFailedDeliveries(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int
+	}{
+		{"temporary failures", []int{http.StatusInternalServerError, http.StatusTooManyRequests}, 3},
+		{"gives up", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3},
+		{"rejected", []int{http.StatusForbidden}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := newLocalServer(t)
+			remote := newRemoteServer(t)
+			remote.follow(t, local, "bob", "alice")
+			remote.waitFor(1)
+
+			remote.answer(tt.statuses...)
+			if _, err := local.tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice", Content: "Hello"}); err != nil {
+				t.Fatalf("Failed to create tweet: %v", err)
+			}
+			remote.waitFor(1 + tt.attempts)
+
+			if got := remote.settle(); len(got) != 1+tt.attempts {
+				t.Errorf("Expected %d delivery attempts, got %d", tt.attempts, len(got)-1)
+			}
+		})
+	}
+}
+
+func TestFederation_UserForResource(t *testing.T) {
+	federation, err := New("https://Social.Example/", nil)
+	if err != nil {
+		t.Fatalf("Failed to create federation: %v", err)
+	}
+
+	tests := []struct {
+		resource string
+		userID   string
+		ok       bool
+	}{
+		{"acct:alice@social.example", "alice", true},
+		{"acct:alice@SOCIAL.EXAMPLE", "alice", true},
+		{"acct:alice@other.example", "", false},
+		{"acct:@social.example", "", false},
+		{"https://social.example/ap/users/bob%20smith", "bob smith", true},
+		{"https://social.example/ap/users/bob/outbox", "", false},
+		{"https://other.example/ap/users/bob", "", false},
+		{"bob", "", false},
+	}
+	for _, tt := range tests {
+		userID, ok := federation.userForResource(tt.resource)
+		if userID != tt.userID || ok != tt.ok {
+			t.Errorf("userForResource(%q): expected %q, %v, got %q, %v", tt.resource, tt.userID, tt.ok, userID, ok)
+		}
+	}
+
+	for _, baseURL := range []string{"", "social.example", "ftp://social.example", "/relative"} {
+		if _, err := New(baseURL, nil); err == nil {
+			t.Errorf("Expected base URL %q to be rejected", baseURL)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package activitypub
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/application"
+)
+
+// maxOutboxItems is the number of most recent activities an outbox lists
+const maxOutboxItems = 20
+
+// Handler serves the WebFinger and ActivityPub endpoints
+type Handler struct {
+	federation   *Federation
+	tweetService application.TweetServiceInterface
+	userService  application.UserServiceInterface
+}
+
+// NewHandler creates a handler for the accounts of the given federation
+func NewHandler(federation *Federation, tweetService application.TweetServiceInterface, userService application.UserServiceInterface) *Handler {
+	return &Handler{
+		federation:   federation,
+		tweetService: tweetService,
+		userService:  userService,
+	}
+}
+
+// Register adds the federation routes to router. The paths must match the
+// IDs the federation builds.
+func (h *Handler) Register(router *mux.Router) {
+	router.HandleFunc("/.well-known/webfinger", h.webFinger).Methods("GET")
+	router.HandleFunc("/ap/users/{id}", h.actor).Methods("GET")
+	router.HandleFunc("/ap/users/{id}/outbox", h.outbox).Methods("GET")
+	router.HandleFunc("/ap/users/{id}/inbox", h.inbox).Methods("POST")
+	router.HandleFunc("/ap/tweets/{id}", h.note).Methods("GET")
+}
+
+// webFinger maps acct:user@host to the user's actor
+func (h *Handler) webFinger(w http.ResponseWriter, r *http.Request) {
+	resource := r.URL.Query().Get("resource")
+	if resource == "" {
+		writeError(w, r, errMissingResource)
+		return
+	}
+	userID, ok := h.federation.userForResource(resource)
+	if !ok {
+		writeError(w, r, errUnknownResource)
+		return
+	}
+	if _, err := h.userService.GetUser(r.Context(), userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	actorURL := h.federation.actorURL(userID)
+	writeJSON(w, http.StatusOK, webFingerContentType, WebFinger{
+		Subject: "acct:" + userID + "@" + h.federation.host,
+		Aliases: []string{actorURL},
+		Links: []WebFingerLink{
+			{Rel: "self", Type: activityContentType, Href: actorURL},
+		},
+	})
+}
+
+// actor serves a user's actor document with the key that verifies their
+// signed requests
+func (h *Handler) actor(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	user, err := h.userService.GetUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	key, err := h.federation.keys.key(userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	publicKey, err := encodePublicKey(&key.PublicKey)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	actorURL := h.federation.actorURL(userID)
+	writeJSON(w, http.StatusOK, activityContentType, Actor{
+		Context:           []string{activityStreamsContext, securityContext},
+		ID:                actorURL,
+		Type:              "Person",
+		PreferredUsername: userID,
+		Name:              user.Name,
+		Inbox:             actorURL + "/inbox",
+		Outbox:            actorURL + "/outbox",
+		PublicKey: PublicKey{
+			ID:           h.federation.keyID(userID),
+			Owner:        actorURL,
+			PublicKeyPEM: publicKey,
+		},
+	})
+}
+
+// outbox lists a user's latest tweets as Create activities
+func (h *Handler) outbox(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["id"]
+	if _, err := h.userService.GetUser(r.Context(), userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tweets, err := h.tweetService.GetUserTweets(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	collection := OrderedCollection{
+		Context:      activityStreamsContext,
+		ID:           h.federation.actorURL(userID) + "/outbox",
+		Type:         "OrderedCollection",
+		TotalItems:   len(tweets),
+		OrderedItems: make([]Activity, 0, min(len(tweets), maxOutboxItems)),
+	}
+	for _, tweet := range tweets[:min(len(tweets), maxOutboxItems)] {
+		collection.OrderedItems = append(collection.OrderedItems, h.federation.create(tweet))
+	}
+	writeJSON(w, http.StatusOK, activityContentType, collection)
+}
+
+// note serves a tweet as the note delivered to remote followers
+func (h *Handler) note(w http.ResponseWriter, r *http.Request) {
+	tweet, err := h.tweetService.GetTweet(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	note := h.federation.note(tweet)
+	note.Context = activityStreamsContext
+	writeJSON(w, http.StatusOK, activityContentType, note)
+}
+
+// inbox receives signed activities from remote servers. Follow and
+// Undo{Follow} add and remove remote followers; every other activity is
+// acknowledged and ignored.
+func (h *Handler) inbox(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := mux.Vars(r)["id"]
+	if _, err := h.userService.GetUser(ctx, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxDocumentSize))
+	if err != nil {
+		writeError(w, r, invalidActivity(err))
+		return
+	}
+	signer, err := h.federation.verify(r, body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var activity incomingActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		writeError(w, r, invalidActivity(err))
+		return
+	}
+	if activity.Type == "" {
+		writeError(w, r, invalidActivity(nil))
+		return
+	}
+	// A server may only speak for its own actors
+	if objectID(activity.Actor) != signer.id {
+		writeError(w, r, errActorMismatch)
+		return
+	}
+
+	actorURL := h.federation.actorURL(userID)
+	federationService := h.federation.federationService
+	switch activity.Type {
+	case typeFollow:
+		if objectID(activity.Object) != actorURL {
+			writeError(w, r, errFollowTarget)
+			return
+		}
+		if err := federationService.AddRemoteFollower(ctx, userID, signer.id); err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if err := h.federation.accept(userID, signer, body); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+	case typeUndo:
+		var follow incomingActivity
+		if json.Unmarshal(activity.Object, &follow) == nil && follow.Type == typeFollow &&
+			objectID(follow.Actor) == signer.id && objectID(follow.Object) == actorURL {
+			if err := federationService.RemoveRemoteFollower(ctx, userID, signer.id); err != nil {
+				writeError(w, r, err)
+				return
+			}
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -0,0 +1,318 @@
+package activitypub
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/application/services"
+)
+
+// get fetches path from the local server and decodes the JSON response
+func get(t *testing.T, local *localServer, path string, out interface{}) *http.Response {
+	t.Helper()
+	resp, err := testClient.Get(local.URL + path)
+	if err != nil {
+		t.Fatalf("Failed to get %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("Failed to decode %s: %v", path, err)
+		}
+	}
+	return resp
+}
+
+// errorCode decodes the code of an error response
+func errorCode(t *testing.T, resp *http.Response) string {
+	t.Helper()
+	defer resp.Body.Close()
+	var body struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode error response: %v", err)
+	}
+	return body.Error.Code
+}
+
+func TestHandler_WebFinger(t *testing.T) {
+	local := newLocalServer(t)
+	host := strings.TrimPrefix(local.URL, "http://")
+
+	tests := []struct {
+		name     string
+		resource string
+		status   int
+		code     string
+	}{
+		{"account", "acct:alice@" + host, http.StatusOK, ""},
+		{"actor ID", local.actorURL("alice"), http.StatusOK, ""},
+		{"unknown user", "acct:nobody@" + host, http.StatusNotFound, "user_not_found"},
+		{"other host", "acct:alice@other.example", http.StatusNotFound, "resource_not_found"},
+		{"missing", "", http.StatusBadRequest, "missing_resource"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := testClient.Get(local.URL + "/.well-known/webfinger?resource=" + url.QueryEscape(tt.resource))
+			if err != nil {
+				t.Fatalf("Failed to query WebFinger: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if tt.code != "" {
+				if code := errorCode(t, resp); code != tt.code {
+					t.Errorf("Expected error code %s, got %s", tt.code, code)
+				}
+				return
+			}
+			defer resp.Body.Close()
+
+			if contentType := resp.Header.Get("Content-Type"); contentType != webFingerContentType {
+				t.Errorf("Expected content type %s, got %s", webFingerContentType, contentType)
+			}
+			var doc WebFinger
+			if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+				t.Fatalf("Failed to decode WebFinger document: %v", err)
+			}
+			if doc.Subject != "acct:alice@"+host {
+				t.Errorf("Expected subject acct:alice@%s, got %s", host, doc.Subject)
+			}
+			if len(doc.Links) != 1 || doc.Links[0].Rel != "self" || doc.Links[0].Href != local.actorURL("alice") {
+				t.Errorf("Expected a self link to the actor, got %+v", doc.Links)
+			}
+		})
+	}
+}
+
+func TestHandler_Actor(t *testing.T) {
+	local := newLocalServer(t)
+
+	var actor Actor
+	resp := get(t, local, "/ap/users/alice", &actor)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != activityContentType {
+		t.Errorf("Expected content type %s, got %s", activityContentType, contentType)
+	}
+	if actor.ID != local.actorURL("alice") || actor.Inbox != actor.ID+"/inbox" || actor.PreferredUsername != "alice" {
+		t.Errorf("Unexpected actor document: %+v", actor)
+	}
+	if actor.PublicKey.ID != actor.ID+"#main-key" || actor.PublicKey.Owner != actor.ID {
+		t.Errorf("Unexpected public key: %+v", actor.PublicKey)
+	}
+	if _, err := decodePublicKey(actor.PublicKey.PublicKeyPEM); err != nil {
+		t.Errorf("Expected a usable public key, got %v", err)
+	}
+
+	if resp := get(t, local, "/ap/users/nobody", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown user, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func TestHandler_FollowDeliverAndUndo(t *testing.T) {
+	local := newLocalServer(t)
+	remote := newRemoteServer(t)
+	ctx := context.Background()
+
+	if resp := remote.follow(t, local, "bob", "alice"); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	followers, err := local.federationService.GetRemoteFollowers(ctx, "alice")
+	if err != nil || len(followers) != 1 || followers[0] != remote.actorID("bob") {
+		t.Fatalf("Expected bob to follow alice, got %v, %v", followers, err)
+	}
+
+	// The follow is accepted by alice, with a signature remote can verify
+	accept := remote.waitFor(1)[0]
+	if accept.activity["type"] != "Accept" || accept.signer != local.actorURL("alice") || accept.path != "/users/bob/inbox" {
+		t.Errorf("Expected an Accept signed by alice in bob's inbox, got %s by %s at %s", accept.activity["type"], accept.signer, accept.path)
+	}
+	if follow, _ := accept.activity["object"].(map[string]interface{}); follow["type"] != "Follow" {
+		t.Errorf("Expected the Accept to carry the Follow, got %v", accept.activity["object"])
+	}
+
+	tweet, err := local.tweetService.CreateTweet(ctx, services.CreateTweetRequest{UserID: "alice", Content: "<b>Hi</b>\nthere"})
+	if err != nil {
+		t.Fatalf("Failed to create tweet: %v", err)
+	}
+	create := remote.waitFor(2)[1]
+	if create.activity["type"] != "Create" || create.signer != local.actorURL("alice") {
+		t.Fatalf("Expected a Create signed by alice, got %s by %s", create.activity["type"], create.signer)
+	}
+	note, _ := create.activity["object"].(map[string]interface{})
+	if note["type"] != "Note" || note["id"] != local.URL+"/ap/tweets/"+tweet.ID || note["attributedTo"] != local.actorURL("alice") {
+		t.Errorf("Unexpected note: %v", note)
+	}
+	if note["content"] != "<p>&lt;b&gt;Hi&lt;/b&gt;<br>there</p>" {
+		t.Errorf("Expected escaped content, got %v", note["content"])
+	}
+
+	// The note can be fetched at its ID
+	var fetched Note
+	if resp := get(t, local, "/ap/tweets/"+tweet.ID, &fetched); resp.StatusCode != http.StatusOK || fetched.ID != note["id"] {
+		t.Errorf("Expected the note to be served at its ID, got %d %+v", resp.StatusCode, fetched)
+	}
+	if resp := get(t, local, "/ap/tweets/missing", nil); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown tweet, got %d", http.StatusNotFound, resp.StatusCode)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"@context": activityStreamsContext,
+		"id":       remote.actorID("bob") + "#undo",
+		"type":     "Undo",
+		"actor":    remote.actorID("bob"),
+		"object": map[string]interface{}{
+			"id":     remote.actorID("bob") + "#follows/alice",
+			"type":   "Follow",
+			"actor":  remote.actorID("bob"),
+			"object": local.actorURL("alice"),
+		},
+	})
+	resp, err := testClient.Do(remote.signedPost(t, local.actorURL("alice")+"/inbox", "bob", body, time.Now()))
+	if err != nil {
+		t.Fatalf("Failed to post undo: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected status %d, got %d", http.StatusAccepted, resp.StatusCode)
+	}
+	if followers, _ := local.federationService.GetRemoteFollowers(ctx, "alice"); len(followers) != 0 {
+		t.Errorf("Expected no remote followers after the undo, got %v", followers)
+	}
+
+	// Tweets are no longer delivered
+	if _, err := local.tweetService.CreateTweet(ctx, services.CreateTweetRequest{UserID: "alice", Content: "Bye"}); err != nil {
+		t.Fatalf("Failed to create tweet: %v", err)
+	}
+	if got := remote.settle(); len(got) != 2 {
+		t.Errorf("Expected no delivery after the undo, got %d", len(got)-2)
+	}
+}
+
+func TestHandler_Outbox(t *testing.T) {
+	local := newLocalServer(t)
+	for i := 0; i < maxOutboxItems+2; i++ {
+		if _, err := local.tweetService.CreateTweet(context.Background(), services.CreateTweetRequest{UserID: "alice", Content: "Post"}); err != nil {
+			t.Fatalf("Failed to create tweet: %v", err)
+		}
+	}
+
+	var outbox struct {
+		ID           string     `json:"id"`
+		Type         string     `json:"type"`
+		TotalItems   int        `json:"totalItems"`
+		OrderedItems []Activity `json:"orderedItems"`
+	}
+	if resp := get(t, local, "/ap/users/alice/outbox", &outbox); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	// The tweet posted by newLocalServer counts too
+	if outbox.TotalItems != maxOutboxItems+3 || len(outbox.OrderedItems) != maxOutboxItems {
+		t.Errorf("Expected %d items listing %d, got %d listing %d", maxOutboxItems+3, maxOutboxItems, outbox.TotalItems, len(outbox.OrderedItems))
+	}
+	for _, item := range outbox.OrderedItems {
+		if item.Type != "Create" || item.Actor != local.actorURL("alice") {
+			t.Errorf("Expected Create activities by alice, got %s by %s", item.Type, item.Actor)
+		}
+	}
+}
+
+func TestHandler_InboxRejectsActivities(t *testing.T) {
+	local := newLocalServer(t)
+	remote := newRemoteServer(t)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	follow := func(actor, object string) []byte {
+		body, _ := json.Marshal(map[string]interface{}{
+			"id":     actor + "#follow",
+			"type":   "Follow",
+			"actor":  actor,
+			"object": object,
+		})
+		return body
+	}
+	inbox := local.actorURL("alice") + "/inbox"
+	valid := follow(remote.actorID("bob"), local.actorURL("alice"))
+
+	tests := []struct {
+		name    string
+		request func() *http.Request
+		status  int
+		code    string
+	}{
+		{"unsigned", func() *http.Request {
+			req, _ := http.NewRequest("POST", inbox, bytes.NewReader(valid))
+			return req
+		}, http.StatusUnauthorized, "missing_signature"},
+		{"tampered body", func() *http.Request {
+			req := remote.signedPost(t, inbox, "bob", valid, time.Now())
+			tampered := follow(remote.actorID("bob"), local.actorURL("carol"))
+			req.Body, req.ContentLength = io.NopCloser(bytes.NewReader(tampered)), int64(len(tampered))
+			return req
+		}, http.StatusUnauthorized, "digest_mismatch"},
+		{"expired", func() *http.Request {
+			return remote.signedPost(t, inbox, "bob", valid, time.Now().Add(-2*time.Hour))
+		}, http.StatusUnauthorized, "signature_expired"},
+		{"wrong key", func() *http.Request {
+			req, _ := http.NewRequest("POST", inbox, bytes.NewReader(valid))
+			if err := signRequest(req, valid, remote.actorID("bob")+"#main-key", otherKey, time.Now()); err != nil {
+				t.Fatalf("Failed to sign request: %v", err)
+			}
+			return req
+		}, http.StatusUnauthorized, "invalid_signature"},
+		{"impostor", func() *http.Request {
+			return remote.signedPost(t, inbox, "impostor", follow(remote.actorID("impostor"), local.actorURL("alice")), time.Now())
+		}, http.StatusUnauthorized, "unknown_actor"},
+		{"actor mismatch", func() *http.Request {
+			return remote.signedPost(t, inbox, "bob", follow(remote.actorID("carol"), local.actorURL("alice")), time.Now())
+		}, http.StatusForbidden, "actor_mismatch"},
+		{"wrong follow target", func() *http.Request {
+			return remote.signedPost(t, inbox, "bob", follow(remote.actorID("bob"), local.actorURL("carol")), time.Now())
+		}, http.StatusBadRequest, "invalid_follow"},
+		{"not an activity", func() *http.Request {
+			return remote.signedPost(t, inbox, "bob", []byte(`{"id":"x"}`), time.Now())
+		}, http.StatusBadRequest, "invalid_activity"},
+		{"unknown user", func() *http.Request {
+			return remote.signedPost(t, local.actorURL("nobody")+"/inbox", "bob", valid, time.Now())
+		}, http.StatusNotFound, "user_not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := testClient.Do(tt.request())
+			if err != nil {
+				t.Fatalf("Failed to post activity: %v", err)
+			}
+			if resp.StatusCode != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if code := errorCode(t, resp); code != tt.code {
+				t.Errorf("Expected error code %s, got %s", tt.code, code)
+			}
+		})
+	}
+
+	if followers, _ := local.federationService.GetRemoteFollowers(context.Background(), "alice"); len(followers) != 0 {
+		t.Errorf("Expected rejected activities to add no followers, got %v", followers)
+	}
+	if got := remote.settle(); len(got) != 0 {
+		t.Errorf("Expected no deliveries, got %d", len(got))
+	}
+}
@@ -0,0 +1,176 @@
+package activitypub
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// keyBits is the size of the RSA keys generated for local actors
+const keyBits = 2048
+
+// keyring holds the signing keys of local actors. A key is generated the
+// first time an actor needs one. With a directory, keys are kept there and
+// survive restarts; without one they last until the process exits, and
+// remote servers fetch the new key when a signature stops verifying.
+type keyring struct {
+	dir   string
+	mutex sync.Mutex
+	keys  map[string]*keyEntry
+}
+
+// keyEntry is a user's key, ready once it has been loaded or generated
+type keyEntry struct {
+	ready chan struct{}
+	key   *rsa.PrivateKey
+	err   error
+}
+
+func newKeyring(dir string) *keyring {
+	return &keyring{dir: dir, keys: make(map[string]*keyEntry)}
+}
+
+// key returns the signing key of a local user. Keys are loaded or generated
+// outside the mutex, so that one user's key does not hold up the others;
+// concurrent callers for the same user wait for the same key.
+func (k *keyring) key(userID string) (*rsa.PrivateKey, error) {
+	k.mutex.Lock()
+	entry, ok := k.keys[userID]
+	if !ok {
+		entry = &keyEntry{ready: make(chan struct{})}
+		k.keys[userID] = entry
+	}
+	k.mutex.Unlock()
+
+	if ok {
+		<-entry.ready
+		return entry.key, entry.err
+	}
+
+	entry.key, entry.err = k.loadOrGenerate(userID)
+	if entry.err != nil {
+		// A later call tries again
+		k.mutex.Lock()
+		delete(k.keys, userID)
+		k.mutex.Unlock()
+	}
+	close(entry.ready)
+	return entry.key, entry.err
+}
+
+// loadOrGenerate reads a user's key from the directory, or generates it and
+// stores it there
+func (k *keyring) loadOrGenerate(userID string) (*rsa.PrivateKey, error) {
+	if k.dir == "" {
+		return rsa.GenerateKey(rand.Reader, keyBits)
+	}
+
+	path := k.path(userID)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return decodePrivateKey(data)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeKeyFile(path, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// path is the file of a user's key. User IDs are hashed so that any ID makes
+// a safe file name.
+func (k *keyring) path(userID string) string {
+	sum := sha256.Sum256([]byte(userID))
+	return filepath.Join(k.dir, hex.EncodeToString(sum[:])+".pem")
+}
+
+// writeKeyFile stores a private key as PKCS #8 PEM, readable only by the
+// owner. It is written to a temporary file and renamed into place, so that a
+// crash never leaves a partial key.
+func writeKeyFile(path string, key *rsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("encode signing key: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("write signing key: %w", err)
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return fmt.Errorf("write signing key: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync signing key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write signing key: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// decodePrivateKey reads an RSA private key in PKCS #8 PEM format
+func decodePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("decode signing key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("signing key is not an RSA key")
+	}
+	return key, nil
+}
+
+// encodePublicKey encodes a public key as PEM in the PKIX format actor
+// documents use
+func encodePublicKey(key *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// decodePublicKey reads an RSA public key in PKIX or PKCS #1 PEM format
+func decodePublicKey(encoded string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(encoded))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an RSA key")
+	}
+	return key, nil
+}
@@ -0,0 +1,56 @@
+package activitypub
+
+import (
+	"crypto/rsa"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestKeyring_KeepsKeysInDir(t *testing.T) {
+	dir := t.TempDir()
+	keys := newKeyring(dir)
+
+	// Concurrent first requests for a user share one generated key
+	const callers = 4
+	found := make([]*rsa.PrivateKey, callers)
+	var wg sync.WaitGroup
+	for i := range found {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key, err := keys.key("alice")
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			found[i] = key
+		}(i)
+	}
+	wg.Wait()
+	for _, key := range found[1:] {
+		if key != found[0] {
+			t.Fatal("Expected every caller to get the same key")
+		}
+	}
+
+	info, err := os.Stat(keys.path("alice"))
+	if err != nil {
+		t.Fatalf("Expected the key to be stored, got %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("Expected the key file to be private, got %v", perm)
+	}
+
+	// A restarted process signs with the same key
+	first, _ := keys.key("alice")
+	reloaded, err := newKeyring(dir).key("alice")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reloaded.Equal(first) {
+		t.Error("Expected the stored key to be loaded after a restart")
+	}
+	if other, _ := keys.key("../alice"); other == nil || other.Equal(first) {
+		t.Error("Expected another user to get their own key")
+	}
+}
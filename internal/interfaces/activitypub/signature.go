@@ -0,0 +1,198 @@
+package activitypub
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Signature errors
+var (
+	ErrMissingSignature = errors.New("request is not signed")
+	ErrInvalidSignature = errors.New("request signature is invalid")
+	ErrSignatureExpired = errors.New("request signature is outside the accepted time window")
+	ErrDigestMismatch   = errors.New("request digest does not match the body")
+)
+
+// maxSignatureSkew is how far the signed Date of a request may be from the
+// current time, in either direction
+const maxSignatureSkew = time.Hour
+
+// signature is a parsed Signature header (draft-cavage-http-signatures)
+type signature struct {
+	keyID     string
+	algorithm string
+	headers   []string
+	signature []byte
+}
+
+// signRequest signs req with key in the way Mastodon and most of the
+// fediverse expect: RSA-SHA256 over the request target, Host and Date, plus
+// a SHA-256 Digest of body when there is one
+func signRequest(req *http.Request, body []byte, keyID string, key *rsa.PrivateKey, now time.Time) error {
+	req.Header.Set("Date", now.UTC().Format(http.TimeFormat))
+	headers := []string{"(request-target)", "host", "date"}
+	if body != nil {
+		req.Header.Set("Digest", digest(body))
+		headers = append(headers, "digest")
+	}
+
+	signed, err := signingString(req, headers)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Signature", fmt.Sprintf(`keyId="%s",algorithm="rsa-sha256",headers="%s",signature="%s"`,
+		keyID, strings.Join(headers, " "), base64.StdEncoding.EncodeToString(sig)))
+	return nil
+}
+
+// parseSignature reads the Signature header of req
+func parseSignature(req *http.Request) (*signature, error) {
+	header := req.Header.Get("Signature")
+	if header == "" {
+		return nil, ErrMissingSignature
+	}
+
+	params := make(map[string]string)
+	for rest := strings.TrimSpace(header); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		name, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed parameters", ErrInvalidSignature)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.IndexByte(value[1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated %s parameter", ErrInvalidSignature, name)
+			}
+			params[name] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			params[name], rest, _ = strings.Cut(value, ",")
+		}
+	}
+
+	sig := &signature{
+		keyID:     params["keyid"],
+		algorithm: strings.ToLower(params["algorithm"]),
+		// Without a headers parameter only the Date header is signed
+		headers: strings.Fields(strings.ToLower(params["headers"])),
+	}
+	if len(sig.headers) == 0 {
+		sig.headers = []string{"date"}
+	}
+	if sig.keyID == "" {
+		return nil, fmt.Errorf("%w: missing keyId", ErrInvalidSignature)
+	}
+	switch sig.algorithm {
+	case "", "rsa-sha256", "hs2019":
+	default:
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidSignature, sig.algorithm)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || len(decoded) == 0 {
+		return nil, fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+	sig.signature = decoded
+	return sig, nil
+}
+
+// checkFreshness verifies that sig covers the parts of req that must not be
+// replayed or altered: the request target, Host, a recent Date and, for
+// requests with a body, a Digest matching body
+func checkFreshness(req *http.Request, body []byte, sig *signature, now time.Time) error {
+	required := []string{"(request-target)", "host", "date"}
+	if len(body) > 0 {
+		required = append(required, "digest")
+	}
+	for _, header := range required {
+		if !slices.Contains(sig.headers, header) {
+			return fmt.Errorf("%w: %s is not signed", ErrInvalidSignature, header)
+		}
+	}
+
+	date, err := http.ParseTime(req.Header.Get("Date"))
+	if err != nil {
+		return fmt.Errorf("%w: invalid Date header", ErrInvalidSignature)
+	}
+	if skew := now.Sub(date); skew > maxSignatureSkew || skew < -maxSignatureSkew {
+		return ErrSignatureExpired
+	}
+
+	if len(body) > 0 && !matchesDigest(req.Header.Get("Digest"), body) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// verifySignature checks sig against req with the signer's public key
+func verifySignature(req *http.Request, sig *signature, key *rsa.PublicKey) error {
+	signed, err := signingString(req, sig.headers)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256([]byte(signed))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig.signature); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// signingString builds the string that is signed from the named headers
+func signingString(req *http.Request, headers []string) (string, error) {
+	lines := make([]string, len(headers))
+	for i, header := range headers {
+		switch header {
+		case "(request-target)":
+			lines[i] = header + ": " + strings.ToLower(req.Method) + " " + req.URL.RequestURI()
+		case "host":
+			host := req.Host
+			if host == "" {
+				host = req.URL.Host
+			}
+			lines[i] = header + ": " + host
+		default:
+			values := req.Header.Values(header)
+			if len(values) == 0 {
+				return "", fmt.Errorf("%w: signed header %s is missing", ErrInvalidSignature, header)
+			}
+			lines[i] = header + ": " + strings.Join(values, ", ")
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// digest returns the Digest header value (RFC 3230) of body
+func digest(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "SHA-256=" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// matchesDigest reports whether a Digest header carries the SHA-256 digest
+// of body. Other algorithms in the header are ignored.
+func matchesDigest(header string, body []byte) bool {
+	want := digest(body)
+	for _, value := range strings.Split(header, ",") {
+		algorithm, encoded, ok := strings.Cut(strings.TrimSpace(value), "=")
+		if ok && strings.EqualFold(algorithm, "SHA-256") {
+			return "SHA-256="+encoded == want
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package activitypub
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testKey is shared by the tests that sign as remote actors, since key
+// generation is slow
+var testKey = sync.OnceValue(func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, keyBits)
+	if err != nil {
+		panic(err)
+	}
+	return key
+})
+
+func signedTestRequest(t *testing.T, body []byte, now time.Time) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest("POST", "https://social.example/ap/users/alice/inbox?x=1", bytes.NewReader(body))
+	if err := signRequest(req, body, "https://remote.example/users/bob#main-key", testKey(), now); err != nil {
+		t.Fatalf("Failed to sign request: %v", err)
+	}
+	return req
+}
+
+func TestSignature_RoundTrip(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"Follow"}`)
+	req := signedTestRequest(t, body, now)
+
+	sig, err := parseSignature(req)
+	if err != nil {
+		t.Fatalf("Expected the signature to parse, got %v", err)
+	}
+	if sig.keyID != "https://remote.example/users/bob#main-key" || sig.algorithm != "rsa-sha256" {
+		t.Errorf("Unexpected signature parameters: %+v", sig)
+	}
+	if err := checkFreshness(req, body, sig, now.Add(time.Minute)); err != nil {
+		t.Errorf("Expected a fresh signature, got %v", err)
+	}
+	if err := verifySignature(req, sig, &testKey().PublicKey); err != nil {
+		t.Errorf("Expected the signature to verify, got %v", err)
+	}
+
+	// Any change to a signed header breaks the signature
+	req.URL.RawQuery = "x=2"
+	if err := verifySignature(req, sig, &testKey().PublicKey); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Expected %v for a changed request target, got %v", ErrInvalidSignature, err)
+	}
+}
+
+func TestSignature_Freshness(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"Follow"}`)
+
+	tests := []struct {
+		name   string
+		sign   time.Time
+		body   []byte
+		modify func(*http.Request)
+		err    error
+	}{
+		{"too old", now.Add(-2 * time.Hour), body, nil, ErrSignatureExpired},
+		{"from the future", now.Add(2 * time.Hour), body, nil, ErrSignatureExpired},
+		{"tampered body", now, []byte(`{"type":"Undo"}`), nil, ErrDigestMismatch},
+		{"digest not signed", now, body, func(req *http.Request) {
+			req.Header.Set("Signature", `keyId="k",headers="(request-target) host date",signature="c2ln"`)
+		}, ErrInvalidSignature},
+		{"date not signed", now, body, func(req *http.Request) {
+			req.Header.Set("Signature", `keyId="k",signature="c2ln",headers="(request-target) host digest"`)
+		}, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := signedTestRequest(t, body, tt.sign)
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			sig, err := parseSignature(req)
+			if err != nil {
+				t.Fatalf("Expected the signature to parse, got %v", err)
+			}
+			if err := checkFreshness(req, tt.body, sig, now); !errors.Is(err, tt.err) {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestParseSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		keyID   string
+		headers []string
+		err     error
+	}{
+		{"missing", "", "", nil, ErrMissingSignature},
+		{"quoted values with commas", `keyId="https://a.example/u,1#k", algorithm="hs2019",headers="(request-target) date",signature="c2ln"`,
+			"https://a.example/u,1#k", []string{"(request-target)", "date"}, nil},
+		{"default headers", `keyId="k",signature="c2ln"`, "k", []string{"date"}, nil},
+		{"missing key", `signature="c2ln"`, "", nil, ErrInvalidSignature},
+		{"unsupported algorithm", `keyId="k",algorithm="hmac-sha256",signature="c2ln"`, "", nil, ErrInvalidSignature},
+		{"bad base64", `keyId="k",signature="!!"`, "", nil, ErrInvalidSignature},
+		{"unterminated quote", `keyId="k,signature="c2ln`, "", nil, ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/inbox", nil)
+			if tt.header != "" {
+				req.Header.Set("Signature", tt.header)
+			}
+			sig, err := parseSignature(req)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if sig.keyID != tt.keyID {
+				t.Errorf("Expected key %q, got %q", tt.keyID, sig.keyID)
+			}
+			if len(sig.headers) != len(tt.headers) {
+				t.Fatalf("Expected headers %v, got %v", tt.headers, sig.headers)
+			}
+			for i := range tt.headers {
+				if sig.headers[i] != tt.headers[i] {
+					t.Errorf("Expected headers %v, got %v", tt.headers, sig.headers)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchesDigest(t *testing.T) {
+	body := []byte("hello")
+	tests := []struct {
+		header string
+		match  bool
+	}{
+		{digest(body), true},
+		{"sha-256=LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ=", true},
+		{"SHA-512=abc, " + digest(body), true},
+		{digest([]byte("other")), false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := matchesDigest(tt.header, body); got != tt.match {
+			t.Errorf("matchesDigest(%q): expected %v, got %v", tt.header, tt.match, got)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package graphql
+
+import (
+	"encoding/base64"
+)
+
+const (
+	// DefaultPageSize is the number of list items returned when first is omitted
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page a client may request with first
+	MaxPageSize = 100
+)
+
+// connection is a page of a list in the cursor connection format
+type connection struct {
+	Edges      []edge
+	PageInfo   pageInfo
+	TotalCount int
+}
+
+type edge struct {
+	Cursor string
+	Node   interface{}
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+// encodeCursor makes an opaque cursor from an item ID. Cursors point at
+// items rather than offsets, so pages stay stable as new tweets arrive.
+func encodeCursor(id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	id, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(id) == 0 {
+		return "", errInvalidCursor
+	}
+	return string(id), nil
+}
+
+// paginate returns up to first items following the item the after cursor
+// points at, or from the start when after is empty
+func paginate[T any](items []T, id func(T) string, first int, after string) (*connection, error) {
+	if first < 1 || first > MaxPageSize {
+		return nil, errInvalidPageSize
+	}
+
+	start := 0
+	if after != "" {
+		afterID, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		start = -1
+		for i, item := range items {
+			if id(item) == afterID {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, errInvalidCursor
+		}
+	}
+
+	end := min(start+first, len(items))
+	conn := &connection{
+		Edges:      make([]edge, 0, end-start),
+		PageInfo:   pageInfo{HasNextPage: end < len(items)},
+		TotalCount: len(items),
+	}
+	for _, item := range items[start:end] {
+		conn.Edges = append(conn.Edges, edge{Cursor: encodeCursor(id(item)), Node: item})
+	}
+	if len(conn.Edges) > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn, nil
+}
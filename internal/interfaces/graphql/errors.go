@@ -0,0 +1,120 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+
+	"uala-challenge/internal/domain"
+)
+
+// apiError is an error with a stable, machine-readable code, reported to
+// clients in the error's extensions as {"code":...} plus any details
+type apiError struct {
+	code    string
+	message string
+	details map[string]interface{}
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError
+func (e *apiError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	for key, value := range e.details {
+		extensions[key] = value
+	}
+	return extensions
+}
+
+// Request and resolver errors
+var (
+	errMissingUserID   = &apiError{code: "missing_user_id", message: "User ID required in X-User-ID header"}
+	errMissingQuery    = &apiError{code: "missing_query", message: "GraphQL query required"}
+	errInvalidCursor   = &apiError{code: "invalid_cursor", message: "Cursor does not refer to an item in this list"}
+	errInvalidPageSize = &apiError{code: "invalid_page_size", message: fmt.Sprintf("first must be between 1 and %d", MaxPageSize),
+		details: map[string]interface{}{"max_page_size": MaxPageSize}}
+	errInternal = &apiError{code: "internal_error", message: "An unexpected error occurred"}
+)
+
+// invalidRequest reports a request body or parameter that could not be decoded
+func invalidRequest(err error) *apiError {
+	return &apiError{code: "invalid_request", message: "Invalid GraphQL request",
+		details: map[string]interface{}{"reason": err.Error()}}
+}
+
+// queryTooDeep reports a query nested deeper than maxDepth
+func queryTooDeep(depth, maxDepth int) *apiError {
+	return &apiError{code: "query_too_deep", message: fmt.Sprintf("Query depth %d exceeds the limit of %d", depth, maxDepth),
+		details: map[string]interface{}{"depth": depth, "max_depth": maxDepth}}
+}
+
+// queryTooComplex reports a query whose estimated cost exceeds maxComplexity
+func queryTooComplex(complexity, maxComplexity int) *apiError {
+	return &apiError{code: "query_too_complex", message: fmt.Sprintf("Query complexity %d exceeds the limit of %d", complexity, maxComplexity),
+		details: map[string]interface{}{"complexity": complexity, "max_complexity": maxComplexity}}
+}
+
+// domainErrors maps domain errors that resolvers may surface. Errors are
+// matched with errors.Is, so wrapped domain errors map correctly.
+var domainErrors = []struct {
+	err    error
+	apiErr *apiError
+}{
+	{domain.ErrUserNotFound, &apiError{code: "user_not_found", message: "User not found"}},
+}
+
+// toAPIError maps a service error to its client representation. Unknown
+// errors are logged and become a generic internal error so that internals
+// never leak to clients.
+func toAPIError(ctx context.Context, err error) error {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping.apiErr
+		}
+	}
+
+	slog.ErrorContext(ctx, "graphql resolver failed", "error", err)
+	return errInternal
+}
+
+// withExtensions fills in the extensions of errors raised by thunks, which
+// the executor reports without them
+func withExtensions(errs []gqlerrors.FormattedError) []gqlerrors.FormattedError {
+	for i, err := range errs {
+		if err.Extensions != nil {
+			continue
+		}
+		if apiErr := originalAPIError(err); apiErr != nil {
+			errs[i].Extensions = apiErr.Extensions()
+		}
+	}
+	return errs
+}
+
+// originalAPIError unwraps the executor's error wrappers down to an apiError
+func originalAPIError(err error) *apiError {
+	for err != nil {
+		switch e := err.(type) {
+		case *apiError:
+			return e
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+// Package graphql serves a GraphQL endpoint so that clients can fetch users,
+// their tweets and follow relationships in one round trip. Resolvers batch
+// their lookups per request, and queries are rejected before they run if
+// they nest too deeply or would fetch too much.
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"uala-challenge/internal/application"
+)
+
+// maxRequestSize bounds the size of a POSTed request body
+const maxRequestSize = 1 << 20
+
+// Handler serves GraphQL queries over HTTP GET and POST
+type Handler struct {
+	schema        graphql.Schema
+	tweetService  application.TweetServiceInterface
+	followService application.FollowServiceInterface
+	userService   application.UserServiceInterface
+	maxDepth      int
+	maxComplexity int
+}
+
+// HandlerOption configures optional handler behaviour
+type HandlerOption func(*Handler)
+
+// WithMaxDepth overrides the default query depth limit
+func WithMaxDepth(maxDepth int) HandlerOption {
+	return func(h *Handler) {
+		h.maxDepth = maxDepth
+	}
+}
+
+// WithMaxComplexity overrides the default query complexity limit
+func WithMaxComplexity(maxComplexity int) HandlerOption {
+	return func(h *Handler) {
+		h.maxComplexity = maxComplexity
+	}
+}
+
+// NewHandler creates a GraphQL handler resolving through the given services
+func NewHandler(tweetService application.TweetServiceInterface, followService application.FollowServiceInterface, userService application.UserServiceInterface, opts ...HandlerOption) *Handler {
+	schema, err := newSchema()
+	if err != nil {
+		// The schema is static, so this is a programming error
+		panic("graphql: invalid schema: " + err.Error())
+	}
+
+	h := &Handler{
+		schema:        schema,
+		tweetService:  tweetService,
+		followService: followService,
+		userService:   userService,
+		maxDepth:      DefaultMaxDepth,
+		maxComplexity: DefaultMaxComplexity,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Request is a GraphQL request, sent as a JSON body or as query parameters
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ServeHTTP runs a query. Requests that cannot be parsed, fail validation
+// or exceed the limits are answered with 400 and never reach the services;
+// errors raised while resolving are reported next to the data with 200.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, apiErr := decodeRequest(w, r)
+	if apiErr != nil {
+		writeErrors(w, requestErrors(apiErr))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		writeErrors(w, invalidQuery(gqlerrors.FormatErrors(err)))
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		writeErrors(w, invalidQuery(validation.Errors))
+		return
+	}
+
+	cost := measure(&h.schema, doc, req.OperationName, req.Variables)
+	if cost.depth > h.maxDepth {
+		writeErrors(w, requestErrors(queryTooDeep(cost.depth, h.maxDepth)))
+		return
+	}
+	if cost.complexity > h.maxComplexity {
+		writeErrors(w, requestErrors(queryTooComplex(cost.complexity, h.maxComplexity)))
+		return
+	}
+
+	ctx := withRequest(r.Context(), &request{
+		viewerID:      r.Header.Get("X-User-ID"),
+		loaders:       newLoaders(h.tweetService, h.followService, h.userService),
+		followService: h.followService,
+	})
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	result.Errors = withExtensions(result.Errors)
+	writeJSON(w, http.StatusOK, result)
+}
+
+// decodeRequest reads a request from the query string of a GET or from the
+// JSON body of a POST
+func decodeRequest(w http.ResponseWriter, r *http.Request) (*Request, *apiError) {
+	req := &Request{}
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, invalidRequest(err)
+			}
+		}
+	} else {
+		body := http.MaxBytesReader(w, r.Body, maxRequestSize)
+		if err := json.NewDecoder(body).Decode(req); err != nil {
+			return nil, invalidRequest(err)
+		}
+	}
+
+	if req.Query == "" {
+		return nil, errMissingQuery
+	}
+	return req, nil
+}
+
+// requestErrors formats an error that rejects a request
+func requestErrors(err *apiError) []gqlerrors.FormattedError {
+	formatted := gqlerrors.FormatError(err)
+	formatted.Extensions = err.Extensions()
+	return []gqlerrors.FormattedError{formatted}
+}
+
+// invalidQuery tags parse and validation errors with a code
+func invalidQuery(errs []gqlerrors.FormattedError) []gqlerrors.FormattedError {
+	for i := range errs {
+		errs[i].Extensions = map[string]interface{}{"code": "invalid_query"}
+	}
+	return errs
+}
+
+// writeErrors answers a request rejected before execution. The response
+// carries no data entry, telling clients that nothing ran.
+func writeErrors(w http.ResponseWriter, errs []gqlerrors.FormattedError) {
+	writeJSON(w, http.StatusBadRequest, struct {
+		Errors []gqlerrors.FormattedError `json:"errors"`
+	}{errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
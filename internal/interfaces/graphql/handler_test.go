@@ -0,0 +1,443 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+// countingTweetService counts batch lookups
+type countingTweetService struct {
+	application.TweetServiceInterface
+	batches int
+}
+
+func (s *countingTweetService) GetTweetsByUserIDs(ctx context.Context, userIDs []string) (map[string][]*domain.Tweet, error) {
+	s.batches++
+	return s.TweetServiceInterface.GetTweetsByUserIDs(ctx, userIDs)
+}
+
+type countingFollowService struct {
+	application.FollowServiceInterface
+	batches int
+}
+
+func (s *countingFollowService) GetFollowees(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	s.batches++
+	return s.FollowServiceInterface.GetFollowees(ctx, userIDs)
+}
+
+func (s *countingFollowService) GetFollowers(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	s.batches++
+	return s.FollowServiceInterface.GetFollowers(ctx, userIDs)
+}
+
+type countingUserService struct {
+	application.UserServiceInterface
+	batches int
+}
+
+func (s *countingUserService) GetUsers(ctx context.Context, ids []string) (map[string]*domain.User, error) {
+	s.batches++
+	return s.UserServiceInterface.GetUsers(ctx, ids)
+}
+
+type testServer struct {
+	handler       *Handler
+	tweetService  *countingTweetService
+	followService *countingFollowService
+	userService   *countingUserService
+}
+
+// newTestServer creates a handler over in-memory storage where alice, bob,
+// carol and dave have tweeted, bob follows alice, carol and dave, and eve,
+// who has never tweeted, follows alice
+func newTestServer(t *testing.T, opts ...HandlerOption) *testServer {
+	t.Helper()
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+
+	s := &testServer{
+		tweetService:  &countingTweetService{TweetServiceInterface: services.NewTweetService(tweetRepo, userRepo)},
+		followService: &countingFollowService{FollowServiceInterface: services.NewFollowService(followRepo, tweetRepo)},
+		userService:   &countingUserService{UserServiceInterface: services.NewUserService(userRepo)},
+	}
+	s.handler = NewHandler(s.tweetService, s.followService, s.userService, opts...)
+
+	ctx := context.Background()
+	for _, tweet := range []services.CreateTweetRequest{
+		{UserID: "alice", Content: "First"},
+		{UserID: "alice", Content: "Second"},
+		{UserID: "alice", Content: "Third"},
+		{UserID: "carol", Content: "Hello"},
+		{UserID: "dave", Content: "Hi"},
+		{UserID: "bob", Content: "Hey"},
+	} {
+		if _, err := s.tweetService.CreateTweet(ctx, tweet); err != nil {
+			t.Fatalf("Failed to create tweet: %v", err)
+		}
+	}
+	for _, follow := range []services.FollowUserRequest{
+		{FollowerID: "bob", FolloweeID: "alice"},
+		{FollowerID: "bob", FolloweeID: "carol"},
+		{FollowerID: "bob", FolloweeID: "dave"},
+		{FollowerID: "eve", FolloweeID: "alice"},
+	} {
+		if err := s.followService.FollowUser(ctx, follow); err != nil {
+			t.Fatalf("Failed to follow: %v", err)
+		}
+	}
+	return s
+}
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// code returns the code of the first error, if any
+func (r response) code() string {
+	if len(r.Errors) == 0 {
+		return ""
+	}
+	code, _ := r.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+func (s *testServer) post(t *testing.T, userID string, req Request) (int, response) {
+	t.Helper()
+
+	body, _ := json.Marshal(req)
+	httpReq := httptest.NewRequest("POST", "/graphql", bytes.NewReader(body))
+	if userID != "" {
+		httpReq.Header.Set("X-User-ID", userID)
+	}
+	return s.serve(t, httpReq)
+}
+
+func (s *testServer) serve(t *testing.T, req *http.Request) (int, response) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, req)
+
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+// lookup walks a decoded JSON value along path
+func lookup(value interface{}, path ...interface{}) interface{} {
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			m, _ := value.(map[string]interface{})
+			value = m[k]
+		case int:
+			list, _ := value.([]interface{})
+			if k >= len(list) {
+				return nil
+			}
+			value = list[k]
+		}
+	}
+	return value
+}
+
+func TestHandler_ProfileQuery(t *testing.T) {
+	s := newTestServer(t)
+
+	status, resp := s.post(t, "", Request{Query: `{
+		user(id: "alice") {
+			id
+			name
+			tweets(first: 2) {
+				totalCount
+				edges { node { content author { id } mediaIds } }
+				pageInfo { hasNextPage }
+			}
+			following { totalCount }
+			followers { totalCount edges { node { id name } } }
+		}
+	}`})
+
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("Expected status 200 without errors, got %d: %+v", status, resp.Errors)
+	}
+	user := lookup(resp.Data, "user")
+	tests := []struct {
+		path     []interface{}
+		expected interface{}
+	}{
+		{[]interface{}{"id"}, "alice"},
+		{[]interface{}{"name"}, "User-alice"},
+		{[]interface{}{"tweets", "totalCount"}, float64(3)},
+		{[]interface{}{"tweets", "edges", 0, "node", "content"}, "Third"},
+		{[]interface{}{"tweets", "edges", 1, "node", "author", "id"}, "alice"},
+		{[]interface{}{"tweets", "pageInfo", "hasNextPage"}, true},
+		{[]interface{}{"following", "totalCount"}, float64(0)},
+		{[]interface{}{"followers", "totalCount"}, float64(2)},
+		{[]interface{}{"followers", "edges", 0, "node", "name"}, "User-bob"},
+		{[]interface{}{"followers", "edges", 1, "node", "id"}, "eve"},
+		// eve has never tweeted, so has no user record
+		{[]interface{}{"followers", "edges", 1, "node", "name"}, nil},
+	}
+	for _, tt := range tests {
+		if got := lookup(user, tt.path...); got != tt.expected {
+			t.Errorf("Expected %v at %v, got %v", tt.expected, tt.path, got)
+		}
+	}
+}
+
+func TestHandler_UnknownUser(t *testing.T) {
+	s := newTestServer(t)
+
+	status, resp := s.post(t, "", Request{Query: `{ user(id: "nobody") { id } }`})
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("Expected status 200 without errors, got %d: %+v", status, resp.Errors)
+	}
+	if user, ok := resp.Data["user"]; !ok || user != nil {
+		t.Errorf("Expected user to be null, got %v", user)
+	}
+}
+
+func TestHandler_BatchesLookups(t *testing.T) {
+	s := newTestServer(t)
+
+	status, resp := s.post(t, "", Request{Query: `{
+		user(id: "bob") {
+			following {
+				edges {
+					node {
+						name
+						tweets(first: 1) { edges { node { author { name } } } }
+						followers { totalCount }
+						following { totalCount }
+					}
+				}
+			}
+		}
+	}`})
+
+	if status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("Expected status 200 without errors, got %d: %+v", status, resp.Errors)
+	}
+	if edges, _ := lookup(resp.Data, "user", "following", "edges").([]interface{}); len(edges) != 3 {
+		t.Fatalf("Expected 3 followees, got %d", len(edges))
+	}
+	if name := lookup(resp.Data, "user", "following", "edges", 2, "node", "tweets", "edges", 0, "node", "author", "name"); name != "User-dave" {
+		t.Errorf("Expected User-dave, got %v", name)
+	}
+
+	// One lookup for bob's user record, one for the followees' names, whose
+	// tweet authors are then served from the cache
+	if s.userService.batches != 2 {
+		t.Errorf("Expected 2 user lookups, got %d", s.userService.batches)
+	}
+	if s.tweetService.batches != 1 {
+		t.Errorf("Expected 1 tweet lookup, got %d", s.tweetService.batches)
+	}
+	// bob's followees, then the followees' followers and followees
+	if s.followService.batches != 3 {
+		t.Errorf("Expected 3 follow lookups, got %d", s.followService.batches)
+	}
+}
+
+func TestHandler_Pagination(t *testing.T) {
+	s := newTestServer(t)
+	query := `query Tweets($after: String) {
+		user(id: "alice") {
+			tweets(first: 2, after: $after) {
+				edges { cursor node { content } }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	_, first := s.post(t, "", Request{Query: query})
+	endCursor, _ := lookup(first.Data, "user", "tweets", "pageInfo", "endCursor").(string)
+	if endCursor == "" || endCursor != lookup(first.Data, "user", "tweets", "edges", 1, "cursor") {
+		t.Fatalf("Expected endCursor to be the last edge's cursor, got %v", first.Data)
+	}
+
+	_, second := s.post(t, "", Request{Query: query, Variables: map[string]interface{}{"after": endCursor}})
+	edges, _ := lookup(second.Data, "user", "tweets", "edges").([]interface{})
+	if len(edges) != 1 || lookup(edges, 0, "node", "content") != "First" {
+		t.Errorf("Expected the oldest tweet on the second page, got %v", edges)
+	}
+	if lookup(second.Data, "user", "tweets", "pageInfo", "hasNextPage") != false {
+		t.Error("Expected no next page")
+	}
+
+	tests := []struct {
+		name      string
+		variables map[string]interface{}
+		query     string
+		code      string
+	}{
+		{"unknown cursor", map[string]interface{}{"after": encodeCursor("missing")}, query, "invalid_cursor"},
+		{"malformed cursor", map[string]interface{}{"after": "!"}, query, "invalid_cursor"},
+		{"page too large", nil, `{ user(id: "alice") { tweets(first: 101) { totalCount } } }`, "invalid_page_size"},
+		{"empty page", nil, `{ user(id: "alice") { tweets(first: 0) { totalCount } } }`, "invalid_page_size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := s.post(t, "", Request{Query: tt.query, Variables: tt.variables})
+			if status != http.StatusOK {
+				t.Errorf("Expected status 200, got %d", status)
+			}
+			if resp.code() != tt.code {
+				t.Errorf("Expected error code %s, got %+v", tt.code, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestHandler_Timeline(t *testing.T) {
+	s := newTestServer(t)
+	query := Request{Query: `{ timeline(first: 10) { totalCount edges { node { author { id } } } } }`}
+
+	_, resp := s.post(t, "bob", query)
+	if total := lookup(resp.Data, "timeline", "totalCount"); total != float64(5) {
+		t.Errorf("Expected 5 timeline tweets, got %v", total)
+	}
+
+	_, resp = s.post(t, "", query)
+	if resp.code() != "missing_user_id" {
+		t.Errorf("Expected error code missing_user_id, got %+v", resp.Errors)
+	}
+}
+
+func TestHandler_Limits(t *testing.T) {
+	s := newTestServer(t, WithMaxDepth(6), WithMaxComplexity(150))
+
+	tests := []struct {
+		name  string
+		query string
+		code  string
+	}{
+		{
+			name:  "within limits",
+			query: `{ user(id: "alice") { tweets(first: 5) { edges { node { content } } } } }`,
+		},
+		{
+			name:  "too deep",
+			query: `{ user(id: "bob") { following(first: 1) { edges { node { following(first: 1) { edges { node { id } } } } } } } }`,
+			code:  "query_too_deep",
+		},
+		{
+			name:  "too complex",
+			query: `{ user(id: "bob") { following(first: 70) { edges { node { id name } } } } }`,
+			code:  "query_too_complex",
+		},
+		{
+			name:  "default page size counts",
+			query: `{ user(id: "bob") { following { edges { node { id name } } } followers { edges { node { id name } } } tweets { edges { node { id content } } } } }`,
+			code:  "query_too_complex",
+		},
+		{
+			name:  "fragments count",
+			query: `{ user(id: "bob") { ...Follows } } fragment Follows on User { following(first: 70) { edges { node { id name } } } }`,
+			code:  "query_too_complex",
+		},
+		{
+			name:  "introspection is free",
+			query: `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.tweetService.batches = 0
+
+			status, resp := s.post(t, "", Request{Query: tt.query})
+			if tt.code == "" {
+				if status != http.StatusOK || len(resp.Errors) > 0 {
+					t.Errorf("Expected status 200 without errors, got %d: %+v", status, resp.Errors)
+				}
+				return
+			}
+			if status != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", status)
+			}
+			if resp.code() != tt.code {
+				t.Errorf("Expected error code %s, got %+v", tt.code, resp.Errors)
+			}
+			if resp.Data != nil {
+				t.Errorf("Expected no data for a rejected query, got %v", resp.Data)
+			}
+		})
+	}
+}
+
+func TestHandler_ComplexityUsesVariables(t *testing.T) {
+	s := newTestServer(t, WithMaxComplexity(200))
+	query := `query Following($first: Int = 10) { user(id: "bob") { following(first: $first) { edges { node { id name } } } } }`
+
+	status, _ := s.post(t, "", Request{Query: query})
+	if status != http.StatusOK {
+		t.Errorf("Expected the default value to be within limits, got status %d", status)
+	}
+
+	status, resp := s.post(t, "", Request{Query: query, Variables: map[string]interface{}{"first": 100}})
+	if status != http.StatusBadRequest || resp.code() != "query_too_complex" {
+		t.Errorf("Expected the variable to exceed the limit, got status %d: %+v", status, resp.Errors)
+	}
+}
+
+func TestHandler_InvalidRequests(t *testing.T) {
+	s := newTestServer(t)
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		code string
+	}{
+		{"malformed body", httptest.NewRequest("POST", "/graphql", strings.NewReader(`{`)), "invalid_request"},
+		{"missing query", httptest.NewRequest("POST", "/graphql", strings.NewReader(`{}`)), "missing_query"},
+		{"syntax error", httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ user("}`)), "invalid_query"},
+		{"unknown field", httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ tweets { id } }"}`)), "invalid_query"},
+		{"malformed variables", httptest.NewRequest("GET", "/graphql?query=%7B__typename%7D&variables=%7B", nil), "invalid_request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, resp := s.serve(t, tt.req)
+			if status != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d", status)
+			}
+			if resp.code() != tt.code {
+				t.Errorf("Expected error code %s, got %+v", tt.code, resp.Errors)
+			}
+		})
+	}
+}
+
+func TestHandler_GET(t *testing.T) {
+	s := newTestServer(t)
+
+	params := url.Values{
+		"query":     {`query User($id: ID!) { user(id: $id) { name } }`},
+		"variables": {`{"id": "carol"}`},
+	}
+	status, resp := s.serve(t, httptest.NewRequest("GET", "/graphql?"+params.Encode(), nil))
+	if status != http.StatusOK || lookup(resp.Data, "user", "name") != "User-carol" {
+		t.Errorf("Expected carol's name, got %d: %v %+v", status, resp.Data, resp.Errors)
+	}
+}
@@ -0,0 +1,202 @@
+package graphql
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const (
+	// DefaultMaxDepth is the deepest field nesting a query may use
+	DefaultMaxDepth = 10
+	// DefaultMaxComplexity is the highest estimated cost a query may have
+	DefaultMaxComplexity = 1000
+)
+
+// queryCost is the estimated size of an operation, measured before it runs
+type queryCost struct {
+	depth      int
+	complexity int
+}
+
+// costAnalysis walks an operation with the schema's field definitions.
+// Every field costs 1, and a list below a connection field multiplies the
+// cost of its selections by the page size the connection asks for, so that
+// totalCount and pageInfo stay cheap. Introspection fields are free, since
+// their size is bounded by the schema.
+type costAnalysis struct {
+	schema    *graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value
+}
+
+// measure returns the cost of the named operation, or of the only one when
+// operationName is empty. The document must already be validated, so that
+// fragments are known and acyclic.
+func measure(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) queryCost {
+	a := &costAnalysis{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		defaults:  make(map[string]ast.Value),
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch def := definition.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				operation = def
+			}
+		}
+	}
+	if operation == nil {
+		return queryCost{}
+	}
+
+	for _, definition := range operation.VariableDefinitions {
+		if definition.DefaultValue != nil {
+			a.defaults[definition.Variable.Name.Value] = definition.DefaultValue
+		}
+	}
+
+	var root *graphql.Object
+	switch operation.Operation {
+	case ast.OperationTypeMutation:
+		root = schema.MutationType()
+	case ast.OperationTypeSubscription:
+		root = schema.SubscriptionType()
+	default:
+		root = schema.QueryType()
+	}
+	if root == nil {
+		// The executor rejects operations the schema does not support
+		return queryCost{}
+	}
+	return a.selectionSet(operation.SelectionSet, root, 1)
+}
+
+// selectionSet returns the cost of a selection set on parent, counting the
+// fields of fragments as if they were selected directly. pageSize is the
+// length of lists selected in the set.
+func (a *costAnalysis) selectionSet(set *ast.SelectionSet, parent graphql.Type, pageSize int) queryCost {
+	var cost queryCost
+	if set == nil {
+		return cost
+	}
+
+	add := func(selection queryCost) {
+		cost.depth = max(cost.depth, selection.depth)
+		cost.complexity += selection.complexity
+	}
+	for _, selection := range set.Selections {
+		switch sel := selection.(type) {
+		case *ast.Field:
+			add(a.field(sel, parent, pageSize))
+		case *ast.InlineFragment:
+			add(a.selectionSet(sel.SelectionSet, a.typeCondition(sel.TypeCondition, parent), pageSize))
+		case *ast.FragmentSpread:
+			if fragment := a.fragments[sel.Name.Value]; fragment != nil {
+				add(a.selectionSet(fragment.SelectionSet, a.typeCondition(fragment.TypeCondition, parent), pageSize))
+			}
+		}
+	}
+	return cost
+}
+
+func (a *costAnalysis) field(field *ast.Field, parent graphql.Type, pageSize int) queryCost {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return queryCost{}
+	}
+
+	var definition *graphql.FieldDefinition
+	if object, ok := parent.(*graphql.Object); ok {
+		definition = object.Fields()[field.Name.Value]
+	}
+	if definition == nil {
+		return queryCost{depth: 1, complexity: 1}
+	}
+
+	childPageSize := 1
+	if takesArgument(definition, "first") {
+		childPageSize = a.pageSize(field)
+	}
+	named, _ := graphql.GetNamed(definition.Type).(graphql.Type)
+	children := a.selectionSet(field.SelectionSet, named, childPageSize)
+
+	multiplier := 1
+	if isList(definition.Type) {
+		multiplier = pageSize
+	}
+	return queryCost{
+		depth:      children.depth + 1,
+		complexity: 1 + multiplier*children.complexity,
+	}
+}
+
+// pageSize returns the page size a field asks for, clamped to the range
+// resolvers accept
+func (a *costAnalysis) pageSize(field *ast.Field) int {
+	size := DefaultPageSize
+	for _, argument := range field.Arguments {
+		if argument.Name.Value == "first" {
+			if value, ok := a.intValue(argument.Value); ok {
+				size = value
+			}
+		}
+	}
+	return min(max(size, 1), MaxPageSize)
+}
+
+// intValue resolves an integer literal or variable
+func (a *costAnalysis) intValue(value ast.Value) (int, bool) {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := a.variables[v.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+		if def, ok := a.defaults[v.Name.Value]; ok {
+			return a.intValue(def)
+		}
+	}
+	return 0, false
+}
+
+// typeCondition returns the type a fragment applies to
+func (a *costAnalysis) typeCondition(condition *ast.Named, parent graphql.Type) graphql.Type {
+	if condition == nil {
+		return parent
+	}
+	if t := a.schema.Type(condition.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+func isList(t graphql.Type) bool {
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+	_, ok := t.(*graphql.List)
+	return ok
+}
+
+func takesArgument(definition *graphql.FieldDefinition, name string) bool {
+	for _, arg := range definition.Args {
+		if arg.Name() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/domain"
+)
+
+// loader batches lookups by user ID. Resolvers register the IDs they need
+// and return a thunk; the executor calls thunks only after every sibling
+// field has resolved, so the first thunk called fetches all the IDs
+// registered so far with one service call. Results are cached for the rest
+// of the request.
+type loader[V any] struct {
+	fetch func(ctx context.Context, ids []string) (map[string]V, error)
+
+	mu      sync.Mutex
+	pending []string
+	queued  map[string]bool
+	values  map[string]V
+	errs    map[string]error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, ids []string) (map[string]V, error)) *loader[V] {
+	return &loader[V]{
+		fetch:  fetch,
+		queued: make(map[string]bool),
+		values: make(map[string]V),
+		errs:   make(map[string]error),
+	}
+}
+
+// load registers id for the next batch and returns a thunk yielding its
+// value. Missing IDs yield the zero value.
+func (l *loader[V]) load(ctx context.Context, id string) func() (V, error) {
+	l.mu.Lock()
+	if !l.queued[id] {
+		l.queued[id] = true
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 && !l.done(id) {
+			l.dispatch(ctx)
+		}
+		return l.values[id], l.errs[id]
+	}
+}
+
+// done reports whether id has been fetched
+func (l *loader[V]) done(id string) bool {
+	if _, ok := l.values[id]; ok {
+		return true
+	}
+	_, ok := l.errs[id]
+	return ok
+}
+
+// dispatch fetches every pending ID in one call. The caller holds l.mu.
+func (l *loader[V]) dispatch(ctx context.Context) {
+	ids := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ctx, ids)
+	for _, id := range ids {
+		if err != nil {
+			l.errs[id] = err
+			continue
+		}
+		l.values[id] = values[id]
+	}
+}
+
+// loaders holds the per-request batch loaders
+type loaders struct {
+	users     *loader[*domain.User]
+	tweets    *loader[[]*domain.Tweet]
+	followees *loader[[]string]
+	followers *loader[[]string]
+}
+
+func newLoaders(tweetService application.TweetServiceInterface, followService application.FollowServiceInterface, userService application.UserServiceInterface) *loaders {
+	return &loaders{
+		users:     newLoader(userService.GetUsers),
+		tweets:    newLoader(tweetService.GetTweetsByUserIDs),
+		followees: newLoader(followService.GetFollowees),
+		followers: newLoader(followService.GetFollowers),
+	}
+}
+
+// request carries per-request state to the resolvers
+type request struct {
+	viewerID      string
+	loaders       *loaders
+	followService application.FollowServiceInterface
+}
+
+type requestKey struct{}
+
+func withRequest(ctx context.Context, req *request) context.Context {
+	return context.WithValue(ctx, requestKey{}, req)
+}
+
+func requestFrom(ctx context.Context) *request {
+	req, _ := ctx.Value(requestKey{}).(*request)
+	return req
+}
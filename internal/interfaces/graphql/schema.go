@@ -0,0 +1,232 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+
+	"uala-challenge/internal/domain"
+)
+
+// newSchema builds the schema. Users are resolved from their ID alone, so
+// that lists of followers or tweet authors never load user records the
+// query does not ask for; names, tweets and follows are fetched through the
+// request's batch loaders.
+func newSchema() (graphql.Schema, error) {
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "PageInfo",
+		Description: "Information for fetching the next page of a connection",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"endCursor":   &graphql.Field{Type: graphql.String, Description: "Cursor of the last edge, to pass as after"},
+		},
+	})
+
+	var userType *graphql.Object
+	tweetType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Tweet",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":        &graphql.Field{Type: graphql.NewNonNull(graphql.ID)},
+				"content":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+				"mediaIds": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.ID))),
+					Resolve: resolveTweetMediaIDs,
+				},
+				"author": &graphql.Field{
+					Type:    graphql.NewNonNull(userType),
+					Resolve: resolveTweetAuthor,
+				},
+			}
+		}),
+	})
+	tweetConnectionType := connectionType("Tweet", tweetType, pageInfoType)
+
+	var userConnectionType *graphql.Object
+	userType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id": &graphql.Field{
+					Type:    graphql.NewNonNull(graphql.ID),
+					Resolve: resolveUserID,
+				},
+				"name": &graphql.Field{
+					Type:        graphql.String,
+					Description: "Display name, or null for users who have never tweeted",
+					Resolve:     resolveUserName,
+				},
+				"tweets": &graphql.Field{
+					Type:        graphql.NewNonNull(tweetConnectionType),
+					Description: "The user's tweets, newest first",
+					Args:        connectionArgs(),
+					Resolve:     resolveUserTweets,
+				},
+				"following": &graphql.Field{
+					Type:        graphql.NewNonNull(userConnectionType),
+					Description: "Users this user follows",
+					Args:        connectionArgs(),
+					Resolve:     resolveUserFollowing,
+				},
+				"followers": &graphql.Field{
+					Type:        graphql.NewNonNull(userConnectionType),
+					Description: "Users following this user",
+					Args:        connectionArgs(),
+					Resolve:     resolveUserFollowers,
+				},
+			}
+		}),
+	})
+	userConnectionType = connectionType("User", userType, pageInfoType)
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type:        userType,
+				Description: "Looks up a user by ID, or returns null if the user is unknown",
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: resolveUser,
+			},
+			"timeline": &graphql.Field{
+				Type:        graphql.NewNonNull(tweetConnectionType),
+				Description: "Tweets from users the caller follows, newest first. Requires the X-User-ID header.",
+				Args:        connectionArgs(),
+				Resolve:     resolveTimeline,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// connectionType builds the connection and edge types for a list of node
+func connectionType(name string, node *graphql.Object, pageInfo *graphql.Object) *graphql.Object {
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Edge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(node)},
+		},
+	})
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name + "Connection",
+		Fields: graphql.Fields{
+			"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfo)},
+			"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+}
+
+// connectionArgs are the forward pagination arguments of a connection field
+func connectionArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"first": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: DefaultPageSize},
+		"after": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+}
+
+// pageArgs reads the pagination arguments of a connection field
+func pageArgs(p graphql.ResolveParams) (first int, after string) {
+	first, _ = p.Args["first"].(int)
+	after, _ = p.Args["after"].(string)
+	return first, after
+}
+
+func resolveUser(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	load := requestFrom(p.Context).loaders.users.load(p.Context, id)
+	return func() (interface{}, error) {
+		user, err := load()
+		if err != nil {
+			return nil, toAPIError(p.Context, err)
+		}
+		if user == nil {
+			return nil, nil
+		}
+		return user.ID, nil
+	}, nil
+}
+
+func resolveTimeline(p graphql.ResolveParams) (interface{}, error) {
+	req := requestFrom(p.Context)
+	if req.viewerID == "" {
+		return nil, errMissingUserID
+	}
+
+	tweets, err := req.followService.GetTimeline(p.Context, req.viewerID)
+	if err != nil {
+		return nil, toAPIError(p.Context, err)
+	}
+	first, after := pageArgs(p)
+	return paginate(tweets, tweetID, first, after)
+}
+
+func resolveTweetMediaIDs(p graphql.ResolveParams) (interface{}, error) {
+	tweet := p.Source.(*domain.Tweet)
+	if tweet.MediaIDs == nil {
+		return []string{}, nil
+	}
+	return tweet.MediaIDs, nil
+}
+
+func resolveTweetAuthor(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(*domain.Tweet).UserID, nil
+}
+
+func resolveUserID(p graphql.ResolveParams) (interface{}, error) {
+	return p.Source.(string), nil
+}
+
+func resolveUserName(p graphql.ResolveParams) (interface{}, error) {
+	load := requestFrom(p.Context).loaders.users.load(p.Context, p.Source.(string))
+	return func() (interface{}, error) {
+		user, err := load()
+		if err != nil {
+			return nil, toAPIError(p.Context, err)
+		}
+		if user == nil {
+			return nil, nil
+		}
+		return user.Name, nil
+	}, nil
+}
+
+func resolveUserTweets(p graphql.ResolveParams) (interface{}, error) {
+	load := requestFrom(p.Context).loaders.tweets.load(p.Context, p.Source.(string))
+	return func() (interface{}, error) {
+		tweets, err := load()
+		if err != nil {
+			return nil, toAPIError(p.Context, err)
+		}
+		first, after := pageArgs(p)
+		return paginate(tweets, tweetID, first, after)
+	}, nil
+}
+
+func resolveUserFollowing(p graphql.ResolveParams) (interface{}, error) {
+	return userConnection(p, requestFrom(p.Context).loaders.followees)
+}
+
+func resolveUserFollowers(p graphql.ResolveParams) (interface{}, error) {
+	return userConnection(p, requestFrom(p.Context).loaders.followers)
+}
+
+// userConnection pages through the user IDs l holds for the source user
+func userConnection(p graphql.ResolveParams, l *loader[[]string]) (interface{}, error) {
+	load := l.load(p.Context, p.Source.(string))
+	return func() (interface{}, error) {
+		userIDs, err := load()
+		if err != nil {
+			return nil, toAPIError(p.Context, err)
+		}
+		first, after := pageArgs(p)
+		return paginate(userIDs, userID, first, after)
+	}, nil
+}
+
+func tweetID(tweet *domain.Tweet) string { return tweet.ID }
+
+func userID(id string) string { return id }
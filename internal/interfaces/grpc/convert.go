@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// toProtoTweet converts a domain tweet to its protobuf message
+func toProtoTweet(tweet *domain.Tweet) *ualav1.Tweet {
+	msg := &ualav1.Tweet{
+		Id:        tweet.ID,
+		UserId:    tweet.UserID,
+		Content:   tweet.Content,
+		CreatedAt: timestamppb.New(tweet.CreatedAt),
+		MediaIds:  tweet.MediaIDs,
+	}
+	if tweet.Poll != nil {
+		msg.Poll = &ualav1.Poll{
+			EndsAt: timestamppb.New(tweet.Poll.EndsAt),
+			Closed: tweet.Poll.Closed,
+		}
+		for _, option := range tweet.Poll.Options {
+			msg.Poll.Options = append(msg.Poll.Options, &ualav1.PollOption{Text: option.Text})
+		}
+	}
+	return msg
+}
+
+// toProtoTweets converts domain tweets to a list response
+func toProtoTweets(tweets []*domain.Tweet) *ualav1.ListTweetsResponse {
+	resp := &ualav1.ListTweetsResponse{Tweets: make([]*ualav1.Tweet, 0, len(tweets))}
+	for _, tweet := range tweets {
+		resp.Tweets = append(resp.Tweets, toProtoTweet(tweet))
+	}
+	return resp
+}
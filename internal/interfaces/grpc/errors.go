@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+)
+
+// errorDomain identifies this API in google.rpc.ErrorInfo details
+const errorDomain = "uala-challenge"
+
+// rpcError is a status code with the stable error code the REST API uses
+// for the same failure, sent as the ErrorInfo reason
+type rpcError struct {
+	code     codes.Code
+	reason   string
+	message  string
+	metadata map[string]string
+}
+
+func (e *rpcError) Error() string {
+	return e.message
+}
+
+// Request errors raised by the servers themselves
+var (
+	errMissingUserID      = &rpcError{code: codes.Unauthenticated, reason: "missing_user_id", message: "User ID required in x-user-id metadata"}
+	errMissingUserIDParam = &rpcError{code: codes.InvalidArgument, reason: "missing_user_id", message: "User ID required"}
+	errMissingFolloweeID  = &rpcError{code: codes.InvalidArgument, reason: "missing_followee_id", message: "Followee ID required"}
+	errUsersDisabled      = &rpcError{code: codes.Unimplemented, reason: "users_disabled", message: "User lookups are not enabled"}
+	errStreamingDisabled  = &rpcError{code: codes.Unimplemented, reason: "streaming_disabled", message: "Live timelines are not enabled"}
+	errShuttingDown       = &rpcError{code: codes.Unavailable, reason: "shutting_down", message: "Server is shutting down"}
+	errInternal           = &rpcError{code: codes.Internal, reason: "internal_error", message: "An unexpected error occurred"}
+)
+
+// tweetTooLong reports content over the given length limit
+func tweetTooLong(maxLength int) *rpcError {
+	return &rpcError{
+		code:     codes.InvalidArgument,
+		reason:   "tweet_too_long",
+		message:  "Tweet content exceeds character limit",
+		metadata: map[string]string{"max_length": strconv.Itoa(maxLength)},
+	}
+}
+
+// domainErrors maps domain errors to gRPC statuses. Reasons match the REST
+// API's error codes. Errors are matched with errors.Is.
+var domainErrors = []struct {
+	err    error
+	rpcErr *rpcError
+}{
+	{domain.ErrTweetEmpty, &rpcError{code: codes.InvalidArgument, reason: "tweet_empty", message: "Tweet content cannot be empty"}},
+	{domain.ErrTweetTooLong, tweetTooLong(domain.MaxTweetLength)},
+	{domain.ErrUserNotFound, &rpcError{code: codes.NotFound, reason: "user_not_found", message: "User not found"}},
+	{domain.ErrCannotFollowSelf, &rpcError{code: codes.InvalidArgument, reason: "cannot_follow_self", message: "Cannot follow yourself"}},
+
+	{domain.ErrPollOptionCount, &rpcError{code: codes.InvalidArgument, reason: "poll_option_count", message: "Poll must have between 2 and 4 options",
+		metadata: map[string]string{"min_options": strconv.Itoa(domain.MinPollOptions), "max_options": strconv.Itoa(domain.MaxPollOptions)}}},
+	{domain.ErrPollOptionInvalid, &rpcError{code: codes.InvalidArgument, reason: "poll_option_invalid", message: "Poll options must be non-empty and at most 25 characters",
+		metadata: map[string]string{"max_option_length": strconv.Itoa(domain.MaxPollOptionLength)}}},
+	{domain.ErrPollDuration, &rpcError{code: codes.InvalidArgument, reason: "poll_duration_invalid", message: "Poll duration must be between 5 minutes and 7 days",
+		metadata: map[string]string{"min_duration_minutes": strconv.Itoa(int(domain.MinPollDuration.Minutes())), "max_duration_minutes": strconv.Itoa(int(domain.MaxPollDuration.Minutes()))}}},
+
+	{domain.ErrUnknownMediaID, &rpcError{code: codes.InvalidArgument, reason: "unknown_media_id", message: "Media ID does not refer to media uploaded by the author"}},
+	{domain.ErrTooManyMedia, &rpcError{code: codes.InvalidArgument, reason: "too_many_media", message: "Tweet exceeds media attachment limit",
+		metadata: map[string]string{"max_media": strconv.Itoa(domain.MaxMediaPerTweet)}}},
+
+	{services.ErrSubscriberTooSlow, &rpcError{code: codes.Unavailable, reason: "stream_lagged", message: "Client fell behind the live timeline; reconnect"}},
+}
+
+// toRPCError maps any error to its gRPC representation. Unknown errors
+// become a generic internal error so that internals never leak to clients.
+func toRPCError(err error) *rpcError {
+	var rpcErr *rpcError
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping.rpcErr
+		}
+	}
+
+	return errInternal
+}
+
+// toStatus converts err to a status error carrying an ErrorInfo detail.
+// Status errors pass through, and context errors keep their Canceled or
+// DeadlineExceeded code.
+func toStatus(ctx context.Context, err error) error {
+	if st, ok := status.FromError(err); ok {
+		return st.Err()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	rpcErr := toRPCError(err)
+	if rpcErr.code == codes.Internal {
+		slog.ErrorContext(ctx, "rpc failed", "error", err)
+	}
+
+	st := status.New(rpcErr.code, rpcErr.message)
+	withDetails, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   rpcErr.reason,
+		Domain:   errorDomain,
+		Metadata: rpcErr.metadata,
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
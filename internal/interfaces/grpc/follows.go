@@ -0,0 +1,54 @@
+package grpc
+
+import (
+	"context"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// followServer implements ualav1.FollowServiceServer
+type followServer struct {
+	ualav1.UnimplementedFollowServiceServer
+	*Server
+}
+
+// Follow makes the calling user follow another user
+func (s *followServer) Follow(ctx context.Context, req *ualav1.FollowRequest) (*ualav1.FollowResponse, error) {
+	followReq, err := followRequest(ctx, req.GetFolloweeId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	err = s.followService.FollowUser(ctx, followReq)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &ualav1.FollowResponse{}, nil
+}
+
+// Unfollow removes a follow relationship
+func (s *followServer) Unfollow(ctx context.Context, req *ualav1.UnfollowRequest) (*ualav1.UnfollowResponse, error) {
+	followReq, err := followRequest(ctx, req.GetFolloweeId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	err = s.followService.UnfollowUser(ctx, followReq)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &ualav1.UnfollowResponse{}, nil
+}
+
+// followRequest builds a follow request from the caller and followee
+func followRequest(ctx context.Context, followeeID string) (services.FollowUserRequest, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return services.FollowUserRequest{}, err
+	}
+	if followeeID == "" {
+		return services.FollowUserRequest{}, errMissingFolloweeID
+	}
+	return services.FollowUserRequest{FollowerID: userID, FolloweeID: followeeID}, nil
+}
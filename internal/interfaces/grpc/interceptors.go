@@ -0,0 +1,116 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+// unaryInterceptors returns the interceptors applied to unary calls, outermost first
+func (s *Server) unaryInterceptors() []grpc.UnaryServerInterceptor {
+	interceptors := []grpc.UnaryServerInterceptor{requestIDUnaryInterceptor}
+	if s.accessLogger != nil {
+		interceptors = append(interceptors, accessLogUnaryInterceptor(s.accessLogger))
+	}
+	return append(interceptors, recoveryUnaryInterceptor)
+}
+
+// streamInterceptors returns the interceptors applied to streaming calls, outermost first
+func (s *Server) streamInterceptors() []grpc.StreamServerInterceptor {
+	interceptors := []grpc.StreamServerInterceptor{requestIDStreamInterceptor}
+	if s.accessLogger != nil {
+		interceptors = append(interceptors, accessLogStreamInterceptor(s.accessLogger))
+	}
+	return append(interceptors, recoveryStreamInterceptor)
+}
+
+// withRequestID propagates a valid client-supplied request ID or generates a
+// new one, echoes it in the response header and stores it in the context
+func withRequestID(ctx context.Context) context.Context {
+	id := incomingValue(ctx, requestIDKey)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return requestid.NewContext(ctx, id)
+}
+
+func requestIDUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withRequestID(ctx), req)
+}
+
+func requestIDStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: stream, ctx: withRequestID(stream.Context())})
+}
+
+// contextStream overrides a server stream's context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// logCall logs one line per call with its method, status code, duration and
+// user. Internal errors are logged at error level.
+func logCall(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	if code == codes.Internal {
+		level = slog.LevelError
+	}
+	logger.LogAttrs(ctx, level, "rpc",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("user_id", incomingValue(ctx, userIDKey)),
+	)
+}
+
+func accessLogUnaryInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func accessLogStreamInterceptor(logger *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		logCall(stream.Context(), logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// recoveryUnaryInterceptor turns a panicking handler into an internal error
+func recoveryUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = toStatus(ctx, fmt.Errorf("panic in %s: %v", info.FullMethod, p))
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// recoveryStreamInterceptor turns a panicking stream handler into an internal error
+func recoveryStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = toStatus(stream.Context(), fmt.Errorf("panic in %s: %v", info.FullMethod, p))
+		}
+	}()
+	return handler(srv, stream)
+}
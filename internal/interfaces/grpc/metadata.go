@@ -0,0 +1,38 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+// userIDKey is the metadata key identifying the calling user, the gRPC
+// counterpart of the X-User-ID header
+const userIDKey = "x-user-id"
+
+// requestIDKey is the metadata key carrying the request ID
+var requestIDKey = strings.ToLower(requestid.Header)
+
+// incomingValue returns the first value of a metadata key, or ""
+func incomingValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// callerID returns the calling user's ID from metadata
+func callerID(ctx context.Context) (string, error) {
+	userID := incomingValue(ctx, userIDKey)
+	if userID == "" {
+		return "", errMissingUserID
+	}
+	return userID, nil
+}
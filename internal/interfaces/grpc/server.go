@@ -0,0 +1,108 @@
+// Package grpc serves the API over gRPC for internal backend services. The
+// services are defined in proto/uala/v1/uala.proto; regenerate the ualav1
+// package with go generate after changing it.
+package grpc
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=uala-challenge --go-grpc_out=../../.. --go-grpc_opt=module=uala-challenge uala/v1/uala.proto
+
+import (
+	"context"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc"
+
+	"uala-challenge/internal/application"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// Server serves the tweet, follow, timeline and user gRPC services
+type Server struct {
+	tweetService        application.TweetServiceInterface
+	followService       application.FollowServiceInterface
+	userService         application.UserServiceInterface
+	liveTimelineService application.LiveTimelineServiceInterface
+	maxTweetLength      int
+	accessLogger        *slog.Logger
+
+	grpcServer *grpc.Server
+	// stopping is cancelled when shutdown begins, ending live streams
+	stopping context.Context
+	stop     context.CancelFunc
+}
+
+// ServerOption configures optional server dependencies
+type ServerOption func(*Server)
+
+// WithUserService enables user lookups
+func WithUserService(userService application.UserServiceInterface) ServerOption {
+	return func(s *Server) {
+		s.userService = userService
+	}
+}
+
+// WithLiveTimelineService enables live timeline streaming
+func WithLiveTimelineService(liveTimelineService application.LiveTimelineServiceInterface) ServerOption {
+	return func(s *Server) {
+		s.liveTimelineService = liveTimelineService
+	}
+}
+
+// WithMaxTweetLength sets the tweet length limit reported in error details.
+// It should match the limit the tweet service enforces.
+func WithMaxTweetLength(maxLength int) ServerOption {
+	return func(s *Server) {
+		s.maxTweetLength = maxLength
+	}
+}
+
+// WithAccessLog logs every call to logger
+func WithAccessLog(logger *slog.Logger) ServerOption {
+	return func(s *Server) {
+		s.accessLogger = logger
+	}
+}
+
+// NewServer creates a gRPC server with every service registered
+func NewServer(tweetService application.TweetServiceInterface, followService application.FollowServiceInterface, opts ...ServerOption) *Server {
+	s := &Server{
+		tweetService:   tweetService,
+		followService:  followService,
+		maxTweetLength: domain.MaxTweetLength,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.stopping, s.stop = context.WithCancel(context.Background())
+
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryInterceptors()...),
+		grpc.ChainStreamInterceptor(s.streamInterceptors()...),
+	)
+	ualav1.RegisterTweetServiceServer(s.grpcServer, &tweetServer{Server: s})
+	ualav1.RegisterFollowServiceServer(s.grpcServer, &followServer{Server: s})
+	ualav1.RegisterTimelineServiceServer(s.grpcServer, &timelineServer{Server: s})
+	ualav1.RegisterUserServiceServer(s.grpcServer, &userServer{Server: s})
+	return s
+}
+
+// Serve serves gRPC on listener until ctx is done. It then ends live
+// streams and waits for in-flight calls to finish.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.grpcServer.Serve(listener)
+	}()
+	slog.Info("grpc server started", "addr", listener.Addr().String())
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.stop()
+	s.grpcServer.GracefulStop()
+	return <-serveErr
+}
@@ -0,0 +1,394 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/storage"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// testAPI holds clients connected over bufconn to a server backed by real
+// services over in-memory storage
+type testAPI struct {
+	tweets   ualav1.TweetServiceClient
+	follows  ualav1.FollowServiceClient
+	timeline ualav1.TimelineServiceClient
+	users    ualav1.UserServiceClient
+	// stop shuts the server down and returns Serve's error
+	stop func() error
+}
+
+func newTestAPI(t *testing.T, opts ...ServerOption) *testAPI {
+	t.Helper()
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	feed := services.NewTweetFeed(services.DefaultFeedBuffer)
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+
+	// Created tweets reach the live feed through the outbox and event bus
+	bus := services.NewEventBus(services.DefaultEventBuffer)
+	bus.Subscribe("live feed", feed.HandleEvent)
+	relay := services.NewOutboxRelay(storage.NewOutboxRepository(inMemoryStorage), bus, domain.SystemClock{})
+	uow := relay.UnitOfWork(storage.NewUnitOfWork(inMemoryStorage))
+
+	opts = append([]ServerOption{
+		WithUserService(services.NewUserService(userRepo)),
+		WithLiveTimelineService(services.NewLiveTimelineService(followRepo, feed)),
+	}, opts...)
+	server := NewServer(
+		services.NewTweetService(tweetRepo, userRepo, services.WithUnitOfWork(uow)),
+		services.NewFollowService(followRepo, tweetRepo, services.WithFollowUnitOfWork(uow)),
+		opts...,
+	)
+
+	listener := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(ctx, listener)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+
+	stopped := false
+	stop := func() error {
+		stopped = true
+		cancel()
+		return <-serveErr
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		if !stopped {
+			stop()
+		}
+	})
+
+	return &testAPI{
+		tweets:   ualav1.NewTweetServiceClient(conn),
+		follows:  ualav1.NewFollowServiceClient(conn),
+		timeline: ualav1.NewTimelineServiceClient(conn),
+		users:    ualav1.NewUserServiceClient(conn),
+		stop:     stop,
+	}
+}
+
+// as returns a context identifying the calling user
+func as(userID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), userIDKey, userID)
+}
+
+// errorInfo returns the status code and ErrorInfo detail of err
+func errorInfo(t *testing.T, err error) (codes.Code, *errdetails.ErrorInfo) {
+	t.Helper()
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("Expected a status error, got %v", err)
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return st.Code(), info
+		}
+	}
+	return st.Code(), nil
+}
+
+func TestServer_TweetsFollowsAndTimeline(t *testing.T) {
+	api := newTestAPI(t)
+
+	tweet, err := api.tweets.CreateTweet(as("alice"), &ualav1.CreateTweetRequest{
+		Content: "Tabs or spaces?",
+		Poll:    &ualav1.CreatePollRequest{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tweet.Id == "" || tweet.UserId != "alice" || tweet.CreatedAt == nil {
+		t.Errorf("Unexpected tweet %v", tweet)
+	}
+	if len(tweet.Poll.GetOptions()) != 2 || tweet.Poll.Options[1].Text != "Spaces" {
+		t.Errorf("Expected poll options to round-trip, got %v", tweet.Poll)
+	}
+
+	userTweets, err := api.tweets.ListUserTweets(context.Background(), &ualav1.ListUserTweetsRequest{UserId: "alice"})
+	if err != nil || len(userTweets.Tweets) != 1 {
+		t.Errorf("Expected 1 tweet by alice, got %v, %v", userTweets, err)
+	}
+
+	user, err := api.users.GetUser(context.Background(), &ualav1.GetUserRequest{UserId: "alice"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if user.Id != "alice" || user.Name != "User-alice" {
+		t.Errorf("Unexpected user %v", user)
+	}
+
+	if _, err := api.follows.Follow(as("bob"), &ualav1.FollowRequest{FolloweeId: "alice"}); err != nil {
+		t.Fatalf("Expected no error following, got %v", err)
+	}
+	timeline, err := api.timeline.GetTimeline(as("bob"), &ualav1.GetTimelineRequest{})
+	if err != nil || len(timeline.Tweets) != 1 || timeline.Tweets[0].Id != tweet.Id {
+		t.Errorf("Expected alice's tweet in bob's timeline, got %v, %v", timeline, err)
+	}
+
+	if _, err := api.follows.Unfollow(as("bob"), &ualav1.UnfollowRequest{FolloweeId: "alice"}); err != nil {
+		t.Fatalf("Expected no error unfollowing, got %v", err)
+	}
+	timeline, _ = api.timeline.GetTimeline(as("bob"), &ualav1.GetTimelineRequest{})
+	if len(timeline.GetTweets()) != 0 {
+		t.Errorf("Expected empty timeline after unfollowing, got %d tweets", len(timeline.GetTweets()))
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	api := newTestAPI(t, WithMaxTweetLength(10))
+
+	tests := []struct {
+		name           string
+		call           func() error
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{
+			name: "empty tweet",
+			call: func() error {
+				_, err := api.tweets.CreateTweet(as("alice"), &ualav1.CreateTweetRequest{})
+				return err
+			},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "tweet_empty",
+		},
+		{
+			name: "missing caller",
+			call: func() error {
+				_, err := api.tweets.CreateTweet(context.Background(), &ualav1.CreateTweetRequest{Content: "hi"})
+				return err
+			},
+			expectedCode:   codes.Unauthenticated,
+			expectedReason: "missing_user_id",
+		},
+		{
+			name: "invalid poll",
+			call: func() error {
+				_, err := api.tweets.CreateTweet(as("alice"), &ualav1.CreateTweetRequest{
+					Content: "Poll",
+					Poll:    &ualav1.CreatePollRequest{Options: []string{"Only"}, DurationMinutes: 60},
+				})
+				return err
+			},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "poll_option_count",
+		},
+		{
+			name: "follow self",
+			call: func() error {
+				_, err := api.follows.Follow(as("alice"), &ualav1.FollowRequest{FolloweeId: "alice"})
+				return err
+			},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "cannot_follow_self",
+		},
+		{
+			name: "missing followee",
+			call: func() error {
+				_, err := api.follows.Unfollow(as("alice"), &ualav1.UnfollowRequest{})
+				return err
+			},
+			expectedCode:   codes.InvalidArgument,
+			expectedReason: "missing_followee_id",
+		},
+		{
+			name: "unknown user",
+			call: func() error {
+				_, err := api.users.GetUser(context.Background(), &ualav1.GetUserRequest{UserId: "nobody"})
+				return err
+			},
+			expectedCode:   codes.NotFound,
+			expectedReason: "user_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, info := errorInfo(t, tt.call())
+			if code != tt.expectedCode {
+				t.Errorf("Expected code %s, got %s", tt.expectedCode, code)
+			}
+			if info == nil || info.Reason != tt.expectedReason || info.Domain != errorDomain {
+				t.Errorf("Expected reason %s, got %v", tt.expectedReason, info)
+			}
+		})
+	}
+
+	t.Run("too long tweet reports the configured limit", func(t *testing.T) {
+		_, err := api.tweets.CreateTweet(as("alice"), &ualav1.CreateTweetRequest{Content: strings.Repeat("a", 300)})
+		code, info := errorInfo(t, err)
+		if code != codes.InvalidArgument || info.GetReason() != "tweet_too_long" {
+			t.Errorf("Expected tweet_too_long, got %s %v", code, info)
+		}
+		if info.GetMetadata()["max_length"] != "10" {
+			t.Errorf("Expected max_length 10, got %v", info.GetMetadata())
+		}
+	})
+}
+
+func TestServer_EchoesRequestID(t *testing.T) {
+	api := newTestAPI(t)
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), requestIDKey, "client-request-1")
+	_, err := api.users.GetUser(ctx, &ualav1.GetUserRequest{UserId: "nobody"}, grpc.Header(&header))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if got := header.Get(requestIDKey); len(got) != 1 || got[0] != "client-request-1" {
+		t.Errorf("Expected request ID to be echoed, got %v", got)
+	}
+}
+
+// receive reads tweets from a stream into a channel until it ends, then
+// sends the stream's error
+func receive(stream ualav1.TimelineService_StreamTimelineClient) (<-chan *ualav1.Tweet, <-chan error) {
+	tweets := make(chan *ualav1.Tweet, 16)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			tweet, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			tweets <- tweet
+		}
+	}()
+	return tweets, errs
+}
+
+func TestServer_StreamTimeline(t *testing.T) {
+	api := newTestAPI(t)
+	if _, err := api.follows.Follow(as("bob"), &ualav1.FollowRequest{FolloweeId: "alice"}); err != nil {
+		t.Fatalf("Expected no error following, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(as("bob"))
+	defer cancel()
+	stream, err := api.timeline.StreamTimeline(ctx, &ualav1.StreamTimelineRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error opening stream, got %v", err)
+	}
+	tweets, errs := receive(stream)
+
+	// The stream subscribes asynchronously, so keep posting until a tweet
+	// arrives. Carol's tweets must never be delivered to bob.
+	var received *ualav1.Tweet
+	for deadline := time.Now().Add(5 * time.Second); received == nil && time.Now().Before(deadline); {
+		api.tweets.CreateTweet(as("carol"), &ualav1.CreateTweetRequest{Content: "Not followed"})
+		api.tweets.CreateTweet(as("alice"), &ualav1.CreateTweetRequest{Content: "Live"})
+		select {
+		case received = <-tweets:
+		case err := <-errs:
+			t.Fatalf("Expected stream to stay open, got %v", err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if received == nil {
+		t.Fatal("Expected a tweet on the stream")
+	}
+	if received.UserId != "alice" || received.Content != "Live" {
+		t.Errorf("Expected alice's tweet, got %v", received)
+	}
+
+	cancel()
+	if err := <-errs; status.Code(err) != codes.Canceled {
+		t.Errorf("Expected Canceled, got %v", err)
+	}
+}
+
+func TestServer_ShutdownEndsStreams(t *testing.T) {
+	api := newTestAPI(t)
+
+	stream, err := api.timeline.StreamTimeline(as("bob"), &ualav1.StreamTimelineRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error opening stream, got %v", err)
+	}
+	_, errs := receive(stream)
+
+	// Make sure the stream reached the server before shutting down
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Expected stream headers, got %v", err)
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- api.stop()
+	}()
+
+	select {
+	case err := <-errs:
+		code, info := errorInfo(t, err)
+		if code != codes.Unavailable || info.GetReason() != "shutting_down" {
+			t.Errorf("Expected Unavailable shutting_down, got %s %v", code, info)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected shutdown to end the stream")
+	}
+	if err := <-stopped; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+}
+
+func TestServer_StreamingDisabled(t *testing.T) {
+	server := NewServer(&services.TweetService{}, &services.FollowService{})
+	listener := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Serve(ctx, listener)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := ualav1.NewTimelineServiceClient(conn).StreamTimeline(as("bob"), &ualav1.StreamTimelineRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if code, info := errorInfo(t, err); code != codes.Unimplemented || info.GetReason() != "streaming_disabled" {
+		t.Errorf("Expected Unimplemented streaming_disabled, got %s %v", code, info)
+	}
+
+	_, err = ualav1.NewUserServiceClient(conn).GetUser(context.Background(), &ualav1.GetUserRequest{UserId: "alice"})
+	if code, info := errorInfo(t, err); code != codes.Unimplemented || info.GetReason() != "users_disabled" {
+		t.Errorf("Expected Unimplemented users_disabled, got %s %v", code, info)
+	}
+}
@@ -0,0 +1,64 @@
+package grpc
+
+import (
+	"context"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// timelineServer implements ualav1.TimelineServiceServer
+type timelineServer struct {
+	ualav1.UnimplementedTimelineServiceServer
+	*Server
+}
+
+// GetTimeline lists tweets from followed users, newest first
+func (s *timelineServer) GetTimeline(ctx context.Context, req *ualav1.GetTimelineRequest) (*ualav1.ListTweetsResponse, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	tweets, err := s.followService.GetTimeline(ctx, userID)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProtoTweets(tweets), nil
+}
+
+// StreamTimeline sends tweets from followed users as they are posted, until
+// the client cancels, falls behind or the server shuts down
+func (s *timelineServer) StreamTimeline(req *ualav1.StreamTimelineRequest, stream ualav1.TimelineService_StreamTimelineServer) error {
+	ctx := stream.Context()
+	if s.liveTimelineService == nil {
+		return toStatus(ctx, errStreamingDisabled)
+	}
+	userID, err := callerID(ctx)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	// Send headers right away so that clients know the stream is open
+	err = stream.SendHeader(nil)
+	if err != nil {
+		return toStatus(ctx, err)
+	}
+
+	// End the stream when shutdown begins so that graceful stop can finish
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer context.AfterFunc(s.stopping, cancel)()
+
+	err = s.liveTimelineService.StreamTimeline(streamCtx, userID, func(tweet *domain.Tweet) error {
+		return stream.Send(toProtoTweet(tweet))
+	})
+	switch {
+	case ctx.Err() != nil:
+		return toStatus(ctx, ctx.Err())
+	case s.stopping.Err() != nil:
+		return toStatus(ctx, errShuttingDown)
+	default:
+		return toStatus(ctx, err)
+	}
+}
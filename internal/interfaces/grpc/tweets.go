@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// tweetServer implements ualav1.TweetServiceServer
+type tweetServer struct {
+	ualav1.UnimplementedTweetServiceServer
+	*Server
+}
+
+// CreateTweet posts a tweet as the calling user
+func (s *tweetServer) CreateTweet(ctx context.Context, req *ualav1.CreateTweetRequest) (*ualav1.Tweet, error) {
+	userID, err := callerID(ctx)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	createReq := services.CreateTweetRequest{
+		UserID:   userID,
+		Content:  req.GetContent(),
+		MediaIDs: req.GetMediaIds(),
+	}
+	if poll := req.GetPoll(); poll != nil {
+		createReq.Poll = &services.CreatePollRequest{
+			Options:         poll.GetOptions(),
+			DurationMinutes: int(poll.GetDurationMinutes()),
+		}
+	}
+
+	tweet, err := s.tweetService.CreateTweet(ctx, createReq)
+	if errors.Is(err, domain.ErrTweetTooLong) {
+		return nil, toStatus(ctx, tweetTooLong(s.maxTweetLength))
+	}
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProtoTweet(tweet), nil
+}
+
+// ListUserTweets lists a user's tweets, newest first
+func (s *tweetServer) ListUserTweets(ctx context.Context, req *ualav1.ListUserTweetsRequest) (*ualav1.ListTweetsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, toStatus(ctx, errMissingUserIDParam)
+	}
+
+	tweets, err := s.tweetService.GetUserTweets(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return toProtoTweets(tweets), nil
+}
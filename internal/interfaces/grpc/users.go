@@ -0,0 +1,29 @@
+package grpc
+
+import (
+	"context"
+
+	"uala-challenge/internal/interfaces/grpc/ualav1"
+)
+
+// userServer implements ualav1.UserServiceServer
+type userServer struct {
+	ualav1.UnimplementedUserServiceServer
+	*Server
+}
+
+// GetUser returns a user by ID
+func (s *userServer) GetUser(ctx context.Context, req *ualav1.GetUserRequest) (*ualav1.User, error) {
+	if s.userService == nil {
+		return nil, toStatus(ctx, errUsersDisabled)
+	}
+	if req.GetUserId() == "" {
+		return nil, toStatus(ctx, errMissingUserIDParam)
+	}
+
+	user, err := s.userService.GetUser(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &ualav1.User{Id: user.ID, Name: user.Name}, nil
+}
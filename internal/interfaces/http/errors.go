@@ -0,0 +1,157 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+// APIError is an error with a stable, machine-readable code. Every endpoint
+// renders errors as {"error":{"code":...,"message":...,"details":...,"request_id":...}}.
+type APIError struct {
+	Status  int                    `json:"-"`
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// Request errors raised by the handlers themselves
+var (
+	errMissingUserID      = &APIError{Status: http.StatusBadRequest, Code: "missing_user_id", Message: "User ID required in X-User-ID header"}
+	errMissingUserIDParam = &APIError{Status: http.StatusBadRequest, Code: "missing_user_id", Message: "User ID required in user_id query parameter"}
+	errMissingFolloweeID  = &APIError{Status: http.StatusBadRequest, Code: "missing_followee_id", Message: "Followee ID required"}
+	errSchedulingDisabled = &APIError{Status: http.StatusBadRequest, Code: "scheduling_disabled", Message: "Scheduled tweets are not enabled"}
+	errScheduledExtras    = &APIError{Status: http.StatusBadRequest, Code: "scheduled_attachments_unsupported", Message: "Scheduled tweets cannot carry a poll or media"}
+	errInvalidUpload      = &APIError{Status: http.StatusBadRequest, Code: "invalid_upload", Message: "Upload must be a raw image body or a multipart file field"}
+	errInvalidPageLimit   = &APIError{Status: http.StatusBadRequest, Code: "invalid_page_limit", Message: "Limit must be a whole number between 1 and 100"}
+	errRouteNotFound      = &APIError{Status: http.StatusNotFound, Code: "route_not_found", Message: "No route matches the request path"}
+	errMethodNotAllowed   = &APIError{Status: http.StatusMethodNotAllowed, Code: "method_not_allowed", Message: "Method not allowed for this route"}
+	errInternal           = &APIError{Status: http.StatusInternalServerError, Code: "internal_error", Message: "An unexpected error occurred"}
+)
+
+// invalidJSON reports a request body that could not be decoded
+func invalidJSON(err error) *APIError {
+	return &APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "invalid_json",
+		Message: "Invalid JSON",
+		Details: map[string]interface{}{"reason": err.Error()},
+	}
+}
+
+// tweetTooLong reports content over the given length limit
+func tweetTooLong(maxLength int) *APIError {
+	return &APIError{
+		Status:  http.StatusBadRequest,
+		Code:    "tweet_too_long",
+		Message: "Tweet content exceeds character limit",
+		Details: map[string]interface{}{"max_length": maxLength},
+	}
+}
+
+// domainErrors maps domain errors to their HTTP representation. Errors are
+// matched with errors.Is, so wrapped domain errors map correctly.
+var domainErrors = []struct {
+	err    error
+	apiErr *APIError
+}{
+	{domain.ErrTweetEmpty, &APIError{Status: http.StatusBadRequest, Code: "tweet_empty", Message: "Tweet content cannot be empty"}},
+	{domain.ErrTweetTooLong, tweetTooLong(domain.MaxTweetLength)},
+	{domain.ErrUserNotFound, &APIError{Status: http.StatusNotFound, Code: "user_not_found", Message: "User not found"}},
+	{domain.ErrCannotFollowSelf, &APIError{Status: http.StatusBadRequest, Code: "cannot_follow_self", Message: "Cannot follow yourself"}},
+	{domain.ErrPageCursorNotFound, &APIError{Status: http.StatusBadRequest, Code: "invalid_cursor", Message: "Cursor does not refer to a tweet"}},
+
+	{domain.ErrScheduledTweetNotFound, &APIError{Status: http.StatusNotFound, Code: "scheduled_tweet_not_found", Message: "Scheduled tweet not found"}},
+	{domain.ErrPublishTimeInPast, &APIError{Status: http.StatusBadRequest, Code: "publish_time_in_past", Message: "Publish time must be in the future"}},
+
+	{domain.ErrPollOptionCount, &APIError{Status: http.StatusBadRequest, Code: "poll_option_count", Message: "Poll must have between 2 and 4 options",
+		Details: map[string]interface{}{"min_options": domain.MinPollOptions, "max_options": domain.MaxPollOptions}}},
+	{domain.ErrPollOptionInvalid, &APIError{Status: http.StatusBadRequest, Code: "poll_option_invalid", Message: "Poll options must be non-empty and at most 25 characters",
+		Details: map[string]interface{}{"max_option_length": domain.MaxPollOptionLength}}},
+	{domain.ErrPollDuration, &APIError{Status: http.StatusBadRequest, Code: "poll_duration_invalid", Message: "Poll duration must be between 5 minutes and 7 days",
+		Details: map[string]interface{}{"min_duration_minutes": int(domain.MinPollDuration.Minutes()), "max_duration_minutes": int(domain.MaxPollDuration.Minutes())}}},
+	{domain.ErrPollNotFound, &APIError{Status: http.StatusNotFound, Code: "poll_not_found", Message: "Poll not found"}},
+	{domain.ErrPollClosed, &APIError{Status: http.StatusConflict, Code: "poll_closed", Message: "Poll is closed"}},
+	{domain.ErrInvalidPollOption, &APIError{Status: http.StatusBadRequest, Code: "invalid_poll_option", Message: "Invalid poll option"}},
+	{domain.ErrAlreadyVoted, &APIError{Status: http.StatusConflict, Code: "already_voted", Message: "Already voted in this poll"}},
+
+	{domain.ErrMediaTooLarge, &APIError{Status: http.StatusRequestEntityTooLarge, Code: "media_too_large", Message: "Media exceeds size limit",
+		Details: map[string]interface{}{"max_size": domain.MaxMediaSize}}},
+	{domain.ErrUnsupportedMediaType, &APIError{Status: http.StatusUnsupportedMediaType, Code: "unsupported_media_type", Message: "Unsupported media type",
+		Details: map[string]interface{}{"supported": []string{"image/jpeg", "image/png", "image/gif", "image/webp"}}}},
+	{domain.ErrInvalidMedia, &APIError{Status: http.StatusBadRequest, Code: "invalid_media", Message: "Media could not be decoded"}},
+	{domain.ErrMediaNotFound, &APIError{Status: http.StatusNotFound, Code: "media_not_found", Message: "Media not found"}},
+	{domain.ErrUnknownMediaID, &APIError{Status: http.StatusBadRequest, Code: "unknown_media_id", Message: "Media ID does not refer to media uploaded by the author"}},
+	{domain.ErrBlobNotFound, &APIError{Status: http.StatusNotFound, Code: "media_not_found", Message: "Media not found"}},
+	{domain.ErrTooManyMedia, &APIError{Status: http.StatusBadRequest, Code: "too_many_media", Message: "Tweet exceeds media attachment limit",
+		Details: map[string]interface{}{"max_media": domain.MaxMediaPerTweet}}},
+
+	{domain.ErrWebhookNotFound, &APIError{Status: http.StatusNotFound, Code: "webhook_not_found", Message: "Webhook not found"}},
+	{domain.ErrInvalidWebhookURL, &APIError{Status: http.StatusBadRequest, Code: "invalid_webhook_url", Message: "Webhook URL must be an absolute http or https URL"}},
+	{domain.ErrWebhookSecretTooShort, &APIError{Status: http.StatusBadRequest, Code: "webhook_secret_too_short", Message: "Webhook secret is too short",
+		Details: map[string]interface{}{"min_length": domain.MinWebhookSecretLength}}},
+	{domain.ErrInvalidWebhookEvents, &APIError{Status: http.StatusBadRequest, Code: "invalid_webhook_events", Message: "Webhook events must be one or more known event types",
+		Details: map[string]interface{}{"supported": domain.WebhookEvents}}},
+	{domain.ErrTooManyWebhooks, &APIError{Status: http.StatusConflict, Code: "too_many_webhooks", Message: "User has reached the webhook limit",
+		Details: map[string]interface{}{"max_webhooks": domain.MaxWebhooksPerUser}}},
+	{domain.ErrWebhookDeliveryNotFound, &APIError{Status: http.StatusNotFound, Code: "webhook_delivery_not_found", Message: "Webhook delivery not found"}},
+	{domain.ErrDeliveryNotDeadLettered, &APIError{Status: http.StatusConflict, Code: "delivery_not_dead_lettered", Message: "Only dead-lettered deliveries can be retried"}},
+}
+
+// toAPIError maps any error to its API representation. Unknown errors become
+// a generic internal error so that internals never leak to clients.
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	for _, mapping := range domainErrors {
+		if errors.Is(err, mapping.err) {
+			return mapping.apiErr
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return toAPIError(domain.ErrMediaTooLarge)
+	}
+
+	return errInternal
+}
+
+// errorResponse is the JSON envelope for API errors
+type errorResponse struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	*APIError
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// writeError renders err as a structured JSON error response
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "request failed", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.Status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Error: errorBody{
+			APIError:  apiErr,
+			RequestID: requestid.FromContext(r.Context()),
+		},
+	})
+}
@@ -0,0 +1,170 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/requestid"
+)
+
+type errorEnvelope struct {
+	Error struct {
+		Code      string                 `json:"code"`
+		Message   string                 `json:"message"`
+		Details   map[string]interface{} `json:"details"`
+		RequestID string                 `json:"request_id"`
+	} `json:"error"`
+}
+
+func decodeError(t *testing.T, w *httptest.ResponseRecorder) errorEnvelope {
+	t.Helper()
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON error, got Content-Type %q", ct)
+	}
+
+	var envelope errorEnvelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal error response %q: %v", w.Body.String(), err)
+	}
+	return envelope
+}
+
+func TestToAPIError(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"domain error", domain.ErrTweetTooLong, http.StatusBadRequest, "tweet_too_long"},
+		{"wrapped domain error", fmt.Errorf("create tweet: %w", domain.ErrTweetTooLong), http.StatusBadRequest, "tweet_too_long"},
+		{"self follow", domain.ErrCannotFollowSelf, http.StatusBadRequest, "cannot_follow_self"},
+		{"not found", fmt.Errorf("lookup: %w", domain.ErrScheduledTweetNotFound), http.StatusNotFound, "scheduled_tweet_not_found"},
+		{"conflict", domain.ErrAlreadyVoted, http.StatusConflict, "already_voted"},
+		{"api error", errMissingUserID, http.StatusBadRequest, "missing_user_id"},
+		{"body too large", &http.MaxBytesError{Limit: 10}, http.StatusRequestEntityTooLarge, "media_too_large"},
+		{"unknown error", errors.New("database is on fire"), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiErr := toAPIError(tt.err)
+			if apiErr.Status != tt.expectedStatus || apiErr.Code != tt.expectedCode {
+				t.Errorf("Expected %d %s, got %d %s", tt.expectedStatus, tt.expectedCode, apiErr.Status, apiErr.Code)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	req := httptest.NewRequest("POST", "/api/v1/tweets", nil)
+	req = req.WithContext(requestid.NewContext(req.Context(), "req-123"))
+	w := httptest.NewRecorder()
+
+	writeError(w, req, fmt.Errorf("wrapped: %w", domain.ErrTweetTooLong))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	envelope := decodeError(t, w)
+	if envelope.Error.Code != "tweet_too_long" {
+		t.Errorf("Expected code tweet_too_long, got %s", envelope.Error.Code)
+	}
+	if envelope.Error.Message == "" {
+		t.Error("Expected a message")
+	}
+	if envelope.Error.Details["max_length"] != float64(domain.MaxTweetLength) {
+		t.Errorf("Expected max_length detail, got %v", envelope.Error.Details)
+	}
+	if envelope.Error.RequestID != "req-123" {
+		t.Errorf("Expected request ID req-123, got %s", envelope.Error.RequestID)
+	}
+}
+
+func TestRouterErrorResponses(t *testing.T) {
+	httpRouter := NewRouter(NewHandler(&mockTweetService{}, &mockFollowService{})).SetupRoutes()
+
+	t.Run("handler errors carry the propagated request ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/follow", bytes.NewBufferString(`{"followee_id": "user123"}`))
+		req.Header.Set("X-User-ID", "user123")
+		req.Header.Set(requestid.Header, "client-supplied-id")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if w.Header().Get(requestid.Header) != "client-supplied-id" {
+			t.Errorf("Expected request ID header to be echoed, got %q", w.Header().Get(requestid.Header))
+		}
+
+		envelope := decodeError(t, w)
+		if envelope.Error.Code != "cannot_follow_self" || envelope.Error.RequestID != "client-supplied-id" {
+			t.Errorf("Unexpected error body: %+v", envelope.Error)
+		}
+	})
+
+	t.Run("invalid request IDs are replaced", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/health", nil)
+		req.Header.Set(requestid.Header, "contains spaces")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if id := w.Header().Get(requestid.Header); id == "" || id == "contains spaces" {
+			t.Errorf("Expected a generated request ID, got %q", id)
+		}
+	})
+
+	t.Run("unknown route", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/nope", nil)
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+		envelope := decodeError(t, w)
+		if envelope.Error.Code != "route_not_found" || envelope.Error.RequestID == "" {
+			t.Errorf("Unexpected error body: %+v", envelope.Error)
+		}
+	})
+
+	t.Run("wrong method", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/v1/tweets", nil)
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status %d, got %d", http.StatusMethodNotAllowed, w.Code)
+		}
+		if w.Header().Get("Allow") != "POST" {
+			t.Errorf("Expected Allow: POST, got %q", w.Header().Get("Allow"))
+		}
+		if envelope := decodeError(t, w); envelope.Error.Code != "method_not_allowed" {
+			t.Errorf("Expected code method_not_allowed, got %s", envelope.Error.Code)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString("{"))
+		req.Header.Set("X-User-ID", "user123")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		envelope := decodeError(t, w)
+		if w.Code != http.StatusBadRequest || envelope.Error.Code != "invalid_json" {
+			t.Errorf("Expected 400 invalid_json, got %d %s", w.Code, envelope.Error.Code)
+		}
+		if envelope.Error.Details["reason"] == nil {
+			t.Error("Expected reason detail")
+		}
+	})
+}
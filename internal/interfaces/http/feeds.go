@@ -0,0 +1,237 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/domain"
+)
+
+const (
+	// maxFeedEntries is the number of most recent tweets a feed lists
+	maxFeedEntries = 50
+	// maxFeedTitleLength is the number of characters of a tweet used as its
+	// entry title
+	maxFeedTitleLength = 60
+
+	// feedTagPrefix starts the tag URIs (RFC 4151) identifying feeds and
+	// entries. It does not depend on the host serving the feed, so entry IDs
+	// stay stable behind proxies and across deployments.
+	feedTagPrefix = "tag:uala-challenge,2024:"
+
+	atomContentType = "application/atom+xml; charset=utf-8"
+	rssContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// atomFeed is an Atom 1.0 feed (RFC 4287)
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomPerson  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// rssFeed is an RSS 2.0 feed
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	SelfLink      rssLink   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+// rssLink is the atom:link RSS feeds use to point at themselves
+type rssLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+	GUID        rssGUID `xml:"guid"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// UserAtomFeedHandler serves a user's latest tweets as an Atom feed
+func (h *Handler) UserAtomFeedHandler(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, atomContentType, func(userID string, tweets []*domain.Tweet, updated time.Time) interface{} {
+		feed := atomFeed{
+			ID:      feedTagPrefix + "users/" + url.PathEscape(userID),
+			Title:   "Tweets from " + userID,
+			Updated: updated.Format(time.RFC3339),
+			Author:  atomPerson{Name: userID},
+			Links: []atomLink{
+				{Rel: "self", Type: "application/atom+xml", Href: requestURL(r)},
+				{Rel: "alternate", Type: "application/json", Href: userTweetsURL(r, userID)},
+			},
+			Entries: make([]atomEntry, 0, len(tweets)),
+		}
+		for _, tweet := range tweets {
+			createdAt := tweet.CreatedAt.UTC().Format(time.RFC3339)
+			feed.Entries = append(feed.Entries, atomEntry{
+				ID:        feedTagPrefix + "tweets/" + tweet.ID,
+				Title:     feedTitle(tweet.Content),
+				Published: createdAt,
+				Updated:   createdAt,
+				Content:   atomContent{Type: "text", Body: tweet.Content},
+			})
+		}
+		return feed
+	})
+}
+
+// UserRSSFeedHandler serves a user's latest tweets as an RSS 2.0 feed
+func (h *Handler) UserRSSFeedHandler(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, rssContentType, func(userID string, tweets []*domain.Tweet, updated time.Time) interface{} {
+		feed := rssFeed{
+			Version: "2.0",
+			AtomNS:  "http://www.w3.org/2005/Atom",
+			Channel: rssChannel{
+				Title:       "Tweets from " + userID,
+				Link:        userTweetsURL(r, userID),
+				Description: "The latest tweets posted by " + userID,
+				SelfLink:    rssLink{Rel: "self", Type: "application/rss+xml", Href: requestURL(r)},
+				Items:       make([]rssItem, 0, len(tweets)),
+			},
+		}
+		if len(tweets) > 0 {
+			feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+		}
+		for _, tweet := range tweets {
+			feed.Channel.Items = append(feed.Channel.Items, rssItem{
+				Title:       feedTitle(tweet.Content),
+				Description: tweet.Content,
+				PubDate:     tweet.CreatedAt.UTC().Format(time.RFC1123Z),
+				GUID:        rssGUID{ID: feedTagPrefix + "tweets/" + tweet.ID},
+			})
+		}
+		return feed
+	})
+}
+
+// serveFeed renders a user's latest tweets with build and answers
+// conditional requests. The ETag is a hash of the rendered feed and
+// Last-Modified is the time of the newest tweet, so readers polling an
+// unchanged feed get 304 Not Modified.
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, contentType string, build func(userID string, tweets []*domain.Tweet, updated time.Time) interface{}) {
+	userID := mux.Vars(r)["id"]
+	tweets, err := h.tweetService.GetUserTweets(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if len(tweets) > maxFeedEntries {
+		tweets = tweets[:maxFeedEntries]
+	}
+
+	// Feeds must carry an update time, so an empty feed uses the epoch,
+	// keeping it stable until the first tweet
+	updated := time.Unix(0, 0).UTC()
+	if len(tweets) > 0 {
+		updated = tweets[0].CreatedAt.UTC()
+	}
+
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	if err := xml.NewEncoder(&body).Encode(build(userID, tweets, updated)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	sum := sha256.Sum256(body.Bytes())
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	var lastModified time.Time
+	if len(tweets) > 0 {
+		lastModified = updated
+	}
+	// ServeContent answers If-None-Match and If-Modified-Since
+	http.ServeContent(w, r, "", lastModified, bytes.NewReader(body.Bytes()))
+}
+
+// feedTitle shortens tweet content to its first line, cut at a word
+// boundary, for use as an entry title
+func feedTitle(content string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if utf8.RuneCountInString(title) <= maxFeedTitleLength {
+		return title
+	}
+
+	runes := []rune(title)[:maxFeedTitleLength]
+	if i := strings.LastIndex(string(runes), " "); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// requestURL reconstructs the absolute URL of a request, honouring the
+// scheme reported by a TLS-terminating proxy
+func requestURL(r *http.Request) string {
+	return baseURL(r) + r.URL.RequestURI()
+}
+
+// userTweetsURL is the absolute URL of a user's tweets in the JSON API
+func userTweetsURL(r *http.Request, userID string) string {
+	return baseURL(r) + "/api/v1/users/tweets?user_id=" + url.QueryEscape(userID)
+}
+
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,242 @@
+package http
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/domain"
+)
+
+// feedTweetService serves a fixed list of tweets, newest first
+type feedTweetService struct {
+	mockTweetService
+	tweets []*domain.Tweet
+}
+
+func (s *feedTweetService) GetUserTweets(ctx context.Context, userID string) ([]*domain.Tweet, error) {
+	return s.tweets, nil
+}
+
+func newFeedRouter(tweets ...*domain.Tweet) (*feedTweetService, http.Handler) {
+	service := &feedTweetService{tweets: tweets}
+	return service, NewRouter(NewHandler(service, &mockFollowService{})).SetupRoutes()
+}
+
+func getFeed(router http.Handler, target string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+var feedTime = time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+func TestUserAtomFeed(t *testing.T) {
+	_, router := newFeedRouter(
+		&domain.Tweet{ID: "t2", UserID: "alice", Content: `Tom & Jerry <3 "quotes"` + "\x00", CreatedAt: feedTime},
+		&domain.Tweet{ID: "t1", UserID: "alice", Content: "Hello", CreatedAt: feedTime.Add(-time.Hour)},
+	)
+
+	w := getFeed(router, "/api/v1/users/alice/feed.atom", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/atom+xml; charset=utf-8" {
+		t.Errorf("Expected Atom content type, got %q", ct)
+	}
+
+	var feed struct {
+		XMLName xml.Name
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Title   string `xml:"title"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Expected well-formed XML, got %v: %s", err, w.Body.String())
+	}
+
+	if feed.XMLName.Space != "http://www.w3.org/2005/Atom" || feed.XMLName.Local != "feed" {
+		t.Errorf("Expected an Atom feed element, got %v", feed.XMLName)
+	}
+	if feed.ID != "tag:uala-challenge,2024:users/alice" {
+		t.Errorf("Expected a stable feed ID, got %q", feed.ID)
+	}
+	if feed.Updated != "2024-05-01T12:30:00Z" {
+		t.Errorf("Expected updated to be the newest tweet's time, got %q", feed.Updated)
+	}
+	if len(feed.Links) == 0 || feed.Links[0].Rel != "self" || feed.Links[0].Href != "http://example.com/api/v1/users/alice/feed.atom" {
+		t.Errorf("Expected a self link, got %+v", feed.Links)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(feed.Entries))
+	}
+	if feed.Entries[0].ID != "tag:uala-challenge,2024:tweets/t2" {
+		t.Errorf("Expected a stable entry ID, got %q", feed.Entries[0].ID)
+	}
+	// Markup is escaped and characters XML cannot carry are replaced
+	if feed.Entries[0].Content != `Tom & Jerry <3 "quotes"`+"�" {
+		t.Errorf("Expected content to round-trip, got %q", feed.Entries[0].Content)
+	}
+}
+
+func TestUserRSSFeed(t *testing.T) {
+	_, router := newFeedRouter(
+		&domain.Tweet{ID: "t1", UserID: "alice", Content: "<b>bold</b>", CreatedAt: feedTime},
+	)
+
+	w := getFeed(router, "/api/v1/users/alice/feed.rss", map[string]string{"X-Forwarded-Proto": "https"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/rss+xml; charset=utf-8" {
+		t.Errorf("Expected RSS content type, got %q", ct)
+	}
+
+	var feed struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title string `xml:"title"`
+			// The namespaced link comes first, as the decoder gives an
+			// element to the first field matching its local name
+			SelfLink struct {
+				Href string `xml:"href,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+			Link  string `xml:"link"`
+			Items []struct {
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+				GUID        struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					ID          string `xml:",chardata"`
+				} `xml:"guid"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(w.Body.Bytes(), &feed); err != nil {
+		t.Fatalf("Expected well-formed XML, got %v: %s", err, w.Body.String())
+	}
+
+	if feed.Version != "2.0" {
+		t.Errorf("Expected RSS 2.0, got %q", feed.Version)
+	}
+	if feed.Channel.Link != "https://example.com/api/v1/users/tweets?user_id=alice" {
+		t.Errorf("Expected the channel to link to the user's tweets, got %q", feed.Channel.Link)
+	}
+	if feed.Channel.SelfLink.Href != "https://example.com/api/v1/users/alice/feed.rss" {
+		t.Errorf("Expected an atom:link to the feed itself, got %q", feed.Channel.SelfLink.Href)
+	}
+	if len(feed.Channel.Items) != 1 {
+		t.Fatalf("Expected 1 item, got %d", len(feed.Channel.Items))
+	}
+	item := feed.Channel.Items[0]
+	if item.Description != "<b>bold</b>" || strings.Contains(w.Body.String(), "<b>") {
+		t.Errorf("Expected escaped markup, got %s", w.Body.String())
+	}
+	if item.PubDate != "Wed, 01 May 2024 12:30:00 +0000" {
+		t.Errorf("Expected an RFC 1123 publish date, got %q", item.PubDate)
+	}
+	if item.GUID.ID != "tag:uala-challenge,2024:tweets/t1" || item.GUID.IsPermaLink != "false" {
+		t.Errorf("Expected a non-permalink stable GUID, got %+v", item.GUID)
+	}
+}
+
+func TestUserFeed_ConditionalGet(t *testing.T) {
+	service, router := newFeedRouter(
+		&domain.Tweet{ID: "t1", UserID: "alice", Content: "Hello", CreatedAt: feedTime},
+	)
+	const target = "/api/v1/users/alice/feed.atom"
+
+	first := getFeed(router, target, nil)
+	etag := first.Header().Get("ETag")
+	lastModified := first.Header().Get("Last-Modified")
+	if etag == "" || lastModified != "Wed, 01 May 2024 12:30:00 GMT" {
+		t.Fatalf("Expected ETag and Last-Modified, got %q and %q", etag, lastModified)
+	}
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"matching ETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"one of several ETags", map[string]string{"If-None-Match": `"other", ` + etag}, http.StatusNotModified},
+		{"stale ETag", map[string]string{"If-None-Match": `"other"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": feedTime.Add(-time.Minute).Format(http.TimeFormat)}, http.StatusOK},
+		{"ETag wins over date", map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := getFeed(router, target, tt.headers)
+			if w.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if tt.status == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("Expected an empty body, got %q", w.Body.String())
+			}
+		})
+	}
+
+	t.Run("new tweet changes the validators", func(t *testing.T) {
+		service.tweets = append([]*domain.Tweet{
+			{ID: "t2", UserID: "alice", Content: "Again", CreatedAt: feedTime.Add(time.Hour)},
+		}, service.tweets...)
+
+		w := getFeed(router, target, map[string]string{"If-None-Match": etag})
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("ETag") == etag {
+			t.Error("Expected a new ETag")
+		}
+	})
+}
+
+func TestUserFeed_Empty(t *testing.T) {
+	_, router := newFeedRouter()
+
+	w := getFeed(router, "/api/v1/users/nobody/feed.atom", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("Last-Modified") != "" {
+		t.Errorf("Expected no Last-Modified for an empty feed, got %q", w.Header().Get("Last-Modified"))
+	}
+	if again := getFeed(router, "/api/v1/users/nobody/feed.atom", nil); again.Header().Get("ETag") != w.Header().Get("ETag") {
+		t.Error("Expected an empty feed to keep its ETag")
+	}
+}
+
+func TestFeedTitle(t *testing.T) {
+	tests := []struct {
+		content  string
+		expected string
+	}{
+		{"Short", "Short"},
+		{"First line\nSecond line", "First line"},
+		{strings.Repeat("word ", 20), "word word word word word word word word word word word word…"},
+		{strings.Repeat("ñ", 70), strings.Repeat("ñ", 60) + "…"},
+	}
+	for _, tt := range tests {
+		if got := feedTitle(tt.content); got != tt.expected {
+			t.Errorf("feedTitle(%q): expected %q, got %q", tt.content, tt.expected, got)
+		}
+	}
+}
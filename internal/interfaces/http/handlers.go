@@ -1,29 +1,121 @@
 package http
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 
 	"uala-challenge/internal/application"
 	"uala-challenge/internal/application/services"
 	"uala-challenge/internal/domain"
 )
 
+// Timeline page sizes
+const (
+	// DefaultTimelinePageSize is the page size when only after is given
+	DefaultTimelinePageSize = 20
+	// MaxTimelinePageSize is the largest limit a client may request
+	MaxTimelinePageSize = 100
+)
+
 // Handler handles HTTP requests
 type Handler struct {
-	tweetService  application.TweetServiceInterface
-	followService application.FollowServiceInterface
+	tweetService    application.TweetServiceInterface
+	followService   application.FollowServiceInterface
+	scheduleService application.ScheduleServiceInterface
+	pollService     application.PollServiceInterface
+	mediaService    application.MediaServiceInterface
+	previewService  application.LinkPreviewServiceInterface
+	webhookService  application.WebhookServiceInterface
+	maxTweetLength  int
+	ready           func() bool
+}
+
+// HandlerOption configures optional handler dependencies
+type HandlerOption func(*Handler)
+
+// WithScheduleService enables scheduled tweets
+func WithScheduleService(scheduleService application.ScheduleServiceInterface) HandlerOption {
+	return func(h *Handler) {
+		h.scheduleService = scheduleService
+	}
+}
+
+// WithPollService enables poll voting and per-viewer poll results
+func WithPollService(pollService application.PollServiceInterface) HandlerOption {
+	return func(h *Handler) {
+		h.pollService = pollService
+	}
+}
+
+// WithMediaService enables media uploads and serving
+func WithMediaService(mediaService application.MediaServiceInterface) HandlerOption {
+	return func(h *Handler) {
+		h.mediaService = mediaService
+	}
 }
 
-func NewHandler(tweetService application.TweetServiceInterface, followService application.FollowServiceInterface) *Handler {
-	return &Handler{
-		tweetService:  tweetService,
-		followService: followService,
+// WithLinkPreviewService attaches link preview cards to tweets
+func WithLinkPreviewService(previewService application.LinkPreviewServiceInterface) HandlerOption {
+	return func(h *Handler) {
+		h.previewService = previewService
+	}
+}
+
+// WithWebhookService enables webhook subscriptions and their delivery log
+func WithWebhookService(webhookService application.WebhookServiceInterface) HandlerOption {
+	return func(h *Handler) {
+		h.webhookService = webhookService
+	}
+}
+
+// WithMaxTweetLength reports the configured tweet length limit in errors
+func WithMaxTweetLength(maxLength int) HandlerOption {
+	return func(h *Handler) {
+		h.maxTweetLength = maxLength
+	}
+}
+
+// WithReadiness makes the health check fail with 503 while ready reports
+// false, for instance once the server has started draining
+func WithReadiness(ready func() bool) HandlerOption {
+	return func(h *Handler) {
+		h.ready = ready
+	}
+}
+
+func NewHandler(tweetService application.TweetServiceInterface, followService application.FollowServiceInterface, opts ...HandlerOption) *Handler {
+	h := &Handler{
+		tweetService:   tweetService,
+		followService:  followService,
+		maxTweetLength: domain.MaxTweetLength,
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 type CreateTweetRequest struct {
-	Content string `json:"content"`
+	Content   string                      `json:"content"`
+	PublishAt *time.Time                  `json:"publish_at,omitempty"`
+	Poll      *services.CreatePollRequest `json:"poll,omitempty"`
+	MediaIDs  []string                    `json:"media_ids,omitempty"`
+}
+
+type VotePollRequest struct {
+	Option int `json:"option"`
+}
+
+type RescheduleTweetRequest struct {
+	PublishAt time.Time `json:"publish_at"`
 }
 
 type FollowUserRequest struct {
@@ -34,49 +126,170 @@ func (h *Handler) CreateTweetHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
-		http.Error(w, "User ID required in X-User-ID header", http.StatusBadRequest)
+		writeError(w, r, errMissingUserID)
 		return
 	}
 
 	var req CreateTweetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	if req.PublishAt != nil {
+		if req.Poll != nil || len(req.MediaIDs) > 0 {
+			writeError(w, r, errScheduledExtras)
+			return
+		}
+		h.scheduleTweet(w, r, userID, req)
 		return
 	}
 
 	tweet, err := h.tweetService.CreateTweet(r.Context(), services.CreateTweetRequest{
-		UserID:  userID,
-		Content: req.Content,
+		UserID:   userID,
+		Content:  req.Content,
+		Poll:     req.Poll,
+		MediaIDs: req.MediaIDs,
 	})
 
 	if err != nil {
-		switch err {
-		case domain.ErrTweetEmpty:
-			http.Error(w, "Tweet content cannot be empty", http.StatusBadRequest)
-		case domain.ErrTweetTooLong:
-			http.Error(w, "Tweet content exceeds character limit", http.StatusBadRequest)
-		default:
-			http.Error(w, "Failed to create tweet", http.StatusInternalServerError)
-		}
+		writeError(w, r, h.contentError(err))
 		return
 	}
 
+	tweets, err := h.decorateTweets(r, userID, []*domain.Tweet{tweet})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	tweet = tweets[0]
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(tweet)
 }
 
+// scheduleTweet queues a tweet carrying publish_at instead of publishing it
+func (h *Handler) scheduleTweet(w http.ResponseWriter, r *http.Request, userID string, req CreateTweetRequest) {
+	if h.scheduleService == nil {
+		writeError(w, r, errSchedulingDisabled)
+		return
+	}
+
+	scheduled, err := h.scheduleService.ScheduleTweet(r.Context(), services.ScheduleTweetRequest{
+		UserID:    userID,
+		Content:   req.Content,
+		PublishAt: *req.PublishAt,
+	})
+
+	if err != nil {
+		writeError(w, r, h.contentError(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+// contentError reports the configured limit for over-long tweet content
+func (h *Handler) contentError(err error) error {
+	if errors.Is(err, domain.ErrTweetTooLong) {
+		return tweetTooLong(h.maxTweetLength)
+	}
+	return err
+}
+
+func (h *Handler) GetScheduledTweetsHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	tweets, err := h.scheduleService.GetScheduledTweets(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tweets": tweets,
+		"count":  len(tweets),
+	})
+}
+
+func (h *Handler) RescheduleTweetHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	var req RescheduleTweetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	scheduled, err := h.scheduleService.RescheduleTweet(r.Context(), services.RescheduleTweetRequest{
+		UserID:    userID,
+		ID:        mux.Vars(r)["id"],
+		PublishAt: req.PublishAt,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scheduled)
+}
+
+func (h *Handler) CancelScheduledTweetHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	err := h.scheduleService.CancelScheduledTweet(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) GetTimelineHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
-		http.Error(w, "User ID required in X-User-ID header", http.StatusBadRequest)
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	if page, paged, err := timelinePage(r); paged || err != nil {
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		h.getTimelinePage(w, r, userID, page)
 		return
 	}
 
 	tweets, err := h.followService.GetTimeline(r.Context(), userID)
+	if err == nil {
+		tweets, err = h.decorateTweets(r, userID, tweets)
+	}
 	if err != nil {
-		http.Error(w, "Failed to get timeline", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -87,17 +300,66 @@ func (h *Handler) GetTimelineHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// getTimelinePage writes one page of the timeline. One extra tweet is
+// fetched to tell whether there is a next page.
+func (h *Handler) getTimelinePage(w http.ResponseWriter, r *http.Request, userID string, page domain.Page) {
+	limit := page.Limit
+	page.Limit++
+	tweets, err := h.followService.GetTimelinePage(r.Context(), userID, page)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := map[string]interface{}{}
+	if len(tweets) > limit {
+		tweets = tweets[:limit]
+		response["next_cursor"] = tweets[limit-1].ID
+	}
+	tweets, err = h.decorateTweets(r, userID, tweets)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response["tweets"] = tweets
+	response["count"] = len(tweets)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// timelinePage reads the limit and after query parameters. paged is false
+// when neither is given, which returns the whole timeline.
+func timelinePage(r *http.Request) (page domain.Page, paged bool, err error) {
+	query := r.URL.Query()
+	if !query.Has("limit") && !query.Has("after") {
+		return domain.Page{}, false, nil
+	}
+
+	page = domain.Page{Limit: DefaultTimelinePageSize, After: query.Get("after")}
+	if query.Has("limit") {
+		page.Limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || page.Limit < 1 || page.Limit > MaxTimelinePageSize {
+			return domain.Page{}, true, errInvalidPageLimit
+		}
+	}
+	return page, true, nil
+}
+
 func (h *Handler) GetUserTweetsHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		http.Error(w, "User ID required", http.StatusBadRequest)
+		writeError(w, r, errMissingUserIDParam)
 		return
 	}
 
 	tweets, err := h.tweetService.GetUserTweets(r.Context(), userID)
+	if err == nil {
+		tweets, err = h.decorateTweets(r, r.Header.Get("X-User-ID"), tweets)
+	}
 	if err != nil {
-		http.Error(w, "Failed to get user tweets", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
@@ -108,22 +370,157 @@ func (h *Handler) GetUserTweetsHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) VotePollHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	var req VotePollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	tweet, err := h.pollService.Vote(r.Context(), services.VotePollRequest{
+		UserID:  userID,
+		TweetID: mux.Vars(r)["id"],
+		Option:  req.Option,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tweet)
+}
+
+// maxUploadBody leaves room for multipart framing around the image itself
+const maxUploadBody = domain.MaxMediaSize + 64<<10
+
+func (h *Handler) UploadMediaHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	data, err := readUpload(w, r)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if !errors.As(err, &maxBytesErr) {
+			err = errInvalidUpload
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	media, err := h.mediaService.Upload(r.Context(), services.UploadMediaRequest{
+		UserID: userID,
+		Data:   data,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(media)
+}
+
+// readUpload reads the image from a multipart "file" field or the raw body
+func readUpload(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadBody)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return io.ReadAll(r.Body)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	_, err = io.Copy(&buf, file)
+	return buf.Bytes(), err
+}
+
+func (h *Handler) GetMediaHandler(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, false)
+}
+
+func (h *Handler) GetMediaThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	h.serveMedia(w, r, true)
+}
+
+// serveMedia streams a media blob. Blobs are content-addressed and never
+// change, so they are cacheable forever and validated by their key.
+func (h *Handler) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	content, err := h.mediaService.OpenMedia(r.Context(), mux.Vars(r)["id"], thumbnail)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer content.Body.Close()
+
+	etag := `"` + content.Key + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", content.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, content.Body)
+}
+
+// decorateTweets resolves poll results for the viewer and attaches link
+// preview cards, depending on which features are enabled
+func (h *Handler) decorateTweets(r *http.Request, viewerID string, tweets []*domain.Tweet) ([]*domain.Tweet, error) {
+	var err error
+	if h.pollService != nil {
+		tweets, err = h.pollService.WithResults(r.Context(), viewerID, tweets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if h.previewService != nil {
+		tweets, err = h.previewService.WithCards(r.Context(), tweets)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tweets, nil
+}
+
 func (h *Handler) FollowUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
-		http.Error(w, "User ID required in X-User-ID header", http.StatusBadRequest)
+		writeError(w, r, errMissingUserID)
 		return
 	}
 
 	var req FollowUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeError(w, r, invalidJSON(err))
 		return
 	}
 
 	if req.FolloweeID == "" {
-		http.Error(w, "Followee ID required", http.StatusBadRequest)
+		writeError(w, r, errMissingFolloweeID)
 		return
 	}
 
@@ -133,15 +530,11 @@ func (h *Handler) FollowUserHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		switch err {
-		case domain.ErrCannotFollowSelf:
-			http.Error(w, "Cannot follow yourself", http.StatusBadRequest)
-		default:
-			http.Error(w, "Failed to follow user", http.StatusInternalServerError)
-		}
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Successfully followed user",
@@ -152,18 +545,18 @@ func (h *Handler) UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.Header.Get("X-User-ID")
 	if userID == "" {
-		http.Error(w, "User ID required in X-User-ID header", http.StatusBadRequest)
+		writeError(w, r, errMissingUserID)
 		return
 	}
 
 	var req FollowUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeError(w, r, invalidJSON(err))
 		return
 	}
 
 	if req.FolloweeID == "" {
-		http.Error(w, "Followee ID required", http.StatusBadRequest)
+		writeError(w, r, errMissingFolloweeID)
 		return
 	}
 
@@ -173,10 +566,11 @@ func (h *Handler) UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		http.Error(w, "Failed to unfollow user", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Successfully unfollowed user",
@@ -185,6 +579,13 @@ func (h *Handler) UnfollowUserHandler(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+	if h.ready != nil && !h.ready() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{
+			"status": "draining",
+		})
+		return
+	}
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "healthy",
 	})
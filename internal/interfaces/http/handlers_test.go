@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"uala-challenge/internal/application/services"
@@ -29,12 +30,20 @@ func (m *mockTweetService) CreateTweet(ctx context.Context, req services.CreateT
 	}, nil
 }
 
+func (m *mockTweetService) GetTweet(ctx context.Context, id string) (*domain.Tweet, error) {
+	return nil, domain.ErrTweetNotFound
+}
+
 func (m *mockTweetService) GetUserTweets(ctx context.Context, userID string) ([]*domain.Tweet, error) {
 	return []*domain.Tweet{
 		{ID: "1", UserID: userID, Content: "Test tweet"},
 	}, nil
 }
 
+func (m *mockTweetService) GetTweetsByUserIDs(ctx context.Context, userIDs []string) (map[string][]*domain.Tweet, error) {
+	return map[string][]*domain.Tweet{}, nil
+}
+
 type mockFollowService struct{}
 
 func (m *mockFollowService) FollowUser(ctx context.Context, req services.FollowUserRequest) error {
@@ -54,6 +63,32 @@ func (m *mockFollowService) GetTimeline(ctx context.Context, userID string) ([]*
 	}, nil
 }
 
+// GetTimelinePage pages through three tweets, newest first
+func (m *mockFollowService) GetTimelinePage(ctx context.Context, userID string, page domain.Page) ([]*domain.Tweet, error) {
+	tweets := []*domain.Tweet{{ID: "3", UserID: "other"}, {ID: "2", UserID: "other"}, {ID: "1", UserID: "other"}}
+	start := 0
+	if page.After != "" {
+		start = -1
+		for i, tweet := range tweets {
+			if tweet.ID == page.After {
+				start = i + 1
+			}
+		}
+		if start < 0 {
+			return nil, domain.ErrPageCursorNotFound
+		}
+	}
+	return tweets[start:min(start+page.Limit, len(tweets))], nil
+}
+
+func (m *mockFollowService) GetFollowees(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return map[string][]string{}, nil
+}
+
+func (m *mockFollowService) GetFollowers(ctx context.Context, userIDs []string) (map[string][]string, error) {
+	return map[string][]string{}, nil
+}
+
 func TestHandler_CreateTweetHandler(t *testing.T) {
 	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
 
@@ -128,6 +163,55 @@ func TestHandler_GetTimelineHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_GetTimelineHandler_Pages(t *testing.T) {
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedCount  int
+		expectedNext   interface{}
+		expectedCode   string
+	}{
+		{"first page", "?limit=2", http.StatusOK, 2, "2", ""},
+		{"last page", "?limit=2&after=2", http.StatusOK, 1, nil, ""},
+		{"default limit", "?after=3", http.StatusOK, 2, nil, ""},
+		{"zero limit", "?limit=0", http.StatusBadRequest, 0, nil, "invalid_page_limit"},
+		{"limit too large", "?limit=101", http.StatusBadRequest, 0, nil, "invalid_page_limit"},
+		{"unknown cursor", "?limit=2&after=missing", http.StatusBadRequest, 0, nil, "invalid_cursor"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/timeline"+tt.query, nil)
+			req.Header.Set("X-User-ID", "user123")
+			w := httptest.NewRecorder()
+			handler.GetTimelineHandler(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body.String())
+			}
+			if tt.expectedCode != "" {
+				if code := decodeError(t, w).Error.Code; code != tt.expectedCode {
+					t.Errorf("Expected code %s, got %s", tt.expectedCode, code)
+				}
+				return
+			}
+
+			var response map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if response["count"] != float64(tt.expectedCount) {
+				t.Errorf("Expected count %d, got %v", tt.expectedCount, response["count"])
+			}
+			if response["next_cursor"] != tt.expectedNext {
+				t.Errorf("Expected next cursor %v, got %v", tt.expectedNext, response["next_cursor"])
+			}
+		})
+	}
+}
+
 func TestHandler_FollowUserHandler(t *testing.T) {
 	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
 
@@ -318,3 +402,47 @@ func TestHandler_HealthCheckHandler(t *testing.T) {
 		t.Errorf("Expected status 'healthy', got %s", response["status"])
 	}
 }
+
+func TestHandler_HealthCheckHandler_Draining(t *testing.T) {
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{}, WithReadiness(func() bool { return false }))
+
+	req := httptest.NewRequest("GET", "/api/v1/health", nil)
+	w := httptest.NewRecorder()
+
+	handler.HealthCheckHandler(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var response map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Errorf("Failed to unmarshal response: %v", err)
+	}
+	if response["status"] != "draining" {
+		t.Errorf("Expected status 'draining', got %s", response["status"])
+	}
+}
+
+func TestHandler_CreateTweetHandler_ReportsConfiguredMaxLength(t *testing.T) {
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{}, WithMaxTweetLength(100))
+
+	jsonBody, _ := json.Marshal(CreateTweetRequest{Content: strings.Repeat("a", domain.MaxTweetLength+1)})
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBuffer(jsonBody))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "user123")
+
+	w := httptest.NewRecorder()
+	handler.CreateTweetHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	envelope := decodeError(t, w)
+	if envelope.Error.Code != "tweet_too_long" {
+		t.Errorf("Expected code tweet_too_long, got %s", envelope.Error.Code)
+	}
+	if got := envelope.Error.Details["max_length"]; got != float64(100) {
+		t.Errorf("Expected max_length 100, got %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"uala-challenge/internal/infrastructure/health"
+)
+
+const (
+	// livezRouteName and readyzRouteName name the probe routes
+	livezRouteName  = "livez"
+	readyzRouteName = "readyz"
+)
+
+// probeResponse is the JSON body of /livez and /readyz. Checks are only
+// listed when the request asks for verbose output.
+type probeResponse struct {
+	Status string        `json:"status"`
+	Checks []probeResult `json:"checks,omitempty"`
+}
+
+type probeResult struct {
+	Name        string     `json:"name"`
+	Status      string     `json:"status"`
+	LatencyMs   float64    `json:"latency_ms"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// probeHandler answers a probe with 200 when every check passes and 503
+// otherwise. ?verbose adds per-check status, latency and last error.
+func probeHandler(run func(context.Context) health.Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		response := probeResponse{Status: report.Status}
+		if isVerbose(r) {
+			response.Checks = make([]probeResult, 0, len(report.Checks))
+			for _, check := range report.Checks {
+				result := probeResult{
+					Name:      check.Name,
+					Status:    check.Status,
+					LatencyMs: float64(check.Latency.Microseconds()) / 1000,
+					Error:     check.Error,
+					LastError: check.LastError,
+				}
+				if !check.LastErrorAt.IsZero() {
+					lastErrorAt := check.LastErrorAt.UTC()
+					result.LastErrorAt = &lastErrorAt
+				}
+				response.Checks = append(response.Checks, result)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !report.Healthy() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(response)
+	})
+}
+
+// isVerbose reports whether ?verbose is present and not explicitly false
+func isVerbose(r *http.Request) bool {
+	values, ok := r.URL.Query()["verbose"]
+	if !ok {
+		return false
+	}
+	if len(values) == 0 || values[0] == "" {
+		return true
+	}
+	verbose, err := strconv.ParseBool(values[0])
+	return err == nil && verbose
+}
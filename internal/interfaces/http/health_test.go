@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/infrastructure/health"
+	"uala-challenge/internal/infrastructure/ratelimit"
+)
+
+func newHealthRouter(registry *health.Registry) http.Handler {
+	limits := RateLimits{
+		Read:  ratelimit.Limit{Burst: 1, Per: time.Minute},
+		Write: ratelimit.Limit{Burst: 1, Per: time.Minute},
+	}
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
+	return NewRouter(handler, WithHealth(registry), WithRateLimit(ratelimit.NewMemoryStore(), limits)).SetupRoutes()
+}
+
+func probe(t *testing.T, router http.Handler, target string) (int, probeResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+
+	var response probeResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal probe response %q: %v", w.Body.String(), err)
+	}
+	return w.Code, response
+}
+
+func TestProbes(t *testing.T) {
+	registry := health.NewRegistry(health.DefaultTimeout)
+	registry.AddLivenessCheck("scheduler", func(context.Context) error { return nil })
+	registry.AddReadinessCheck("storage", func(context.Context) error { return errors.New("data dir missing") })
+	router := newHealthRouter(registry)
+
+	t.Run("livez passes without readiness checks", func(t *testing.T) {
+		status, response := probe(t, router, "/livez")
+		if status != http.StatusOK || response.Status != health.StatusOK {
+			t.Errorf("Expected 200 ok, got %d %s", status, response.Status)
+		}
+		if response.Checks != nil {
+			t.Errorf("Expected no checks without verbose, got %+v", response.Checks)
+		}
+	})
+
+	t.Run("readyz fails on a failing check", func(t *testing.T) {
+		status, response := probe(t, router, "/readyz")
+		if status != http.StatusServiceUnavailable || response.Status != health.StatusFailing {
+			t.Errorf("Expected 503 failing, got %d %s", status, response.Status)
+		}
+	})
+
+	t.Run("verbose lists every check", func(t *testing.T) {
+		_, response := probe(t, router, "/readyz?verbose")
+		if len(response.Checks) != 2 {
+			t.Fatalf("Expected 2 checks, got %+v", response.Checks)
+		}
+		storage := response.Checks[1]
+		if storage.Name != "storage" || storage.Error != "data dir missing" || storage.LastError != "data dir missing" {
+			t.Errorf("Expected failing storage check, got %+v", storage)
+		}
+		if storage.LastErrorAt == nil {
+			t.Error("Expected last_error_at to be set")
+		}
+		if response.Checks[0].Name != "scheduler" || response.Checks[0].LastErrorAt != nil {
+			t.Errorf("Expected healthy scheduler check, got %+v", response.Checks[0])
+		}
+	})
+
+	t.Run("verbose=false is terse", func(t *testing.T) {
+		if _, response := probe(t, router, "/readyz?verbose=false"); response.Checks != nil {
+			t.Errorf("Expected no checks, got %+v", response.Checks)
+		}
+	})
+
+	t.Run("probes are not rate limited", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/livez", nil))
+			if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+				t.Fatalf("Expected unlimited probe, got %d", w.Code)
+			}
+		}
+	})
+}
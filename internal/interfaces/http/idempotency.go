@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/infrastructure/idempotency"
+)
+
+const (
+	// idempotencyKeyHeader carries the client-chosen key on POST requests
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses replayed from the store
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength bounds the accepted key size
+	maxIdempotencyKeyLength = 255
+)
+
+// Idempotency errors
+var (
+	errInvalidIdempotencyKey = &APIError{Status: http.StatusBadRequest, Code: "invalid_idempotency_key",
+		Message: "Idempotency-Key must be 1-255 printable ASCII characters"}
+	errIdempotencyKeyReused = &APIError{Status: http.StatusUnprocessableEntity, Code: "idempotency_key_reused",
+		Message: "Idempotency-Key was already used with a different request"}
+	errIdempotencyInProgress = &APIError{Status: http.StatusConflict, Code: "idempotency_request_in_progress",
+		Message: "A request with this Idempotency-Key is still being processed"}
+)
+
+// idempotencyMiddleware makes POST requests carrying an Idempotency-Key
+// replayable. The first response per (user, key) is stored for ttl and
+// returned verbatim on retries; reusing a key with a different method, path
+// or body is rejected. Server errors are not stored, so they can be retried.
+func idempotencyMiddleware(store idempotency.Store, ttl time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				writeError(w, r, errInvalidIdempotencyKey)
+				return
+			}
+
+			// Bodies larger than any valid request are left for the handler
+			// to reject, without buffering them here.
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxUploadBody+1))
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if int64(len(body)) > maxUploadBody {
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			storeKey := r.Header.Get("X-User-ID") + ":" + key
+			fingerprint := requestFingerprint(r, body)
+			record, err := store.Begin(r.Context(), storeKey, fingerprint, ttl)
+			if err != nil {
+				writeError(w, r, err)
+				return
+			}
+			if record != nil {
+				replayIdempotentResponse(w, r, record, fingerprint)
+				return
+			}
+
+			recorder := newResponseRecorder(w)
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.Release(r.Context(), storeKey); err != nil {
+						slog.ErrorContext(r.Context(), "failed to release idempotency key", "error", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+			if !recorder.wroteHeader {
+				recorder.WriteHeader(http.StatusOK)
+			}
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			if err := store.Complete(r.Context(), storeKey, recorder.response()); err != nil {
+				slog.ErrorContext(r.Context(), "failed to store idempotent response", "error", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replayIdempotentResponse answers a request whose key is already taken
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, record *idempotency.Record, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		writeError(w, r, errIdempotencyKeyReused)
+	case !record.Completed():
+		writeError(w, r, errIdempotencyInProgress)
+	default:
+		for name, values := range record.Response.Header {
+			w.Header()[name] = values
+		}
+		w.Header().Set(idempotentReplayedHeader, "true")
+		w.WriteHeader(record.Response.Status)
+		w.Write(record.Response.Body)
+	}
+}
+
+// requestFingerprint identifies a request by method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder passes a response through while keeping a copy. Handler
+// headers are collected separately so that headers set by outer middleware,
+// such as the request ID, are not recorded and replayed.
+type responseRecorder struct {
+	http.ResponseWriter
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, header: make(http.Header)}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.wroteHeader {
+		return
+	}
+	rec.wroteHeader = true
+	rec.status = status
+
+	for name, values := range rec.header {
+		rec.ResponseWriter.Header()[name] = values
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if !rec.wroteHeader {
+		rec.WriteHeader(http.StatusOK)
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// response returns the recorded response
+func (rec *responseRecorder) response() idempotency.Response {
+	return idempotency.Response{
+		Status: rec.status,
+		Header: rec.header.Clone(),
+		Body:   bytes.Clone(rec.body.Bytes()),
+	}
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/idempotency"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+func newIdempotentRouter(store idempotency.Store) (http.Handler, *services.TweetService) {
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetService := services.NewTweetService(storage.NewTweetRepository(inMemoryStorage), storage.NewUserRepository(inMemoryStorage))
+	followService := services.NewFollowService(storage.NewFollowRepository(inMemoryStorage), storage.NewTweetRepository(inMemoryStorage))
+
+	handler := NewHandler(tweetService, followService)
+	return NewRouter(handler, WithIdempotency(store, time.Hour)).SetupRoutes(), tweetService
+}
+
+func idempotentTweetRequest(userID, key, content string) *http.Request {
+	body, _ := json.Marshal(map[string]string{"content": content})
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", userID)
+	if key != "" {
+		req.Header.Set(idempotencyKeyHeader, key)
+	}
+	return req
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	router, tweetService := newIdempotentRouter(idempotency.NewMemoryStore())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, idempotentTweetRequest("user123", "retry-1", "Hello"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var original domain.Tweet
+	json.Unmarshal(w.Body.Bytes(), &original)
+
+	t.Run("retries replay the first response", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, idempotentTweetRequest("user123", "retry-1", "Hello"))
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		if w.Header().Get(idempotentReplayedHeader) != "true" {
+			t.Error("Expected replayed response to be marked")
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Expected replayed Content-Type, got %q", w.Header().Get("Content-Type"))
+		}
+
+		var replayed domain.Tweet
+		json.Unmarshal(w.Body.Bytes(), &replayed)
+		if replayed.ID != original.ID {
+			t.Errorf("Expected tweet %s, got %s", original.ID, replayed.ID)
+		}
+
+		tweets, _ := tweetService.GetUserTweets(context.Background(), "user123")
+		if len(tweets) != 1 {
+			t.Errorf("Expected 1 tweet, got %d", len(tweets))
+		}
+	})
+
+	t.Run("reusing a key with a different body is rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, idempotentTweetRequest("user123", "retry-1", "Something else"))
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+		}
+		if envelope := decodeError(t, w); envelope.Error.Code != "idempotency_key_reused" {
+			t.Errorf("Expected code idempotency_key_reused, got %s", envelope.Error.Code)
+		}
+	})
+
+	t.Run("keys are scoped per user", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, idempotentTweetRequest("user456", "retry-1", "Hello"))
+
+		if w.Code != http.StatusCreated || w.Header().Get(idempotentReplayedHeader) != "" {
+			t.Errorf("Expected a fresh response, got %d", w.Code)
+		}
+	})
+
+	t.Run("requests without a key are not deduplicated", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), idempotentTweetRequest("user789", "", "Hello"))
+		}
+		tweets, _ := tweetService.GetUserTweets(context.Background(), "user789")
+		if len(tweets) != 2 {
+			t.Errorf("Expected 2 tweets, got %d", len(tweets))
+		}
+	})
+
+	t.Run("invalid keys are rejected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, idempotentTweetRequest("user123", strings.Repeat("k", maxIdempotencyKeyLength+1), "Hello"))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestIdempotencyMiddleware_ErrorsAreReplayed(t *testing.T) {
+	router, _ := newIdempotentRouter(idempotency.NewMemoryStore())
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, idempotentTweetRequest("user123", "empty", ""))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+		if replayed := w.Header().Get(idempotentReplayedHeader) == "true"; replayed != (i == 1) {
+			t.Errorf("Attempt %d: unexpected replay marker %v", i+1, replayed)
+		}
+	}
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	router, _ := newIdempotentRouter(store)
+
+	req := idempotentTweetRequest("user123", "slow", "Hello")
+	body, _ := json.Marshal(map[string]string{"content": "Hello"})
+	store.Begin(context.Background(), "user123:slow", requestFingerprint(req, body), time.Hour)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if envelope := decodeError(t, w); envelope.Error.Code != "idempotency_request_in_progress" {
+		t.Errorf("Expected code idempotency_request_in_progress, got %s", envelope.Error.Code)
+	}
+}
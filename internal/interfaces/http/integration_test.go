@@ -2,13 +2,21 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
 	"uala-challenge/internal/infrastructure/storage"
+	"uala-challenge/internal/infrastructure/webhook"
 )
 
 // TestCompleteWorkflow tests the complete microblogging workflow from the demo
@@ -345,3 +353,462 @@ func createUnfollowRequest(followerID, followeeID string) *http.Request {
 	req.Header.Set("X-User-ID", followerID)
 	return req
 }
+
+// TestScheduledTweetWorkflow tests scheduling, rescheduling and cancelling tweets
+func TestScheduledTweetWorkflow(t *testing.T) {
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	scheduledRepo := storage.NewScheduledTweetRepository(inMemoryStorage)
+
+	tweetService := services.NewTweetService(tweetRepo, userRepo)
+	followService := services.NewFollowService(followRepo, tweetRepo)
+	scheduleService := services.NewScheduleService(scheduledRepo, tweetRepo, userRepo, domain.SystemClock{})
+
+	handler := NewHandler(tweetService, followService, WithScheduleService(scheduleService))
+	router := NewRouter(handler)
+	httpRouter := router.SetupRoutes()
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	var scheduledID string
+	t.Run("Schedule a tweet", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Coming soon", "publish_at": "`+publishAt+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "marketing")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		scheduledID = response["id"].(string)
+
+		// The tweet must not be published yet
+		req = httptest.NewRequest("GET", "/api/v1/users/tweets?user_id=marketing", nil)
+		w = httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if count := int(response["count"].(float64)); count != 0 {
+			t.Errorf("Expected 0 published tweets, got %d", count)
+		}
+	})
+
+	t.Run("Reject invalid scheduled tweet at submit time", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "", "publish_at": "`+publishAt+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "marketing")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("List scheduled tweets", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/tweets/scheduled", nil)
+		req.Header.Set("X-User-ID", "marketing")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if count := int(response["count"].(float64)); count != 1 {
+			t.Errorf("Expected 1 scheduled tweet, got %d", count)
+		}
+	})
+
+	t.Run("Reschedule a tweet", func(t *testing.T) {
+		later := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+		req := httptest.NewRequest("PUT", "/api/v1/tweets/scheduled/"+scheduledID, bytes.NewBufferString(`{"publish_at": "`+later+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "marketing")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("Cancel a tweet", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/api/v1/tweets/scheduled/"+scheduledID, nil)
+		req.Header.Set("X-User-ID", "marketing")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+
+		// Cancelling again reports not found
+		req = httptest.NewRequest("DELETE", "/api/v1/tweets/scheduled/"+scheduledID, nil)
+		req.Header.Set("X-User-ID", "marketing")
+		w = httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+// TestPollWorkflow tests creating a poll, voting and result visibility
+func TestPollWorkflow(t *testing.T) {
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	pollRepo := storage.NewPollRepository(inMemoryStorage)
+
+	tweetService := services.NewTweetService(tweetRepo, userRepo)
+	followService := services.NewFollowService(followRepo, tweetRepo)
+	pollService := services.NewPollService(tweetRepo, pollRepo, domain.SystemClock{})
+
+	handler := NewHandler(tweetService, followService, WithPollService(pollService))
+	router := NewRouter(handler)
+	httpRouter := router.SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Tabs or spaces?", "poll": {"options": ["Tabs", "Spaces"], "duration_minutes": 60}}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-User-ID", "alice123")
+	w := httptest.NewRecorder()
+	httpRouter.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var created map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	tweetID := created["id"].(string)
+
+	vote := func(userID string, option int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(VotePollRequest{Option: option})
+		req := httptest.NewRequest("POST", "/api/v1/tweets/"+tweetID+"/poll/votes", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+		return w
+	}
+
+	pollFor := func(viewerID string) map[string]interface{} {
+		req := httptest.NewRequest("GET", "/api/v1/users/tweets?user_id=alice123", nil)
+		req.Header.Set("X-User-ID", viewerID)
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		tweet := response["tweets"].([]interface{})[0].(map[string]interface{})
+		return tweet["poll"].(map[string]interface{})
+	}
+
+	t.Run("Invalid poll is rejected", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Pick one", "poll": {"options": ["Only"], "duration_minutes": 60}}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "alice123")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Vote and see results", func(t *testing.T) {
+		if w := vote("bob456", 1); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		poll := pollFor("bob456")
+		if poll["total_votes"] == nil {
+			t.Error("Expected tallies to be visible to a voter")
+		}
+
+		poll = pollFor("charlie789")
+		if poll["total_votes"] != nil {
+			t.Error("Expected tallies to be hidden from a non-voter")
+		}
+	})
+
+	t.Run("Second vote is rejected", func(t *testing.T) {
+		if w := vote("bob456", 0); w.Code != http.StatusConflict {
+			t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+		}
+	})
+
+	t.Run("Invalid option is rejected", func(t *testing.T) {
+		if w := vote("charlie789", 7); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+// TestMediaWorkflow tests uploading, serving and attaching media
+func TestMediaWorkflow(t *testing.T) {
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	mediaRepo := storage.NewMediaRepository(inMemoryStorage)
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	tweetService := services.NewTweetService(tweetRepo, userRepo, services.WithMediaRepository(mediaRepo))
+	followService := services.NewFollowService(followRepo, tweetRepo)
+	mediaService := services.NewMediaService(mediaRepo, blobStore, domain.SystemClock{})
+
+	handler := NewHandler(tweetService, followService, WithMediaService(mediaService))
+	router := NewRouter(handler)
+	httpRouter := router.SetupRoutes()
+
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+
+	var mediaID string
+	t.Run("Upload an image", func(t *testing.T) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "pixel.png")
+		part.Write(pngData.Bytes())
+		form.Close()
+
+		req := httptest.NewRequest("POST", "/api/v1/media", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("X-User-ID", "alice123")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		var media map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &media); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		mediaID = media["id"].(string)
+		if media["content_type"] != "image/png" {
+			t.Errorf("Expected content type image/png, got %v", media["content_type"])
+		}
+	})
+
+	t.Run("Reject unsupported uploads", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/media", bytes.NewBufferString("<svg/>"))
+		req.Header.Set("X-User-ID", "alice123")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Errorf("Expected status %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+		}
+	})
+
+	t.Run("Serve media with caching headers", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/media/" + mediaID, "/api/v1/media/" + mediaID + "/thumbnail"} {
+			req := httptest.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			httpRouter.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if w.Header().Get("Content-Type") != "image/png" {
+				t.Errorf("Expected image/png, got %s", w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Cache-Control") == "" {
+				t.Error("Expected Cache-Control header")
+			}
+
+			etag := w.Header().Get("ETag")
+			req = httptest.NewRequest("GET", path, nil)
+			req.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			httpRouter.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotModified {
+				t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+			}
+		}
+	})
+
+	t.Run("Attach media to a tweet", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Look at this", "media_ids": ["`+mediaID+`"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "alice123")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+
+		// Another user cannot attach Alice's media
+		req = httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Stolen", "media_ids": ["`+mediaID+`"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "bob456")
+		w = httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("Unknown media", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/media/missing", nil)
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+// TestWebhookWorkflow tests subscribing a webhook and receiving a signed
+// delivery for a follow
+func TestWebhookWorkflow(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r
+		bodies <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	webhookService := services.NewWebhookService(storage.NewWebhookRepository(inMemoryStorage), storage.NewWebhookDeliveryRepository(inMemoryStorage),
+		storage.NewFollowRepository(inMemoryStorage), webhook.NewSender(webhook.WithHTTPClient(&http.Client{Timeout: 5 * time.Second})),
+		domain.SystemClock{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go webhookService.Run(ctx, 1)
+
+	bus := services.NewEventBus(services.DefaultEventBuffer)
+	bus.Subscribe("webhooks", webhookService.HandleEvent)
+	relay := services.NewOutboxRelay(storage.NewOutboxRepository(inMemoryStorage), bus, domain.SystemClock{})
+	uow := relay.UnitOfWork(storage.NewUnitOfWork(inMemoryStorage))
+
+	tweetService := services.NewTweetService(tweetRepo, storage.NewUserRepository(inMemoryStorage), services.WithUnitOfWork(uow))
+	followService := services.NewFollowService(storage.NewFollowRepository(inMemoryStorage), tweetRepo, services.WithFollowUnitOfWork(uow))
+	httpRouter := NewRouter(NewHandler(tweetService, followService, WithWebhookService(webhookService))).SetupRoutes()
+
+	secret := "a-very-secret-key"
+	var webhookID string
+	t.Run("Create a webhook", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/webhooks", bytes.NewBufferString(`{"url": "`+receiver.URL+`/hook", "secret": "`+secret+`", "events": ["user.followed"]}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "alice")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		if bytes.Contains(w.Body.Bytes(), []byte(secret)) {
+			t.Error("Expected the secret not to be returned")
+		}
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		webhookID = response["id"].(string)
+	})
+
+	t.Run("Receive a signed follow event", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/follow", bytes.NewBufferString(`{"followee_id": "alice"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", "bob")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var r *http.Request
+		select {
+		case r = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the webhook to receive the event")
+		}
+		body := <-bodies
+
+		if r.Header.Get(webhook.HeaderEvent) != "user.followed" {
+			t.Errorf("Expected event user.followed, got %q", r.Header.Get(webhook.HeaderEvent))
+		}
+		if !webhook.Verify(secret, r.Header.Get(webhook.HeaderTimestamp), body, r.Header.Get(webhook.HeaderSignature)) {
+			t.Error("Expected a valid signature")
+		}
+		var event struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Fatalf("Failed to unmarshal event: %v", err)
+		}
+		if event.Data["follower_id"] != "bob" || event.Data["followee_id"] != "alice" {
+			t.Errorf("Expected bob to follow alice, got %v", event.Data)
+		}
+	})
+
+	t.Run("List deliveries", func(t *testing.T) {
+		// The attempt is recorded just after the response is sent
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			req := httptest.NewRequest("GET", "/api/v1/webhooks/"+webhookID+"/deliveries", nil)
+			req.Header.Set("X-User-ID", "alice")
+			w := httptest.NewRecorder()
+			httpRouter.ServeHTTP(w, req)
+
+			var response struct {
+				Deliveries []struct {
+					Status         string `json:"status"`
+					ResponseStatus int    `json:"response_status"`
+				} `json:"deliveries"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response.Deliveries) != 1 {
+				t.Fatalf("Expected 1 delivery, got %d", len(response.Deliveries))
+			}
+			if response.Deliveries[0].Status == "succeeded" {
+				if response.Deliveries[0].ResponseStatus != http.StatusNoContent {
+					t.Errorf("Expected response status %d, got %d", http.StatusNoContent, response.Deliveries[0].ResponseStatus)
+				}
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected the delivery to succeed, got %s", response.Deliveries[0].Status)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+}
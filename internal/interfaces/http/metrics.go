@@ -0,0 +1,44 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/infrastructure/metrics"
+)
+
+const (
+	// metricsRouteName names the Prometheus scrape route
+	metricsRouteName = "metrics"
+	// unmatchedRoute labels requests that matched no route
+	unmatchedRoute = "unmatched"
+)
+
+// metricsMiddleware records request counts, latencies and in-flight requests
+// labelled by route template
+func metricsMiddleware(m *metrics.Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			done := m.RequestStarted()
+			defer done()
+
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			m.ObserveRequest(r.Method, routeTemplate(r), sw.status, time.Since(start))
+		})
+	}
+}
+
+// routeTemplate returns the matched route's path template, so that requests
+// for different tweets or media share a label
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return unmatchedRoute
+}
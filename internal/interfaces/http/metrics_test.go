@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/infrastructure/metrics"
+	"uala-challenge/internal/infrastructure/ratelimit"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
+	limits := RateLimits{Read: ratelimit.Limit{Burst: 1, Per: time.Minute}}
+	httpRouter := NewRouter(handler,
+		WithMetrics(metrics.New()),
+		WithRateLimit(ratelimit.NewMemoryStore(), limits),
+	).SetupRoutes()
+
+	for _, path := range []string{"/api/v1/users/tweets?user_id=user123", "/api/v1/users/tweets?user_id=user456", "/api/v1/nope"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User-ID", "user123")
+		httpRouter.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Scrapes are exempt from rate limiting
+	var body string
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		body = w.Body.String()
+	}
+
+	expected := []string{
+		`uala_http_requests_total{method="GET",route="/api/v1/users/tweets",status="200"} 1`,
+		`uala_http_requests_total{method="GET",route="/api/v1/users/tweets",status="429"} 1`,
+		`uala_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`uala_http_request_duration_seconds_count{method="GET",route="/api/v1/users/tweets"} 2`,
+		`uala_http_requests_in_flight 1`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("Expected metrics to contain %q", line)
+		}
+	}
+}
+
+func TestMetricsEndpoint_DisabledByDefault(t *testing.T) {
+	httpRouter := NewRouter(NewHandler(&mockTweetService{}, &mockFollowService{})).SetupRoutes()
+
+	w := httptest.NewRecorder()
+	httpRouter.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
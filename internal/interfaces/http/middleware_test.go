@@ -0,0 +1,132 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/infrastructure/logging"
+	"uala-challenge/internal/infrastructure/storage"
+)
+
+// decodeLogLines parses JSON log output into one map per line
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("Expected JSON log line, got %q", line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.New(&buf, logging.Options{})
+	httpRouter := NewRouter(NewHandler(&mockTweetService{}, &mockFollowService{}), WithAccessLog(logger)).SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Hello"}`))
+	req.Header.Set("X-User-ID", "user123")
+	req.Header.Set("X-Request-ID", "req-abc")
+	w := httptest.NewRecorder()
+	httpRouter.ServeHTTP(w, req)
+
+	httpRouter.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/nope", nil))
+
+	entries := decodeLogLines(t, &buf)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 access log lines, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	expected := map[string]interface{}{
+		"msg":        "request",
+		"level":      "INFO",
+		"method":     "POST",
+		"route":      "/api/v1/tweets",
+		"path":       "/api/v1/tweets",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(w.Body.Len()),
+		"user_id":    "user123",
+		"request_id": "req-abc",
+	}
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("Expected %s=%v, got %v", key, value, entry[key])
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Error("Expected a duration")
+	}
+
+	if entries[1]["route"] != unmatchedRoute || entries[1]["status"] != float64(http.StatusNotFound) {
+		t.Errorf("Expected unmatched 404 to be logged, got %v", entries[1])
+	}
+}
+
+func TestServiceLogsCarryRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.New(&buf, logging.Options{})
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	defer slog.SetDefault(previous)
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetService := services.NewTweetService(storage.NewTweetRepository(inMemoryStorage), storage.NewUserRepository(inMemoryStorage))
+	followService := services.NewFollowService(storage.NewFollowRepository(inMemoryStorage), storage.NewTweetRepository(inMemoryStorage))
+	httpRouter := NewRouter(NewHandler(tweetService, followService)).SetupRoutes()
+
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "Hello"}`))
+	req.Header.Set("X-User-ID", "user123")
+	req.Header.Set("X-Request-ID", "req-service")
+	httpRouter.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := decodeLogLines(t, &buf)
+	if len(entries) != 1 || entries[0]["msg"] != "tweet created" {
+		t.Fatalf("Expected a tweet created log line, got %v", entries)
+	}
+	if entries[0]["request_id"] != "req-service" || entries[0]["user_id"] != "user123" {
+		t.Errorf("Expected request and user IDs, got %v", entries[0])
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		origins        []string
+		requestOrigin  string
+		expectedHeader string
+	}{
+		{"no origins allows any", nil, "https://evil.example", "*"},
+		{"wildcard allows any", []string{"*"}, "https://evil.example", "*"},
+		{"listed origin is echoed", []string{"https://app.example"}, "https://app.example", "https://app.example"},
+		{"unlisted origin is refused", []string{"https://app.example"}, "https://evil.example", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := corsMiddleware(tt.origins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			req := httptest.NewRequest("GET", "/api/v1/timeline", nil)
+			req.Header.Set("Origin", tt.requestOrigin)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.expectedHeader {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.expectedHeader, got)
+			}
+		})
+	}
+}
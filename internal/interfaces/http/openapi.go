@@ -0,0 +1,50 @@
+package http
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec documents every route registered by Router.SetupRoutes.
+// TestOpenAPI_EveryRouteIsDocumented keeps the two in sync.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+const (
+	// openAPIRouteName and docsRouteName name the documentation routes
+	openAPIRouteName = "openapi"
+	docsRouteName    = "docs"
+)
+
+// swaggerUIPage renders the OpenAPI document with Swagger UI
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Uala Microblog API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "/api/v1/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+// OpenAPIHandler serves the OpenAPI 3 document
+func (h *Handler) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// DocsHandler serves a Swagger UI page for the OpenAPI document
+func (h *Handler) DocsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
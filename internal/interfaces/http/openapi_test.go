@@ -0,0 +1,435 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/health"
+	"uala-challenge/internal/infrastructure/idempotency"
+	"uala-challenge/internal/infrastructure/metrics"
+	"uala-challenge/internal/infrastructure/ratelimit"
+	"uala-challenge/internal/infrastructure/storage"
+	"uala-challenge/internal/interfaces/activitypub"
+	"uala-challenge/internal/interfaces/graphql"
+)
+
+func init() {
+	// Images, feeds, metrics and the docs page are validated as opaque bodies
+	for _, contentType := range []string{"image/png", "application/atom+xml", "application/rss+xml", "text/plain", "text/html"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+	// WebFinger and ActivityPub documents are JSON
+	for _, contentType := range []string{"application/jrd+json", "application/activity+json"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.JSONBodyDecoder)
+	}
+}
+
+func loadOpenAPI(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		t.Fatalf("Failed to load OpenAPI document: %v", err)
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		t.Fatalf("Invalid OpenAPI document: %v", err)
+	}
+	return doc
+}
+
+// documentedAPI is a router with every optional feature enabled, backed by
+// real services over in-memory storage
+type documentedAPI struct {
+	router         *mux.Router
+	previewRepo    domain.LinkPreviewRepository
+	webhookService *services.WebhookService
+}
+
+// rejectingSender fails every webhook delivery, so that deliveries are
+// dead-lettered after their only attempt
+type rejectingSender struct{}
+
+func (rejectingSender) Send(context.Context, *domain.Webhook, *domain.WebhookDelivery) (int, error) {
+	return http.StatusServiceUnavailable, errors.New("webhook unavailable")
+}
+
+func newDocumentedAPI(t *testing.T, opts ...RouterOption) documentedAPI {
+	t.Helper()
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	scheduledRepo := storage.NewScheduledTweetRepository(inMemoryStorage)
+	pollRepo := storage.NewPollRepository(inMemoryStorage)
+	mediaRepo := storage.NewMediaRepository(inMemoryStorage)
+	previewRepo := storage.NewLinkPreviewRepository(inMemoryStorage)
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	webhookService := services.NewWebhookService(storage.NewWebhookRepository(inMemoryStorage), storage.NewWebhookDeliveryRepository(inMemoryStorage),
+		storage.NewFollowRepository(inMemoryStorage), rejectingSender{}, domain.SystemClock{}, services.WithWebhookRetries(1, time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		webhookService.Run(ctx, 1)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	bus := services.NewEventBus(services.DefaultEventBuffer)
+	bus.Subscribe("webhooks", webhookService.HandleEvent)
+	relay := services.NewOutboxRelay(storage.NewOutboxRepository(inMemoryStorage), bus, domain.SystemClock{})
+	uow := relay.UnitOfWork(storage.NewUnitOfWork(inMemoryStorage))
+
+	previewService := services.NewLinkPreviewService(previewRepo, nil, domain.SystemClock{})
+	tweetService := services.NewTweetService(tweetRepo, userRepo,
+		services.WithMediaRepository(mediaRepo),
+		services.WithUnfurler(previewService),
+		services.WithUnitOfWork(uow),
+	)
+	followService := services.NewFollowService(followRepo, tweetRepo, services.WithFollowUnitOfWork(uow))
+	handler := NewHandler(tweetService, followService,
+		WithScheduleService(services.NewScheduleService(scheduledRepo, tweetRepo, userRepo, domain.SystemClock{}, services.WithScheduleUnitOfWork(uow))),
+		WithPollService(services.NewPollService(tweetRepo, pollRepo, domain.SystemClock{})),
+		WithMediaService(services.NewMediaService(mediaRepo, blobStore, domain.SystemClock{})),
+		WithLinkPreviewService(previewService),
+		WithWebhookService(webhookService),
+	)
+
+	registry := health.NewRegistry(health.DefaultTimeout)
+	registry.AddLivenessCheck("worker", func(context.Context) error { return nil })
+	// Requests built by httptest are addressed to example.com
+	federation, err := activitypub.New("http://example.com", services.NewFederationService(followRepo, userRepo))
+	if err != nil {
+		t.Fatalf("Failed to create federation: %v", err)
+	}
+	opts = append([]RouterOption{
+		WithHealth(registry),
+		WithMetrics(metrics.New()),
+		WithIdempotency(idempotency.NewMemoryStore(), idempotency.DefaultTTL),
+		WithGraphQL(graphql.NewHandler(tweetService, followService, services.NewUserService(userRepo))),
+		WithActivityPub(activitypub.NewHandler(federation, tweetService, services.NewUserService(userRepo))),
+	}, opts...)
+
+	return documentedAPI{
+		router:         NewRouter(handler, opts...).SetupRoutes(),
+		previewRepo:    previewRepo,
+		webhookService: webhookService,
+	}
+}
+
+func TestOpenAPI_EveryRouteIsDocumented(t *testing.T) {
+	doc := loadOpenAPI(t)
+	api := newDocumentedAPI(t, WithRateLimit(ratelimit.NewMemoryStore(), DefaultRateLimits()))
+
+	registered := make(map[string]bool)
+	err := api.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Path prefixes for subrouters carry no methods
+			return nil
+		}
+		for _, method := range methods {
+			registered[method+" "+template] = true
+			item := doc.Paths.Find(template)
+			if item == nil || item.GetOperation(method) == nil {
+				t.Errorf("Route %s %s is not documented in openapi.json", method, template)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk routes: %v", err)
+	}
+
+	var stale []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			if !registered[method+" "+path] {
+				stale = append(stale, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(stale)
+	for _, operation := range stale {
+		t.Errorf("Documented operation %s has no route", operation)
+	}
+}
+
+// specValidator serves requests and checks each exchange against the spec
+type specValidator struct {
+	t       *testing.T
+	handler http.Handler
+	routes  routers.Router
+}
+
+func newSpecValidator(t *testing.T, handler http.Handler) *specValidator {
+	t.Helper()
+	routes, err := gorillamux.NewRouter(loadOpenAPI(t))
+	if err != nil {
+		t.Fatalf("Failed to build OpenAPI router: %v", err)
+	}
+	return &specValidator{t: t, handler: handler, routes: routes}
+}
+
+// exchange describes one request. Requests that are meant to be rejected
+// skip request validation; every response is validated.
+type exchange struct {
+	method      string
+	target      string
+	userID      string
+	contentType string
+	body        []byte
+	headers     map[string]string
+	invalid     bool
+	status      int
+}
+
+func (v *specValidator) do(e exchange) *httptest.ResponseRecorder {
+	v.t.Helper()
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(e.method, e.target, bytes.NewReader(e.body))
+		if e.body != nil {
+			contentType := e.contentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			req.Header.Set("Content-Type", contentType)
+		}
+		if e.userID != "" {
+			req.Header.Set("X-User-ID", e.userID)
+		}
+		for name, value := range e.headers {
+			req.Header.Set(name, value)
+		}
+		return req
+	}
+
+	w := httptest.NewRecorder()
+	v.handler.ServeHTTP(w, newRequest())
+	if w.Code != e.status {
+		v.t.Errorf("%s %s: expected status %d, got %d: %s", e.method, e.target, e.status, w.Code, w.Body.String())
+	}
+
+	req := newRequest()
+	route, pathParams, err := v.routes.FindRoute(req)
+	if err != nil {
+		v.t.Errorf("%s %s: no documented operation: %v", e.method, e.target, err)
+		return w
+	}
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+	}
+	if !e.invalid {
+		if err := openapi3filter.ValidateRequest(context.Background(), input); err != nil {
+			v.t.Errorf("%s %s: request does not match the spec: %v", e.method, e.target, err)
+		}
+	}
+	err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options:                input.Options,
+	})
+	if err != nil {
+		v.t.Errorf("%s %s: %d response does not match the spec: %v", e.method, e.target, w.Code, err)
+	}
+	return w
+}
+
+// id decodes the "id" field of a JSON response
+func id(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct {
+		ID string `json:"id"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return body.ID
+}
+
+func TestOpenAPI_ResponsesMatchSchemas(t *testing.T) {
+	api := newDocumentedAPI(t)
+	v := newSpecValidator(t, api.router)
+
+	api.previewRepo.Save(context.Background(), &domain.LinkPreview{
+		URL: "https://example.com/post", Title: "Example", FetchedAt: time.Now(),
+	})
+
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	var form bytes.Buffer
+	multipartWriter := multipart.NewWriter(&form)
+	part, _ := multipartWriter.CreateFormFile("file", "pixel.png")
+	part.Write(pngData.Bytes())
+	multipartWriter.Close()
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	later := time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339)
+
+	// Tweets
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": "Read https://example.com/post"}`), status: http.StatusCreated})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": ""}`), status: http.StatusBadRequest})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": "` + strings.Repeat("a", 281) + `"}`), status: http.StatusBadRequest})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{`), invalid: true, status: http.StatusBadRequest})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", body: []byte(`{"content": "anonymous"}`), invalid: true, status: http.StatusBadRequest})
+
+	// Idempotent retries
+	retry := exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": "once"}`),
+		headers: map[string]string{"Idempotency-Key": "k1"}, status: http.StatusCreated}
+	v.do(retry)
+	v.do(retry)
+	retry.body, retry.status = []byte(`{"content": "twice"}`), http.StatusUnprocessableEntity
+	v.do(retry)
+
+	// Polls
+	pollTweet := v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice",
+		body: []byte(`{"content": "Tabs or spaces?", "poll": {"options": ["Tabs", "Spaces"], "duration_minutes": 60}}`), status: http.StatusCreated})
+	votes := "/api/v1/tweets/" + id(t, pollTweet) + "/poll/votes"
+	v.do(exchange{method: "POST", target: votes, userID: "bob", body: []byte(`{"option": 1}`), status: http.StatusOK})
+	v.do(exchange{method: "POST", target: votes, userID: "bob", body: []byte(`{"option": 0}`), status: http.StatusConflict})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets/missing/poll/votes", userID: "bob", body: []byte(`{"option": 0}`), status: http.StatusNotFound})
+
+	// Webhooks, subscribed before the events they receive
+	webhook := v.do(exchange{method: "POST", target: "/api/v1/webhooks", userID: "alice",
+		body: []byte(`{"url": "https://example.com/hooks", "secret": "0123456789abcdef", "events": ["user.followed"]}`), status: http.StatusCreated})
+	v.do(exchange{method: "POST", target: "/api/v1/webhooks", userID: "alice",
+		body: []byte(`{"url": "https://example.com/hooks", "secret": "short", "events": ["user.followed"]}`), invalid: true, status: http.StatusBadRequest})
+	v.do(exchange{method: "GET", target: "/api/v1/webhooks", userID: "alice", status: http.StatusOK})
+	webhookURL := "/api/v1/webhooks/" + id(t, webhook)
+
+	// Follows and timelines
+	v.do(exchange{method: "POST", target: "/api/v1/follow", userID: "bob", body: []byte(`{"followee_id": "alice"}`), status: http.StatusOK})
+	v.do(exchange{method: "POST", target: "/api/v1/follow", userID: "bob", body: []byte(`{"followee_id": "bob"}`), status: http.StatusBadRequest})
+	v.do(exchange{method: "GET", target: "/api/v1/timeline", userID: "bob", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/timeline", userID: "carol", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/users/tweets?user_id=alice", userID: "bob", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/users/tweets", invalid: true, status: http.StatusBadRequest})
+	atom := v.do(exchange{method: "GET", target: "/api/v1/users/alice/feed.atom", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/users/alice/feed.atom", headers: map[string]string{"If-None-Match": atom.Header().Get("ETag")}, status: http.StatusNotModified})
+	v.do(exchange{method: "GET", target: "/api/v1/users/alice/feed.rss", status: http.StatusOK})
+	v.do(exchange{method: "POST", target: "/api/v1/unfollow", userID: "bob", body: []byte(`{"followee_id": "alice"}`), status: http.StatusOK})
+
+	// Webhook deliveries
+	deliveryID := waitForDeadDelivery(t, api.webhookService, "alice", id(t, webhook))
+	v.do(exchange{method: "GET", target: webhookURL + "/deliveries", userID: "alice", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/webhooks/missing/deliveries", userID: "alice", status: http.StatusNotFound})
+	v.do(exchange{method: "POST", target: webhookURL + "/deliveries/" + deliveryID + "/retry", userID: "alice", status: http.StatusAccepted})
+	v.do(exchange{method: "POST", target: webhookURL + "/deliveries/missing/retry", userID: "alice", status: http.StatusNotFound})
+	v.do(exchange{method: "DELETE", target: webhookURL, userID: "bob", status: http.StatusNotFound})
+	v.do(exchange{method: "DELETE", target: webhookURL, userID: "alice", status: http.StatusNoContent})
+
+	// Scheduled tweets
+	scheduled := v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": "Soon", "publish_at": "` + publishAt + `"}`), status: http.StatusAccepted})
+	scheduledURL := "/api/v1/tweets/scheduled/" + id(t, scheduled)
+	v.do(exchange{method: "GET", target: "/api/v1/tweets/scheduled", userID: "alice", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/tweets/scheduled", userID: "carol", status: http.StatusOK})
+	v.do(exchange{method: "PUT", target: scheduledURL, userID: "alice", body: []byte(`{"publish_at": "` + later + `"}`), status: http.StatusOK})
+	v.do(exchange{method: "PUT", target: "/api/v1/tweets/scheduled/missing", userID: "alice", body: []byte(`{"publish_at": "` + later + `"}`), status: http.StatusNotFound})
+	v.do(exchange{method: "DELETE", target: scheduledURL, userID: "alice", status: http.StatusNoContent})
+
+	// Media
+	uploaded := v.do(exchange{method: "POST", target: "/api/v1/media", userID: "alice", contentType: "image/png", body: pngData.Bytes(), status: http.StatusCreated})
+	v.do(exchange{method: "POST", target: "/api/v1/media", userID: "alice", contentType: multipartWriter.FormDataContentType(), body: form.Bytes(), status: http.StatusCreated})
+	v.do(exchange{method: "POST", target: "/api/v1/media", userID: "alice", contentType: "text/plain", body: []byte("not an image"), invalid: true, status: http.StatusUnsupportedMediaType})
+	mediaURL := "/api/v1/media/" + id(t, uploaded)
+	media := v.do(exchange{method: "GET", target: mediaURL, status: http.StatusOK})
+	v.do(exchange{method: "GET", target: mediaURL, headers: map[string]string{"If-None-Match": media.Header().Get("ETag")}, status: http.StatusNotModified})
+	v.do(exchange{method: "GET", target: mediaURL + "/thumbnail", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/media/missing", status: http.StatusNotFound})
+	v.do(exchange{method: "POST", target: "/api/v1/tweets", userID: "alice", body: []byte(`{"content": "Look", "media_ids": ["` + id(t, uploaded) + `"]}`), status: http.StatusCreated})
+
+	// GraphQL
+	v.do(exchange{method: "POST", target: "/graphql", body: []byte(`{"query": "{ user(id: \"alice\") { name tweets(first: 2) { totalCount edges { cursor node { id content createdAt } } pageInfo { hasNextPage endCursor } } } }"}`), status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/graphql?query=%7B%20timeline%20%7B%20totalCount%20%7D%20%7D", userID: "bob", status: http.StatusOK})
+	v.do(exchange{method: "POST", target: "/graphql", body: []byte(`{"query": "{ user(id: \"alice\") { nickname } }"}`), status: http.StatusBadRequest})
+
+	// ActivityPub
+	v.do(exchange{method: "GET", target: "/.well-known/webfinger?resource=acct:alice@example.com", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/.well-known/webfinger?resource=acct:nobody@example.com", status: http.StatusNotFound})
+	v.do(exchange{method: "GET", target: "/.well-known/webfinger", invalid: true, status: http.StatusBadRequest})
+	v.do(exchange{method: "GET", target: "/ap/users/alice", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/ap/users/nobody", status: http.StatusNotFound})
+	v.do(exchange{method: "GET", target: "/ap/users/alice/outbox", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/ap/tweets/" + id(t, pollTweet), status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/ap/tweets/missing", status: http.StatusNotFound})
+	v.do(exchange{method: "POST", target: "/ap/users/alice/inbox", contentType: "application/activity+json",
+		body: []byte(`{"type": "Follow", "actor": "https://remote.example/users/bob", "object": "http://example.com/ap/users/alice"}`), invalid: true, status: http.StatusUnauthorized})
+
+	// Operations
+	v.do(exchange{method: "GET", target: "/api/v1/health", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/livez", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/readyz?verbose", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/metrics", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/openapi.json", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/docs", status: http.StatusOK})
+}
+
+func TestOpenAPI_RateLimitedResponseMatchesSchema(t *testing.T) {
+	limits := RateLimits{
+		Read:  ratelimit.Limit{Burst: 1, Per: time.Minute},
+		Write: ratelimit.Limit{Burst: 1, Per: time.Minute},
+	}
+	api := newDocumentedAPI(t, WithRateLimit(ratelimit.NewMemoryStore(), limits))
+	v := newSpecValidator(t, api.router)
+
+	v.do(exchange{method: "GET", target: "/api/v1/timeline", userID: "alice", status: http.StatusOK})
+	v.do(exchange{method: "GET", target: "/api/v1/timeline", userID: "alice", status: http.StatusTooManyRequests})
+}
+
+// waitForDeadDelivery waits until one of a webhook's deliveries has been
+// dead-lettered and returns its ID
+func waitForDeadDelivery(t *testing.T, webhookService *services.WebhookService, userID, webhookID string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := webhookService.GetDeliveries(context.Background(), userID, webhookID)
+		if err != nil {
+			t.Fatalf("Failed to list deliveries: %v", err)
+		}
+		for _, delivery := range deliveries {
+			if delivery.Status == domain.DeliveryDead {
+				return delivery.ID
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected a dead-lettered delivery, got %d deliveries", len(deliveries))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
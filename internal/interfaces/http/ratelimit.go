@@ -0,0 +1,168 @@
+package http
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/infrastructure/ratelimit"
+)
+
+const (
+	// healthRouteName names the health check route
+	healthRouteName = "health"
+	// graphqlRouteName names the GraphQL route, whose POSTs are queries
+	graphqlRouteName = "graphql"
+)
+
+// RateLimits holds the token bucket limits per route class. A zero limit
+// disables limiting for that class.
+type RateLimits struct {
+	// Read applies to GET and HEAD requests and to GraphQL queries
+	Read ratelimit.Limit
+	// Write applies to every other method
+	Write ratelimit.Limit
+	// IPMultiple scales the limits for the bucket that every request from
+	// one IP shares, so that users behind the same NAT or proxy each get
+	// their own quota while a client sending a new X-User-ID every time is
+	// still held to one. Values below 1 count as 1.
+	IPMultiple int
+}
+
+// DefaultRateLimits returns the limits used by the server
+func DefaultRateLimits() RateLimits {
+	return RateLimits{
+		Read:       ratelimit.PerMinute(300),
+		Write:      ratelimit.PerMinute(30),
+		IPMultiple: 10,
+	}
+}
+
+// forRequest returns the route class and limit that apply to a request
+func (l RateLimits) forRequest(r *http.Request) (string, ratelimit.Limit) {
+	if route := mux.CurrentRoute(r); route != nil && route.GetName() == graphqlRouteName {
+		return "read", l.Read
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return "read", l.Read
+	default:
+		return "write", l.Write
+	}
+}
+
+// rateLimitMiddleware enforces per-client token buckets and reports quota in
+// X-RateLimit-* headers. A client is its X-User-ID, or its remote IP when it
+// sends none. The header is not authenticated, so every request is also
+// charged to a larger bucket shared by its IP, which stops a client from
+// escaping its quota by sending a new user ID each time. A request is only
+// charged when both buckets allow it. If the store fails, requests are let
+// through.
+func rateLimitMiddleware(store ratelimit.Store, limits RateLimits) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			class, limit := limits.forRequest(r)
+			if !limit.Enabled() || isUnlimitedRoute(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := takeRateLimit(r, store, class, limit, limits.IPMultiple)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limit store unavailable, allowing request", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(ceilUnix(result.Reset), 10))
+
+			if !result.Allowed {
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				writeError(w, r, rateLimited(retryAfter))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// takeRateLimit charges a request to its client's bucket and its IP's, and
+// returns the result of the tightest: the one that refused it, or the one
+// with the fewest tokens left
+func takeRateLimit(r *http.Request, store ratelimit.Store, class string, limit ratelimit.Limit, ipMultiple int) (ratelimit.Result, error) {
+	client, ip := rateLimitKeys(r)
+	ipLimit := ratelimit.Limit{Burst: limit.Burst * max(ipMultiple, 1), Per: limit.Per}
+	results, err := store.TakeAll(r.Context(), []ratelimit.Charge{
+		{Key: class + ":" + client, Limit: limit},
+		{Key: class + ":" + ip, Limit: ipLimit},
+	})
+	if err != nil {
+		return ratelimit.Result{}, err
+	}
+
+	tightest := results[0]
+	for _, result := range results[1:] {
+		if result.RetryAfter > tightest.RetryAfter || (result.Allowed && result.Remaining < tightest.Remaining) {
+			tightest = result
+		}
+	}
+	return tightest, nil
+}
+
+// rateLimited reports an exhausted quota
+func rateLimited(retryAfter int) *APIError {
+	return &APIError{
+		Status:  http.StatusTooManyRequests,
+		Code:    "rate_limited",
+		Message: "Too many requests",
+		Details: map[string]interface{}{"retry_after_seconds": retryAfter},
+	}
+}
+
+// rateLimitKeys identifies the buckets a request counts against: its
+// client's, by user or else by IP, and the one its IP shares
+func rateLimitKeys(r *http.Request) (client, ip string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	client = "anon:" + host
+	if userID := r.Header.Get("X-User-ID"); userID != "" {
+		client = "user:" + userID
+	}
+	return client, "ip:" + host
+}
+
+// isUnlimitedRoute reports whether a request targets an operational route,
+// such as health checks or metrics scrapes, that is never rate limited
+func isUnlimitedRoute(r *http.Request) bool {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return false
+	}
+	name := route.GetName()
+	switch name {
+	case healthRouteName, livezRouteName, readyzRouteName, metricsRouteName:
+		return true
+	}
+	return false
+}
+
+// ceilUnix rounds a time up to whole Unix seconds
+func ceilUnix(t time.Time) int64 {
+	seconds := t.Unix()
+	if t.Nanosecond() > 0 {
+		seconds++
+	}
+	return seconds
+}
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/infrastructure/ratelimit"
+)
+
+type failingRateLimitStore struct{}
+
+func (s failingRateLimitStore) Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func (s failingRateLimitStore) TakeAll(ctx context.Context, charges []ratelimit.Charge) ([]ratelimit.Result, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func newRateLimitedRouter(store ratelimit.Store) http.Handler {
+	limits := RateLimits{
+		Read:       ratelimit.Limit{Burst: 3, Per: time.Minute},
+		Write:      ratelimit.Limit{Burst: 2, Per: time.Minute},
+		IPMultiple: 2,
+	}
+	handler := NewHandler(&mockTweetService{}, &mockFollowService{})
+	graphql := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	return NewRouter(handler, WithRateLimit(store, limits), WithGraphQL(graphql)).SetupRoutes()
+}
+
+func postTweet(router http.Handler, userID string) *httptest.ResponseRecorder {
+	return postTweetFrom(router, userID, "192.0.2.1:1234")
+}
+
+func postTweetFrom(router http.Handler, userID, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/api/v1/tweets", bytes.NewBufferString(`{"content": "hello"}`))
+	req.Header.Set("X-User-ID", userID)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore())
+
+	for i := 1; i >= 0; i-- {
+		w := postTweet(router, "user123")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" {
+			t.Errorf("Expected X-RateLimit-Limit 2, got %q", w.Header().Get("X-RateLimit-Limit"))
+		}
+		if w.Header().Get("X-RateLimit-Remaining") != strconv.Itoa(i) {
+			t.Errorf("Expected X-RateLimit-Remaining %d, got %q", i, w.Header().Get("X-RateLimit-Remaining"))
+		}
+		reset, err := strconv.ParseInt(w.Header().Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil || reset < time.Now().Unix() {
+			t.Errorf("Expected a future X-RateLimit-Reset, got %q", w.Header().Get("X-RateLimit-Reset"))
+		}
+	}
+
+	w := postTweet(router, "user123")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After 30, got %q", w.Header().Get("Retry-After"))
+	}
+	if envelope := decodeError(t, w); envelope.Error.Code != "rate_limited" {
+		t.Errorf("Expected code rate_limited, got %s", envelope.Error.Code)
+	}
+
+	t.Run("other users have their own quota", func(t *testing.T) {
+		if w := postTweetFrom(router, "user456", "198.51.100.1:1234"); w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	})
+
+	t.Run("users are limited across IPs", func(t *testing.T) {
+		if w := postTweetFrom(router, "user123", "198.51.100.2:1234"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+	})
+
+	t.Run("reads have a separate quota", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/timeline", nil)
+		req.Header.Set("X-User-ID", "user123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Errorf("Expected read limit 3, got %q", w.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+
+	t.Run("graphql queries use the read quota", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/graphql", bytes.NewBufferString(`{"query": "{ timeline { totalCount } }"}`))
+		req.Header.Set("X-User-ID", "user123")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "3" {
+			t.Errorf("Expected read limit 3, got %q", w.Header().Get("X-RateLimit-Limit"))
+		}
+	})
+
+	t.Run("health checks are not limited", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/health", nil))
+			if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "" {
+				t.Fatalf("Expected unlimited health check, got %d", w.Code)
+			}
+		}
+	})
+}
+
+func TestRateLimitMiddleware_AnonymousByIP(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore())
+
+	get := func(remoteAddr string) int {
+		req := httptest.NewRequest("GET", "/api/v1/users/tweets?user_id=user123", nil)
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Different ports of the same address share a bucket
+	for port := 1000; port < 1003; port++ {
+		if code := get("203.0.113.7:" + strconv.Itoa(port)); code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, code)
+		}
+	}
+	if code := get("203.0.113.7:2000"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, code)
+	}
+	if code := get("198.51.100.1:1000"); code != http.StatusOK {
+		t.Errorf("Expected other IP to be allowed, got %d", code)
+	}
+}
+
+func TestRateLimitMiddleware_RotatingUserIDs(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore())
+
+	// A new X-User-ID on every request still counts against the IP, whose
+	// bucket is twice the size
+	for i := 0; i < 4; i++ {
+		if w := postTweet(router, "user"+strconv.Itoa(i)); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+	w := postTweet(router, "user4")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Errorf("Expected X-RateLimit-Remaining 0, got %q", w.Header().Get("X-RateLimit-Remaining"))
+	}
+}
+
+func TestRateLimitMiddleware_SharedIP(t *testing.T) {
+	router := newRateLimitedRouter(ratelimit.NewMemoryStore())
+
+	// Requests a user's own bucket refuses cost the IP nothing, so the other
+	// user behind the same address still gets a full quota
+	for i, want := range []int{http.StatusCreated, http.StatusCreated, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if w := postTweet(router, "alice"); w.Code != want {
+			t.Fatalf("Request %d: expected status %d, got %d", i, want, w.Code)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if w := postTweet(router, "bob"); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+
+	// The address's bucket is now empty for everyone behind it
+	if w := postTweet(router, "carol"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+}
+
+func TestRateLimitMiddleware_StoreFailureAllowsRequests(t *testing.T) {
+	router := newRateLimitedRouter(failingRateLimitStore{})
+
+	for i := 0; i < 3; i++ {
+		if w := postTweet(router, "user123"); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+	}
+}
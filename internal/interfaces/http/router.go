@@ -1,21 +1,122 @@
 package http
 
 import (
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/infrastructure/health"
+	"uala-challenge/internal/infrastructure/idempotency"
+	"uala-challenge/internal/infrastructure/metrics"
+	"uala-challenge/internal/infrastructure/ratelimit"
 )
 
 // Router sets up HTTP routes
 type Router struct {
-	handler *Handler
+	handler          *Handler
+	rateLimitStore   ratelimit.Store
+	rateLimits       RateLimits
+	idempotencyStore idempotency.Store
+	idempotencyTTL   time.Duration
+	metrics          *metrics.Metrics
+	tracerProvider   trace.TracerProvider
+	accessLogger     *slog.Logger
+	corsOrigins      []string
+	health           *health.Registry
+	graphql          http.Handler
+	activityPub      RouteRegistrar
+}
+
+// RouteRegistrar adds the routes of another interface package to the router
+type RouteRegistrar interface {
+	Register(router *mux.Router)
+}
+
+// RouterOption configures optional router behaviour
+type RouterOption func(*Router)
+
+// WithRateLimit enables per-client rate limiting backed by the given store
+func WithRateLimit(store ratelimit.Store, limits RateLimits) RouterOption {
+	return func(r *Router) {
+		r.rateLimitStore = store
+		r.rateLimits = limits
+	}
+}
+
+// WithIdempotency enables Idempotency-Key handling for POST requests,
+// remembering responses in the given store for ttl
+func WithIdempotency(store idempotency.Store, ttl time.Duration) RouterOption {
+	return func(r *Router) {
+		r.idempotencyStore = store
+		r.idempotencyTTL = ttl
+	}
+}
+
+// WithMetrics records HTTP metrics and serves them at /metrics
+func WithMetrics(m *metrics.Metrics) RouterOption {
+	return func(r *Router) {
+		r.metrics = m
+	}
+}
+
+// WithTracing starts a span for every request using the given provider
+func WithTracing(tp trace.TracerProvider) RouterOption {
+	return func(r *Router) {
+		r.tracerProvider = tp
+	}
+}
+
+// WithAccessLog logs every request to logger
+func WithAccessLog(logger *slog.Logger) RouterOption {
+	return func(r *Router) {
+		r.accessLogger = logger
+	}
+}
+
+// WithCORSOrigins restricts cross-origin requests to the given origins.
+// By default any origin is allowed.
+func WithCORSOrigins(origins []string) RouterOption {
+	return func(r *Router) {
+		r.corsOrigins = origins
+	}
+}
+
+// WithHealth serves liveness and readiness probes at /livez and /readyz
+// backed by the given registry
+func WithHealth(registry *health.Registry) RouterOption {
+	return func(r *Router) {
+		r.health = registry
+	}
+}
+
+// WithGraphQL serves the given GraphQL handler at /graphql
+func WithGraphQL(handler http.Handler) RouterOption {
+	return func(r *Router) {
+		r.graphql = handler
+	}
+}
+
+// WithActivityPub serves the WebFinger and ActivityPub routes added by
+// registrar
+func WithActivityPub(registrar RouteRegistrar) RouterOption {
+	return func(r *Router) {
+		r.activityPub = registrar
+	}
 }
 
 // NewRouter creates a new router
-func NewRouter(handler *Handler) *Router {
-	return &Router{
+func NewRouter(handler *Handler, opts ...RouterOption) *Router {
+	r := &Router{
 		handler: handler,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
 // SetupRoutes configures all HTTP routes
@@ -29,32 +130,170 @@ func (r *Router) SetupRoutes() *mux.Router {
 	api.HandleFunc("/tweets", r.handler.CreateTweetHandler).Methods("POST")
 	api.HandleFunc("/timeline", r.handler.GetTimelineHandler).Methods("GET")
 	api.HandleFunc("/users/tweets", r.handler.GetUserTweetsHandler).Methods("GET")
+	api.HandleFunc("/users/{id}/feed.atom", r.handler.UserAtomFeedHandler).Methods("GET")
+	api.HandleFunc("/users/{id}/feed.rss", r.handler.UserRSSFeedHandler).Methods("GET")
+
+	// Scheduled tweet routes
+	if r.handler.scheduleService != nil {
+		api.HandleFunc("/tweets/scheduled", r.handler.GetScheduledTweetsHandler).Methods("GET")
+		api.HandleFunc("/tweets/scheduled/{id}", r.handler.RescheduleTweetHandler).Methods("PUT")
+		api.HandleFunc("/tweets/scheduled/{id}", r.handler.CancelScheduledTweetHandler).Methods("DELETE")
+	}
+
+	// Poll routes
+	if r.handler.pollService != nil {
+		api.HandleFunc("/tweets/{id}/poll/votes", r.handler.VotePollHandler).Methods("POST")
+	}
+
+	// Media routes
+	if r.handler.mediaService != nil {
+		api.HandleFunc("/media", r.handler.UploadMediaHandler).Methods("POST")
+		api.HandleFunc("/media/{id}", r.handler.GetMediaHandler).Methods("GET")
+		api.HandleFunc("/media/{id}/thumbnail", r.handler.GetMediaThumbnailHandler).Methods("GET")
+	}
 
 	// Follow routes
 	api.HandleFunc("/follow", r.handler.FollowUserHandler).Methods("POST")
 	api.HandleFunc("/unfollow", r.handler.UnfollowUserHandler).Methods("POST")
 
+	// Webhook routes
+	if r.handler.webhookService != nil {
+		api.HandleFunc("/webhooks", r.handler.CreateWebhookHandler).Methods("POST")
+		api.HandleFunc("/webhooks", r.handler.GetWebhooksHandler).Methods("GET")
+		api.HandleFunc("/webhooks/{id}", r.handler.DeleteWebhookHandler).Methods("DELETE")
+		api.HandleFunc("/webhooks/{id}/deliveries", r.handler.GetWebhookDeliveriesHandler).Methods("GET")
+		api.HandleFunc("/webhooks/{id}/deliveries/{delivery_id}/retry", r.handler.RetryWebhookDeliveryHandler).Methods("POST")
+	}
+
 	// Health check
-	api.HandleFunc("/health", r.handler.HealthCheckHandler).Methods("GET")
+	api.HandleFunc("/health", r.handler.HealthCheckHandler).Methods("GET").Name(healthRouteName)
+
+	// API documentation
+	api.HandleFunc("/openapi.json", r.handler.OpenAPIHandler).Methods("GET").Name(openAPIRouteName)
+	api.HandleFunc("/docs", r.handler.DocsHandler).Methods("GET").Name(docsRouteName)
+
+	// GraphQL queries
+	if r.graphql != nil {
+		router.Handle("/graphql", r.graphql).Methods("GET", "POST").Name(graphqlRouteName)
+	}
+
+	// ActivityPub federation
+	if r.activityPub != nil {
+		r.activityPub.Register(router)
+	}
+
+	// Liveness and readiness probes
+	if r.health != nil {
+		router.Handle("/livez", probeHandler(r.health.Live)).Methods("GET").Name(livezRouteName)
+		router.Handle("/readyz", probeHandler(r.health.Ready)).Methods("GET").Name(readyzRouteName)
+	}
+
+	// Prometheus scrape endpoint
+	if r.metrics != nil {
+		router.Handle("/metrics", r.metrics.Handler()).Methods("GET").Name(metricsRouteName)
+	}
 
-	// Add CORS middleware
-	router.Use(corsMiddleware)
+	// Unmatched routes answer with structured errors too. Router middleware
+	// only runs for matched routes, so the fallbacks are wrapped explicitly.
+	fallback := func(h http.Handler) http.Handler {
+		if r.metrics != nil {
+			h = metricsMiddleware(r.metrics)(h)
+		}
+		if r.accessLogger != nil {
+			h = accessLogMiddleware(r.accessLogger)(h)
+		}
+		if r.tracerProvider != nil {
+			h = tracingMiddleware(r.tracerProvider)(h)
+		}
+		return corsMiddleware(r.corsOrigins)(requestIDMiddleware(h))
+	}
+	router.NotFoundHandler = fallback(notFoundHandler(router))
+	router.MethodNotAllowedHandler = fallback(errorHandler(errMethodNotAllowed))
+
+	// Add middleware
+	router.Use(corsMiddleware(r.corsOrigins))
+	router.Use(requestIDMiddleware)
+	if r.tracerProvider != nil {
+		router.Use(tracingMiddleware(r.tracerProvider))
+	}
+	if r.accessLogger != nil {
+		router.Use(accessLogMiddleware(r.accessLogger))
+	}
+	if r.metrics != nil {
+		router.Use(metricsMiddleware(r.metrics))
+	}
+	if r.rateLimitStore != nil {
+		router.Use(rateLimitMiddleware(r.rateLimitStore, r.rateLimits))
+	}
+	if r.idempotencyStore != nil {
+		router.Use(idempotencyMiddleware(r.idempotencyStore, r.idempotencyTTL))
+	}
 
 	return router
 }
 
-// corsMiddleware adds CORS headers for development
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-User-ID")
+// probeMethods are the methods tried when deciding between 404 and 405.
+var probeMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// notFoundHandler answers unmatched requests. mux reports a method mismatch
+// inside a subrouter as "not found", so the router is probed with the other
+// methods and a 405 with an Allow header is returned when one of them matches.
+func notFoundHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var allowed []string
+		for _, method := range probeMethods {
+			if method == req.Method {
+				continue
+			}
+			probe := req.Clone(req.Context())
+			probe.Method = method
+			var match mux.RouteMatch
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
 		}
 
-		next.ServeHTTP(w, r)
+		if len(allowed) == 0 {
+			writeError(w, req, errRouteNotFound)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, req, errMethodNotAllowed)
 	})
 }
+
+// corsMiddleware adds CORS headers. With no origins, or with "*" among
+// them, any origin is allowed; otherwise only listed origins are echoed back.
+func corsMiddleware(origins []string) mux.MiddlewareFunc {
+	allowAll := len(origins) == 0
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowAll {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Add("Vary", "Origin")
+				if origin := r.Header.Get("Origin"); allowed[origin] {
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-User-ID, X-Request-ID, Idempotency-Key, traceparent, tracestate")
+			w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After, Idempotent-Replayed")
+
+			if r.Method == "OPTIONS" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/infrastructure/requestid"
+	"uala-challenge/internal/infrastructure/tracing"
+)
+
+// tracerName identifies the spans created by the HTTP layer
+const tracerName = "uala-challenge/internal/interfaces/http"
+
+// tracingMiddleware starts a server span per request, continuing the trace
+// from an incoming W3C traceparent header when present. The span is named
+// after the route template and carried in the request context, so service
+// and repository spans become its children.
+func tracingMiddleware(tp trace.TracerProvider) mux.MiddlewareFunc {
+	tracer := tp.Tracer(tracerName)
+	propagator := tracing.Propagator()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := routeTemplate(r)
+			ctx, span := tracer.Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.HTTPRoute(route),
+					semconv.URLPath(r.URL.Path),
+					attribute.String("request.id", requestid.FromContext(r.Context())),
+				),
+			)
+			defer span.End()
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(sw.status))
+			if sw.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/infrastructure/storage"
+	"uala-challenge/internal/infrastructure/tracing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	tweetRepo := tracing.TraceTweetRepository(storage.NewTweetRepository(inMemoryStorage), tp)
+	tweetService := services.NewTweetService(tweetRepo, storage.NewUserRepository(inMemoryStorage))
+	followService := services.NewFollowService(storage.NewFollowRepository(inMemoryStorage), tweetRepo)
+	handler := NewHandler(tracing.TraceTweetService(tweetService, tp), followService)
+	httpRouter := NewRouter(handler, WithTracing(tp)).SetupRoutes()
+
+	t.Run("continues incoming trace", func(t *testing.T) {
+		exporter.Reset()
+		const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		const parentSpanID = "00f067aa0ba902b7"
+
+		req := httptest.NewRequest("GET", "/api/v1/users/tweets?user_id=alice", nil)
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentSpanID+"-01")
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		spans := exporter.GetSpans()
+		var server, service, repository *tracetest.SpanStub
+		for i := range spans {
+			switch spans[i].Name {
+			case "GET /api/v1/users/tweets":
+				server = &spans[i]
+			case "TweetService.GetUserTweets":
+				service = &spans[i]
+			case "TweetRepository.GetByUserID":
+				repository = &spans[i]
+			}
+		}
+		if server == nil || service == nil || repository == nil {
+			t.Fatalf("Expected server, service and repository spans, got %d spans", len(spans))
+		}
+
+		if server.SpanKind != trace.SpanKindServer {
+			t.Errorf("Expected server span kind, got %v", server.SpanKind)
+		}
+		if server.SpanContext.TraceID().String() != traceID {
+			t.Errorf("Expected trace %s, got %s", traceID, server.SpanContext.TraceID())
+		}
+		if server.Parent.SpanID().String() != parentSpanID || !server.Parent.IsRemote() {
+			t.Errorf("Expected remote parent %s, got %s", parentSpanID, server.Parent.SpanID())
+		}
+		if service.Parent.SpanID() != server.SpanContext.SpanID() {
+			t.Error("Expected service span to be a child of the server span")
+		}
+		if repository.Parent.SpanID() != service.SpanContext.SpanID() {
+			t.Error("Expected repository span to be a child of the service span")
+		}
+
+		attrs := make(map[string]string)
+		for _, attr := range server.Attributes {
+			attrs[string(attr.Key)] = attr.Value.Emit()
+		}
+		if attrs["http.route"] != "/api/v1/users/tweets" || attrs["http.response.status_code"] != "200" {
+			t.Errorf("Unexpected server span attributes: %v", attrs)
+		}
+		if attrs["request.id"] != w.Header().Get("X-Request-ID") {
+			t.Errorf("Expected request.id %s, got %s", w.Header().Get("X-Request-ID"), attrs["request.id"])
+		}
+	})
+
+	t.Run("starts a new trace without traceparent", func(t *testing.T) {
+		exporter.Reset()
+
+		w := httptest.NewRecorder()
+		httpRouter.ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/nope", nil))
+
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("Expected 1 span, got %d", len(spans))
+		}
+		if spans[0].Name != "GET unmatched" || spans[0].Parent.IsValid() {
+			t.Errorf("Expected a root span for the unmatched route, got %s", spans[0].Name)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"uala-challenge/internal/application/services"
+)
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// CreateWebhookHandler subscribes a URL to events. The secret is never
+// returned; clients keep their own copy to verify signatures.
+func (h *Handler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, invalidJSON(err))
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), services.CreateWebhookRequest{
+		UserID: userID,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(webhook)
+}
+
+func (h *Handler) GetWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": webhooks,
+		"count":    len(webhooks),
+	})
+}
+
+func (h *Handler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	err := h.webhookService.DeleteWebhook(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetWebhookDeliveriesHandler lists a webhook's most recent deliveries,
+// pending, succeeded and dead-lettered, newest first
+func (h *Handler) GetWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	})
+}
+
+// RetryWebhookDeliveryHandler queues a dead-lettered delivery again
+func (h *Handler) RetryWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		writeError(w, r, errMissingUserID)
+		return
+	}
+
+	vars := mux.Vars(r)
+	delivery, err := h.webhookService.RetryDelivery(r.Context(), userID, vars["id"], vars["delivery_id"])
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
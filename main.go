@@ -1,44 +1,384 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
 
 	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/config"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/health"
+	"uala-challenge/internal/infrastructure/idempotency"
+	"uala-challenge/internal/infrastructure/lifecycle"
+	"uala-challenge/internal/infrastructure/logging"
+	"uala-challenge/internal/infrastructure/metrics"
+	"uala-challenge/internal/infrastructure/ratelimit"
 	"uala-challenge/internal/infrastructure/storage"
+	"uala-challenge/internal/infrastructure/storage/sqlite"
+	"uala-challenge/internal/infrastructure/tracing"
+	"uala-challenge/internal/infrastructure/unfurl"
+	"uala-challenge/internal/infrastructure/webhook"
+	"uala-challenge/internal/interfaces/activitypub"
+	graphqlInterface "uala-challenge/internal/interfaces/graphql"
+	grpcInterface "uala-challenge/internal/interfaces/grpc"
 	httpInterface "uala-challenge/internal/interfaces/http"
 )
 
 func main() {
-	// Initialize infrastructure layer
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-projections" {
+		os.Exit(rebuildProjections(os.Args[2:]))
+	}
+
+	// Configuration comes from defaults, an optional config file,
+	// environment variables and flags, in increasing order of precedence
+	cfg, printConfig, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(2)
+	}
+	if printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Printing configuration: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Structured logs go to stdout
+	logger, err := logging.New(os.Stdout, logging.Options{
+		Level:  cfg.Log.Level,
+		Format: cfg.Log.Format,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid logging configuration: %v\n", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// The lifecycle stops everything in order on SIGINT or SIGTERM:
+	// readiness fails, connections drain, workers stop, then shutdown hooks
+	// run in reverse registration order
+	app := lifecycle.New()
+
+	// Components register liveness and readiness checks served at /livez
+	// and /readyz. Readiness fails as soon as draining starts.
+	healthRegistry := health.NewRegistry(health.DefaultTimeout)
+	healthRegistry.AddReadinessCheck("lifecycle", func(context.Context) error {
+		if !app.Ready() {
+			return errors.New("shutting down")
+		}
+		return nil
+	})
+
+	// Tracing exports to stdout or OTLP when an exporter is configured; the
+	// OTLP exporter honours the standard OTEL_EXPORTER_OTLP_* variables
+	tracerProvider, shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:     cfg.Tracing.Exporter,
+		ServiceName:  "uala-challenge",
+		OTLPEndpoint: cfg.Tracing.OTLPEndpoint,
+	})
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
+	app.OnShutdown("flush traces", shutdownTracing)
+
+	// Services and storage share the system clock and time-ordered UUIDv7
+	// IDs, which stamp tweets and outbox events alike
+	clock, ids := domain.SystemClock{}, domain.UUIDv7Generator{}
+
+	// Initialize infrastructure layer. Repositories are wrapped so that
+	// every operation is timed and traced.
+	appMetrics := metrics.New()
 	inMemoryStorage := storage.NewInMemoryRepository()
-	userRepo := storage.NewUserRepository(inMemoryStorage)
-	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
-	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	var userStore domain.UserRepository = storage.NewUserRepository(inMemoryStorage)
+	var tweetStore domain.TweetRepository = storage.NewTweetRepository(inMemoryStorage)
+	var followStore domain.FollowRepository = storage.NewFollowRepository(inMemoryStorage)
+	var outboxStore domain.OutboxRepository = storage.NewOutboxRepository(inMemoryStorage)
+	var mediaStore domain.MediaRepository = storage.NewMediaRepository(inMemoryStorage)
+	var pollStore domain.PollRepository = storage.NewPollRepository(inMemoryStorage)
+	var unitOfWorkStore domain.UnitOfWork = storage.NewUnitOfWork(inMemoryStorage, storage.WithUnitOfWorkClock(clock), storage.WithUnitOfWorkIDGenerator(ids))
+
+	// The event-sourced backend appends every change to users, tweets,
+	// follows, media and poll votes to a log in the data directory and replays it into the
+	// in-memory projections on startup
+	if cfg.Storage.Backend == config.StorageEventSourced {
+		eventStore, err := storage.NewEventStore(cfg.Storage.DataDir, inMemoryStorage, storage.WithEventClock(clock), storage.WithEventIDGenerator(ids))
+		if err != nil {
+			fatal("failed to open event store", err)
+		}
+		app.OnShutdown("close event log", func(context.Context) error { return eventStore.Close() })
+		healthRegistry.AddReadinessCheck("storage.events", eventStore.Ping)
+		userStore, tweetStore, followStore = eventStore.Users(), eventStore.Tweets(), eventStore.Follows()
+		mediaStore, pollStore = eventStore.Media(), eventStore.Polls()
+		outboxStore, unitOfWorkStore = eventStore.Outbox(), eventStore.UnitOfWork()
+	}
+
+	// The SQLite backend keeps them, and the outbox, in a database in the
+	// data directory, migrating its schema on startup
+	if cfg.Storage.Backend == config.StorageSQLite {
+		db, err := sqlite.Open(cfg.Storage.DataDir, sqlite.WithClock(clock), sqlite.WithIDGenerator(ids))
+		if err != nil {
+			fatal("failed to open database", err)
+		}
+		app.OnShutdown("close database", func(context.Context) error { return db.Close() })
+		healthRegistry.AddReadinessCheck("storage.sqlite", db.Ping)
+		userStore, tweetStore, followStore = db.Users(), db.Tweets(), db.Follows()
+		mediaStore, pollStore = db.Media(), db.Polls()
+		outboxStore, unitOfWorkStore = db.Outbox(), db.UnitOfWork()
+	}
+	userRepo := tracing.TraceUserRepository(metrics.InstrumentUserRepository(userStore, appMetrics), tracerProvider)
+	tweetRepo := tracing.TraceTweetRepository(metrics.InstrumentTweetRepository(tweetStore, appMetrics), tracerProvider)
+	followRepo := tracing.TraceFollowRepository(metrics.InstrumentFollowRepository(followStore, appMetrics), tracerProvider)
+	pollRepo := tracing.TracePollRepository(metrics.InstrumentPollRepository(pollStore, appMetrics), tracerProvider)
+	mediaRepo := tracing.TraceMediaRepository(metrics.InstrumentMediaRepository(mediaStore, appMetrics), tracerProvider)
+	previewRepo := tracing.TraceLinkPreviewRepository(metrics.InstrumentLinkPreviewRepository(storage.NewLinkPreviewRepository(inMemoryStorage), appMetrics), tracerProvider)
+
+	// With federation enabled, created tweets are also delivered to remote
+	// followers on Mastodon and other ActivityPub servers
+	var federation *activitypub.Federation
+	if cfg.Federation.BaseURL != "" {
+		// Signing keys are kept with the data, when there is any, so that
+		// remote servers keep accepting signatures after a restart
+		var federationOpts []activitypub.Option
+		if cfg.Storage.DataDir != "" {
+			federationOpts = append(federationOpts, activitypub.WithKeyDir(filepath.Join(cfg.Storage.DataDir, "activitypub-keys")))
+		}
+		federation, err = activitypub.New(cfg.Federation.BaseURL, services.NewFederationService(followRepo, userRepo), federationOpts...)
+		if err != nil {
+			fatal("failed to set up federation", err)
+		}
+	}
+
+	// The persistent backends keep scheduled tweets in the data directory
+	// so that pending posts survive restarts
+	var scheduledRepo domain.ScheduledTweetRepository = storage.NewScheduledTweetRepository(inMemoryStorage)
+	if cfg.Storage.Persistent() {
+		fileRepo, err := storage.NewFileScheduledTweetRepository(cfg.Storage.DataDir)
+		if err != nil {
+			fatal("failed to open scheduled tweet store", err)
+		}
+		app.OnShutdown("flush scheduled tweets", func(context.Context) error { return fileRepo.Close() })
+		healthRegistry.AddReadinessCheck("storage.scheduled_tweets", fileRepo.Ping)
+		scheduledRepo = fileRepo
+	}
+	scheduledRepo = tracing.TraceScheduledTweetRepository(metrics.InstrumentScheduledTweetRepository(scheduledRepo, appMetrics), tracerProvider)
+
+	// Webhook subscriptions and the delivery queue are persisted the same
+	// way, so queued events are still delivered after a restart
+	var webhookRepo domain.WebhookRepository = storage.NewWebhookRepository(inMemoryStorage)
+	var deliveryRepo domain.WebhookDeliveryRepository = storage.NewWebhookDeliveryRepository(inMemoryStorage)
+	if cfg.Storage.Persistent() {
+		fileWebhookRepo, err := storage.NewFileWebhookRepository(cfg.Storage.DataDir)
+		if err != nil {
+			fatal("failed to open webhook store", err)
+		}
+		fileDeliveryRepo, err := storage.NewFileWebhookDeliveryRepository(cfg.Storage.DataDir)
+		if err != nil {
+			fatal("failed to open webhook delivery store", err)
+		}
+		app.OnShutdown("flush webhooks", func(context.Context) error { return fileWebhookRepo.Close() })
+		app.OnShutdown("flush webhook deliveries", func(context.Context) error { return fileDeliveryRepo.Close() })
+		healthRegistry.AddReadinessCheck("storage.webhooks", fileWebhookRepo.Ping)
+		healthRegistry.AddReadinessCheck("storage.webhook_deliveries", fileDeliveryRepo.Ping)
+		webhookRepo, deliveryRepo = fileWebhookRepo, fileDeliveryRepo
+	}
+	webhookRepo = tracing.TraceWebhookRepository(metrics.InstrumentWebhookRepository(webhookRepo, appMetrics), tracerProvider)
+	deliveryRepo = tracing.TraceWebhookDeliveryRepository(metrics.InstrumentWebhookDeliveryRepository(deliveryRepo, appMetrics), tracerProvider)
+
+	// Uploaded media blobs live on the local filesystem
+	mediaDir := filepath.Join(os.TempDir(), "uala-media")
+	if cfg.Storage.DataDir != "" {
+		mediaDir = filepath.Join(cfg.Storage.DataDir, "media")
+	}
+	blobStore, err := storage.NewLocalBlobStore(mediaDir)
+	if err != nil {
+		fatal("failed to open media store", err)
+	}
+	healthRegistry.AddReadinessCheck("storage.media", blobStore.Ping)
 
 	// Initialize application layer (services)
-	tweetService := services.NewTweetService(tweetRepo, userRepo)
-	followService := services.NewFollowService(followRepo, tweetRepo)
+	previewService := services.NewLinkPreviewService(previewRepo, unfurl.NewHTTPFetcher(unfurl.DefaultOptions()), clock)
+	webhookService := services.NewWebhookService(webhookRepo, deliveryRepo, followRepo, webhook.NewSender(), clock, services.WithWebhookIDGenerator(ids))
+
+	// Users, tweets and follows are written in units of work that commit
+	// domain events to an outbox with the change. The relay publishes them
+	// on the event bus: webhook deliveries are queued and the live feed is
+	// updated before the request returns, and federation fans out in the
+	// background. Events whose subscribers failed are published again.
+	tweetFeed := services.NewTweetFeed(services.DefaultFeedBuffer)
+	eventBus := services.NewEventBus(services.DefaultEventBuffer)
+	eventBus.Subscribe("webhooks", webhookService.HandleEvent)
+	eventBus.Subscribe("live feed", tweetFeed.HandleEvent)
+	if federation != nil {
+		eventBus.SubscribeAsync("federation", federation.HandleEvent)
+	}
+	outboxRepo := tracing.TraceOutboxRepository(metrics.InstrumentOutboxRepository(outboxStore, appMetrics), tracerProvider)
+	outboxRelay := services.NewOutboxRelay(outboxRepo, eventBus, clock)
+	unitOfWork := outboxRelay.UnitOfWork(tracing.TraceUnitOfWork(metrics.InstrumentUnitOfWork(unitOfWorkStore, appMetrics), tracerProvider))
+
+	tweetService := services.NewTweetService(tweetRepo, userRepo,
+		services.WithMediaRepository(mediaRepo),
+		services.WithUnfurler(previewService),
+		services.WithUnitOfWork(unitOfWork),
+		services.WithMaxTweetLength(cfg.Tweets.MaxLength),
+		services.WithClock(clock),
+		services.WithIDGenerator(ids),
+	)
+	followService := services.NewFollowService(followRepo, tweetRepo,
+		services.WithFollowUnitOfWork(unitOfWork),
+		services.WithTimelineSorter(tracing.TraceTimelineSorter(services.SortTimeline, tracerProvider)),
+	)
+	scheduleService := services.NewScheduleService(scheduledRepo, tweetRepo, userRepo, clock,
+		services.WithScheduleUnitOfWork(unitOfWork),
+		services.WithScheduledTweetMaxLength(cfg.Tweets.MaxLength),
+		services.WithScheduleIDGenerator(ids),
+	)
+	pollService := services.NewPollService(tweetRepo, pollRepo, clock)
+	mediaService := services.NewMediaService(mediaRepo, blobStore, clock, services.WithMediaIDGenerator(ids))
+	userService := services.NewUserService(userRepo)
+	liveTimelineService := services.NewLiveTimelineService(followRepo, tweetFeed)
+
+	// Start background workers
+	scheduler := services.NewScheduler(scheduleService, services.DefaultSchedulerInterval)
+	app.Go("scheduler", scheduler.Run)
+	healthRegistry.AddLivenessCheck("scheduler", scheduler.Check)
+	app.Go("link previews", func(ctx context.Context) {
+		previewService.Run(ctx, services.DefaultUnfurlWorkers)
+	})
+	app.Go("event bus", eventBus.Run)
+	app.Go("outbox relay", outboxRelay.Run)
+	healthRegistry.AddReadinessCheck("event_bus", outboxRelay.Check)
+	app.Go("webhook delivery", func(ctx context.Context) {
+		webhookService.Run(ctx, services.DefaultWebhookWorkers)
+	})
+	if federation != nil {
+		app.Go("federation delivery", func(ctx context.Context) {
+			federation.Run(ctx, activitypub.DefaultDeliveryWorkers)
+		})
+	}
+
+	tracedTweetService := tracing.TraceTweetService(metrics.InstrumentTweetService(tweetService, appMetrics), tracerProvider)
+	tracedFollowService := tracing.TraceFollowService(metrics.InstrumentFollowService(followService, appMetrics), tracerProvider)
+
+	// Internal backend services can call the same services over gRPC on a
+	// separate port. It stops with the workers, after HTTP has drained.
+	if cfg.Server.GRPCAddr != "" {
+		grpcListener, err := net.Listen("tcp", cfg.Server.GRPCAddr)
+		if err != nil {
+			fatal("failed to listen for grpc", err)
+		}
+		grpcServer := grpcInterface.NewServer(tracedTweetService, tracedFollowService,
+			grpcInterface.WithUserService(userService),
+			grpcInterface.WithLiveTimelineService(liveTimelineService),
+			grpcInterface.WithMaxTweetLength(cfg.Tweets.MaxLength),
+			grpcInterface.WithAccessLog(logger),
+		)
+		app.Go("grpc server", func(ctx context.Context) {
+			if err := grpcServer.Serve(ctx, grpcListener); err != nil {
+				slog.Error("grpc server stopped with errors", "error", err)
+			}
+		})
+	}
 
 	// Initialize interface layer (HTTP handlers)
-	handler := httpInterface.NewHandler(tweetService, followService)
-	router := httpInterface.NewRouter(handler)
-
-	// Setup routes
-	httpRouter := router.SetupRoutes()
-
-	// Start server
-	port := ":8080"
-	fmt.Printf("Server starting on port %s\n", port)
-	fmt.Println("Available endpoints:")
-	fmt.Println("  POST   /api/v1/tweets         - Create a tweet")
-	fmt.Println("  GET    /api/v1/timeline       - Get user timeline")
-	fmt.Println("  GET    /api/v1/users/tweets   - Get user tweets")
-	fmt.Println("  POST   /api/v1/follow         - Follow a user")
-	fmt.Println("  POST   /api/v1/unfollow       - Unfollow a user")
-	fmt.Println("  GET    /api/v1/health         - Health check")
-	fmt.Println("\nNote: Include X-User-ID header for user identification")
-
-	log.Fatal(http.ListenAndServe(port, httpRouter))
+	handler := httpInterface.NewHandler(
+		tracedTweetService,
+		tracedFollowService,
+		httpInterface.WithScheduleService(tracing.TraceScheduleService(scheduleService, tracerProvider)),
+		httpInterface.WithPollService(tracing.TracePollService(pollService, tracerProvider)),
+		httpInterface.WithMediaService(tracing.TraceMediaService(mediaService, tracerProvider)),
+		httpInterface.WithLinkPreviewService(tracing.TraceLinkPreviewService(previewService, tracerProvider)),
+		httpInterface.WithWebhookService(tracing.TraceWebhookService(webhookService, tracerProvider)),
+		httpInterface.WithMaxTweetLength(cfg.Tweets.MaxLength),
+		httpInterface.WithReadiness(app.Ready),
+	)
+	routerOpts := []httpInterface.RouterOption{
+		httpInterface.WithIdempotency(idempotency.NewMemoryStore(), idempotency.DefaultTTL),
+		httpInterface.WithMetrics(appMetrics),
+		httpInterface.WithTracing(tracerProvider),
+		httpInterface.WithAccessLog(logger),
+		httpInterface.WithCORSOrigins(cfg.CORS.AllowedOrigins),
+		httpInterface.WithHealth(healthRegistry),
+		httpInterface.WithGraphQL(graphqlInterface.NewHandler(tracedTweetService, tracedFollowService, userService)),
+	}
+	if federation != nil {
+		routerOpts = append(routerOpts, httpInterface.WithActivityPub(activitypub.NewHandler(federation, tracedTweetService, userService)))
+	}
+	if cfg.RateLimit.Enabled {
+		routerOpts = append(routerOpts, httpInterface.WithRateLimit(ratelimit.NewMemoryStore(), httpInterface.RateLimits{
+			Read:       ratelimit.PerMinute(cfg.RateLimit.ReadsPerMinute),
+			Write:      ratelimit.PerMinute(cfg.RateLimit.WritesPerMinute),
+			IPMultiple: httpInterface.DefaultRateLimits().IPMultiple,
+		}))
+	}
+	router := httpInterface.NewRouter(handler, routerOpts...)
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           router.SetupRoutes(),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadTimeout),
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelWarn),
+	}
+
+	// Serve until a termination signal arrives, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = app.Run(ctx, server, lifecycle.Options{
+		ShutdownDelay:   time.Duration(cfg.Server.ShutdownDelay),
+		ShutdownTimeout: time.Duration(cfg.Server.ShutdownTimeout),
+	})
+	if err != nil {
+		fatal("server stopped with errors", err)
+	}
+}
+
+// rebuildProjections replays the whole event log of the event-sourced
+// backend and writes a fresh snapshot, so that the next startup sees
+// projections built from every event. It takes the same configuration as
+// the server, which must not be running on the data directory, and returns
+// the exit code.
+func rebuildProjections(args []string) int {
+	cfg, _, err := config.Load(args, os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		return 2
+	}
+	if cfg.Storage.Backend != config.StorageEventSourced {
+		fmt.Fprintf(os.Stderr, "rebuild-projections needs the %s storage backend\n", config.StorageEventSourced)
+		return 2
+	}
+
+	start := time.Now()
+	replayed, err := storage.RebuildProjections(cfg.Storage.DataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Rebuilding projections: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Replayed %d event log entries in %s\n", replayed, time.Since(start).Round(time.Millisecond))
+	return 0
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,262 @@
+// Package client is a typed Go client for the microblog HTTP API.
+//
+//	c := client.New("http://localhost:8080", client.WithUserID("alice"))
+//	tweet, err := c.CreateTweet(ctx, client.CreateTweetRequest{Content: "Hello"})
+//
+// Requests that fail with 429 or a 5xx status, or that never reach the
+// server, are retried with exponential backoff. POST requests carry an
+// Idempotency-Key so that a retry never creates a resource twice. API errors
+// are returned as *Error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// apiPrefix is prepended to every API path
+const apiPrefix = "/api/v1"
+
+// Default retry policy
+const (
+	DefaultMaxRetries = 3
+	DefaultMinBackoff = 200 * time.Millisecond
+	DefaultMaxBackoff = 5 * time.Second
+)
+
+// DefaultPageSize is how many items list iterators ask for per page
+const DefaultPageSize = 20
+
+// Client calls the API on behalf of one user
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	userID     string
+	userAgent  string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	pageSize   int
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the underlying HTTP client. Defaults to a client with
+// a 30 second timeout.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithUserID sends requests as the given user via the X-User-ID header
+func WithUserID(userID string) Option {
+	return func(c *Client) {
+		c.userID = userID
+	}
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetry sets how many times a failed request is retried and the bounds
+// of the exponential backoff between attempts. maxRetries 0 disables retries.
+func WithRetry(maxRetries int, minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithPageSize sets how many items list iterators ask for per page, up to
+// the server's maximum of 100
+func WithPageSize(pageSize int) Option {
+	return func(c *Client) {
+		c.pageSize = pageSize
+	}
+}
+
+// New creates a client for the API served at baseURL, such as
+// "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		userAgent:  "uala-challenge-go-client",
+		maxRetries: DefaultMaxRetries,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		pageSize:   DefaultPageSize,
+		sleep:      sleep,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// As returns a copy of the client that acts as another user
+func (c *Client) As(userID string) *Client {
+	copied := *c
+	copied.userID = userID
+	return &copied
+}
+
+// request describes one API call
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	header      http.Header
+	// noRetry sends the request once regardless of the retry policy
+	noRetry bool
+}
+
+// jsonRequest builds a request with a JSON-encoded body
+func jsonRequest(method, path string, body interface{}) (request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return request{}, fmt.Errorf("encode request: %w", err)
+	}
+	return request{method: method, path: path, body: data, contentType: "application/json"}, nil
+}
+
+// do sends req, retrying per the client policy, and decodes a successful
+// JSON response into out when out is not nil. The caller must close the
+// returned response body when out is nil.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (*http.Response, error) {
+	// A stable key makes retried POSTs safe: the server replays the first
+	// response instead of creating the resource again
+	idempotencyKey := ""
+	if req.method == http.MethodPost && req.header.Get("Idempotency-Key") == "" {
+		idempotencyKey = uuid.NewString()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, req, idempotencyKey)
+		if err == nil && resp.StatusCode < 400 {
+			if out == nil {
+				return resp, nil
+			}
+			defer resp.Body.Close()
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return nil, fmt.Errorf("decode response: %w", err)
+			}
+			return resp, nil
+		}
+
+		var wait time.Duration
+		if err == nil {
+			err = decodeError(resp)
+			wait = err.(*Error).RetryAfter
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if req.noRetry || attempt >= c.maxRetries || !retryable(err) {
+			return nil, err
+		}
+
+		if backoff := c.backoff(attempt); wait < backoff {
+			wait = backoff
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs a single attempt
+func (c *Client) send(ctx context.Context, req request, idempotencyKey string) (*http.Response, error) {
+	target := c.baseURL + apiPrefix + req.path
+	if len(req.query) > 0 {
+		target += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target, body)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range req.header {
+		httpReq.Header[name] = values
+	}
+	if req.contentType != "" {
+		httpReq.Header.Set("Content-Type", req.contentType)
+	}
+	if c.userID != "" {
+		httpReq.Header.Set("X-User-ID", c.userID)
+	}
+	if idempotencyKey != "" {
+		httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	httpReq.Header.Set("User-Agent", c.userAgent)
+
+	return c.httpClient.Do(httpReq)
+}
+
+// retryable reports whether err may succeed on a later attempt: rate
+// limits, server errors and transport failures
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// backoff returns the delay before retry attempt+1: exponential growth from
+// minBackoff, capped at maxBackoff, with up to 50% jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.minBackoff << attempt
+	if delay > c.maxBackoff || delay <= 0 {
+		delay = c.maxBackoff
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
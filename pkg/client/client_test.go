@@ -0,0 +1,394 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"uala-challenge/internal/application/services"
+	"uala-challenge/internal/domain"
+	"uala-challenge/internal/infrastructure/idempotency"
+	"uala-challenge/internal/infrastructure/storage"
+	httpInterface "uala-challenge/internal/interfaces/http"
+)
+
+// newTestServer serves the real router with every feature enabled. wrap,
+// when not nil, intercepts requests before they reach the router.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) *httptest.Server {
+	t.Helper()
+
+	inMemoryStorage := storage.NewInMemoryRepository()
+	userRepo := storage.NewUserRepository(inMemoryStorage)
+	tweetRepo := storage.NewTweetRepository(inMemoryStorage)
+	followRepo := storage.NewFollowRepository(inMemoryStorage)
+	mediaRepo := storage.NewMediaRepository(inMemoryStorage)
+	blobStore, err := storage.NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	handler := httpInterface.NewHandler(
+		services.NewTweetService(tweetRepo, userRepo, services.WithMediaRepository(mediaRepo)),
+		services.NewFollowService(followRepo, tweetRepo),
+		httpInterface.WithScheduleService(services.NewScheduleService(storage.NewScheduledTweetRepository(inMemoryStorage), tweetRepo, userRepo, domain.SystemClock{})),
+		httpInterface.WithPollService(services.NewPollService(tweetRepo, storage.NewPollRepository(inMemoryStorage), domain.SystemClock{})),
+		httpInterface.WithMediaService(services.NewMediaService(mediaRepo, blobStore, domain.SystemClock{})),
+	)
+	var router http.Handler = httpInterface.NewRouter(handler,
+		httpInterface.WithIdempotency(idempotency.NewMemoryStore(), idempotency.DefaultTTL),
+	).SetupRoutes()
+	if wrap != nil {
+		router = wrap(router)
+	}
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestClient creates a client that records backoff delays instead of sleeping
+func newTestClient(server *httptest.Server, opts ...Option) (*Client, *[]time.Duration) {
+	var mutex sync.Mutex
+	var delays []time.Duration
+	c := New(server.URL, opts...)
+	c.sleep = func(ctx context.Context, d time.Duration) error {
+		mutex.Lock()
+		delays = append(delays, d)
+		mutex.Unlock()
+		return ctx.Err()
+	}
+	return c, &delays
+}
+
+func TestClient_Workflow(t *testing.T) {
+	ctx := context.Background()
+	server := newTestServer(t, nil)
+	alice, _ := newTestClient(server, WithUserID("alice"))
+	bob := alice.As("bob")
+
+	if status, err := alice.Health(ctx); err != nil || status != "healthy" {
+		t.Fatalf("Expected healthy server, got %q, %v", status, err)
+	}
+
+	first, err := alice.CreateTweet(ctx, CreateTweetRequest{Content: "Hello"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first.ID == "" || first.UserID != "alice" || first.Content != "Hello" {
+		t.Errorf("Unexpected tweet %+v", first)
+	}
+
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	media, err := alice.UploadMedia(ctx, &pngData, "image/png")
+	if err != nil {
+		t.Fatalf("Expected no error uploading media, got %v", err)
+	}
+	if media.ContentType != "image/png" || media.Width != 16 {
+		t.Errorf("Unexpected media %+v", media)
+	}
+
+	blob, err := alice.GetMedia(ctx, media.ID)
+	if err != nil {
+		t.Fatalf("Expected no error downloading media, got %v", err)
+	}
+	data, _ := io.ReadAll(blob.Body)
+	blob.Body.Close()
+	if blob.ContentType != "image/png" || len(data) == 0 || blob.ETag == "" {
+		t.Errorf("Unexpected blob %s with %d bytes", blob.ContentType, len(data))
+	}
+	thumbnail, err := alice.GetMediaThumbnail(ctx, media.ID)
+	if err != nil {
+		t.Fatalf("Expected no error downloading thumbnail, got %v", err)
+	}
+	thumbnail.Body.Close()
+
+	withPoll, err := alice.CreateTweet(ctx, CreateTweetRequest{
+		Content:  "Tabs or spaces?",
+		Poll:     &CreatePollRequest{Options: []string{"Tabs", "Spaces"}, DurationMinutes: 60},
+		MediaIDs: []string{media.ID},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err := bob.Follow(ctx, "alice"); err != nil {
+		t.Fatalf("Expected no error following, got %v", err)
+	}
+	timeline, err := bob.Timeline(ctx).All()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(timeline) != 2 || timeline[0].ID != withPoll.ID || timeline[1].ID != first.ID {
+		t.Errorf("Expected both tweets newest first, got %+v", timeline)
+	}
+	if timeline[0].Poll == nil || timeline[0].Poll.TotalVotes != nil {
+		t.Errorf("Expected hidden poll results before voting, got %+v", timeline[0].Poll)
+	}
+
+	voted, err := bob.VotePoll(ctx, withPoll.ID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error voting, got %v", err)
+	}
+	if voted.Poll.TotalVotes == nil || *voted.Poll.TotalVotes != 1 || *voted.Poll.VotedOption != 1 {
+		t.Errorf("Expected visible results after voting, got %+v", voted.Poll)
+	}
+
+	userTweets, err := bob.UserTweets(ctx, "alice").All()
+	if err != nil || len(userTweets) != 2 {
+		t.Errorf("Expected 2 tweets by alice, got %d, %v", len(userTweets), err)
+	}
+
+	if err := bob.Unfollow(ctx, "alice"); err != nil {
+		t.Fatalf("Expected no error unfollowing, got %v", err)
+	}
+	if timeline, _ := bob.Timeline(ctx).All(); len(timeline) != 0 {
+		t.Errorf("Expected empty timeline after unfollowing, got %d tweets", len(timeline))
+	}
+
+	publishAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	scheduled, err := alice.ScheduleTweet(ctx, "Later", publishAt)
+	if err != nil {
+		t.Fatalf("Expected no error scheduling, got %v", err)
+	}
+	if !scheduled.PublishAt.Equal(publishAt) {
+		t.Errorf("Expected publish time %v, got %v", publishAt, scheduled.PublishAt)
+	}
+	rescheduled, err := alice.RescheduleTweet(ctx, scheduled.ID, publishAt.Add(time.Hour))
+	if err != nil || !rescheduled.PublishAt.Equal(publishAt.Add(time.Hour)) {
+		t.Errorf("Expected rescheduled tweet, got %+v, %v", rescheduled, err)
+	}
+	pending, err := alice.ScheduledTweets(ctx).All()
+	if err != nil || len(pending) != 1 || pending[0].ID != scheduled.ID {
+		t.Errorf("Expected one pending tweet, got %+v, %v", pending, err)
+	}
+	if err := alice.CancelScheduledTweet(ctx, scheduled.ID); err != nil {
+		t.Fatalf("Expected no error cancelling, got %v", err)
+	}
+	if pending, _ := alice.ScheduledTweets(ctx).All(); len(pending) != 0 {
+		t.Errorf("Expected no pending tweets, got %d", len(pending))
+	}
+}
+
+func TestClient_DecodesErrors(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestClient(newTestServer(t, nil), WithUserID("alice"))
+
+	_, err := c.CreateTweet(ctx, CreateTweetRequest{Content: ""})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *Error, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadRequest || apiErr.Code != "tweet_empty" || apiErr.RequestID == "" {
+		t.Errorf("Unexpected error %+v", apiErr)
+	}
+
+	_, err = c.VotePoll(ctx, "missing", 0)
+	if !IsCode(err, "poll_not_found") {
+		t.Errorf("Expected poll_not_found, got %v", err)
+	}
+
+	_, err = c.CreateTweet(ctx, CreateTweetRequest{Content: string(make([]byte, 281))})
+	if !errors.As(err, &apiErr) || apiErr.Details["max_length"] != float64(280) {
+		t.Errorf("Expected max_length detail, got %v", err)
+	}
+}
+
+func TestClient_RetriesAreIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	// The first attempt reaches the server but its response is lost
+	var attempts atomic.Int32
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPost && attempts.Add(1) == 1 {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	c, delays := newTestClient(server, WithUserID("alice"))
+
+	tweet, err := c.CreateTweet(ctx, CreateTweetRequest{Content: "Exactly once"})
+	if err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if attempts.Load() != 2 || len(*delays) != 1 {
+		t.Errorf("Expected 2 attempts and 1 backoff, got %d and %d", attempts.Load(), len(*delays))
+	}
+
+	tweets, _ := c.UserTweets(ctx, "alice").All()
+	if len(tweets) != 1 || tweets[0].ID != tweet.ID {
+		t.Errorf("Expected the tweet to be created once, got %d tweets", len(tweets))
+	}
+}
+
+func TestClient_HonoursRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) == 1 {
+				w.Header().Set("Retry-After", "2")
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				io.WriteString(w, `{"error": {"code": "rate_limited", "message": "Too many requests"}}`)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	c, delays := newTestClient(server, WithUserID("alice"))
+
+	if _, err := c.Timeline(context.Background()).All(); err != nil {
+		t.Fatalf("Expected retry to succeed, got %v", err)
+	}
+	if len(*delays) != 1 || (*delays)[0] < 2*time.Second {
+		t.Errorf("Expected to wait at least Retry-After, got %v", *delays)
+	}
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		expectedAttempts int32
+	}{
+		{"server errors are retried until the limit", http.StatusServiceUnavailable, 3},
+		{"client errors are not retried", http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := newTestServer(t, func(http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					attempts.Add(1)
+					w.WriteHeader(tt.status)
+				})
+			})
+			c, _ := newTestClient(server, WithUserID("alice"), WithRetry(2, time.Millisecond, time.Millisecond))
+
+			_, err := c.CreateTweet(context.Background(), CreateTweetRequest{Content: "hello"})
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("Expected %d error, got %v", tt.status, err)
+			}
+			if attempts.Load() != tt.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.expectedAttempts, attempts.Load())
+			}
+		})
+	}
+}
+
+func TestClient_ContextCancellation(t *testing.T) {
+	t.Run("during a request", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-release:
+				case <-r.Context().Done():
+				}
+			})
+		})
+		c, _ := newTestClient(server, WithUserID("alice"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if _, err := c.Timeline(ctx).All(); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+	})
+
+	t.Run("during backoff", func(t *testing.T) {
+		server := newTestServer(t, func(http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+		})
+		c := New(server.URL, WithUserID("alice"), WithRetry(5, time.Hour, time.Hour))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := c.Timeline(ctx).All(); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Error("Expected backoff to stop when the context is done")
+		}
+	})
+}
+
+func TestIterator_FollowsCursor(t *testing.T) {
+	ctx := context.Background()
+
+	var mutex sync.Mutex
+	var pages []string
+	server := newTestServer(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v1/timeline" {
+				mutex.Lock()
+				pages = append(pages, r.URL.RawQuery)
+				mutex.Unlock()
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	alice, _ := newTestClient(server, WithUserID("alice"), WithPageSize(2))
+	bob := alice.As("bob")
+
+	var posted []string
+	for i := 0; i < 5; i++ {
+		tweet, err := bob.CreateTweet(ctx, CreateTweetRequest{Content: "Tweet"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		posted = append(posted, tweet.ID)
+	}
+	if err := alice.Follow(ctx, "bob"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	it := alice.Timeline(ctx)
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Value().ID)
+	}
+	if it.Err() != nil {
+		t.Fatalf("Expected no error, got %v", it.Err())
+	}
+	if len(ids) != len(posted) {
+		t.Fatalf("Expected %d tweets, got %d", len(posted), len(ids))
+	}
+	for i, id := range ids {
+		if want := posted[len(posted)-1-i]; id != want {
+			t.Errorf("Expected %s at position %d, got %s", want, i, id)
+		}
+	}
+
+	want := []string{"limit=2", "after=" + ids[1] + "&limit=2", "after=" + ids[3] + "&limit=2"}
+	if len(pages) != len(want) {
+		t.Fatalf("Expected %d page requests, got %v", len(want), pages)
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("Expected page request %q, got %q", want[i], pages[i])
+		}
+	}
+	if it.Next() {
+		t.Error("Expected exhausted iterator to stay exhausted")
+	}
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Error is an error response from the API
+type Error struct {
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+	// Code is the stable machine-readable error code, such as "tweet_too_long"
+	Code string
+	// Message is a human-readable description
+	Message string
+	// Details carries code-specific context, such as "max_length"
+	Details map[string]interface{}
+	// RequestID identifies the request in server logs
+	RequestID string
+	// RetryAfter is how long the server asked the client to wait, if it did
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("api error %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsCode reports whether err is an API error with the given code
+func IsCode(err error, code string) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// errorEnvelope mirrors the API's {"error": {...}} error body
+type errorEnvelope struct {
+	Error struct {
+		Code      string                 `json:"code"`
+		Message   string                 `json:"message"`
+		Details   map[string]interface{} `json:"details"`
+		RequestID string                 `json:"request_id"`
+	} `json:"error"`
+}
+
+// decodeError builds an *Error from a failed response and closes its body.
+// Bodies that are not error envelopes keep the HTTP status text as message.
+func decodeError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		Message:    http.StatusText(resp.StatusCode),
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var envelope errorEnvelope
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if json.Unmarshal(data, &envelope) == nil && envelope.Error.Code != "" {
+		apiErr.Code = envelope.Error.Code
+		apiErr.Message = envelope.Error.Message
+		apiErr.Details = envelope.Error.Details
+		if envelope.Error.RequestID != "" {
+			apiErr.RequestID = envelope.Error.RequestID
+		}
+	}
+	return apiErr
+}
@@ -0,0 +1,28 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Follow makes the caller follow userID
+func (c *Client) Follow(ctx context.Context, userID string) error {
+	return c.follow(ctx, "/follow", userID)
+}
+
+// Unfollow makes the caller stop following userID
+func (c *Client) Unfollow(ctx context.Context, userID string) error {
+	return c.follow(ctx, "/unfollow", userID)
+}
+
+func (c *Client) follow(ctx context.Context, path, userID string) error {
+	r, err := jsonRequest(http.MethodPost, path, map[string]string{"followee_id": userID})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		Message string `json:"message"`
+	}
+	_, err = c.do(ctx, r, &resp)
+	return err
+}
@@ -0,0 +1,19 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Health reports the server's health status, "healthy" when it is serving.
+// It is not retried: a server that is shutting down returns an *Error with
+// status 503.
+func (c *Client) Health(ctx context.Context) (string, error) {
+	var resp struct {
+		Status string `json:"status"`
+	}
+	if _, err := c.do(ctx, request{method: http.MethodGet, path: "/health", noRetry: true}, &resp); err != nil {
+		return "", err
+	}
+	return resp.Status, nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import "context"
+
+// page is one page of a list response
+type page[T any] struct {
+	items      []T
+	nextCursor string
+}
+
+// Iterator walks a list endpoint page by page, fetching lazily:
+//
+//	it := c.Timeline(ctx)
+//	for it.Next() {
+//		tweet := it.Value()
+//	}
+//	if err := it.Err(); err != nil { ... }
+//
+// Pages are followed by passing the response's next_cursor back as after.
+// Endpoints that do not paginate return everything in a single page.
+type Iterator[T any] struct {
+	ctx     context.Context
+	fetch   func(ctx context.Context, cursor string) (page[T], error)
+	items   []T
+	current T
+	cursor  string
+	fetched bool
+	err     error
+}
+
+func newIterator[T any](ctx context.Context, fetch func(ctx context.Context, cursor string) (page[T], error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, fetch: fetch}
+}
+
+// Next advances to the next item, fetching the next page when needed. It
+// returns false when the list is exhausted or a request failed.
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || (it.fetched && it.cursor == "") {
+			return false
+		}
+		p, err := it.fetch(it.ctx, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.items = p.items
+		it.cursor = p.nextCursor
+	}
+
+	it.current = it.items[0]
+	it.items = it.items[1:]
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice
+func (it *Iterator[T]) All() ([]T, error) {
+	var all []T
+	for it.Next() {
+		all = append(all, it.Value())
+	}
+	return all, it.Err()
+}
@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// UploadMedia uploads an image of the given content type, such as
+// "image/png", to attach to tweets through CreateTweetRequest.MediaIDs
+func (c *Client) UploadMedia(ctx context.Context, image io.Reader, contentType string) (*Media, error) {
+	// The body is buffered so that it can be resent on retries
+	data, err := io.ReadAll(image)
+	if err != nil {
+		return nil, fmt.Errorf("read image: %w", err)
+	}
+
+	var media Media
+	r := request{method: http.MethodPost, path: "/media", body: data, contentType: contentType}
+	if _, err := c.do(ctx, r, &media); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// GetMedia downloads an uploaded image
+func (c *Client) GetMedia(ctx context.Context, id string) (*Blob, error) {
+	return c.blob(ctx, "/media/"+url.PathEscape(id))
+}
+
+// GetMediaThumbnail downloads the thumbnail of an uploaded image
+func (c *Client) GetMediaThumbnail(ctx context.Context, id string) (*Blob, error) {
+	return c.blob(ctx, "/media/"+url.PathEscape(id)+"/thumbnail")
+}
+
+func (c *Client) blob(ctx context.Context, path string) (*Blob, error) {
+	resp, err := c.do(ctx, request{method: http.MethodGet, path: path}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Blob{
+		Body:        resp.Body,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        resp.Header.Get("ETag"),
+	}, nil
+}
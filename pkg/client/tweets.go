@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// listResponse is the body of the list endpoints
+type listResponse[T any] struct {
+	Tweets     []T    `json:"tweets"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor"`
+}
+
+// list fetches one page of a list endpoint. pageSize is sent as limit to
+// endpoints that paginate and is 0 for those that do not.
+func list[T any](c *Client, path string, query url.Values, pageSize int) func(ctx context.Context, cursor string) (page[T], error) {
+	return func(ctx context.Context, cursor string) (page[T], error) {
+		q := url.Values{}
+		for key, values := range query {
+			q[key] = values
+		}
+		if pageSize > 0 {
+			q.Set("limit", strconv.Itoa(pageSize))
+		}
+		if cursor != "" {
+			q.Set("after", cursor)
+		}
+
+		var resp listResponse[T]
+		if _, err := c.do(ctx, request{method: http.MethodGet, path: path, query: q}, &resp); err != nil {
+			return page[T]{}, err
+		}
+		return page[T]{items: resp.Tweets, nextCursor: resp.NextCursor}, nil
+	}
+}
+
+// CreateTweet publishes a tweet
+func (c *Client) CreateTweet(ctx context.Context, req CreateTweetRequest) (*Tweet, error) {
+	r, err := jsonRequest(http.MethodPost, "/tweets", req)
+	if err != nil {
+		return nil, err
+	}
+	var tweet Tweet
+	if _, err := c.do(ctx, r, &tweet); err != nil {
+		return nil, err
+	}
+	return &tweet, nil
+}
+
+// ScheduleTweet queues a tweet to be published at publishAt
+func (c *Client) ScheduleTweet(ctx context.Context, content string, publishAt time.Time) (*ScheduledTweet, error) {
+	r, err := jsonRequest(http.MethodPost, "/tweets", map[string]interface{}{
+		"content":    content,
+		"publish_at": publishAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var scheduled ScheduledTweet
+	if _, err := c.do(ctx, r, &scheduled); err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+// Timeline iterates over tweets from users the caller follows, newest first,
+// fetching WithPageSize tweets at a time
+func (c *Client) Timeline(ctx context.Context) *Iterator[Tweet] {
+	return newIterator(ctx, list[Tweet](c, "/timeline", nil, c.pageSize))
+}
+
+// UserTweets iterates over tweets posted by userID, newest first
+func (c *Client) UserTweets(ctx context.Context, userID string) *Iterator[Tweet] {
+	return newIterator(ctx, list[Tweet](c, "/users/tweets", url.Values{"user_id": {userID}}, 0))
+}
+
+// ScheduledTweets iterates over the caller's pending scheduled tweets
+func (c *Client) ScheduledTweets(ctx context.Context) *Iterator[ScheduledTweet] {
+	return newIterator(ctx, list[ScheduledTweet](c, "/tweets/scheduled", nil, 0))
+}
+
+// RescheduleTweet moves a scheduled tweet to a new publish time
+func (c *Client) RescheduleTweet(ctx context.Context, id string, publishAt time.Time) (*ScheduledTweet, error) {
+	r, err := jsonRequest(http.MethodPut, "/tweets/scheduled/"+url.PathEscape(id), map[string]interface{}{
+		"publish_at": publishAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var scheduled ScheduledTweet
+	if _, err := c.do(ctx, r, &scheduled); err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
+
+// CancelScheduledTweet deletes a scheduled tweet before it is published
+func (c *Client) CancelScheduledTweet(ctx context.Context, id string) error {
+	resp, err := c.do(ctx, request{method: http.MethodDelete, path: "/tweets/scheduled/" + url.PathEscape(id)}, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// VotePoll votes for option, a zero-based index, in a tweet's poll and
+// returns the tweet with the results now visible to the caller
+func (c *Client) VotePoll(ctx context.Context, tweetID string, option int) (*Tweet, error) {
+	r, err := jsonRequest(http.MethodPost, "/tweets/"+url.PathEscape(tweetID)+"/poll/votes", map[string]int{
+		"option": option,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tweet Tweet
+	if _, err := c.do(ctx, r, &tweet); err != nil {
+		return nil, err
+	}
+	return &tweet, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"io"
+	"time"
+)
+
+// Tweet is a published tweet
+type Tweet struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"user_id"`
+	Content   string       `json:"content"`
+	CreatedAt time.Time    `json:"created_at"`
+	Poll      *Poll        `json:"poll,omitempty"`
+	MediaIDs  []string     `json:"media_ids,omitempty"`
+	Card      *LinkPreview `json:"card,omitempty"`
+}
+
+// Poll is a poll attached to a tweet. Results are only present once the
+// viewer has voted or the poll has closed.
+type Poll struct {
+	Options     []PollOption `json:"options"`
+	EndsAt      time.Time    `json:"ends_at"`
+	Closed      bool         `json:"closed"`
+	TotalVotes  *int         `json:"total_votes,omitempty"`
+	VotedOption *int         `json:"voted_option,omitempty"`
+}
+
+// PollOption is one choice of a poll
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int   `json:"votes,omitempty"`
+}
+
+// LinkPreview is the preview card of the first link in a tweet
+type LinkPreview struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ImageURL    string    `json:"image_url,omitempty"`
+	SiteName    string    `json:"site_name,omitempty"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// Media is an uploaded image
+type Media struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ScheduledTweet is a tweet waiting to be published
+type ScheduledTweet struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Content   string    `json:"content"`
+	PublishAt time.Time `json:"publish_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateTweetRequest is the content of a new tweet
+type CreateTweetRequest struct {
+	Content  string             `json:"content"`
+	Poll     *CreatePollRequest `json:"poll,omitempty"`
+	MediaIDs []string           `json:"media_ids,omitempty"`
+}
+
+// CreatePollRequest attaches a poll to a new tweet
+type CreatePollRequest struct {
+	Options         []string `json:"options"`
+	DurationMinutes int      `json:"duration_minutes"`
+}
+
+// Blob is downloaded media. The caller must close Body.
+type Blob struct {
+	Body        io.ReadCloser
+	ContentType string
+	ETag        string
+}